@@ -23,6 +23,7 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/cache"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/fuse"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
@@ -85,11 +86,20 @@ func CacheFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 			Value: "/var/cache/pfs-cache-dir/meta-cache",
 			Usage: "meta cache local path",
 		},
+		&cli.StringFlag{
+			Name:  "meta-cache-load",
+			Usage: "load meta cache from the file dumped by 'pfs-fuse dump' before serving the mount",
+		},
 		&cli.DurationFlag{
 			Name:  "data-cache-expire",
 			Value: 0,
 			Usage: "data cache expire",
 		},
+		&cli.Int64Flag{
+			Name:  "data-cache-quota",
+			Value: 0,
+			Usage: "max bytes of data cache on disk, least recently used blocks are evicted beyond it, 0 means limited only by free disk space",
+		},
 		&cli.DurationFlag{
 			Name:  "meta-cache-expire",
 			Value: 5 * time.Second,
@@ -122,6 +132,21 @@ func CacheFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 			Value: 200 * 1024 * 1024,
 			Usage: "size of read-ahead data",
 		},
+		&cli.BoolFlag{
+			Name:  "data-cache-writeback",
+			Value: false,
+			Usage: "stage written data in data-cache-path and upload it to ufs asynchronously",
+		},
+		&cli.Int64Flag{
+			Name:  "data-cache-max-dirty-size",
+			Value: cache.DefaultMaxDirtySize,
+			Usage: "max size of written data not uploaded yet in write-back mode, writes are blocked when exceeded",
+		},
+		&cli.IntFlag{
+			Name:  "data-cache-upload-concurrency",
+			Value: cache.DefaultUploadConcurrency,
+			Usage: "number of concurrent uploads in write-back mode",
+		},
 	}
 }
 
@@ -156,6 +181,12 @@ func MountFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 			Usage:       "Permission bits for files, only effective for S3 file system. (default: 0644)",
 			Destination: &fuseConf.FileMode,
 		},
+		&cli.BoolFlag{
+			Name:        "tiered-migrate",
+			Value:       false,
+			Usage:       "run the migration between tiers in this process, only effective for tiered file system",
+			Destination: &ufs.TieredMigrate,
+		},
 	}
 }
 
@@ -50,6 +50,8 @@ func Main(args []string) error {
 			service.CmdUmount(),
 			service.CmdStats(),
 			service.CmdBench(),
+			service.CmdDump(),
+			service.CmdWarmup(),
 		},
 	}
 	return app.Run(args)
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/meta"
+)
+
+func CmdDump() *cli.Command {
+	return &cli.Command{
+		Name:      "dump",
+		Action:    dump,
+		Category:  "INSPECTOR",
+		Usage:     "Dump meta cache of pfs-fuse into a JSON file",
+		ArgsUsage: "META-CACHE-DB [FILE]",
+		Description: `
+Dump the entries and attrs in meta cache, and the links of fs, into a versioned JSON file, which can be
+inspected or loaded by the mount option '--meta-cache-load'. The meta cache db is the one under
+meta-cache-path, and it should not be opened by a running mount.
+
+Examples:
+$ pfs-fuse dump /var/cache/pfs-cache-dir/meta-cache/fs-root-sftp_abcde.db meta-dump.json
+
+# Dump to stdout
+$ pfs-fuse dump /var/cache/pfs-cache-dir/meta-cache/fs-root-sftp_abcde.db`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "meta-cache-driver",
+				Value: kv.LevelDB,
+				Usage: "meta cache driver, e.g. leveldb, nutsdb",
+			},
+		},
+	}
+}
+
+func dump(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 {
+		return fmt.Errorf("META-CACHE-DB is needed")
+	}
+	dbPath := ctx.Args().Get(0)
+	if _, err := os.Stat(dbPath); err != nil {
+		log.Errorf("meta cache db[%s] is not valid: %v", dbPath, err)
+		return err
+	}
+	client, err := kv.OpenClient(ctx.String("meta-cache-driver"), dbPath)
+	if err != nil {
+		log.Errorf("open meta cache db[%s] failed: %v", dbPath, err)
+		return err
+	}
+	defer client.Close()
+
+	var w io.Writer = os.Stdout
+	if ctx.Args().Len() > 1 {
+		file, err := os.Create(ctx.Args().Get(1))
+		if err != nil {
+			log.Errorf("create dump file failed: %v", err)
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err = meta.DumpKvMeta(client, w); err != nil {
+		log.Errorf("dump meta cache db[%s] failed: %v", dbPath, err)
+		return err
+	}
+	return nil
+}
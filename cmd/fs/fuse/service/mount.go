@@ -128,6 +128,8 @@ func setup(c *cli.Context) error {
 	opts.IgnoreSecurityLabels = c.Bool("ignore-security-labels")
 	opts.DisableXAttrs = c.Bool("disable-xattrs")
 	opts.AllowOther = c.Bool("allow-other")
+	// advisory locks are handled by meta, instead of kernel
+	opts.EnableLocks = true
 
 	// Wrap the default registry, all prometheus.MustRegister() calls should be afterwards
 	// InitVFS() has many registers, should be after wrapRegister()
@@ -217,6 +219,9 @@ func mount(c *cli.Context) error {
 		os.Exit(-1)
 	}
 	server.Wait()
+	if store := vfs.GetVFS().Store; store != nil {
+		store.Close()
+	}
 	return err
 }
 
@@ -344,9 +349,13 @@ func InitVFS(c *cli.Context, registry *prometheus.Registry) error {
 		BlockSize:    c.Int("block-size"),
 		MaxReadAhead: c.Int("data-read-ahead-size"),
 		Expire:       c.Duration("data-cache-expire"),
+		Quota:        c.Int64("data-cache-quota"),
 		Config: kv.Config{
 			CachePath: c.String("data-cache-path"),
 		},
+		WriteBack:         c.Bool("data-cache-writeback"),
+		MaxDirtySize:      c.Int64("data-cache-max-dirty-size"),
+		UploadConcurrency: c.Int("data-cache-upload-concurrency"),
 	}
 	vfsOptions := []vfs.Option{
 		vfs.WithDataCacheConfig(d),
@@ -370,9 +379,24 @@ func InitVFS(c *cli.Context, registry *prometheus.Registry) error {
 		log.Errorf("init vfs failed: %v", err)
 		return err
 	}
+	if dumpFile := c.String("meta-cache-load"); dumpFile != "" {
+		// the meta cache is only for speeding up, so mount goes on even if it fails to load
+		if err := loadMetaCache(dumpFile); err != nil {
+			log.Warnf("load meta cache from file[%s] failed: %v", dumpFile, err)
+		}
+	}
 	return nil
 }
 
+func loadMetaCache(dumpFile string) error {
+	file, err := os.Open(dumpFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return vfs.GetVFS().Meta.LoadMeta(file)
+}
+
 func signalHandle(mp string) {
 	signalChan := make(chan os.Signal, 10)
 	signal.Notify(signalChan, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP, syscall.SIGKILL)
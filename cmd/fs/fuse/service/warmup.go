@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
+)
+
+const (
+	warmupFiles  = "pfs_warmup_files"
+	warmupBytes  = "pfs_warmup_bytes"
+	warmupFailed = "pfs_warmup_failed"
+)
+
+func CmdWarmup() *cli.Command {
+	return &cli.Command{
+		Name:      "warmup",
+		Action:    warmup,
+		Category:  "TOOL",
+		Usage:     "Fill the local data cache of pfs-fuse with files under the given paths",
+		ArgsUsage: "MOUNTPOINT PATH...",
+		Description: `
+This is a tool that reads all files under the given paths through the mount point, so that
+the data blocks are filled into the local data cache of the target mount point.
+The paths are relative to the mount point, the whole file system is warmed up if no path is given.
+
+Examples:
+$ pfs-fuse warmup /mnt/mount_point /dataset/train /dataset/test
+
+# Warm up with 50 threads
+$ pfs-fuse warmup /mnt/mount_point /dataset -t 50`,
+		Flags: []cli.Flag{
+			&cli.UintFlag{
+				Name:    "threads",
+				Aliases: []string{"t"},
+				Value:   10,
+				Usage:   "number of concurrent threads reading files",
+			},
+			&cli.UintFlag{
+				Name:  "interval",
+				Value: 1,
+				Usage: "interval in seconds between each progress update",
+			},
+		},
+	}
+}
+
+func warmup(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 {
+		return fmt.Errorf("MOUNTPOINT is needed")
+	}
+	mp := ctx.Args().First()
+	inode, err := utils.GetFileInode(mp)
+	if err != nil {
+		log.Fatalf("lookup inode for %s: %s", mp, err)
+	}
+	if inode != 1 {
+		log.Fatalf("path %s is not a mount point", mp)
+	}
+	paths := ctx.Args().Tail()
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	interval := ctx.Uint("interval")
+	if interval == 0 {
+		interval = 1
+	}
+
+	watcher := &statsWatcher{
+		tty:      isatty.IsTerminal(os.Stdout.Fd()),
+		interval: interval,
+		path:     path.Join(mp, ".stats"),
+		sections: []*section{
+			{"warmup", []*item{
+				{"files", warmupFiles, metricCount | metricCounter},
+				{"read", warmupBytes, metricByte | metricCounter},
+				{"fail", warmupFailed, metricCount | metricCounter},
+			}},
+			{"blockcache", []*item{
+				{"write", "pfs_blockcache_write_bytes", metricByte | metricCounter},
+			}},
+		},
+	}
+	watcher.formatHeader()
+
+	var progress utils.WarmupProgress
+	done := make(chan error, 1)
+	begin := time.Now()
+	go func() {
+		done <- utils.Warmup(context.Background(), mp, paths, int(ctx.Uint("threads")), &progress)
+	}()
+
+	readWarmupStats := func() map[string]float64 {
+		current := readStats(watcher.path)
+		if current == nil {
+			current = make(map[string]float64)
+		}
+		files, bytes, failed := progress.Load()
+		current[warmupFiles], current[warmupBytes], current[warmupFailed] = float64(files), float64(bytes), float64(failed)
+		return current
+	}
+
+	var tick uint
+	var start, last, current map[string]float64
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	current = readWarmupStats()
+	start = current
+	last = current
+	for {
+		if tick%(watcher.interval*30) == 0 {
+			fmt.Println(watcher.header)
+		}
+		if tick%watcher.interval == 0 {
+			watcher.printDiff(start, current, false)
+			start = current
+		} else {
+			watcher.printDiff(last, current, true)
+		}
+		last = current
+		tick++
+		select {
+		case err = <-done:
+			files, bytes, failed := progress.Load()
+			cost := time.Since(begin)
+			fmt.Printf("\nwarmed up %d files (%.1f MiB) in %.1f s, %.1f MiB/s, %d files failed\n",
+				files, float64(bytes)/1024/1024, cost.Seconds(), float64(bytes)/1024/1024/cost.Seconds(), failed)
+			return err
+		case <-ticker.C:
+		}
+		current = readWarmupStats()
+	}
+}
@@ -45,6 +45,11 @@ func CacheWorkerFlags() []cli.Flag {
 			Value: "",
 			Usage: "podCachePath",
 		},
+		&cli.StringFlag{
+			Name:  "mountPoint",
+			Value: "",
+			Usage: "mount point of the fuse client, warm-up tasks read files through it",
+		},
 		&cli.StringFlag{
 			Name:  "server",
 			Value: "",
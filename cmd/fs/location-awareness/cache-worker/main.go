@@ -125,7 +125,7 @@ func act(c *cli.Context) error {
 		NodeName:  nodName,
 	}
 	go func() {
-		_ = location_awareness.ReportCacheLoop(cacheReportParams, podCachePath, httpClient)
+		_ = location_awareness.ReportCacheLoop(cacheReportParams, podCachePath, c.String("mountPoint"), httpClient)
 	}()
 
 	stopSig := make(chan os.Signal, 1)
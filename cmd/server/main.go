@@ -20,7 +20,9 @@ import (
 	jobCtrl "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/job"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/pipeline"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/queue"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/middleware"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/notification"
 	router "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/v1"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
@@ -110,6 +112,7 @@ func start() error {
 	stopChan := make(chan struct{})
 	defer close(stopChan)
 	go fs.CleanMountPodController(ServerConf.Fs.MountPodExpire, ServerConf.Fs.CleanMountPodIntervalTime, stopChan)
+	go notification.RetryPendingDeliveries(stopChan)
 
 	trace_logger.Start(ServerConf.TraceLog)
 
@@ -204,6 +207,11 @@ func setup() {
 		gracefullyExit(err)
 	}
 
+	if err := middleware.InitJWT(&ServerConf.ApiServer); err != nil {
+		log.Errorf("init jwt err: %v", err)
+		gracefullyExit(err)
+	}
+
 	if err := newAndStartJobManager(); err != nil {
 		log.Errorf("create pfjob manager failed, err %v", err)
 		gracefullyExit(err)
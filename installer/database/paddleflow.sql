@@ -51,6 +51,7 @@ CREATE TABLE IF NOT EXISTS `queue` (
     `location` text DEFAULT NULL,
     `status` varchar(20) DEFAULT NULL,
     `scheduling_policy` varchar(2048) DEFAULT NULL,
+    `scheduling_policy_args` text DEFAULT NULL,
     `created_at` datetime(3) DEFAULT NULL,
     `updated_at` datetime(3) DEFAULT NULL,
     `deleted_at` datetime(3) DEFAULT NULL,
@@ -148,6 +149,35 @@ CREATE TABLE IF NOT EXISTS `grant` (
     UNIQUE KEY (`id`)
 )ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
 
+CREATE TABLE IF NOT EXISTS `token_revocation` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `token_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'revoked token id, empty means all tokens of user',
+    `user_name` VARCHAR(60) NOT NULL COMMENT 'owner of revoked tokens',
+    `issued_before` bigint(20) NOT NULL DEFAULT 0 COMMENT 'tokens of user issued before are revoked',
+    `expires_at` bigint(20) NOT NULL DEFAULT 0 COMMENT 'revoked tokens are expired after, 0 means never',
+    `created_at` datetime DEFAULT NULL COMMENT 'create time',
+    PRIMARY KEY (`pk`),
+    INDEX (`token_id`),
+    INDEX (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='token revocation list';
+
+CREATE TABLE IF NOT EXISTS `access_token` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` VARCHAR(60) NOT NULL,
+    `name` VARCHAR(128) NOT NULL COMMENT 'token name',
+    `user_name` VARCHAR(60) NOT NULL COMMENT 'owner of token',
+    `token_hash` VARCHAR(64) NOT NULL COMMENT 'sha256 of token',
+    `scopes` VARCHAR(1024) NOT NULL DEFAULT '' COMMENT 'comma separated scopes',
+    `expires_at` datetime DEFAULT NULL COMMENT 'expire time, null means never',
+    `created_at` datetime DEFAULT NULL COMMENT 'create time',
+    `updated_at` datetime DEFAULT NULL COMMENT 'update time',
+    `deleted_at` datetime DEFAULT NULL COMMENT 'delete time',
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`id`),
+    UNIQUE KEY (`token_hash`),
+    INDEX (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='personal access token';
+
 CREATE TABLE IF NOT EXISTS `run` (
     `pk` bigint(20) NOT NULL AUTO_INCREMENT,
     `id` varchar(60) NOT NULL,
@@ -196,6 +226,8 @@ CREATE TABLE IF NOT EXISTS `run_job` (
     `cache_run_id` varchar(60),
     `cache_job_id` varchar(60),
     `extra_fs_json` text,
+    `attempt` int NOT NULL DEFAULT 1,
+    `attempts_json` text,
     `created_at` datetime(3) DEFAULT NULL,
     `activated_at` datetime(3) DEFAULT NULL,
     `updated_at` datetime(3) DEFAULT NULL,
@@ -282,6 +314,7 @@ CREATE TABLE IF NOT EXISTS `schedule` (
     `pipeline_version_id` varchar(60) NOT NULL,
     `user_name` varchar(60) NOT NULL,
     `crontab` varchar(60) NOT NULL,
+    `trigger_config` mediumtext,
     `fs_config` varchar(1024) NOT NULL,
     `options` text,
     `message` text,
@@ -295,6 +328,40 @@ CREATE TABLE IF NOT EXISTS `schedule` (
     PRIMARY KEY (`pk`)
 ) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
 
+CREATE TABLE IF NOT EXISTS `notifier` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `name` varchar(60) NOT NULL,
+    `user_name` varchar(60) NOT NULL,
+    `pipeline_id` varchar(60) NOT NULL DEFAULT '' COMMENT 'empty means all pipelines of user',
+    `type` varchar(32) NOT NULL COMMENT 'webhook, email or file',
+    `kinds` varchar(256) NOT NULL COMMENT 'json list of run, job, schedule',
+    `config` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    INDEX (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='notifier of run, job and schedule';
+
+CREATE TABLE IF NOT EXISTS `notification_delivery` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `notifier_id` varchar(60) NOT NULL,
+    `kind` varchar(32) NOT NULL COMMENT 'run, job or schedule',
+    `object_id` varchar(60) NOT NULL,
+    `status` varchar(32) NOT NULL COMMENT 'final status of object',
+    `attempts` int NOT NULL DEFAULT 0,
+    `result` varchar(32) NOT NULL COMMENT 'pending, succeeded or failed',
+    `message` text,
+    `event` text COMMENT 'content to deliver, used by retries',
+    `next_retry_at` datetime(3) DEFAULT NULL COMMENT 'time to retry the pending delivery',
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY idx_delivery_object (`notifier_id`, `kind`, `object_id`, `status`),
+    INDEX idx_delivery_pending (`result`, `next_retry_at`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='delivery log of notification';
+
 CREATE TABLE IF NOT EXISTS `run_cache` (
     `pk` bigint(20) NOT NULL AUTO_INCREMENT,
     `id` varchar(60) NOT NULL,
@@ -409,6 +476,26 @@ CREATE TABLE IF NOT EXISTS `fs_cache` (
     INDEX idx_fs_id_nodename (`fs_id`,`nodename`)
     )ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin ROW_FORMAT=COMPRESSED KEY_BLOCK_SIZE=8 COMMENT='manage file system cache ';
 
+CREATE TABLE IF NOT EXISTS `fs_cache_warmup` (
+    `pk` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'pk',
+    `warmup_id` varchar(36) NOT NULL COMMENT 'unique warmup id',
+    `fs_id` varchar(200) NOT NULL COMMENT 'file system id',
+    `cluster_id` varchar(60) DEFAULT '' COMMENT 'cluster id',
+    `nodename` varchar(255) NOT NULL COMMENT 'node name',
+    `paths` text COMMENT 'paths to warm up, json list',
+    `concurrency` int(11) NOT NULL DEFAULT 0 COMMENT 'files read concurrently',
+    `status` varchar(32) NOT NULL COMMENT 'pending/running/succeeded/failed',
+    `files` bigint(20) NOT NULL DEFAULT 0 COMMENT 'files warmed up',
+    `bytes` bigint(20) NOT NULL DEFAULT 0 COMMENT 'bytes warmed up',
+    `message` text COMMENT 'error message',
+    `created_at` datetime NOT NULL COMMENT 'create time',
+    `updated_at` datetime NOT NULL COMMENT 'update time',
+    `deleted_at` datetime(3) DEFAULT NULL  COMMENT 'delete time',
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`warmup_id`),
+    INDEX idx_warmup_node (`fs_id`,`cluster_id`,`nodename`)
+    )ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin ROW_FORMAT=COMPRESSED KEY_BLOCK_SIZE=8 COMMENT='file system cache warm-up task';
+
 CREATE TABLE IF NOT EXISTS `paddleflow_node_info` (
     `pk` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'pk',
     `cluster_id` varchar(255) NOT NULL DEFAULT '',
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PyTorchJobKind is the kind name.
+	PyTorchJobKind = "PyTorchJob"
+	// PyTorchJobDefaultContainerName is the name of the PyTorchJob container.
+	PyTorchJobDefaultContainerName = "pytorch"
+	// PyTorchJobDefaultPortName is name of the port used to communicate between Master and
+	// workers.
+	PyTorchJobDefaultPortName = "pytorchjob-port"
+	// PyTorchJobDefaultPort is default value of the port.
+	PyTorchJobDefaultPort = 23456
+
+	// PyTorchJobReplicaTypeMaster is the type of Master of distributed PyTorch
+	PyTorchJobReplicaTypeMaster ReplicaType = "Master"
+	// PyTorchJobReplicaTypeWorker is the type for workers of distributed PyTorch.
+	PyTorchJobReplicaTypeWorker ReplicaType = "Worker"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PyTorchJob Represents a PyTorchJob resource.
+type PyTorchJob struct {
+	// Standard Kubernetes type metadata.
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard Kubernetes object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired state of the PyTorchJob.
+	Spec PyTorchJobSpec `json:"spec,omitempty"`
+
+	// Most recently observed status of the PyTorchJob.
+	// Read-only (modified by the system).
+	Status JobStatus `json:"status,omitempty"`
+}
+
+// PyTorchJobSpec is a desired state description of the PyTorchJob.
+type PyTorchJobSpec struct {
+	// RunPolicy encapsulates various runtime policies of the distributed training
+	// job, for example how to clean up resources and how long the job can stay
+	// active.
+	RunPolicy RunPolicy `json:"runPolicy"`
+
+	// A map of PyTorchReplicaType (type) to ReplicaSpec (value). Specifies the PyTorch cluster configuration.
+	// For example,
+	//   {
+	//     "Master": PyTorchReplicaSpec,
+	//     "Worker": PyTorchReplicaSpec,
+	//   }
+	PyTorchReplicaSpecs map[ReplicaType]*ReplicaSpec `json:"pytorchReplicaSpecs"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PyTorchJobList is a list of PyTorchJobs.
+type PyTorchJobList struct {
+	// Standard type metadata.
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata.
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of PyTorchJobs.
+	Items []PyTorchJob `json:"items"`
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AccessTokenPrefix is the prefix of personal access token, which distinguishes it from login token
+const AccessTokenPrefix = "pfat_"
+
+// HashAccessToken returns the hash of personal access token to store and look up. A fast hash is enough,
+// as the token is random and long enough to resist brute force.
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Scopes of personal access token, a scope is "<group>:<action>" or ScopeAll. Write scope implies read
+// scope of the same group, and routes which are not in any group, such as user and grant, are only
+// allowed by ScopeAll.
+const (
+	ScopeAll = "all"
+
+	ScopeActionRead   = "read"
+	ScopeActionWrite  = "write"
+	ScopeActionCreate = "create"
+
+	ScopeGroupJob      = "job"
+	ScopeGroupRun      = "run"
+	ScopeGroupPipeline = "pipeline"
+	ScopeGroupSchedule = "schedule"
+	ScopeGroupFs       = "fs"
+	ScopeGroupQueue    = "queue"
+	ScopeGroupCluster  = "cluster"
+
+	// ScopeRunCreate only allows to create runs
+	ScopeRunCreate = ScopeGroupRun + ":" + ScopeActionCreate
+)
+
+// scopeGroups maps the first segment of route path to scope group
+var scopeGroups = map[string]string{
+	"job":        ScopeGroupJob,
+	"wsjob":      ScopeGroupJob,
+	"statistics": ScopeGroupJob,
+	"run":        ScopeGroupRun,
+	"runjson":    ScopeGroupRun,
+	"runCache":   ScopeGroupRun,
+	"artifact":   ScopeGroupRun,
+	"log":        ScopeGroupRun,
+	"pipeline":   ScopeGroupPipeline,
+	"schedule":   ScopeGroupSchedule,
+	"fs":         ScopeGroupFs,
+	"fsCache":    ScopeGroupFs,
+	"link":       ScopeGroupFs,
+	"queue":      ScopeGroupQueue,
+	"flavour":    ScopeGroupQueue,
+	"cluster":    ScopeGroupCluster,
+}
+
+// ValidateScopes checks the scopes of personal access token
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("scopes can not be empty")
+	}
+	groups := make(map[string]bool)
+	for _, group := range scopeGroups {
+		groups[group] = true
+	}
+	for _, scope := range scopes {
+		if scope == ScopeAll || scope == ScopeRunCreate {
+			continue
+		}
+		items := strings.Split(scope, ":")
+		if len(items) != 2 || !groups[items[0]] || (items[1] != ScopeActionRead && items[1] != ScopeActionWrite) {
+			return fmt.Errorf("scope[%s] is invalid", scope)
+		}
+	}
+	return nil
+}
+
+// ScopesAllow returns whether the scopes allow the request to the route path, which is the path after
+// api version prefix, such as "/job/job-0001".
+func ScopesAllow(scopes []string, method, path string) bool {
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	group, inGroup := scopeGroups[segments[0]]
+	action := ScopeActionWrite
+	if method == http.MethodGet || method == http.MethodHead {
+		action = ScopeActionRead
+	}
+	for _, scope := range scopes {
+		switch {
+		case scope == ScopeAll:
+			return true
+		case !inGroup:
+			continue
+		case scope == group+":"+action || scope == group+":"+ScopeActionWrite:
+			return true
+		case scope == ScopeRunCreate && method == http.MethodPost &&
+			(path == "/run" || path == "/runjson"):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateScopes(t *testing.T) {
+	assert.NotNil(t, ValidateScopes(nil))
+	assert.NotNil(t, ValidateScopes([]string{"user:read"}))
+	assert.NotNil(t, ValidateScopes([]string{"job"}))
+	assert.Nil(t, ValidateScopes([]string{ScopeAll, "fs:read", "job:write", ScopeRunCreate}))
+}
+
+func TestScopesAllow(t *testing.T) {
+	assert.True(t, ScopesAllow([]string{ScopeAll}, http.MethodDelete, "/user/u1"))
+	assert.True(t, ScopesAllow([]string{"fs:read"}, http.MethodGet, "/fsCache/fs1"))
+	assert.False(t, ScopesAllow([]string{"fs:read"}, http.MethodPost, "/fs"))
+	assert.True(t, ScopesAllow([]string{"job:write"}, http.MethodGet, "/job"))
+	assert.True(t, ScopesAllow([]string{ScopeRunCreate}, http.MethodPost, "/run"))
+	assert.False(t, ScopesAllow([]string{ScopeRunCreate}, http.MethodPut, "/run/run-0001"))
+	assert.False(t, ScopesAllow([]string{"job:write"}, http.MethodGet, "/user"))
+}
@@ -19,15 +19,18 @@ package common
 const (
 	SeparatorComma = ","
 
-	PrefixSchedule   = "schedule-"
-	PrefixRun        = "run-"
-	PrefixPipeline   = "ppl-"
-	PrefixCache      = "cch-"
-	PrefixGrant      = "grant"
-	PrefixQueue      = "queue"
-	PrefixCluster    = "cluster"
-	PrefixFlavour    = "flavour"
-	PrefixConnection = "conn"
+	PrefixSchedule    = "schedule-"
+	PrefixRun         = "run-"
+	PrefixPipeline    = "ppl-"
+	PrefixCache       = "cch-"
+	PrefixGrant       = "grant"
+	PrefixQueue       = "queue"
+	PrefixCluster     = "cluster"
+	PrefixFlavour     = "flavour"
+	PrefixConnection  = "conn"
+	PrefixToken       = "token"
+	PrefixAccessToken = "pat"
+	PrefixNotifier    = "notifier-"
 
 	ResourceTypeSchedule      = "schedule"
 	ResourceTypeRun           = "run"
@@ -40,6 +43,10 @@ const (
 	ResourceTypePipeline      = "pipeline"
 	ResourceTypeCluster       = "cluster"
 	ResourceTypeJob           = "job"
+	ResourceTypeNotifier      = "notifier"
+
+	PaddleflowRouterPrefix    = "/api/paddleflow"
+	PaddleflowRouterVersionV1 = "/v1"
 
 	HeaderKeyRequestID     = "x-pf-request-id"
 	HeaderKeyUserName      = "x-pf-user-name"
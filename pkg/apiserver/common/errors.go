@@ -93,6 +93,9 @@ const (
 	JobCreateFailed = "JobCreateFailed" // job create failed
 	JobNotFound     = "JobNotFound"
 
+	NotifierNotFound     = "NotifierNotFound"
+	NotifierInvalidField = "NotifierInvalidField"
+
 	ClusterNameNotFound      = "ClusterNameNotFound"
 	ClusterIdNotFound        = "ClusterIdNotFound"
 	ClusterNotFound          = "ClusterNotFound"
@@ -193,6 +196,9 @@ var errorHTTPStatus = map[string]int{
 	JobCreateFailed: http.StatusBadRequest,
 	JobNotFound:     http.StatusNotFound,
 
+	NotifierNotFound:     http.StatusNotFound,
+	NotifierInvalidField: http.StatusBadRequest,
+
 	ClusterNameNotFound:      http.StatusBadRequest,
 	ClusterIdNotFound:        http.StatusBadRequest,
 	ClusterNotFound:          http.StatusBadRequest,
@@ -273,6 +279,9 @@ var errorMessage = map[string]string{
 	JobInvalidField: "job field invalid",
 	JobCreateFailed: "job create failed",
 
+	NotifierNotFound:     "notifier not found",
+	NotifierInvalidField: "notifier field invalid",
+
 	RunNameDuplicated:     "Run name already exists",
 	RunNotFound:           "RunID not found",
 	PipelineNotFound:      "Pipeline not found",
@@ -321,6 +330,8 @@ type ErrorResponse struct {
 	RequestID    string `json:"requestID"`
 	ErrorCode    string `json:"code"`
 	ErrorMessage string `json:"message"`
+	// Errors contains the detailed errors, e.g. the validation error of each field
+	Errors interface{} `json:"errors,omitempty"`
 }
 
 func GetMessageByCode(code string) string {
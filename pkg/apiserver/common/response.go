@@ -38,6 +38,11 @@ func RenderErr(w http.ResponseWriter, requestID string, code string) {
 }
 
 func RenderErrWithMessage(w http.ResponseWriter, requestID string, code string, message string) {
+	RenderErrWithDetails(w, requestID, code, message, nil)
+}
+
+// RenderErrWithDetails renders the error response with detailed errors, such as the validation error of each field
+func RenderErrWithDetails(w http.ResponseWriter, requestID string, code string, message string, errors interface{}) {
 	if code == "" {
 		code = InternalError
 	}
@@ -49,6 +54,7 @@ func RenderErrWithMessage(w http.ResponseWriter, requestID string, code string,
 		RequestID:    requestID,
 		ErrorCode:    code,
 		ErrorMessage: message,
+		Errors:       errors,
 	}
 	// code没有设置对应的http状态码
 	if httpCode == 0 {
@@ -128,7 +128,7 @@ func InformationFromURL(url string, properties map[string]string) (fileSystemTyp
 	urlSplit := strings.Split(url, "/")
 
 	switch fileSystemType {
-	case common.LocalType, common.MockType:
+	case common.LocalType, common.MockType, common.TieredType:
 		serverAddress = ""
 		subPath = "/" + SubPathFromUrl(urlSplit, LocalSplit)
 	case common.HDFSType:
@@ -76,6 +76,13 @@ func TestInformationFromUrl(t *testing.T) {
 			wantServerAddress:  "",
 			wantSubPath:        "/data/myfs",
 		},
+		{
+			name:               "tiered",
+			args:               args{url: "tiered://dataset"},
+			wantFileSystemType: "tiered",
+			wantServerAddress:  "",
+			wantSubPath:        "/dataset",
+		},
 		{
 			name:               "s3",
 			args:               args{url: "s3://bucket/path", properties: map[string]string{common.Endpoint: "192.168.1.4"}},
@@ -34,9 +34,12 @@ type CacheReportRequest struct {
 	CacheDir  string `json:"cacheDir" validate:"required"`
 	NodeName  string `json:"nodename" validate:"required"`
 	UsedSize  int    `json:"usedsize" validate:"required"`
+	// Warmups reports progress of warm-up tasks running on the node
+	Warmups []WarmupReport `json:"warmups"`
 }
 
-func ReportCache(ctx *logger.RequestContext, req CacheReportRequest) error {
+// ReportCache saves the cache of a node, and returns warm-up tasks to be run on the node
+func ReportCache(ctx *logger.RequestContext, req CacheReportRequest) (*CacheReportResponse, error) {
 	fsCache := &model.FSCache{
 		FsID:      common.ID(req.Username, req.FsName),
 		CacheDir:  req.CacheDir,
@@ -49,7 +52,7 @@ func ReportCache(ctx *logger.RequestContext, req CacheReportRequest) error {
 	if err != nil {
 		ctx.ErrorCode = common.InternalError
 		ctx.Logging().Errorf("ReportCache Update[%s] err:%v", fsCache.FsID, err)
-		return err
+		return nil, err
 	}
 	if n == 0 {
 		err = storage.FsCache.Add(fsCache)
@@ -57,9 +60,14 @@ func ReportCache(ctx *logger.RequestContext, req CacheReportRequest) error {
 	if err != nil {
 		ctx.ErrorCode = common.InternalError
 		ctx.Logging().Errorf("ReportCache Create[%s] err:%v", fsCache.FsID, err)
-		return err
+		return nil, err
 	}
-	return nil
+	tasks, err := reportWarmup(ctx, fsCache.FsID, req)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	return &CacheReportResponse{Warmups: tasks}, nil
 }
 
 func removeFSCache(fsID string) error {
@@ -68,6 +76,11 @@ func removeFSCache(fsID string) error {
 		log.Error(err.Error())
 		return err
 	}
+	if err := storage.FsWarmup.DeleteByFsID(fsID); err != nil {
+		err := fmt.Errorf("removeFSCache warmup of [%s] failed: %v", fsID, err)
+		log.Error(err.Error())
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,217 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	DefaultWarmupConcurrency = 10
+	MaxWarmupConcurrency     = 100
+	// WarmupRedispatchTimeout is how long a running task can go without progress report before it is sent to
+	// the cache-worker again, e.g. the cache-worker restarted and lost the task. Reports are sent every 15-25s
+	WarmupRedispatchTimeout = 2 * time.Minute
+	// WarmupExpireTimeout is how long a running task can go without progress report before it is failed,
+	// e.g. the node is gone
+	WarmupExpireTimeout = 30 * time.Minute
+)
+
+type CreateCacheWarmupRequest struct {
+	FsID string `json:"-"`
+	// Paths are relative to the root of fs
+	Paths []string `json:"paths"`
+	// NodeNames selects nodes to warm up, nodes which have cache of the fs are all selected if empty
+	NodeNames   []string `json:"nodenames"`
+	Concurrency int      `json:"concurrency"`
+}
+
+type CacheWarmupResponse struct {
+	WarmupList []model.FSCacheWarmup `json:"warmupList"`
+}
+
+// WarmupReport is the progress of a warm-up task reported by cache-worker
+type WarmupReport struct {
+	WarmupID string `json:"warmupID"`
+	Status   string `json:"status"`
+	Files    int64  `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	Message  string `json:"message"`
+}
+
+type WarmupTask struct {
+	WarmupID    string   `json:"warmupID"`
+	Paths       []string `json:"paths"`
+	Concurrency int      `json:"concurrency"`
+}
+
+type CacheReportResponse struct {
+	Warmups []WarmupTask `json:"warmups"`
+}
+
+func validateCacheWarmup(req *CreateCacheWarmupRequest) error {
+	if len(req.Paths) == 0 {
+		return fmt.Errorf("paths should not be empty")
+	}
+	for i, p := range req.Paths {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("path should not be empty")
+		}
+		// 路径统一为相对 fs 根目录的绝对路径，不允许通过 .. 访问 fs 以外的目录
+		req.Paths[i] = path.Clean("/" + p)
+	}
+	if req.Concurrency < 0 || req.Concurrency > MaxWarmupConcurrency {
+		return fmt.Errorf("concurrency[%d] should be in [0, %d]", req.Concurrency, MaxWarmupConcurrency)
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = DefaultWarmupConcurrency
+	}
+	return nil
+}
+
+// CreateCacheWarmup creates a warm-up task for each selected node which has cache of the fs, the tasks are
+// dispatched to cache-worker when it reports cache next time
+func CreateCacheWarmup(ctx *logger.RequestContext, req CreateCacheWarmupRequest) (*CacheWarmupResponse, error) {
+	if err := validateCacheWarmup(&req); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("validate cache warmup of fs[%s] failed: %v", req.FsID, err)
+		return nil, err
+	}
+	caches, err := storage.FsCache.List(req.FsID, "")
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		ctx.Logging().Errorf("list cache of fs[%s] failed: %v", req.FsID, err)
+		return nil, err
+	}
+	selected := make(map[string]bool, len(req.NodeNames))
+	for _, nodeName := range req.NodeNames {
+		selected[nodeName] = true
+	}
+	warmups := make([]model.FSCacheWarmup, 0)
+	found := make(map[string]bool)
+	for _, cache := range caches {
+		if len(selected) > 0 && !selected[cache.NodeName] {
+			continue
+		}
+		key := cache.ClusterID + "/" + cache.NodeName
+		if found[key] {
+			continue
+		}
+		found[cache.NodeName], found[key] = true, true
+		warmups = append(warmups, model.FSCacheWarmup{
+			WarmupID:    uuid.NewString(),
+			FsID:        req.FsID,
+			ClusterID:   cache.ClusterID,
+			NodeName:    cache.NodeName,
+			Paths:       req.Paths,
+			Concurrency: req.Concurrency,
+			Status:      model.WarmupStatusPending,
+		})
+	}
+	for nodeName := range selected {
+		if !found[nodeName] {
+			ctx.ErrorCode = common.InvalidArguments
+			err := fmt.Errorf("node[%s] has no cache of fs[%s]", nodeName, req.FsID)
+			ctx.Logging().Errorf("create cache warmup failed: %v", err)
+			return nil, err
+		}
+	}
+	if len(warmups) == 0 {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("fs[%s] has no cache on any node, mount it with cache first", req.FsID)
+		ctx.Logging().Errorf("create cache warmup failed: %v", err)
+		return nil, err
+	}
+	if err = storage.FsWarmup.Create(warmups); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		ctx.Logging().Errorf("create cache warmup of fs[%s] failed: %v", req.FsID, err)
+		return nil, err
+	}
+	return &CacheWarmupResponse{WarmupList: warmups}, nil
+}
+
+func ListCacheWarmup(ctx *logger.RequestContext, fsID string) (*CacheWarmupResponse, error) {
+	if err := storage.FsWarmup.FailStale(fsID, time.Now().Add(-WarmupExpireTimeout),
+		fmt.Sprintf("no progress reported in %s", WarmupExpireTimeout)); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		ctx.Logging().Errorf("fail stale cache warmup of fs[%s] failed: %v", fsID, err)
+		return nil, err
+	}
+	warmups, err := storage.FsWarmup.List(fsID, "", "")
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		ctx.Logging().Errorf("list cache warmup of fs[%s] failed: %v", fsID, err)
+		return nil, err
+	}
+	return &CacheWarmupResponse{WarmupList: warmups}, nil
+}
+
+// reportWarmup saves progress reported by cache-worker, and returns pending tasks of the node, together with
+// running tasks whose progress is not reported for WarmupRedispatchTimeout
+func reportWarmup(ctx *logger.RequestContext, fsID string, req CacheReportRequest) ([]WarmupTask, error) {
+	for _, report := range req.Warmups {
+		status := report.Status
+		if status != model.WarmupStatusRunning && !model.IsFinishedWarmupStatus(status) {
+			ctx.Logging().Warningf("warmup[%s] reported with invalid status[%s]", report.WarmupID, status)
+			continue
+		}
+		if err := storage.FsWarmup.UpdateProgress(fsID, req.ClusterID, req.NodeName, report.WarmupID, status,
+			report.Files, report.Bytes, report.Message); err != nil {
+			ctx.Logging().Errorf("update progress of warmup[%s] failed: %v", report.WarmupID, err)
+			return nil, err
+		}
+	}
+	unfinished, err := storage.FsWarmup.List(fsID, req.ClusterID, req.NodeName,
+		model.WarmupStatusPending, model.WarmupStatusRunning)
+	if err != nil {
+		ctx.Logging().Errorf("list unfinished warmup of fs[%s] on node[%s] failed: %v", fsID, req.NodeName, err)
+		return nil, err
+	}
+	staleBefore := time.Now().Add(-WarmupRedispatchTimeout)
+	tasks := make([]WarmupTask, 0, len(unfinished))
+	warmupIDs := make([]string, 0, len(unfinished))
+	for _, warmup := range unfinished {
+		if warmup.Status == model.WarmupStatusRunning {
+			if !warmup.UpdatedAt.Before(staleBefore) {
+				continue
+			}
+			ctx.Logging().Warningf("warmup[%s] on node[%s] has no progress reported since %s, dispatch it again",
+				warmup.WarmupID, req.NodeName, warmup.UpdateTime)
+		}
+		tasks = append(tasks, WarmupTask{
+			WarmupID:    warmup.WarmupID,
+			Paths:       warmup.Paths,
+			Concurrency: warmup.Concurrency,
+		})
+		warmupIDs = append(warmupIDs, warmup.WarmupID)
+	}
+	if err = storage.FsWarmup.Dispatch(warmupIDs); err != nil {
+		ctx.Logging().Errorf("dispatch warmup of fs[%s] on node[%s] failed: %v", fsID, req.NodeName, err)
+		return nil, err
+	}
+	return tasks, nil
+}
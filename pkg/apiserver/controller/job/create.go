@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
@@ -117,9 +118,9 @@ func validateJobFramework(ctx *logger.RequestContext, jobType schema.JobType, fr
 		}
 	case schema.TypeDistributed:
 		switch framework {
-		case schema.FrameworkSpark, schema.FrameworkPaddle:
+		case schema.FrameworkSpark, schema.FrameworkPaddle, schema.FrameworkTF, schema.FrameworkPytorch:
 			err = nil
-		case schema.FrameworkTF, schema.FrameworkMPI:
+		case schema.FrameworkMPI:
 			err = fmt.Errorf("framework: %s for distributed job will be supported in the future", framework)
 		default:
 			err = fmt.Errorf("invalid framework %s for distributed job", framework)
@@ -162,6 +163,11 @@ func validateCommonJobInfo(ctx *logger.RequestContext, requestCommonJobInfo *Com
 		ctx.ErrorCode = common.JobInvalidField
 		return err
 	}
+	if err := checkExpectedDuration(requestCommonJobInfo.SchedulingPolicy.ExpectedDuration); err != nil {
+		ctx.Logging().Errorf("Failed to check expected duration: %v", err)
+		ctx.ErrorCode = common.JobInvalidField
+		return err
+	}
 
 	return nil
 }
@@ -174,7 +180,7 @@ func validateJobMembers(ctx *logger.RequestContext, request *CreateJobInfo) erro
 		return err
 	}
 
-	frameworkRoles := getFrameworkRoles(request.Framework)
+	frameworkRoles := GetFrameworkRoles(request.Framework)
 	// calculate total member resource, and compare with queue.MaxResource
 	sumResource := resources.EmptyResource()
 	for index, member := range request.Members {
@@ -338,6 +344,21 @@ func checkPriority(schedulingPolicy, parentSP *SchedulingPolicy) error {
 	return nil
 }
 
+// checkExpectedDuration check the expected duration of job, which is optional
+func checkExpectedDuration(expectedDuration string) error {
+	if expectedDuration == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(expectedDuration)
+	if err != nil {
+		return fmt.Errorf("expectedDuration[%s] is invalid, err: %v", expectedDuration, err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("expectedDuration[%s] must be positive", expectedDuration)
+	}
+	return nil
+}
+
 func validateMembersQueue(ctx *logger.RequestContext, member *MemberSpec, schePolicy SchedulingPolicy) error {
 	queueName := schePolicy.Queue
 
@@ -430,6 +451,12 @@ func checkMemberRole(framework schema.Framework, roles map[schema.MemberRole]int
 				err = fmt.Errorf("framework %s in collective mode, only setting role work", framework)
 			}
 		}
+	case schema.FrameworkPytorch:
+		// collective mode, which has only one master
+		jobMode = schema.EnvJobModeCollective
+		if roles[schema.RoleMaster] != 1 {
+			err = fmt.Errorf("pytorch job must be set role master, and replicas of master must be 1")
+		}
 	case schema.FrameworkSpark:
 		jobMode = schema.EnvJobModePS
 		if roles[schema.RoleDriver] < 1 {
@@ -447,7 +474,8 @@ func checkMemberRole(framework schema.Framework, roles map[schema.MemberRole]int
 	return jobMode, err
 }
 
-func getFrameworkRoles(framework schema.Framework) map[schema.MemberRole]int {
+// GetFrameworkRoles returns the roles supported by framework, and the replicas of each role is initialized with 0
+func GetFrameworkRoles(framework schema.Framework) map[schema.MemberRole]int {
 	var roles = make(map[schema.MemberRole]int)
 	switch framework {
 	case schema.FrameworkPaddle, schema.FrameworkTF:
@@ -457,7 +485,7 @@ func getFrameworkRoles(framework schema.Framework) map[schema.MemberRole]int {
 	case schema.FrameworkSpark:
 		roles[schema.RoleDriver] = 0
 		roles[schema.RoleExecutor] = 0
-	case schema.FrameworkMPI:
+	case schema.FrameworkMPI, schema.FrameworkPytorch:
 		roles[schema.RoleMaster] = 0
 		roles[schema.RoleWorker] = 0
 	case schema.FrameworkStandalone:
@@ -550,6 +578,7 @@ func buildCommonInfo(conf *schema.Conf, commonJobInfo *CommonJobInfo) {
 	conf.SetQueueID(schedulingPolicy.QueueID)
 	conf.SetQueueName(schedulingPolicy.Queue)
 	conf.SetPriority(schedulingPolicy.Priority)
+	conf.SetExpectedDuration(schedulingPolicy.ExpectedDuration)
 	conf.SetClusterID(schedulingPolicy.ClusterId)
 	conf.SetNamespace(schedulingPolicy.Namespace)
 }
@@ -675,7 +704,7 @@ func validateWorkflowJob(ctx *logger.RequestContext, request *CreateWfJobRequest
 }
 
 // CreatePPLJob create a run job, used by pipeline
-func CreatePPLJob(conf schema.PFJobConf) (string, error) {
+func CreatePPLJob(conf *schema.PPLJobConf) (string, error) {
 	createJobInfo, err := jobConfToCreateJobInfo(conf)
 	if err != nil {
 		log.Errorf("convert job config to CreateJobInfo failed. err: %s", err)
@@ -692,7 +721,7 @@ func CreatePPLJob(conf schema.PFJobConf) (string, error) {
 	return jobResponse.ID, nil
 }
 
-func ValidatePPLJob(conf schema.PFJobConf) error {
+func ValidatePPLJob(conf *schema.PPLJobConf) error {
 	createJobInfo, err := jobConfToCreateJobInfo(conf)
 	if err != nil {
 		log.Errorf("convert job config to CreateJobInfo failed. err: %s", err)
@@ -711,7 +740,7 @@ func ValidatePPLJob(conf schema.PFJobConf) error {
 	return validateJob(ctx, createJobInfo)
 }
 
-func jobConfToCreateJobInfo(conf schema.PFJobConf) (*CreateJobInfo, error) {
+func jobConfToCreateJobInfo(conf *schema.PPLJobConf) (*CreateJobInfo, error) {
 	commonJobInfo := CommonJobInfo{
 		ID:   generateJobID(conf.GetName()),
 		Name: conf.GetName(),
@@ -722,9 +751,7 @@ func jobConfToCreateJobInfo(conf schema.PFJobConf) (*CreateJobInfo, error) {
 		UserName: conf.GetUserName(),
 	}
 	jobSpec := JobSpec{
-		Flavour: schema.Flavour{
-			Name: conf.GetFlavour(),
-		},
+		Flavour:          conf.Flavour,
 		FileSystem:       conf.GetFileSystem(),
 		ExtraFileSystems: conf.GetExtraFS(),
 		Image:            conf.GetImage(),
@@ -734,14 +761,45 @@ func jobConfToCreateJobInfo(conf schema.PFJobConf) (*CreateJobInfo, error) {
 	}
 
 	jobType := conf.Type()
+	if conf.IsDistributed() {
+		jobType = schema.TypeDistributed
+	}
 	var err error
 	var framework schema.Framework
+	var members []MemberSpec
 	switch jobType {
 	case "", schema.TypeSingle, schema.TypeVcJob:
 		jobType = schema.TypeSingle
 		framework = schema.FrameworkStandalone
+		members = []MemberSpec{
+			{
+				CommonJobInfo: commonJobInfo,
+				JobSpec:       jobSpec,
+				Role:          string(schema.RoleWorker),
+				Replicas:      1,
+			},
+		}
 	case schema.TypeDistributed:
-		err = fmt.Errorf("distributed job is not implemented")
+		framework = conf.Framework
+		if len(conf.Members) == 0 {
+			err = fmt.Errorf("members of distributed job with framework[%s] are empty", framework)
+			break
+		}
+		for _, m := range conf.Members {
+			memberSpec := jobSpec
+			if m.Flavour != "" {
+				memberSpec.Flavour = schema.Flavour{Name: m.Flavour}
+			}
+			if m.Command != "" {
+				memberSpec.Command = m.Command
+			}
+			members = append(members, MemberSpec{
+				CommonJobInfo: commonJobInfo,
+				JobSpec:       memberSpec,
+				Role:          string(m.Role),
+				Replicas:      m.Replicas,
+			})
+		}
 	default:
 		err = fmt.Errorf("job type %s is not support", jobType)
 	}
@@ -754,14 +812,7 @@ func jobConfToCreateJobInfo(conf schema.PFJobConf) (*CreateJobInfo, error) {
 		CommonJobInfo: commonJobInfo,
 		Type:          jobType,
 		Framework:     framework,
-		Members: []MemberSpec{
-			{
-				CommonJobInfo: commonJobInfo,
-				JobSpec:       jobSpec,
-				Role:          string(schema.RoleWorker),
-				Replicas:      1,
-			},
-		},
+		Members:       members,
 	}, nil
 }
 
@@ -22,6 +22,7 @@ import (
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/notification"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -96,6 +97,8 @@ type SchedulingPolicy struct {
 	ClusterId    string              `json:"-"`
 	Namespace    string              `json:"-"`
 	Priority     string              `json:"priority,omitempty"`
+	// ExpectedDuration is the estimated running time of job, such as 30m or 2h
+	ExpectedDuration string `json:"expectedDuration,omitempty"`
 }
 
 // JobSpec the spec fields for jobs
@@ -181,6 +184,10 @@ func StopJob(ctx *logger.RequestContext, jobID string) error {
 
 	if job.Status == schema.StatusJobInit {
 		err = models.UpdateJobStatus(jobID, "job is terminated.", schema.StatusJobTerminated)
+		if err == nil {
+			// the job is not submitted yet, remove it from the queue of job manager
+			notification.NotifyJob(jobID, schema.StatusJobTerminated)
+		}
 	} else {
 		var runtimeSvc runtime.RuntimeService
 		runtimeSvc, err = getRuntimeByQueue(ctx, job.QueueID)
@@ -0,0 +1,198 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"fmt"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/pipeline"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/notification"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+)
+
+// maskedSecret 用于在返回结果中隐藏 webhook secret 和 smtp 密码
+const maskedSecret = "******"
+
+type CreateNotifierRequest struct {
+	Name string `json:"name"`
+	// PipelineID 为空时对用户所有的 run/job/schedule 生效
+	PipelineID string `json:"pipelineID"`
+	Type       string `json:"type"`
+	// Kinds 为空时关注 run、job、schedule 所有对象
+	Kinds  []string              `json:"kinds"`
+	Config models.NotifierConfig `json:"config"`
+}
+
+type ListNotifierResponse struct {
+	NotifierList []models.Notifier `json:"notifierList"`
+}
+
+type ListDeliveryResponse struct {
+	DeliveryList []models.NotificationDelivery `json:"deliveryList"`
+}
+
+func validateCreateNotifier(ctx *logger.RequestContext, request *CreateNotifierRequest) error {
+	if request.Name == "" {
+		return fmt.Errorf("name should not be empty")
+	}
+	for _, kind := range request.Kinds {
+		if !containsString(models.NotifyKindList, kind) {
+			return fmt.Errorf("kind[%s] not supported, should be one of %v", kind, models.NotifyKindList)
+		}
+	}
+	if err := notification.ValidateConfig(ctx.UserName, request.Type, request.Config); err != nil {
+		return err
+	}
+	if request.PipelineID != "" {
+		hasAuth, _, err := pipeline.CheckPipelinePermission(ctx.UserName, request.PipelineID)
+		if err != nil {
+			return err
+		}
+		if !hasAuth {
+			ctx.ErrorCode = common.AccessDenied
+			return common.NoAccessError(ctx.UserName, common.ResourceTypePipeline, request.PipelineID)
+		}
+	}
+	return nil
+}
+
+func CreateNotifier(ctx *logger.RequestContext, request *CreateNotifierRequest) (*models.Notifier, error) {
+	ctx.Logging().Debugf("begin create notifier: %+v", request)
+	if err := validateCreateNotifier(ctx, request); err != nil {
+		if ctx.ErrorCode == "" {
+			ctx.ErrorCode = common.NotifierInvalidField
+		}
+		ctx.Logging().Errorf("create notifier failed. error: %v", err)
+		return nil, err
+	}
+
+	conf := request.Config
+	var err error
+	if conf.Secret != "" {
+		if conf.Secret, err = common.AesEncrypt(conf.Secret, common.AESEncryptKey); err != nil {
+			ctx.ErrorCode = common.InternalError
+			ctx.Logging().Errorf("encrypt secret of notifier failed. error: %v", err)
+			return nil, err
+		}
+	}
+	if conf.Password != "" {
+		if conf.Password, err = common.AesEncrypt(conf.Password, common.AESEncryptKey); err != nil {
+			ctx.ErrorCode = common.InternalError
+			ctx.Logging().Errorf("encrypt password of notifier failed. error: %v", err)
+			return nil, err
+		}
+	}
+	notifier := &models.Notifier{
+		Name:       request.Name,
+		UserName:   ctx.UserName,
+		PipelineID: request.PipelineID,
+		Type:       request.Type,
+		Kinds:      request.Kinds,
+		Config:     conf,
+	}
+	if err := models.CreateNotifier(ctx.Logging(), notifier); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	maskNotifier(notifier)
+	return notifier, nil
+}
+
+// ListNotifier 普通用户列出自己的通知方式，root 用户列出所有的
+func ListNotifier(ctx *logger.RequestContext) (*ListNotifierResponse, error) {
+	userName := ctx.UserName
+	if common.IsRootUser(userName) {
+		userName = ""
+	}
+	notifiers, err := models.ListNotifier(ctx.Logging(), userName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	for i := range notifiers {
+		maskNotifier(&notifiers[i])
+	}
+	return &ListNotifierResponse{NotifierList: notifiers}, nil
+}
+
+func GetNotifier(ctx *logger.RequestContext, notifierID string) (*models.Notifier, error) {
+	notifier, err := getNotifierWithPermission(ctx, notifierID)
+	if err != nil {
+		return nil, err
+	}
+	maskNotifier(&notifier)
+	return &notifier, nil
+}
+
+func DeleteNotifier(ctx *logger.RequestContext, notifierID string) error {
+	if _, err := getNotifierWithPermission(ctx, notifierID); err != nil {
+		return err
+	}
+	if err := models.DeleteNotifier(ctx.Logging(), notifierID); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+// ListDelivery 按时间倒序列出通知方式最近的投递记录
+func ListDelivery(ctx *logger.RequestContext, notifierID string, maxKeys int) (*ListDeliveryResponse, error) {
+	if _, err := getNotifierWithPermission(ctx, notifierID); err != nil {
+		return nil, err
+	}
+	deliveries, err := models.ListDelivery(ctx.Logging(), notifierID, maxKeys)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &ListDeliveryResponse{DeliveryList: deliveries}, nil
+}
+
+func getNotifierWithPermission(ctx *logger.RequestContext, notifierID string) (models.Notifier, error) {
+	notifier, err := models.GetNotifier(ctx.Logging(), notifierID)
+	if err != nil {
+		ctx.ErrorCode = common.NotifierNotFound
+		return models.Notifier{}, err
+	}
+	if !common.IsRootUser(ctx.UserName) && ctx.UserName != notifier.UserName {
+		ctx.ErrorCode = common.AccessDenied
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeNotifier, notifierID)
+		ctx.Logging().Errorln(err.Error())
+		return models.Notifier{}, err
+	}
+	return notifier, nil
+}
+
+func maskNotifier(notifier *models.Notifier) {
+	if notifier.Config.Secret != "" {
+		notifier.Config.Secret = maskedSecret
+	}
+	if notifier.Config.Password != "" {
+		notifier.Config.Password = maskedSecret
+	}
+}
+
+func containsString(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
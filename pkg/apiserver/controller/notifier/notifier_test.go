@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestNotifier(t *testing.T) {
+	driver.InitMockDB()
+	ppl := models.Pipeline{Name: "ppl1", UserName: "user1"}
+	pplVersion := models.PipelineVersion{FsName: "fsname", UserName: "user1"}
+	pplID, _, err := models.CreatePipeline(logger.Logger(), &ppl, &pplVersion)
+	assert.Nil(t, err)
+
+	ctx1 := &logger.RequestContext{UserName: "user1"}
+	ctx2 := &logger.RequestContext{UserName: "user2"}
+
+	// bad cases
+	_, err = CreateNotifier(ctx1, &CreateNotifierRequest{Name: "hook", Type: models.NotifierTypeWebhook})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.NotifierInvalidField, ctx1.ErrorCode)
+	_, err = CreateNotifier(ctx1, &CreateNotifierRequest{Name: "file", Type: models.NotifierTypeFile, Kinds: []string{"pipeline"}})
+	assert.NotNil(t, err)
+	_, err = CreateNotifier(ctx2, &CreateNotifierRequest{Name: "file", Type: models.NotifierTypeFile, PipelineID: pplID})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, ctx2.ErrorCode)
+
+	// secret is encrypted in db and never returned
+	ctx1.ErrorCode = ""
+	notifier, err := CreateNotifier(ctx1, &CreateNotifierRequest{
+		Name:       "hook",
+		PipelineID: pplID,
+		Type:       models.NotifierTypeWebhook,
+		Kinds:      []string{models.NotifyKindRun},
+		Config:     models.NotifierConfig{URL: "https://hook.example.com/hook", Secret: "secret1"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, maskedSecret, notifier.Config.Secret)
+	notifierInDB, err := models.GetNotifier(logger.Logger(), notifier.ID)
+	assert.Nil(t, err)
+	secret, err := common.AesDecrypt(notifierInDB.Config.Secret, common.AESEncryptKey)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret1", secret)
+
+	_, err = CreateNotifier(ctx2, &CreateNotifierRequest{Name: "file", Type: models.NotifierTypeFile})
+	assert.Nil(t, err)
+
+	listResp, err := ListNotifier(ctx1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.NotifierList))
+	listResp, err = ListNotifier(&logger.RequestContext{UserName: "root"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(listResp.NotifierList))
+
+	_, err = GetNotifier(ctx2, notifier.ID)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, ctx2.ErrorCode)
+	got, err := GetNotifier(ctx1, notifier.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{models.NotifyKindRun}, got.Kinds)
+	assert.Equal(t, maskedSecret, got.Config.Secret)
+
+	deliveryResp, err := ListDelivery(ctx1, notifier.ID, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(deliveryResp.DeliveryList))
+
+	assert.NotNil(t, DeleteNotifier(ctx2, notifier.ID))
+	assert.Nil(t, DeleteNotifier(ctx1, notifier.ID))
+	_, err = GetNotifier(ctx1, notifier.ID)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.NotifierNotFound, ctx1.ErrorCode)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/notification"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -158,6 +159,18 @@ func UpdateRunByWfEvent(id string, event interface{}) (int64, bool) {
 			globalScheduler.ConcurrencyChannel <- prevRun.ScheduleID
 			logging.Debugf("send scheduleID[%s] to concurrency channel succeed.", prevRun.ScheduleID)
 		}
+
+		if prevRun.Status != status {
+			notification.Notify(notification.Event{
+				Kind:       models.NotifyKindRun,
+				ID:         runID,
+				Name:       prevRun.Name,
+				UserName:   prevRun.UserName,
+				PipelineID: prevRun.GetPipelineID(),
+				Status:     status,
+				Message:    wfEvent.Message,
+			})
+		}
 	}
 
 	return 0, true
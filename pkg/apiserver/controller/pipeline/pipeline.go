@@ -96,14 +96,15 @@ func (pb *PipelineBrief) updateFromPipelineModel(pipeline models.Pipeline) {
 }
 
 type PipelineVersionBrief struct {
-	ID           string `json:"pipelineVersionID"`
-	PipelineID   string `json:"pipelineID"`
-	FsName       string `json:"fsName"`
-	YamlPath     string `json:"yamlPath"`
-	PipelineYaml string `json:"pipelineYaml"`
-	UserName     string `json:"username"`
-	CreateTime   string `json:"createTime"`
-	UpdateTime   string `json:"updateTime"`
+	ID           string                `json:"pipelineVersionID"`
+	PipelineID   string                `json:"pipelineID"`
+	FsName       string                `json:"fsName"`
+	YamlPath     string                `json:"yamlPath"`
+	PipelineYaml string                `json:"pipelineYaml"`
+	UserName     string                `json:"username"`
+	CreateTime   string                `json:"createTime"`
+	UpdateTime   string                `json:"updateTime"`
+	Parameters   []pplcommon.ParamSpec `json:"parameters,omitempty"` // only returned by GetPipelineVersion
 }
 
 func (pdb *PipelineVersionBrief) updateFromPipelineVersionModel(pipelineVersion models.PipelineVersion) {
@@ -520,6 +521,15 @@ func GetPipelineVersion(ctx *logger.RequestContext, pipelineID string, pipelineV
 	getPipelineVersionResponse := GetPipelineVersionResponse{}
 	getPipelineVersionResponse.Pipeline.updateFromPipelineModel(ppl)
 	getPipelineVersionResponse.PipelineVersion.updateFromPipelineVersionModel(pplVersion)
+
+	// parameter specs declared in pipeline yaml, the yaml has been validated when pipeline version created
+	wfs, err := schema.GetWorkflowSource([]byte(pplVersion.PipelineYaml))
+	if err != nil {
+		ctx.Logging().Warnf("parse yaml of pipeline[%s] version[%s] failed, parameters are not returned. err:%v",
+			pipelineID, pipelineVersionID, err)
+	} else {
+		getPipelineVersionResponse.PipelineVersion.Parameters = pplcommon.GetParamSpecs(&wfs)
+	}
 	return getPipelineVersionResponse, nil
 }
 
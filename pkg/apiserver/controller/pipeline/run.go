@@ -21,6 +21,8 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -53,6 +55,7 @@ const (
 
 	FinalRunStatus = "FINAL_RUN_STATUS"
 	FinalRunMsg    = "FINAL_RUN_MSG"
+	RunTrigger     = "RUN_TRIGGER"
 )
 
 type CreateRunRequest struct {
@@ -95,7 +98,8 @@ type ArtifactsJson struct {
 }
 
 type UpdateRunRequest struct {
-	StopForce bool `json:"stopForce"`
+	StopForce  bool     `json:"stopForce"`
+	Components []string `json:"components"` // rerun 时需要重新运行的节点全名，如 step1、dag1.step1
 }
 
 type DeleteRunRequest struct {
@@ -121,6 +125,7 @@ type RunBrief struct {
 	Message       string `json:"runMsg"`
 	Status        string `json:"status"`
 	ScheduledTime string `json:"scheduledTime"`
+	Trigger       string `json:"trigger,omitempty"`
 	CreateTime    string `json:"createTime"`
 	ActivateTime  string `json:"activateTime"`
 	UpdateTime    string `json:"updateTime"`
@@ -144,6 +149,7 @@ func (b *RunBrief) modelToListResp(run models.Run) {
 	b.CreateTime = run.CreateTime
 	b.ActivateTime = run.ActivateTime
 	b.UpdateTime = run.UpdateTime
+	b.Trigger = run.Trigger
 
 	if run.ScheduledAt.Valid {
 		b.ScheduledTime = run.ScheduledAt.Time.Format("2006-01-02 15:04:05")
@@ -558,7 +564,7 @@ func CreateRun(ctx logger.RequestContext, request *CreateRunRequest, extra map[s
 		Disabled:       request.Disabled,
 		ScheduleID:     request.ScheduleID,
 		ScheduledAt:    scheduledAt,
-		RunOptions:     schema.RunOptions{FSUsername: userName},
+		RunOptions:     schema.RunOptions{FSUsername: userName, Trigger: extra[RunTrigger]},
 		Status:         "", // to be filled later
 		Message:        "", // to be filld later
 	}
@@ -698,6 +704,14 @@ func ValidateAndCreateRun(ctx logger.RequestContext, run *models.Run, userName s
 		return nil, "", err
 	}
 
+	// 在初始化workflow之前，根据参数定义校验请求中的参数，返回每个参数的校验错误
+	trace_logger.Key(requestId).Infof("validate run parameters")
+	if err := pplcommon.ValidateRunParams(&run.WorkflowSource, run.Parameters); err != nil {
+		logger.Logger().Errorf("validate run parameters failed. err:%v", err)
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, "", err
+	}
+
 	trace_logger.Key(requestId).Infof("validate and init workflow")
 	// validate workflow in func NewWorkflow
 	wfPtr, err := newWorkflowByRun(*run)
@@ -951,6 +965,61 @@ func RetryRun(ctx *logger.RequestContext, runID string) (string, error) {
 	return newRunID, nil
 }
 
+// RerunRun 以已结束的run为基础发起新的run，只重新运行 components 中的节点及其下游节点，
+// 其余节点复用原run中的运行结果，返回新run的ID
+func RerunRun(ctx *logger.RequestContext, runID string, components []string) (string, error) {
+	ctx.Logging().Debugf("begin rerun run. runID:%s, components:%v\n", runID, components)
+	if len(components) == 0 {
+		err := fmt.Errorf("components should not be empty when rerun run[%s]", runID)
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorln(err.Error())
+		return "", err
+	}
+
+	// check run exist && check user access right
+	run, err := GetRunByID(ctx.Logging(), ctx.UserName, runID)
+	if err != nil {
+		ctx.Logging().Errorf("rerun run[%s] failed when getting run. error: %v\n", runID, err)
+		return "", err
+	}
+
+	// only runs in final status can rerun
+	if !common.IsRunFinalStatus(run.Status) {
+		err := fmt.Errorf("run[%s] has status[%s]. only runs in final status: %v can be rerun",
+			runID, run.Status, common.RunFinalStatus)
+		ctx.ErrorCode = common.ActionNotAllowed
+		ctx.Logging().Errorln(err.Error())
+		return "", err
+	}
+
+	if err := setRunWorkflowSource(&run); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return "", err
+	}
+
+	rerunComps, ancestors, err := getRerunComponents(&run.WorkflowSource, components)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("rerun run[%s] failed. error: %v\n", runID, err)
+		return "", err
+	}
+
+	if err := checkFs(run.RunOptions.FSUsername, &run.WorkflowSource); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("rerun run[%s] failed when checking fs. error: %v\n", runID, err)
+		return "", err
+	}
+
+	newRunID, err := rerunRun(run, rerunComps, ancestors)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("rerun run[%s] failed. error: %v\n", runID, err)
+		return "", err
+	}
+	ctx.Logging().Debugf("rerun run[%s] successful, new run[%s]", runID, newRunID)
+	return newRunID, nil
+}
+
 func DeleteRun(ctx *logger.RequestContext, id string, request *DeleteRunRequest) error {
 	ctx.Logging().Debugf("begin delete run: %s", id)
 
@@ -1055,21 +1124,10 @@ func restartRun(run models.Run, isResume bool) (string, error) {
 		return "", err
 	}
 
-	wfs, err := schema.GetWorkflowSource([]byte(run.RunYaml))
-	if err != nil {
-		logger.LoggerForRun(run.ID).Errorf("get WorkflowSource by yaml failed. yaml: %s \n, err:%v", run.RunYaml, err)
+	if err := setRunWorkflowSource(&run); err != nil {
 		return "", err
 	}
 
-	wfs.Name = run.Name
-	if run.DockerEnv != "" {
-		wfs.DockerEnv = run.DockerEnv
-	}
-	if run.Disabled != "" {
-		wfs.Disabled = run.Disabled
-	}
-	run.WorkflowSource = wfs
-
 	fsUserName := run.RunOptions.FSUsername
 
 	if err := checkFs(fsUserName, &run.WorkflowSource); err != nil {
@@ -1096,6 +1154,196 @@ func restartRun(run models.Run, isResume bool) (string, error) {
 	return runID, nil
 }
 
+// rerunRun 拷贝原run中不需要重新运行的dag和job，创建新的run，并从这些dag和job的状态开始运行
+func rerunRun(run models.Run, rerunComps, ancestors map[string]bool) (string, error) {
+	logEntry := logger.LoggerForRun(run.ID)
+	jobs, err := models.GetRunJobsOfRun(logEntry, run.ID)
+	if err != nil {
+		return "", err
+	}
+	dags, err := models.GetRunDagsOfRun(logEntry, run.ID)
+	if err != nil {
+		return "", err
+	}
+	newJobs, newDags := filterRerunRuntime(&run.WorkflowSource, jobs, dags, rerunComps, ancestors)
+
+	run.Status = common.StatusRunInitiating
+	run.Message = ""
+	run.CreatedAt = time.Time{}
+	run.UpdatedAt = time.Time{}
+	run.ActivatedAt = sql.NullTime{}
+	if err := createRunWithRuntime(logEntry, &run, newJobs, newDags); err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if info := recover(); info != nil {
+			errmsg := fmt.Sprintf("StartWf failed, %v", info)
+			logger.LoggerForRun(run.ID).Errorf(errmsg)
+			if err := updateRunStatusAndMsg(run.ID, common.StatusRunFailed, errmsg); err != nil {
+				logger.LoggerForRun(run.ID).Errorf("set run status as failed after StartWf panic failed")
+			}
+		}
+	}()
+
+	return startRestartedWf(run, false)
+}
+
+// getRerunComponents 校验需要重新运行的节点，并返回所有需要重新运行的节点全名，及包含这些节点的dag的全名
+// 需要重新运行的节点包括 components 中的节点，以及这些节点及其所在的各层dag在同级节点中的所有下游节点
+func getRerunComponents(wfs *schema.WorkflowSource, components []string) (map[string]bool, map[string]bool, error) {
+	rerunComps := map[string]bool{}
+	ancestors := map[string]bool{}
+	for _, fullName := range components {
+		if _, _, ok := wfs.GetCompsMapAndRelName(wfs.EntryPoints.EntryPoints, fullName); !ok {
+			return nil, nil, fmt.Errorf("component[%s] not exist in entry_points", fullName)
+		}
+		if disabled, err := wfs.IsDisabled(fullName); err != nil {
+			return nil, nil, err
+		} else if disabled {
+			return nil, nil, fmt.Errorf("component[%s] is disabled, cannot be rerun", fullName)
+		}
+
+		names := strings.Split(fullName, ".")
+		for i := range names {
+			name := strings.Join(names[:i+1], ".")
+			prefix := strings.Join(names[:i], ".")
+			if prefix != "" {
+				prefix += "."
+			}
+			if i < len(names)-1 {
+				ancestors[name] = true
+			} else {
+				rerunComps[name] = true
+			}
+
+			siblings, relName, _ := wfs.GetCompsMapAndRelName(wfs.EntryPoints.EntryPoints, name)
+			for _, downstream := range getDownstreamComponents(siblings, relName) {
+				rerunComps[prefix+downstream] = true
+			}
+		}
+	}
+	return rerunComps, ancestors, nil
+}
+
+// getDownstreamComponents 返回同级节点中，直接或间接依赖于 name 的所有节点
+func getDownstreamComponents(components map[string]schema.Component, name string) []string {
+	downstreams := map[string]bool{name: true}
+	for changed := true; changed; {
+		changed = false
+		for compName, comp := range components {
+			if downstreams[compName] {
+				continue
+			}
+			for _, dep := range comp.GetDeps() {
+				if downstreams[dep] {
+					downstreams[compName] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	res := []string{}
+	for compName := range downstreams {
+		if compName != name {
+			res = append(res, compName)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// filterRerunRuntime 过滤出rerun时需要拷贝的dag和job：
+// 1. 与 restart 一致，剔除canceled的job、dag，剔除failed、termiated的job
+// 2. 剔除需要重新运行的节点及其子节点
+// 3. 包含需要重新运行的节点的dag（包括最外层的dag），需要将状态重置，使其能够被重新调度
+// 拷贝的dag和job保留原有的参数及artifact，供下游节点使用
+func filterRerunRuntime(wfs *schema.WorkflowSource, jobs []models.RunJob, dags []models.RunDag,
+	rerunComps, ancestors map[string]bool) ([]models.RunJob, []models.RunDag) {
+	dagMap := map[string]models.RunDag{}
+	for _, dag := range dags {
+		dagMap[dag.ID] = dag
+	}
+
+	needRerun := func(fullName string) bool {
+		names := strings.Split(fullName, ".")
+		for i := range names {
+			if rerunComps[strings.Join(names[:i+1], ".")] {
+				return true
+			}
+		}
+		return false
+	}
+
+	newDags := []models.RunDag{}
+	for _, dag := range dags {
+		if dag.Status == schema.StatusJobCancelled {
+			continue
+		}
+		fullName := getRuntimeFullName(dag.ParentDagID, dag.DagName, dagMap)
+		if dag.ParentDagID != "" && needRerun(fullName) {
+			continue
+		}
+		if dag.ParentDagID == "" || ancestors[fullName] {
+			dag.Status = schema.StatusJobInit
+			dag.Message = ""
+		}
+		newDags = append(newDags, dag)
+	}
+
+	newJobs := []models.RunJob{}
+	for _, job := range jobs {
+		if job.Status == schema.StatusJobCancelled ||
+			job.Status == schema.StatusJobFailed || job.Status == schema.StatusJobTerminated {
+			continue
+		}
+		// post_process 中的节点在 entry_points 运行结束后重新运行
+		if _, ok := wfs.PostProcess[job.StepName]; ok && job.ParentDagID == "" {
+			continue
+		}
+		if needRerun(getRuntimeFullName(job.ParentDagID, job.StepName, dagMap)) {
+			continue
+		}
+		newJobs = append(newJobs, job)
+	}
+	return newJobs, newDags
+}
+
+// getRuntimeFullName 根据 ParentDagID 返回 dag 或 job 对应节点的全名，最外层的dag不包含在全名中
+func getRuntimeFullName(parentDagID, name string, dagMap map[string]models.RunDag) string {
+	names := []string{name}
+	for parentDagID != "" {
+		parent, ok := dagMap[parentDagID]
+		if !ok || parent.ParentDagID == "" {
+			break
+		}
+		names = append([]string{parent.DagName}, names...)
+		parentDagID = parent.ParentDagID
+	}
+	return strings.Join(names, ".")
+}
+
+// setRunWorkflowSource 根据run中保存的yaml及运行设置，还原run的WorkflowSource
+func setRunWorkflowSource(run *models.Run) error {
+	wfs, err := schema.GetWorkflowSource([]byte(run.RunYaml))
+	if err != nil {
+		logger.LoggerForRun(run.ID).Errorf("get WorkflowSource by yaml failed. yaml: %s \n, err:%v", run.RunYaml, err)
+		return err
+	}
+
+	wfs.Name = run.Name
+	if run.DockerEnv != "" {
+		wfs.DockerEnv = run.DockerEnv
+	}
+	if run.Disabled != "" {
+		wfs.Disabled = run.Disabled
+	}
+	run.WorkflowSource = wfs
+	return nil
+}
+
 func StartWf(run models.Run, wfPtr *pipeline.Workflow) error {
 	logEntry := logger.LoggerForRun(run.ID)
 	logEntry.Debugf("StartWf run:%+v", run)
@@ -1153,39 +1401,47 @@ func RestartWf(run models.Run, isResume bool) (string, error) {
 			}
 		}
 
-		// 创建新Run记录，拷贝runtime中的所有dag和job
-		run.Pk = 0
-		run.ID = ""
-		run.RunOptions.StopForce = false
-		run.Encode()
-		if _, err := models.CreateRun(logEntry, &run); err != nil {
+		if err := createRunWithRuntime(logEntry, &run, newJobs, newDags); err != nil {
 			return "", err
 		}
+	}
 
-		for i, dag := range newDags {
-			dag.Pk = 0
-			dag.RunID = run.ID
-			if _, err := models.CreateRunDag(logEntry, &dag); err != nil {
-				return "", err
-			}
-			newDags[i] = dag
-		}
+	return startRestartedWf(run, isResume)
+}
 
-		for i, job := range newJobs {
-			job.Pk = 0
-			job.RunID = run.ID
-			if _, err := models.CreateRunJob(logEntry, &job); err != nil {
-				return "", err
-			}
-			newJobs[i] = job
+// createRunWithRuntime 创建新Run记录，拷贝runtime中的所有dag和job，并组装成runtime
+func createRunWithRuntime(logEntry *log.Entry, run *models.Run, jobs []models.RunJob, dags []models.RunDag) error {
+	run.Pk = 0
+	run.ID = ""
+	run.RunOptions.StopForce = false
+	run.Encode()
+	if _, err := models.CreateRun(logEntry, run); err != nil {
+		return err
+	}
+
+	for i, dag := range dags {
+		dag.Pk = 0
+		dag.RunID = run.ID
+		if _, err := models.CreateRunDag(logEntry, &dag); err != nil {
+			return err
 		}
+		dags[i] = dag
+	}
 
-		// 组装成runtime
-		if err := run.InitRuntime(newJobs, newDags); err != nil {
-			return "", err
+	for i, job := range jobs {
+		job.Pk = 0
+		job.RunID = run.ID
+		if _, err := models.CreateRunJob(logEntry, &job); err != nil {
+			return err
 		}
+		jobs[i] = job
 	}
 
+	return run.InitRuntime(jobs, dags)
+}
+
+func startRestartedWf(run models.Run, isResume bool) (string, error) {
+	logEntry := logger.LoggerForRun(run.ID)
 	wfPtr, err := newWorkflowByRun(run)
 	if err != nil {
 		return "", err
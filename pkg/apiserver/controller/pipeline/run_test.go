@@ -295,3 +295,89 @@ func TestCreateRunByJson(t *testing.T) {
 	assert.Nil(t, err)
 	fmt.Println(wfPtr.Source.EntryPoints.EntryPoints["main"].(*schema.WorkflowSourceStep).Cache)
 }
+
+func TestRerunRun(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+	run1 := getMockRun1()
+	runID, err := models.CreateRun(ctx.Logging(), &run1)
+	assert.Nil(t, err)
+
+	_, err = RerunRun(ctx, runID, []string{})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+
+	// 只有处于终态的run才能rerun
+	ctx = &logger.RequestContext{UserName: MockRootUser}
+	_, err = RerunRun(ctx, runID, []string{"main"})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+
+	err = models.UpdateRunStatus(ctx.Logging(), runID, common.StatusRunSucceeded)
+	assert.Nil(t, err)
+	ctx = &logger.RequestContext{UserName: MockRootUser}
+	_, err = RerunRun(ctx, runID, []string{"not-exist"})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+	assert.Equal(t, "component[not-exist] not exist in entry_points", err.Error())
+}
+
+func TestGetRerunComponents(t *testing.T) {
+	wfs, err := schema.GetWorkflowSource(loadCase(runDagYamlPath))
+	assert.Nil(t, err)
+
+	rerunComps, ancestors, err := getRerunComponents(&wfs, []string{"square-loop.square"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"square-loop.square": true, "sum": true}, rerunComps)
+	assert.Equal(t, map[string]bool{"square-loop": true}, ancestors)
+
+	rerunComps, ancestors, err = getRerunComponents(&wfs, []string{"split-by-threshold"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"split-by-threshold": true, "process-positive": true, "process-negetive": true}, rerunComps)
+	assert.Equal(t, map[string]bool{}, ancestors)
+
+	// reference 节点中的子节点
+	rerunComps, ancestors, err = getRerunComponents(&wfs, []string{"process-negetive.condition2.show"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"process-negetive.condition2.show": true, "process-negetive.condition2.abs": true}, rerunComps)
+	assert.Equal(t, map[string]bool{"process-negetive": true, "process-negetive.condition2": true}, ancestors)
+
+	_, _, err = getRerunComponents(&wfs, []string{"square-loop.sum"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "component[square-loop.sum] not exist in entry_points", err.Error())
+
+	_, _, err = getRerunComponents(&wfs, []string{"disStep"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "component[disStep] is disabled, cannot be rerun", err.Error())
+}
+
+func TestFilterRerunRuntime(t *testing.T) {
+	wfs, err := schema.GetWorkflowSource(loadCase(runDagYamlPath))
+	assert.Nil(t, err)
+
+	dags := []models.RunDag{
+		{ID: "dag-0", DagName: "", Status: schema.StatusJobSucceeded},
+		{ID: "dag-1", ParentDagID: "dag-0", DagName: "square-loop", Status: schema.StatusJobSucceeded},
+		{ID: "dag-2", ParentDagID: "dag-0", DagName: "process-positive", Status: schema.StatusJobCancelled},
+	}
+	jobs := []models.RunJob{
+		{ID: "job-0", ParentDagID: "dag-0", StepName: "randint", Status: schema.StatusJobSucceeded},
+		{ID: "job-1", ParentDagID: "dag-1", StepName: "square", LoopSeq: 0, Status: schema.StatusJobSucceeded},
+		{ID: "job-2", ParentDagID: "dag-1", StepName: "square", LoopSeq: 1, Status: schema.StatusJobSucceeded},
+		{ID: "job-3", ParentDagID: "dag-0", StepName: "sum", Status: schema.StatusJobSucceeded},
+		{ID: "job-4", ParentDagID: "dag-0", StepName: "split-by-threshold", Status: schema.StatusJobFailed},
+	}
+
+	rerunComps, ancestors, err := getRerunComponents(&wfs, []string{"square-loop.square"})
+	assert.Nil(t, err)
+	newJobs, newDags := filterRerunRuntime(&wfs, jobs, dags, rerunComps, ancestors)
+
+	// 包含需要重新运行节点的dag的状态被重置，cancelled 的dag被剔除
+	assert.Equal(t, 2, len(newDags))
+	for _, dag := range newDags {
+		assert.Equal(t, schema.StatusJobInit, dag.Status)
+	}
+	// 需要重新运行的节点及 failed 的节点被剔除
+	assert.Equal(t, 1, len(newJobs))
+	assert.Equal(t, "job-0", newJobs[0].ID)
+}
@@ -28,24 +28,26 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/notification"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 )
 
 type CreateScheduleRequest struct {
-	Name              string `json:"name"`
-	Desc              string `json:"desc"` // optional
-	PipelineID        string `json:"pipelineID"`
-	PipelineVersionID string `json:"pipelineVersionID"`
-	Crontab           string `json:"crontab"`
-	StartTime         string `json:"startTime"`         // optional
-	EndTime           string `json:"endTime"`           // optional
-	Concurrency       int    `json:"concurrency"`       // optional, 默认 0, 表示不限制
-	ConcurrencyPolicy string `json:"concurrencyPolicy"` // optional, 默认 suspend
-	ExpireInterval    int    `json:"expireInterval"`    // optional, 默认 0, 表示不限制
-	Catchup           bool   `json:"catchup"`           // optional, 默认 false
-	UserName          string `json:"username"`          // optional, 只有root用户使用其他用户fsname时，需要指定对应username
+	Name              string                 `json:"name"`
+	Desc              string                 `json:"desc"` // optional
+	PipelineID        string                 `json:"pipelineID"`
+	PipelineVersionID string                 `json:"pipelineVersionID"`
+	Crontab           string                 `json:"crontab"`           // 事件触发时不需要设置
+	Trigger           models.ScheduleTrigger `json:"trigger"`           // optional, 默认为 cron
+	StartTime         string                 `json:"startTime"`         // optional
+	EndTime           string                 `json:"endTime"`           // optional
+	Concurrency       int                    `json:"concurrency"`       // optional, 默认 0, 表示不限制
+	ConcurrencyPolicy string                 `json:"concurrencyPolicy"` // optional, 默认 suspend
+	ExpireInterval    int                    `json:"expireInterval"`    // optional, 默认 0, 表示不限制
+	Catchup           bool                   `json:"catchup"`           // optional, 默认 false
+	UserName          string                 `json:"username"`          // optional, 只有root用户使用其他用户fsname时，需要指定对应username
 }
 
 type CreateScheduleResponse struct {
@@ -61,6 +63,7 @@ type ScheduleBrief struct {
 	UserName          string                 `json:"username"`
 	FsConfig          models.FsConfig        `json:"fsConfig"`
 	Crontab           string                 `json:"crontab"`
+	Trigger           models.ScheduleTrigger `json:"trigger"`
 	Options           models.ScheduleOptions `json:"options"`
 	StartTime         string                 `json:"startTime"`
 	EndTime           string                 `json:"endTime"`
@@ -105,6 +108,12 @@ func (b *ScheduleBrief) updateFromScheduleModel(schedule models.Schedule) (err e
 		return err
 	}
 
+	b.Trigger, err = models.DecodeScheduleTrigger(schedule.TriggerConfig)
+	if err != nil {
+		return err
+	}
+	b.Trigger.ClearState()
+
 	if schedule.StartAt.Valid {
 		b.StartTime = schedule.StartAt.Time.Format("2006-01-02 15:04:05")
 	} else {
@@ -272,21 +281,48 @@ func CreateSchedule(ctx *logger.RequestContext, request *CreateScheduleRequest)
 		return CreateScheduleResponse{}, fmt.Errorf(errMsg)
 	}
 
-	// 校验crontab
-	cronSchedule, err := cron.ParseStandard(request.Crontab)
+	// 校验触发方式
+	trigger, err := validateScheduleTrigger(ctx, request)
 	if err != nil {
 		ctx.ErrorCode = common.InvalidArguments
-		errMsg := fmt.Sprintf("check crontab failed in creating schedule. error:%v", err)
+		errMsg := fmt.Sprintf("create schedule failed, %s", err.Error())
 		ctx.Logging().Errorf(errMsg)
 		return CreateScheduleResponse{}, fmt.Errorf(errMsg)
 	}
 
-	// 根据crontab, 以及startTime, 生成nextRunAt
 	var nextRunAt time.Time
-	if startAt.Valid {
-		nextRunAt = cronSchedule.Next(startAt.Time)
+	if trigger.IsEventTrigger() {
+		// 事件触发的周期调度，只处理 startTime 之后发生的事件，每隔 pollInterval 检查一次事件
+		trigger.CheckedAt = currentTime
+		if startAt.Valid {
+			trigger.CheckedAt = startAt.Time
+		}
+		trigger.StartAt = trigger.CheckedAt
+		nextRunAt = trigger.CheckedAt.Add(time.Duration(trigger.PollInterval) * time.Second)
 	} else {
-		nextRunAt = cronSchedule.Next(currentTime)
+		// 校验crontab
+		cronSchedule, err := cron.ParseStandard(request.Crontab)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidArguments
+			errMsg := fmt.Sprintf("check crontab failed in creating schedule. error:%v", err)
+			ctx.Logging().Errorf(errMsg)
+			return CreateScheduleResponse{}, fmt.Errorf(errMsg)
+		}
+
+		// 根据crontab, 以及startTime, 生成nextRunAt
+		if startAt.Valid {
+			nextRunAt = cronSchedule.Next(startAt.Time)
+		} else {
+			nextRunAt = cronSchedule.Next(currentTime)
+		}
+	}
+
+	StrTrigger, err := trigger.Encode(ctx.Logging())
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		errMsg := fmt.Sprintf("create schedule failed, dump trigger[%v] error: %s", trigger, err.Error())
+		ctx.Logging().Errorf(errMsg)
+		return CreateScheduleResponse{}, fmt.Errorf(errMsg)
 	}
 
 	// 校验用户对pplID pplVersionID是否有权限
@@ -327,6 +363,7 @@ func CreateSchedule(ctx *logger.RequestContext, request *CreateScheduleRequest)
 		UserName:          ctx.UserName,
 		FsConfig:          string(StrFsConfig),
 		Crontab:           request.Crontab,
+		TriggerConfig:     StrTrigger,
 		Options:           string(StrOptions),
 		Status:            models.ScheduleStatusRunning,
 		StartAt:           startAt,
@@ -355,6 +392,33 @@ func CreateSchedule(ctx *logger.RequestContext, request *CreateScheduleRequest)
 	return CreateScheduleResponse{ScheduleID: scheduleID}, nil
 }
 
+// 校验周期调度的触发方式，事件触发时，用户需要有被监听的 fs 或者 pipeline 的权限
+func validateScheduleTrigger(ctx *logger.RequestContext, request *CreateScheduleRequest) (models.ScheduleTrigger, error) {
+	trigger, err := models.NewScheduleTrigger(ctx.Logging(), request.Trigger)
+	if err != nil {
+		return models.ScheduleTrigger{}, err
+	}
+
+	if trigger.IsEventTrigger() && request.Crontab != "" {
+		return models.ScheduleTrigger{}, fmt.Errorf("crontab should not be set for trigger[%s]", trigger.Type)
+	}
+
+	switch trigger.Type {
+	case models.ScheduleTriggerFileArrival:
+		if _, err := CheckFsAndGetID(ctx.UserName, request.UserName, trigger.FsName); err != nil {
+			return models.ScheduleTrigger{}, err
+		}
+	case models.ScheduleTriggerRunCompletion:
+		hasAuth, _, err := CheckPipelinePermission(ctx.UserName, trigger.PipelineID)
+		if err != nil {
+			return models.ScheduleTrigger{}, err
+		} else if !hasAuth {
+			return models.ScheduleTrigger{}, common.NoAccessError(ctx.UserName, common.ResourceTypePipeline, trigger.PipelineID)
+		}
+	}
+	return trigger, nil
+}
+
 // 给scheduler发创建channel信号
 func SendSingnal(opType, scheduleID string) error {
 	globalScheduler := GetGlobalScheduler()
@@ -514,6 +578,8 @@ func StopSchedule(ctx *logger.RequestContext, scheduleID string) error {
 		ctx.ErrorCode = common.InternalError
 		return fmt.Errorf(errMsg)
 	}
+	schedule.Status = models.ScheduleStatusTerminated
+	notifySchedule(schedule, fmt.Sprintf("stopped by user[%s]", ctx.UserName))
 
 	// 给scheduler发stop channel信号
 	err = SendSingnal(OpTypeStop, scheduleID)
@@ -567,3 +633,16 @@ func DeleteSchedule(ctx *logger.RequestContext, scheduleID string) error {
 
 	return nil
 }
+
+// 周期调度到达终态时，通知用户配置的通知方式
+func notifySchedule(schedule models.Schedule, message string) {
+	notification.Notify(notification.Event{
+		Kind:       models.NotifyKindSchedule,
+		ID:         schedule.ID,
+		Name:       schedule.Name,
+		UserName:   schedule.UserName,
+		PipelineID: schedule.PipelineID,
+		Status:     schedule.Status,
+		Message:    message,
+	})
+}
@@ -29,6 +29,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
@@ -234,6 +235,25 @@ func TestCreateSchedule(t *testing.T) {
 	createScheduleReq.Name = "schedule_4"
 	assert.Nil(t, err)
 	assert.Equal(t, resp.ScheduleID, "schedule-000004")
+
+	// 失败: 事件触发时不能设置 crontab
+	createScheduleReq.Name = "schedule_5"
+	createScheduleReq.StartTime = ""
+	createScheduleReq.Trigger = models.ScheduleTrigger{Type: models.ScheduleTriggerRunCompletion, PipelineID: "ppl-000002"}
+	resp, err = CreateSchedule(ctx, &createScheduleReq)
+	assert.NotNil(t, err)
+	assert.Equal(t, fmt.Errorf("create schedule failed, crontab should not be set for trigger[runCompletion]"), err)
+
+	// 成功: 事件触发，第一次检查事件的时间为 pollInterval 之后
+	createScheduleReq.Crontab = ""
+	resp, err = CreateSchedule(ctx, &createScheduleReq)
+	assert.Nil(t, err)
+
+	getScheduleResp, err = GetSchedule(ctx, resp.ScheduleID, "", 0, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ScheduleTriggerRunCompletion, getScheduleResp.Trigger.Type)
+	assert.Equal(t, []string{common.StatusRunSucceeded}, getScheduleResp.Trigger.Status)
+	assert.Equal(t, getScheduleResp.Trigger.CheckedAt.Add(time.Minute).Format("2006-01-02 15:04:05"), getScheduleResp.NextRunTime)
 }
 
 // todo: 测试marker不为空
@@ -19,6 +19,7 @@ package pipeline
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	cron "github.com/robfig/cron/v3"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
@@ -35,6 +37,9 @@ const (
 	OpTypeCreate = "create"
 	OpTypeStop   = "stop"
 	OpTypeDelete = "delete"
+
+	// runCompletion 类型的周期调度，每次检查时会往前多查询的时间
+	runCompletionLookback = 10 * time.Minute
 )
 
 type OpInfo struct {
@@ -288,9 +293,28 @@ func (s *Scheduler) generateRunListForSchedule(schedule models.Schedule, current
 	return expiredList, execList, skipList, nextRunAt, stopCount, nil
 }
 
-func (s *Scheduler) createRun(schedule models.Schedule, fsConfig models.FsConfig, nextRunAt time.Time, status, msg string) {
-	logger.Logger().Infof("start to create run in ScheduledAt[%s] for schedule[%s] with status[%s]",
-		s.formatTime(&nextRunAt), schedule.ID, status)
+// 周期调度每次需要发起的 run，对于 cron 类型的周期调度，触发时间即为调度时间，对于事件触发的周期调度，触发时间为事件发生的时间
+type scheduleEvent struct {
+	at      time.Time
+	trigger string // 触发原因，会记录在 run 中
+
+	// 事件触发的周期调度中，事件对应的文件路径或者 run ID，以及文件的状态
+	key  string
+	file models.TriggerFileState
+}
+
+func newCronEvents(schedule models.Schedule, runAtList []time.Time) []scheduleEvent {
+	events := make([]scheduleEvent, 0, len(runAtList))
+	for _, runAt := range runAtList {
+		events = append(events, scheduleEvent{at: runAt, trigger: fmt.Sprintf("%s[%s]", models.ScheduleTriggerCron, schedule.Crontab)})
+	}
+	return events
+}
+
+func (s *Scheduler) createRun(schedule models.Schedule, fsConfig models.FsConfig, event scheduleEvent, status, msg string) {
+	nextRunAt := event.at
+	logger.Logger().Infof("start to create run in ScheduledAt[%s] for schedule[%s] with status[%s], trigger[%s]",
+		s.formatTime(&nextRunAt), schedule.ID, status, event.trigger)
 	createRequest := CreateRunRequest{
 		UserName:          fsConfig.Username,
 		Name:              schedule.Name,
@@ -307,7 +331,7 @@ func (s *Scheduler) createRun(schedule models.Schedule, fsConfig models.FsConfig
 		RequestID: uuid.NewString(),
 	}
 
-	extra := map[string]string{}
+	extra := map[string]string{RunTrigger: event.trigger}
 	if status != "" {
 		extra[FinalRunStatus] = status
 		extra[FinalRunMsg] = msg
@@ -329,42 +353,42 @@ func (s *Scheduler) stopRun(runID string, schedule models.Schedule) {
 
 func (s *Scheduler) processRunList(
 	schedule models.Schedule, options models.ScheduleOptions, fsConfig models.FsConfig, currentTime time.Time,
-	expiredList, skipList, execList []time.Time, stopCount int, activeRuns []models.Run) {
+	expiredList, skipList, execList []scheduleEvent, stopCount int, activeRuns []models.Run) {
 	// 根据调度时间，先处理expiredList，创建状态为skipped的run，发起任务失败了只打日志，不影响周期调度
-	for _, expiredRunAt := range expiredList {
+	for _, expired := range expiredList {
 		status := common.StatusRunSkipped
 		runMsg := fmt.Sprintf("skip run of schedule[%s] with schedule time[%s], beyond expire interval[%d] before currentTime[%s]",
-			schedule.ID, s.formatTime(&expiredRunAt), options.ExpireInterval, s.formatTime(&currentTime))
+			schedule.ID, s.formatTime(&expired.at), options.ExpireInterval, s.formatTime(&currentTime))
 		logger.Logger().Info(runMsg)
-		s.createRun(schedule, fsConfig, expiredRunAt, status, runMsg)
+		s.createRun(schedule, fsConfig, expired, status, runMsg)
 	}
 
 	if options.ConcurrencyPolicy == models.ConcurrencyPolicyReplace {
 		// 根据调度时间，replace策略下，需要为在创建正常run前，处理skipList，创建状态为skipped的run
 		// 发起任务失败了只打日志，不影响周期调度
-		for _, skipRunAt := range skipList {
+		for _, skipped := range skipList {
 			status := common.StatusRunSkipped
 			runMsg := fmt.Sprintf("skip run of schedule[%s] with schedule time[%s], concurrency already reach[%d] in policy[%s]",
-				schedule.ID, s.formatTime(&skipRunAt), options.Concurrency, options.ConcurrencyPolicy)
+				schedule.ID, s.formatTime(&skipped.at), options.Concurrency, options.ConcurrencyPolicy)
 			logger.Logger().Info(runMsg)
-			s.createRun(schedule, fsConfig, skipRunAt, status, runMsg)
+			s.createRun(schedule, fsConfig, skipped, status, runMsg)
 		}
 	}
 
 	// 再根据 execList，发起run，发起任务失败了只打日志，不影响周期调度
-	for _, execRunAt := range execList {
-		s.createRun(schedule, fsConfig, execRunAt, "", "")
+	for _, exec := range execList {
+		s.createRun(schedule, fsConfig, exec, "", "")
 	}
 
 	if options.ConcurrencyPolicy == models.ConcurrencyPolicySkip {
 		// 根据调度时间，skip策略下，需要为在创建正常run后，处理skipList，创建状态为skipped的run
 		// 发起任务失败了只打日志，不影响周期调度
-		for _, skipRunAt := range skipList {
+		for _, skipped := range skipList {
 			status := common.StatusRunSkipped
 			runMsg := fmt.Sprintf("skip run of schedule[%s] with schedule time[%s], concurrency already reach[%d] in policy[%s]",
-				schedule.ID, s.formatTime(&skipRunAt), options.Concurrency, options.ConcurrencyPolicy)
+				schedule.ID, s.formatTime(&skipped.at), options.Concurrency, options.ConcurrencyPolicy)
 			logger.Logger().Info(runMsg)
-			s.createRun(schedule, fsConfig, skipRunAt, status, runMsg)
+			s.createRun(schedule, fsConfig, skipped, status, runMsg)
 		}
 	}
 
@@ -386,7 +410,9 @@ func (s *Scheduler) updateScheduleAndWakeupTime(schedule models.Schedule, curren
 	}
 
 	// 更新 status 字段
+	finished := false
 	if schedule.EndAt.Valid && nextRunAt.After(schedule.EndAt.Time) {
+		finished = schedule.Status != models.ScheduleStatusSuccess
 		schedule.Status = models.ScheduleStatusSuccess
 		to_update = true
 	}
@@ -399,6 +425,8 @@ func (s *Scheduler) updateScheduleAndWakeupTime(schedule models.Schedule, curren
 			errMsg := fmt.Sprintf("update schedule[%s] of pipeline detail[%s] failed, error:%v",
 				schedule.ID, schedule.PipelineVersionID, result.Error)
 			logger.Logger().Errorf(errMsg)
+		} else if finished {
+			notifySchedule(schedule, "")
 		}
 	}
 
@@ -455,6 +483,19 @@ func (s *Scheduler) dealWithTimeout() (nextWakeupTime *time.Time, err error) {
 			continue
 		}
 
+		trigger, err := models.DecodeScheduleTrigger(schedule.TriggerConfig)
+		if err != nil {
+			logger.Logger().Errorf("decode trigger[%s] of schedule[%s] failed, err:[%s]", schedule.TriggerConfig, schedule.ID, err.Error())
+			continue
+		}
+
+		// 事件触发的周期调度
+		if trigger.IsEventTrigger() {
+			nextWakeupTime = s.dealWithEventSchedule(schedule, options, trigger, fsConfig, currentTime, activeRuns, nextWakeupTime)
+			logger.Logger().Infof("after dealWithEventSchedule for schedule[%s], nextWakeupTime[%s]", schedule.ID, s.formatTime(nextWakeupTime))
+			continue
+		}
+
 		// 先处理同时满足currentTime之前，而且schedule.EndAt之前的任务
 		activeCount := len(activeRuns)
 		expiredList, execList, skipList, nextRunAt, stopCount, err := s.generateRunListForSchedule(schedule, currentTime, activeCount)
@@ -465,7 +506,8 @@ func (s *Scheduler) dealWithTimeout() (nextWakeupTime *time.Time, err error) {
 		// 为expiredList, skipList, execList发起对应任务
 		// 根据stopCount停止activeRuns
 		logger.Logger().Infof("before processRunList, expiredList[%v], execList[%v], skipList[%v], activeCount:[%d], stopCount[%d]", expiredList, execList, skipList, activeCount, stopCount)
-		s.processRunList(schedule, options, fsConfig, currentTime, expiredList, skipList, execList, stopCount, activeRuns)
+		s.processRunList(schedule, options, fsConfig, currentTime, newCronEvents(schedule, expiredList),
+			newCronEvents(schedule, skipList), newCronEvents(schedule, execList), stopCount, activeRuns)
 
 		// 更新数据库记录（如果nextRunAt，或者status字段有更新的话），以及更新 nextWakeupTime
 		nextWakeupTime = s.updateScheduleAndWakeupTime(schedule, currentTime, nextRunAt, nextWakeupTime)
@@ -475,6 +517,220 @@ func (s *Scheduler) dealWithTimeout() (nextWakeupTime *time.Time, err error) {
 	return nextWakeupTime, err
 }
 
+// 处理事件触发的周期调度，主要分成以下步骤：
+// 1. 如果还没有到检查事件的时间，只需要更新 nextWakeupTime
+// 2. 获取还没有处理过的事件，根据 expire interval 以及 concurrency 配置，决定每个事件对应的 run 是否发起
+// 3. 记录已经处理过的事件，更新 CheckedAt，并将 nextRunAt 设置为下一次检查事件的时间
+func (s *Scheduler) dealWithEventSchedule(schedule models.Schedule, options models.ScheduleOptions, trigger models.ScheduleTrigger,
+	fsConfig models.FsConfig, currentTime time.Time, activeRuns []models.Run, nextWakeupTime *time.Time) *time.Time {
+	if schedule.NextRunAt.After(currentTime) {
+		return s.updateScheduleAndWakeupTime(schedule, currentTime, schedule.NextRunAt, nextWakeupTime)
+	}
+
+	// 只处理 EndAt 之前发生的事件
+	checkUntil := currentTime
+	if schedule.EndAt.Valid && checkUntil.After(schedule.EndAt.Time) {
+		checkUntil = schedule.EndAt.Time
+	}
+
+	events, err := s.getScheduleEvents(schedule, &trigger, fsConfig, checkUntil)
+	if err != nil {
+		// 获取事件失败时，trigger 中记录的状态保持不变，等待下一次检查，因此不会遗漏事件
+		logger.Logger().Errorf("get events of schedule[%s] failed, err:[%s]", schedule.ID, err.Error())
+		events = []scheduleEvent{}
+		checkUntil = trigger.CheckedAt
+	}
+
+	activeCount := len(activeRuns)
+	expiredList, execList, skipList, processedCount, stopCount := s.generateRunListForEvents(schedule, options, events, currentTime, activeCount)
+
+	logger.Logger().Infof("before processRunList, expiredList[%v], execList[%v], skipList[%v], activeCount:[%d], stopCount[%d]", expiredList, execList, skipList, activeCount, stopCount)
+	s.processRunList(schedule, options, fsConfig, currentTime, expiredList, skipList, execList, stopCount, activeRuns)
+
+	// suspend 策略下，没有被处理的事件不会被记录，需要在下一次检查时重新处理
+	s.markEventsHandled(&trigger, events[:processedCount])
+	if processedCount < len(events) && trigger.Type == models.ScheduleTriggerRunCompletion {
+		checkUntil = events[processedCount].at
+	}
+	trigger.CheckedAt = checkUntil
+	s.pruneHandledRuns(&trigger)
+	strTrigger, err := trigger.Encode(logger.Logger())
+	if err != nil {
+		logger.Logger().Errorf("encode trigger of schedule[%s] failed, err:[%s]", schedule.ID, err.Error())
+	} else {
+		schedule.TriggerConfig = strTrigger
+	}
+
+	// 下一次检查事件的时间不会晚于 EndAt，此后 nextRunAt 会晚于 EndAt，周期调度结束
+	nextRunAt := time.Now().Add(time.Duration(trigger.PollInterval) * time.Second)
+	if schedule.EndAt.Valid && trigger.CheckedAt.Before(schedule.EndAt.Time) && nextRunAt.After(schedule.EndAt.Time) {
+		nextRunAt = schedule.EndAt.Time
+	}
+	return s.updateScheduleAndWakeupTime(schedule, currentTime, nextRunAt, nextWakeupTime)
+}
+
+// 获取在 currentTime 之前发生、且还没有处理过的事件，按照发生时间排序
+// 对于 fileArrival，会同时更新 trigger 中记录的文件状态：已经被删除的文件不再记录，新出现或者被修改的文件记录为 pending
+func (s *Scheduler) getScheduleEvents(schedule models.Schedule, trigger *models.ScheduleTrigger, fsConfig models.FsConfig,
+	currentTime time.Time) ([]scheduleEvent, error) {
+	events := []scheduleEvent{}
+	switch trigger.Type {
+	case models.ScheduleTriggerFileArrival:
+		fsUserName := schedule.UserName
+		if fsConfig.Username != "" {
+			fsUserName = fsConfig.Username
+		}
+		fsHandler, err := handler.NewFsHandlerWithServer(common.ID(fsUserName, trigger.FsName), logger.Logger())
+		if err != nil {
+			return nil, err
+		}
+
+		files, err := fsHandler.GlobFileInfo(trigger.Pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		// 第一次检查时，StartAt 之前便已经存在的文件不会触发 run
+		firstCheck := !trigger.CheckedAt.After(trigger.StartAt)
+		seenFiles := map[string]models.TriggerFileState{}
+		pendingFiles := map[string]models.TriggerFileState{}
+		for path, info := range files {
+			state := models.TriggerFileState{Size: info.Size(), ModTime: info.ModTime()}
+			seen, isSeen := trigger.SeenFiles[path]
+			switch {
+			case isSeen && seen.Equal(state):
+				seenFiles[path] = seen
+			case state.ModTime.After(currentTime):
+				// EndAt 之后被修改的文件不会触发 run
+				if isSeen {
+					seenFiles[path] = seen
+				}
+			case firstCheck && !isSeen && !state.ModTime.After(trigger.StartAt):
+				seenFiles[path] = state
+			default:
+				// 与上一次检查时的状态相同，说明文件已经写入完成
+				if pending, ok := trigger.PendingFiles[path]; ok && pending.Equal(state) {
+					events = append(events, scheduleEvent{
+						at:      state.ModTime,
+						trigger: fmt.Sprintf("%s[%s:%s]", trigger.Type, trigger.FsName, path),
+						key:     path,
+						file:    state,
+					})
+				}
+				pendingFiles[path] = state
+			}
+		}
+		trigger.SeenFiles, trigger.PendingFiles = seenFiles, pendingFiles
+	case models.ScheduleTriggerRunCompletion:
+		// 通过 run ID 对事件去重，往前多查询一段时间，避免 updated_at 早于 CheckedAt 的 run 在上一次检查后才提交而被遗漏
+		runs, err := models.ListFinishedRunsOfPipeline(logger.Logger(), trigger.PipelineID, trigger.Status,
+			s.runCompletionCheckFrom(trigger), currentTime)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range runs {
+			// 避免周期调度被自己发起的 run 触发
+			if run.ScheduleID == schedule.ID {
+				continue
+			}
+			if _, ok := trigger.HandledRuns[run.ID]; ok {
+				continue
+			}
+			events = append(events, scheduleEvent{
+				at:      run.UpdatedAt,
+				trigger: fmt.Sprintf("%s[%s:%s]", trigger.Type, run.ID, run.Status),
+				key:     run.ID,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+	return events, nil
+}
+
+func (s *Scheduler) runCompletionCheckFrom(trigger *models.ScheduleTrigger) time.Time {
+	from := trigger.CheckedAt.Add(-runCompletionLookback)
+	if from.Before(trigger.StartAt) {
+		from = trigger.StartAt
+	}
+	return from
+}
+
+// 记录已经处理过的事件，这些事件不会再次触发 run
+func (s *Scheduler) markEventsHandled(trigger *models.ScheduleTrigger, events []scheduleEvent) {
+	for _, event := range events {
+		switch trigger.Type {
+		case models.ScheduleTriggerFileArrival:
+			if trigger.SeenFiles == nil {
+				trigger.SeenFiles = map[string]models.TriggerFileState{}
+			}
+			trigger.SeenFiles[event.key] = event.file
+			delete(trigger.PendingFiles, event.key)
+		case models.ScheduleTriggerRunCompletion:
+			if trigger.HandledRuns == nil {
+				trigger.HandledRuns = map[string]time.Time{}
+			}
+			trigger.HandledRuns[event.key] = event.at
+		}
+	}
+}
+
+// 早于查询范围的 run 不会再被查询到，无需继续记录
+func (s *Scheduler) pruneHandledRuns(trigger *models.ScheduleTrigger) {
+	from := s.runCompletionCheckFrom(trigger)
+	for runID, at := range trigger.HandledRuns {
+		if at.Before(from) {
+			delete(trigger.HandledRuns, runID)
+		}
+	}
+}
+
+// 与 generateRunListForSchedule 相同，根据 expire interval 以及 concurrency 配置，决定每个事件对应的 run 是否发起
+// suspend 策略下，超过并发度后的事件不会被处理，processedCount 为被处理的事件的数目
+func (s *Scheduler) generateRunListForEvents(schedule models.Schedule, options models.ScheduleOptions, events []scheduleEvent,
+	currentTime time.Time, activeCount int) (expiredList, execList, skipList []scheduleEvent, processedCount, stopCount int) {
+	totalCount := activeCount
+	processedCount = len(events)
+	expireIntervalDurtion := time.Duration(options.ExpireInterval) * time.Second
+	for index, event := range events {
+		if options.ExpireInterval != 0 && event.at.Add(expireIntervalDurtion).Before(currentTime) {
+			logger.Logger().Infof("skip event[%s] of schedule[%s], beyond expire interval[%d] from currentTime[%s]",
+				event.trigger, schedule.ID, options.ExpireInterval, s.formatTime(&currentTime))
+			expiredList = append(expiredList, event)
+			continue
+		}
+
+		if options.Concurrency == 0 || totalCount < options.Concurrency {
+			execList = append(execList, event)
+			totalCount += 1
+		} else if options.ConcurrencyPolicy == models.ConcurrencyPolicySuspend {
+			logger.Logger().Infof("concurrency of schedule with ID[%s] already reach[%d], so suspend", schedule.ID, options.Concurrency)
+			processedCount = index
+			break
+		} else if options.ConcurrencyPolicy == models.ConcurrencyPolicyReplace {
+			execList = append(execList, event)
+			totalCount += 1
+		} else if options.ConcurrencyPolicy == models.ConcurrencyPolicySkip {
+			logger.Logger().Infof("concurrency of schedule with ID[%s] already reach[%d], so skip", schedule.ID, options.Concurrency)
+			skipList = append(skipList, event)
+		}
+	}
+
+	if options.Concurrency != 0 && options.ConcurrencyPolicy == models.ConcurrencyPolicyReplace && totalCount > options.Concurrency {
+		if len(execList) >= options.Concurrency {
+			skipList = append(skipList, execList[:len(execList)-options.Concurrency]...)
+			execList = execList[len(execList)-options.Concurrency:]
+			stopCount = activeCount
+		} else {
+			stopCount = totalCount - options.Concurrency
+		}
+	}
+
+	return expiredList, execList, skipList, processedCount, stopCount
+}
+
 // 1. 判断要不要重新计算全局timeout（计算耗时，尽量过滤非必需场景）
 // - 如果当前schdule状态不是running，不做任何处理
 // - 查询当前schedule的并发度，如果当前并发度>=concurrency，不做任何处理
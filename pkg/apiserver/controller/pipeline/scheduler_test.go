@@ -28,10 +28,12 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/pipeline"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
 )
 
@@ -484,3 +486,166 @@ func TestConcurrency(t *testing.T) {
 
 	// 带测试：concurrencyPolicy是replace，而且有运行中的任务
 }
+
+func createEventSchedule(t *testing.T, logEntry *log.Entry, pplID, pplVersionID string, trigger models.ScheduleTrigger,
+	concurrency int) models.Schedule {
+	scheduleOptions, err := models.NewScheduleOptions(logEntry, true, 0, concurrency, models.ConcurrencyPolicySuspend)
+	assert.Nil(t, err)
+	strOptions, err := scheduleOptions.Encode(logEntry)
+	assert.Nil(t, err)
+
+	fsConfig := models.FsConfig{Username: "user1"}
+	strFsConfig, err := fsConfig.Encode(logEntry)
+	assert.Nil(t, err)
+
+	trigger, err = models.NewScheduleTrigger(logEntry, trigger)
+	assert.Nil(t, err)
+	trigger.CheckedAt = time.Now().Add(-time.Minute)
+	trigger.StartAt = trigger.CheckedAt
+	strTrigger, err := trigger.Encode(logEntry)
+	assert.Nil(t, err)
+
+	schedule := models.Schedule{
+		Name:              "schedule_" + trigger.Type,
+		PipelineID:        pplID,
+		PipelineVersionID: pplVersionID,
+		UserName:          "user1",
+		FsConfig:          strFsConfig,
+		TriggerConfig:     strTrigger,
+		Options:           strOptions,
+		Status:            models.ScheduleStatusRunning,
+		NextRunAt:         time.Now().Add(-time.Second),
+	}
+	schedule.ID, err = models.CreateSchedule(logEntry, schedule)
+	assert.Nil(t, err)
+	return schedule
+}
+
+// 测试事件触发的周期调度
+func TestEventTrigger(t *testing.T) {
+	driver.InitMockDB()
+	logEntry := log.WithFields(log.Fields{})
+
+	patch1 := gomonkey.ApplyFunc(checkFs, func(string, *schema.WorkflowSource) error {
+		return nil
+	})
+	patch2 := gomonkey.ApplyFunc(StartWf, func(models.Run, *pipeline.Workflow) error {
+		return nil
+	})
+	defer patch1.Reset()
+	defer patch2.Reset()
+
+	pplID1, pplID2, pplVersionID1, pplVersionID2 := insertPipeline(t, logEntry)
+
+	// runCompletion: pipeline2 中以 succeeded 状态结束的 run 会触发 pipeline1 的 run
+	runYaml, err := os.ReadFile("../../../../example/pipeline/base_pipeline/run.yaml")
+	assert.Nil(t, err)
+	for _, status := range []string{common.StatusRunSucceeded, common.StatusRunFailed} {
+		run := models.Run{
+			Name:     "watched",
+			Source:   pplID2 + "-" + pplVersionID2,
+			UserName: "root",
+			RunYaml:  string(runYaml),
+			Status:   status,
+		}
+		assert.Nil(t, run.Encode())
+		_, err := models.CreateRun(logEntry, &run)
+		assert.Nil(t, err)
+	}
+
+	schedule := createEventSchedule(t, logEntry, pplID1, pplVersionID1,
+		models.ScheduleTrigger{Type: models.ScheduleTriggerRunCompletion, PipelineID: pplID2}, 0)
+
+	scheduler := Scheduler{}
+	nextWakeupTime, err := scheduler.dealWithTimeout()
+	assert.Nil(t, err)
+	assert.True(t, nextWakeupTime.After(time.Now()))
+
+	runs, err := models.ListRun(logger.Logger(), 0, 0, nil, nil, nil, nil, nil, []string{schedule.ID})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, "runCompletion[run-000001:succeeded]", runs[0].Trigger)
+
+	// 还没有到下一次检查事件的时间，不会重复发起 run
+	_, err = scheduler.dealWithTimeout()
+	assert.Nil(t, err)
+	runs, err = models.ListRun(logger.Logger(), 0, 0, nil, nil, nil, nil, nil, []string{schedule.ID})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 1)
+
+	// 同一个 run 只会触发一次，即使其 updated_at 发生了变化
+	assert.Nil(t, models.UpdateRun(logEntry, "run-000001", models.Run{Message: "updated"}))
+	expireNextRunAt(t, schedule.ID)
+	_, err = scheduler.dealWithTimeout()
+	assert.Nil(t, err)
+	runs, err = models.ListRun(logger.Logger(), 0, 0, nil, nil, nil, nil, nil, []string{schedule.ID})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 1)
+
+	err = models.UpdateScheduleStatus(logEntry, schedule.ID, models.ScheduleStatusTerminated)
+	assert.Nil(t, err)
+
+	// fileArrival: 与 pattern 匹配的新文件，在连续两次检查中均没有变化时才会触发 run
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer os.RemoveAll("./mock_fs_handler")
+	assert.Nil(t, os.MkdirAll("./mock_fs_handler/data", 0755))
+	// old.csv 在周期调度开始之前便已经存在，不会触发 run
+	for name, age := range map[string]time.Duration{"old.csv": 2 * time.Minute, "a.csv": 30 * time.Second,
+		"b.csv": 20 * time.Second, "c.txt": 10 * time.Second} {
+		path := "./mock_fs_handler/data/" + name
+		assert.Nil(t, os.WriteFile(path, []byte(name), 0644))
+		mtime := time.Now().Add(-age)
+		assert.Nil(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	schedule = createEventSchedule(t, logEntry, pplID1, pplVersionID1,
+		models.ScheduleTrigger{Type: models.ScheduleTriggerFileArrival, FsName: "fs", Pattern: "data/*.csv"}, 0)
+	_, err = scheduler.dealWithTimeout()
+	assert.Nil(t, err)
+
+	runs, err = models.ListRun(logger.Logger(), 0, 0, nil, nil, nil, nil, nil, []string{schedule.ID})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 0)
+	trigger := getScheduleTrigger(t, logEntry, schedule.ID)
+	assert.Contains(t, trigger.SeenFiles, "data/old.csv")
+	assert.Len(t, trigger.PendingFiles, 2)
+
+	// b.csv 在两次检查之间被修改，只有 a.csv 触发 run
+	assert.Nil(t, os.WriteFile("./mock_fs_handler/data/b.csv", []byte("b.csv is still writing"), 0644))
+	expireNextRunAt(t, schedule.ID)
+	_, err = scheduler.dealWithTimeout()
+	assert.Nil(t, err)
+
+	runs, err = models.ListRun(logger.Logger(), 0, 0, nil, nil, nil, nil, nil, []string{schedule.ID})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, "fileArrival[fs:data/a.csv]", runs[0].Trigger)
+	trigger = getScheduleTrigger(t, logEntry, schedule.ID)
+	assert.Contains(t, trigger.SeenFiles, "data/a.csv")
+	assert.Contains(t, trigger.PendingFiles, "data/b.csv")
+
+	// b.csv 写入完成后触发 run，a.csv 不会重复触发
+	expireNextRunAt(t, schedule.ID)
+	_, err = scheduler.dealWithTimeout()
+	assert.Nil(t, err)
+
+	runs, err = models.ListRun(logger.Logger(), 0, 0, nil, nil, nil, nil, nil, []string{schedule.ID})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 2)
+	trigger = getScheduleTrigger(t, logEntry, schedule.ID)
+	assert.Len(t, trigger.SeenFiles, 3)
+	assert.Len(t, trigger.PendingFiles, 0)
+}
+
+func expireNextRunAt(t *testing.T, scheduleID string) {
+	tx := storage.DB.Model(&models.Schedule{}).Where("id = ?", scheduleID).Update("next_run_at", time.Now().Add(-time.Second))
+	assert.Nil(t, tx.Error)
+}
+
+func getScheduleTrigger(t *testing.T, logEntry *log.Entry, scheduleID string) models.ScheduleTrigger {
+	schedule, err := models.GetSchedule(logEntry, scheduleID)
+	assert.Nil(t, err)
+	trigger, err := models.DecodeScheduleTrigger(schedule.TriggerConfig)
+	assert.Nil(t, err)
+	return trigger
+}
@@ -18,7 +18,11 @@ package pipeline
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"sort"
 
+	"gopkg.in/yaml.v2"
 	"gorm.io/gorm"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
@@ -285,3 +289,387 @@ func isLastArtifactEventPk(ctx *logger.RequestContext, pk int64) bool {
 	}
 	return false
 }
+
+//---------------------lineage---------------------//
+
+// ArtifactLineageStep is a step which produces or consumes artifacts in the lineage, depth is the
+// distance to the queried artifact, and it is 0 for the producers
+type ArtifactLineageStep struct {
+	RunID   string   `json:"runID"`
+	Step    string   `json:"step"`
+	JobID   string   `json:"jobID"`
+	Depth   int      `json:"depth"`
+	Inputs  []string `json:"inputs"`
+	Outputs []string `json:"outputs"`
+}
+
+type ArtifactLineageResponse struct {
+	FsName       string                `json:"fsName"`
+	ArtifactPath string                `json:"artifactPath"`
+	Producers    []ArtifactLineageStep `json:"producers"`
+	Consumers    []ArtifactLineageStep `json:"consumers"`
+}
+
+type lineageStepKey struct {
+	runID string
+	step  string
+}
+
+// GetArtifactLineage returns the steps which produce the artifact, and the steps which consume it transitively,
+// that is, the steps consuming the outputs of a consumer are consumers too, up to maxDepth.
+func GetArtifactLineage(ctx *logger.RequestContext, fsName, artifactPath string, maxDepth int) (ArtifactLineageResponse, error) {
+	ctx.Logging().Debugf("begin get lineage of artifact. fsname:%s, artifactPath:%s, maxDepth:%d", fsName, artifactPath, maxDepth)
+	var userFilter []string
+	if !common.IsRootUser(ctx.UserName) {
+		userFilter = []string{ctx.UserName}
+	}
+	fsFilter := []string{fsName}
+
+	producerEvents, err := models.ListArtifactEvent(ctx.Logging(), 0, 0, userFilter, fsFilter, nil,
+		[]string{schema.ArtifactTypeOutput}, []string{artifactPath})
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return ArtifactLineageResponse{}, err
+	}
+	producers, err := listLineageSteps(ctx, userFilter, fsFilter, producerEvents, nil, 0)
+	if err != nil {
+		return ArtifactLineageResponse{}, err
+	}
+
+	consumers := []ArtifactLineageStep{}
+	visitedSteps := map[lineageStepKey]bool{}
+	visitedPaths := map[string]bool{artifactPath: true}
+	frontier := []string{artifactPath}
+	for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+		inputEvents, err := models.ListArtifactEvent(ctx.Logging(), 0, 0, userFilter, fsFilter, nil,
+			[]string{schema.ArtifactTypeInput}, frontier)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return ArtifactLineageResponse{}, err
+		}
+		steps, err := listLineageSteps(ctx, userFilter, fsFilter, inputEvents, visitedSteps, depth)
+		if err != nil {
+			return ArtifactLineageResponse{}, err
+		}
+
+		frontier = []string{}
+		for _, step := range steps {
+			visitedSteps[lineageStepKey{runID: step.RunID, step: step.Step}] = true
+			for _, output := range step.Outputs {
+				if !visitedPaths[output] {
+					visitedPaths[output] = true
+					frontier = append(frontier, output)
+				}
+			}
+		}
+		consumers = append(consumers, steps...)
+	}
+
+	if len(producers) == 0 && len(consumers) == 0 {
+		ctx.ErrorCode = common.ArtifactEventNotFound
+		err := common.NotFoundError(common.ResourceTypeArtifactEvent, artifactPath)
+		ctx.Logging().Errorln(err.Error())
+		return ArtifactLineageResponse{}, err
+	}
+	return ArtifactLineageResponse{
+		FsName:       fsName,
+		ArtifactPath: artifactPath,
+		Producers:    producers,
+		Consumers:    consumers,
+	}, nil
+}
+
+// listLineageSteps converts the steps of events to lineage steps, with all the inputs and outputs of each step
+func listLineageSteps(ctx *logger.RequestContext, userFilter, fsFilter []string, events []models.ArtifactEvent,
+	excluded map[lineageStepKey]bool, depth int) ([]ArtifactLineageStep, error) {
+	stepMap := map[lineageStepKey]*ArtifactLineageStep{}
+	runFilter := []string{}
+	for _, event := range events {
+		key := lineageStepKey{runID: event.RunID, step: event.Step}
+		if excluded[key] || stepMap[key] != nil {
+			continue
+		}
+		stepMap[key] = &ArtifactLineageStep{
+			RunID:   event.RunID,
+			Step:    event.Step,
+			JobID:   event.JobID,
+			Depth:   depth,
+			Inputs:  []string{},
+			Outputs: []string{},
+		}
+		runFilter = append(runFilter, event.RunID)
+	}
+	if len(stepMap) == 0 {
+		return []ArtifactLineageStep{}, nil
+	}
+
+	runEvents, err := models.ListArtifactEvent(ctx.Logging(), 0, 0, userFilter, fsFilter, runFilter, nil, nil)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	for _, event := range runEvents {
+		step, ok := stepMap[lineageStepKey{runID: event.RunID, step: event.Step}]
+		if !ok {
+			continue
+		}
+		// the latest job of step is used, as the step may be retried
+		step.JobID = event.JobID
+		switch event.Type {
+		case schema.ArtifactTypeInput:
+			step.Inputs = appendIfMissing(step.Inputs, event.ArtifactPath)
+		case schema.ArtifactTypeOutput:
+			step.Outputs = appendIfMissing(step.Outputs, event.ArtifactPath)
+		}
+	}
+
+	steps := make([]ArtifactLineageStep, 0, len(stepMap))
+	for _, step := range stepMap {
+		sort.Strings(step.Inputs)
+		sort.Strings(step.Outputs)
+		steps = append(steps, *step)
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].RunID != steps[j].RunID {
+			return steps[i].RunID < steps[j].RunID
+		}
+		return steps[i].Step < steps[j].Step
+	})
+	return steps, nil
+}
+
+func appendIfMissing(list []string, item string) []string {
+	for _, s := range list {
+		if s == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
+
+//---------------------run diff---------------------//
+
+// ValueDiff is a value which differs between two runs, base or target is nil if the key is absent in that run
+type ValueDiff struct {
+	Key    string      `json:"key"`
+	Base   interface{} `json:"base"`
+	Target interface{} `json:"target"`
+}
+
+type StepCache struct {
+	JobID      string `json:"jobID"`
+	Cached     bool   `json:"cached"`
+	CacheRunID string `json:"cacheRunID"`
+	CacheJobID string `json:"cacheJobID"`
+}
+
+// StepCacheDiff is a step whose cache hit differs between two runs, base or target is nil if the step is not run
+type StepCacheDiff struct {
+	Step    string     `json:"step"`
+	LoopSeq int        `json:"loopSeq"`
+	Base    *StepCache `json:"base"`
+	Target  *StepCache `json:"target"`
+}
+
+type RunDiffResponse struct {
+	BaseRunID   string          `json:"baseRunID"`
+	TargetRunID string          `json:"targetRunID"`
+	Parameters  []ValueDiff     `json:"parameters"`
+	RunYaml     []ValueDiff     `json:"runYaml"`
+	Cache       []StepCacheDiff `json:"cache"`
+	Artifacts   []ValueDiff     `json:"artifacts"`
+}
+
+// DiffRun compares two runs, and returns the differences of parameters, run yaml, cache hits and output artifacts.
+// The run yaml is compared by the flattened keys, such as entry_points.step1.command.
+func DiffRun(ctx *logger.RequestContext, baseRunID, targetRunID string) (RunDiffResponse, error) {
+	ctx.Logging().Debugf("begin diff run[%s] and run[%s]", baseRunID, targetRunID)
+	baseRun, err := getRunForDiff(ctx, baseRunID)
+	if err != nil {
+		return RunDiffResponse{}, err
+	}
+	targetRun, err := getRunForDiff(ctx, targetRunID)
+	if err != nil {
+		return RunDiffResponse{}, err
+	}
+
+	resp := RunDiffResponse{
+		BaseRunID:   baseRunID,
+		TargetRunID: targetRunID,
+		Parameters:  diffValues(baseRun.Parameters, targetRun.Parameters),
+	}
+
+	baseYaml, targetYaml := map[string]interface{}{}, map[string]interface{}{}
+	if err := flattenYaml(baseRun.RunYaml, baseYaml); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("parse run yaml of run[%s] failed. error: %v", baseRunID, err)
+		return RunDiffResponse{}, err
+	}
+	if err := flattenYaml(targetRun.RunYaml, targetYaml); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("parse run yaml of run[%s] failed. error: %v", targetRunID, err)
+		return RunDiffResponse{}, err
+	}
+	resp.RunYaml = diffValues(baseYaml, targetYaml)
+
+	resp.Cache, err = diffRunCache(ctx, baseRunID, targetRunID)
+	if err != nil {
+		return RunDiffResponse{}, err
+	}
+
+	baseArtifacts, err := listRunOutputArtifacts(ctx, baseRunID)
+	if err != nil {
+		return RunDiffResponse{}, err
+	}
+	targetArtifacts, err := listRunOutputArtifacts(ctx, targetRunID)
+	if err != nil {
+		return RunDiffResponse{}, err
+	}
+	resp.Artifacts = diffValues(baseArtifacts, targetArtifacts)
+	return resp, nil
+}
+
+func getRunForDiff(ctx *logger.RequestContext, runID string) (models.Run, error) {
+	run, err := models.GetRunByID(ctx.Logging(), runID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RunNotFound
+			err = common.NotFoundError(common.ResourceTypeRun, runID)
+		} else {
+			ctx.ErrorCode = common.InternalError
+		}
+		ctx.Logging().Errorln(err.Error())
+		return models.Run{}, err
+	}
+	if !common.IsRootUser(ctx.UserName) && ctx.UserName != run.UserName {
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeRun, runID)
+		ctx.ErrorCode = common.AccessDenied
+		ctx.Logging().Errorln(err.Error())
+		return models.Run{}, err
+	}
+	return run, nil
+}
+
+func diffRunCache(ctx *logger.RequestContext, baseRunID, targetRunID string) ([]StepCacheDiff, error) {
+	type stepKey struct {
+		step    string
+		loopSeq int
+	}
+	stepCaches := func(runID string) (map[stepKey]*StepCache, error) {
+		jobs, err := models.GetRunJobsOfRun(ctx.Logging(), runID)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return nil, err
+		}
+		caches := map[stepKey]*StepCache{}
+		for _, job := range jobs {
+			caches[stepKey{step: job.StepName, loopSeq: job.LoopSeq}] = &StepCache{
+				JobID:      job.ID,
+				Cached:     job.CacheRunID != "",
+				CacheRunID: job.CacheRunID,
+				CacheJobID: job.CacheJobID,
+			}
+		}
+		return caches, nil
+	}
+	baseCaches, err := stepCaches(baseRunID)
+	if err != nil {
+		return nil, err
+	}
+	targetCaches, err := stepCaches(targetRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []StepCacheDiff{}
+	for key, base := range baseCaches {
+		target := targetCaches[key]
+		if target == nil || target.Cached != base.Cached {
+			diffs = append(diffs, StepCacheDiff{Step: key.step, LoopSeq: key.loopSeq, Base: base, Target: target})
+		}
+	}
+	for key, target := range targetCaches {
+		if _, ok := baseCaches[key]; !ok {
+			diffs = append(diffs, StepCacheDiff{Step: key.step, LoopSeq: key.loopSeq, Target: target})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Step != diffs[j].Step {
+			return diffs[i].Step < diffs[j].Step
+		}
+		return diffs[i].LoopSeq < diffs[j].LoopSeq
+	})
+	return diffs, nil
+}
+
+// listRunOutputArtifacts returns the output artifact paths of run, keyed by step.artifactName
+func listRunOutputArtifacts(ctx *logger.RequestContext, runID string) (map[string]interface{}, error) {
+	events, err := models.ListArtifactEvent(ctx.Logging(), 0, 0, nil, nil, []string{runID},
+		[]string{schema.ArtifactTypeOutput}, nil)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	artifacts := map[string]interface{}{}
+	for _, event := range events {
+		artifacts[event.Step+"."+event.ArtifactName] = event.ArtifactPath
+	}
+	return artifacts, nil
+}
+
+func diffValues(base, target map[string]interface{}) []ValueDiff {
+	diffs := []ValueDiff{}
+	for key, baseValue := range base {
+		targetValue, ok := target[key]
+		if !ok || !reflect.DeepEqual(baseValue, targetValue) {
+			diffs = append(diffs, ValueDiff{Key: key, Base: baseValue, Target: targetValue})
+		}
+	}
+	for key, targetValue := range target {
+		if _, ok := base[key]; !ok {
+			diffs = append(diffs, ValueDiff{Key: key, Target: targetValue})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+// flattenYaml flattens the run yaml into result, the keys of nested maps are joined by dot, and the index of
+// list is appended to the key, such as entry_points.step1.cache.fs_scope[0]
+func flattenYaml(runYaml string, result map[string]interface{}) error {
+	var content interface{}
+	if err := yaml.Unmarshal([]byte(runYaml), &content); err != nil {
+		return err
+	}
+	flattenValue("", content, result)
+	return nil
+}
+
+func flattenValue(prefix string, value interface{}, result map[string]interface{}) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			flattenValue(join(fmt.Sprintf("%v", key)), item, result)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			flattenValue(join(key), item, result)
+		}
+	case []interface{}:
+		for index, item := range v {
+			flattenValue(fmt.Sprintf("%s[%d]", prefix, index), item, result)
+		}
+	default:
+		if prefix != "" {
+			result[prefix] = v
+		}
+	}
+}
@@ -23,6 +23,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
 )
@@ -65,3 +68,135 @@ func TestLogCache(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, strings.Contains(cacheID, "cch-"))
 }
+
+func TestGetArtifactLineage(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+	// run-1.train 产出 /model，run-2.eval 使用 /model 产出 /report，run-3.publish 使用 /report
+	events := []schema.LogRunArtifactRequest{
+		{RunID: "run-1", Step: "train", JobID: "job-1", ArtifactPath: "/data", Type: schema.ArtifactTypeInput},
+		{RunID: "run-1", Step: "train", JobID: "job-1", ArtifactPath: "/model", Type: schema.ArtifactTypeOutput},
+		{RunID: "run-2", Step: "eval", JobID: "job-2", ArtifactPath: "/model", Type: schema.ArtifactTypeInput},
+		{RunID: "run-2", Step: "eval", JobID: "job-2", ArtifactPath: "/report", Type: schema.ArtifactTypeOutput},
+		{RunID: "run-3", Step: "publish", JobID: "job-3", ArtifactPath: "/report", Type: schema.ArtifactTypeInput},
+		{RunID: "run-4", Step: "train", JobID: "job-4", ArtifactPath: "/model", Type: schema.ArtifactTypeOutput, UserName: MockUserID2},
+	}
+	for _, event := range events {
+		event.FsName = "fs1"
+		if event.UserName == "" {
+			event.UserName = MockRootUser
+		}
+		assert.Nil(t, LogArtifactEvent(event))
+	}
+
+	lineage, err := GetArtifactLineage(ctx, "fs1", "/model", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []ArtifactLineageStep{
+		{RunID: "run-1", Step: "train", JobID: "job-1", Depth: 0, Inputs: []string{"/data"}, Outputs: []string{"/model"}},
+		{RunID: "run-4", Step: "train", JobID: "job-4", Depth: 0, Inputs: []string{}, Outputs: []string{"/model"}},
+	}, lineage.Producers)
+	assert.Equal(t, []ArtifactLineageStep{
+		{RunID: "run-2", Step: "eval", JobID: "job-2", Depth: 1, Inputs: []string{"/model"}, Outputs: []string{"/report"}},
+		{RunID: "run-3", Step: "publish", JobID: "job-3", Depth: 2, Inputs: []string{"/report"}, Outputs: []string{}},
+	}, lineage.Consumers)
+
+	// maxDepth 限制下游血缘的深度，普通用户只能看到自己的运行产物
+	lineage, err = GetArtifactLineage(&logger.RequestContext{UserName: MockUserID2}, "fs1", "/model", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(lineage.Producers))
+	assert.Equal(t, "run-4", lineage.Producers[0].RunID)
+	assert.Empty(t, lineage.Consumers)
+
+	lineage, err = GetArtifactLineage(ctx, "fs1", "/model", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(lineage.Consumers))
+	assert.Equal(t, "run-2", lineage.Consumers[0].RunID)
+
+	_, err = GetArtifactLineage(ctx, "fs1", "/not-exist", 10)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.ArtifactEventNotFound, ctx.ErrorCode)
+}
+
+func TestDiffRun(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	runYaml := `
+name: diff
+docker_env: images/training.tgz
+entry_points:
+  train:
+    command: "python train.py --lr=%s"
+    parameters:
+      epoch: %d
+`
+	baseRun := models.Run{
+		Name:       "base",
+		UserName:   MockRootUser,
+		RunYaml:    fmt.Sprintf(runYaml, "0.1", 10),
+		Parameters: map[string]interface{}{"lr": "0.1", "batch": 32},
+	}
+	targetRun := models.Run{
+		Name:       "target",
+		UserName:   MockRootUser,
+		RunYaml:    fmt.Sprintf(runYaml, "0.01", 10),
+		Parameters: map[string]interface{}{"lr": "0.01", "batch": 32, "seed": 1},
+	}
+	var err error
+	for _, run := range []*models.Run{&baseRun, &targetRun} {
+		assert.Nil(t, run.Encode())
+		run.ID, err = models.CreateRun(ctx.Logging(), run)
+		assert.Nil(t, err)
+	}
+
+	jobs := []models.RunJob{
+		{ID: "job-1", RunID: baseRun.ID, StepName: "train"},
+		{ID: "job-2", RunID: baseRun.ID, StepName: "eval"},
+		{ID: "job-3", RunID: targetRun.ID, StepName: "train", CacheRunID: baseRun.ID, CacheJobID: "job-1"},
+		{ID: "job-4", RunID: targetRun.ID, StepName: "eval"},
+	}
+	for _, job := range jobs {
+		_, err = models.CreateRunJob(ctx.Logging(), &job)
+		assert.Nil(t, err)
+	}
+
+	events := []schema.LogRunArtifactRequest{
+		{RunID: baseRun.ID, Step: "train", ArtifactName: "model", ArtifactPath: "/base/model"},
+		{RunID: baseRun.ID, Step: "eval", ArtifactName: "report", ArtifactPath: "/report"},
+		{RunID: targetRun.ID, Step: "train", ArtifactName: "model", ArtifactPath: "/target/model"},
+		{RunID: targetRun.ID, Step: "eval", ArtifactName: "report", ArtifactPath: "/report"},
+	}
+	for _, event := range events {
+		event.Type = schema.ArtifactTypeOutput
+		assert.Nil(t, LogArtifactEvent(event))
+	}
+
+	diff, err := DiffRun(ctx, baseRun.ID, targetRun.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []ValueDiff{
+		{Key: "lr", Base: "0.1", Target: "0.01"},
+		{Key: "seed", Target: float64(1)},
+	}, diff.Parameters)
+	assert.Equal(t, []ValueDiff{
+		{Key: "entry_points.train.command", Base: "python train.py --lr=0.1", Target: "python train.py --lr=0.01"},
+	}, diff.RunYaml)
+	assert.Equal(t, []StepCacheDiff{
+		{
+			Step:   "train",
+			Base:   &StepCache{JobID: "job-1"},
+			Target: &StepCache{JobID: "job-3", Cached: true, CacheRunID: baseRun.ID, CacheJobID: "job-1"},
+		},
+	}, diff.Cache)
+	assert.Equal(t, []ValueDiff{
+		{Key: "train.model", Base: "/base/model", Target: "/target/model"},
+	}, diff.Artifacts)
+
+	ctx2 := &logger.RequestContext{UserName: MockUserID2}
+	_, err = DiffRun(ctx2, baseRun.ID, targetRun.ID)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, ctx2.ErrorCode)
+
+	_, err = DiffRun(ctx, baseRun.ID, "run-not-exist")
+	assert.NotNil(t, err)
+	assert.Equal(t, common.RunNotFound, ctx.ErrorCode)
+}
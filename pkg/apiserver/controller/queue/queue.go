@@ -34,6 +34,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 )
@@ -50,8 +51,9 @@ type CreateQueueRequest struct {
 	MinResources schema.ResourceInfo `json:"minResources"`
 	Location     map[string]string   `json:"location"`
 	// 任务调度策略
-	SchedulingPolicy []string `json:"schedulingPolicy,omitempty"`
-	Status           string   `json:"-"`
+	SchedulingPolicy     []string                     `json:"schedulingPolicy,omitempty"`
+	SchedulingPolicyArgs map[string]map[string]string `json:"schedulingPolicyArgs,omitempty"`
+	Status               string                       `json:"-"`
 }
 
 type UpdateQueueRequest struct {
@@ -63,8 +65,9 @@ type UpdateQueueRequest struct {
 	MinResources schema.ResourceInfo `json:"minResources,omitempty"`
 	Location     map[string]string   `json:"location,omitempty"`
 	// 任务调度策略
-	SchedulingPolicy []string `json:"schedulingPolicy,omitempty"`
-	Status           string   `json:"-"`
+	SchedulingPolicy     []string                     `json:"schedulingPolicy,omitempty"`
+	SchedulingPolicyArgs map[string]map[string]string `json:"schedulingPolicyArgs,omitempty"`
+	Status               string                       `json:"-"`
 }
 
 type CreateQueueResponse struct {
@@ -267,6 +270,12 @@ func CreateQueue(ctx *logger.RequestContext, request *CreateQueueRequest) (Creat
 		}
 	}
 
+	if err = validateSchedulingPolicyArgs(request.SchedulingPolicy, request.SchedulingPolicyArgs); err != nil {
+		ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
+		ctx.ErrorCode = common.InvalidArguments
+		return CreateQueueResponse{}, err
+	}
+
 	if request.Location == nil {
 		request.Location = make(map[string]string)
 	}
@@ -293,15 +302,16 @@ func CreateQueue(ctx *logger.RequestContext, request *CreateQueueRequest) (Creat
 		Model: models.Model{
 			ID: uuid.GenerateID(common.PrefixQueue),
 		},
-		Name:             request.Name,
-		Namespace:        request.Namespace,
-		QuotaType:        request.QuotaType,
-		ClusterId:        clusterInfo.ID,
-		MaxResources:     maxResources,
-		MinResources:     minResources,
-		Location:         request.Location,
-		SchedulingPolicy: request.SchedulingPolicy,
-		Status:           schema.StatusQueueCreating,
+		Name:                 request.Name,
+		Namespace:            request.Namespace,
+		QuotaType:            request.QuotaType,
+		ClusterId:            clusterInfo.ID,
+		MaxResources:         maxResources,
+		MinResources:         minResources,
+		Location:             request.Location,
+		SchedulingPolicy:     request.SchedulingPolicy,
+		SchedulingPolicyArgs: request.SchedulingPolicyArgs,
+		Status:               schema.StatusQueueCreating,
 	}
 	err = models.CreateQueue(&queueInfo)
 	if err != nil {
@@ -359,6 +369,38 @@ func CreateQueue(ctx *logger.RequestContext, request *CreateQueueRequest) (Creat
 	return response, nil
 }
 
+func isPolicyExist(policies []string, policy string) bool {
+	for _, p := range policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSchedulingPolicyArgs checks the arguments of scheduling policies by building sort policies with them
+func validateSchedulingPolicyArgs(policies []string, policyArgs map[string]map[string]string) error {
+	for policy, args := range policyArgs {
+		if !isPolicyExist(policies, policy) {
+			return fmt.Errorf("arguments of scheduling policy %s are set, but the policy is not used by queue", policy)
+		}
+		if policy == api.BackfillPolicyName {
+			if _, err := api.NewBackfillConf(args); err != nil {
+				return fmt.Errorf("arguments of scheduling policy %s are invalid, err: %v", policy, err)
+			}
+			continue
+		}
+		policyNew, find := api.QueueSortPolicies[policy]
+		if !find {
+			continue
+		}
+		if _, err := policyNew(args); err != nil {
+			return fmt.Errorf("arguments of scheduling policy %s are invalid, err: %v", policy, err)
+		}
+	}
+	return nil
+}
+
 func UpdateQueue(ctx *logger.RequestContext, request *UpdateQueueRequest) (UpdateQueueResponse, error) {
 	ctx.Logging().Debugf("begin update request. request:%s", config.PrettyFormat(request))
 	if !common.IsRootUser(ctx.UserName) {
@@ -452,27 +494,45 @@ func UpdateQueue(ctx *logger.RequestContext, request *UpdateQueueRequest) (Updat
 	}
 
 	// validate scheduling policy
-	if len(request.SchedulingPolicy) != 0 {
+	if len(request.SchedulingPolicy) != 0 || len(request.SchedulingPolicyArgs) != 0 {
 		log.Debug("update queue scheduling policy")
 		// TODO: change the data type of schedulingPolicy to map[string]interface{}
-		schedulingPolicy := make(map[string]struct{})
-		for _, policy := range queueInfo.SchedulingPolicy {
-			schedulingPolicy[policy] = struct{}{}
-		}
+		removedPolicy := make(map[string]struct{})
+		sp := []string{}
 		for _, policy := range request.SchedulingPolicy {
 			if strings.HasSuffix(policy, "-") {
 				// remove old scheduling policy
-				sp := strings.TrimRight(policy, "-")
-				delete(schedulingPolicy, sp)
-			} else {
-				schedulingPolicy[policy] = struct{}{}
+				removedPolicy[strings.TrimRight(policy, "-")] = struct{}{}
 			}
 		}
-		sp := []string{}
-		for policy, _ := range schedulingPolicy {
+		// keep the order of scheduling policies, as they are chained in order
+		for _, policy := range append(queueInfo.SchedulingPolicy, request.SchedulingPolicy...) {
+			if _, removed := removedPolicy[strings.TrimRight(policy, "-")]; removed || isPolicyExist(sp, policy) {
+				continue
+			}
 			sp = append(sp, policy)
 		}
+		policyArgs := make(map[string]map[string]string)
+		for policy, args := range queueInfo.SchedulingPolicyArgs {
+			if _, removed := removedPolicy[policy]; !removed {
+				policyArgs[policy] = args
+			}
+		}
+		for policy, args := range request.SchedulingPolicyArgs {
+			if len(args) == 0 {
+				// remove arguments of scheduling policy when value is empty
+				delete(policyArgs, policy)
+			} else {
+				policyArgs[policy] = args
+			}
+		}
+		if err = validateSchedulingPolicyArgs(sp, policyArgs); err != nil {
+			ctx.Logging().Errorf("update queue failed. error: %s", err.Error())
+			ctx.ErrorCode = common.InvalidArguments
+			return UpdateQueueResponse{}, err
+		}
 		queueInfo.SchedulingPolicy = sp
+		queueInfo.SchedulingPolicyArgs = policyArgs
 	}
 
 	// init runtimeSvc if updateCluster is necessary
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// accessTokenLength is the bytes of random part of personal access token
+const accessTokenLength = 20
+
+type CreateAccessTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpireDays is the days before token expires, 0 means never
+	ExpireDays int `json:"expireDays"`
+}
+
+type CreateAccessTokenResponse struct {
+	model.AccessToken
+	// Token is only returned when created, it can not be got again
+	Token string `json:"token"`
+}
+
+type ListAccessTokenResponse struct {
+	Tokens []model.AccessToken `json:"tokenList"`
+}
+
+// checkTokenOwner checks whether the request user can manage the tokens of userName, root or user himself
+func checkTokenOwner(ctx *logger.RequestContext, userName string) error {
+	if !common.IsRootUser(ctx.UserName) && !strings.EqualFold(ctx.UserName, userName) {
+		ctx.ErrorCode = common.AccessDenied
+		ctx.Logging().Errorf("user[%s] can not manage access tokens of user[%s]", ctx.UserName, userName)
+		return errors.New("access denied")
+	}
+	if _, err := storage.Auth.GetUserByName(ctx, userName); err != nil {
+		ctx.ErrorCode = common.UserNotExist
+		ctx.Logging().Errorf("user[%s] not exist", userName)
+		return errors.New("user not exist")
+	}
+	return nil
+}
+
+func CreateAccessToken(ctx *logger.RequestContext, userName string, req *CreateAccessTokenRequest) (*CreateAccessTokenResponse, error) {
+	ctx.Logging().Debugf("begin create access token. userName:%s, name:%s", userName, req.Name)
+	if err := checkTokenOwner(ctx, userName); err != nil {
+		return nil, err
+	}
+	if req.Name == "" || req.ExpireDays < 0 {
+		ctx.ErrorCode = common.InvalidHTTPRequest
+		ctx.Logging().Errorf("create access token failed. name[%s] or expireDays[%d] is invalid", req.Name, req.ExpireDays)
+		return nil, errors.New("name or expireDays is invalid")
+	}
+	if err := common.ValidateScopes(req.Scopes); err != nil {
+		ctx.ErrorCode = common.InvalidHTTPRequest
+		ctx.Logging().Errorf("create access token failed. error:%s", err.Error())
+		return nil, err
+	}
+
+	secret := make([]byte, accessTokenLength)
+	if _, err := rand.Read(secret); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("generate access token failed. error:%s", err.Error())
+		return nil, err
+	}
+	plain := common.AccessTokenPrefix + hex.EncodeToString(secret)
+	token := model.AccessToken{
+		Name:      req.Name,
+		UserName:  userName,
+		TokenHash: common.HashAccessToken(plain),
+		Scopes:    req.Scopes,
+	}
+	if req.ExpireDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpireDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := storage.Auth.CreateAccessToken(ctx, &token); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &CreateAccessTokenResponse{AccessToken: token, Token: plain}, nil
+}
+
+func ListAccessToken(ctx *logger.RequestContext, userName string) (*ListAccessTokenResponse, error) {
+	ctx.Logging().Debugf("begin list access tokens. userName:%s", userName)
+	if err := checkTokenOwner(ctx, userName); err != nil {
+		return nil, err
+	}
+	tokens, err := storage.Auth.ListAccessToken(ctx, userName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &ListAccessTokenResponse{Tokens: tokens}, nil
+}
+
+func DeleteAccessToken(ctx *logger.RequestContext, userName, tokenID string) error {
+	ctx.Logging().Debugf("begin delete access token. userName:%s, tokenID:%s", userName, tokenID)
+	if err := checkTokenOwner(ctx, userName); err != nil {
+		return err
+	}
+	if err := storage.Auth.DeleteAccessToken(ctx, userName, tokenID); err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		ctx.Logging().Errorf("delete access token[%s] failed. error:%s", tokenID, err.Error())
+		return err
+	}
+	return nil
+}
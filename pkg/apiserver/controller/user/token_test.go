@@ -0,0 +1,74 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+func TestAccessToken(t *testing.T) {
+	TestCreateUser(t)
+	ctx := &logger.RequestContext{UserName: MockUser1}
+
+	// bad cases
+	_, err := CreateAccessToken(ctx, MockUser1, &CreateAccessTokenRequest{Name: "ci", Scopes: []string{"job:delete"}})
+	assert.NotNil(t, err)
+	_, err = CreateAccessToken(ctx, MockUser1, &CreateAccessTokenRequest{Name: "ci"})
+	assert.NotNil(t, err)
+	_, err = CreateAccessToken(ctx, MockRootUser, &CreateAccessTokenRequest{Name: "ci", Scopes: []string{common.ScopeAll}})
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+
+	resp, err := CreateAccessToken(ctx, MockUser1, &CreateAccessTokenRequest{
+		Name:       "ci",
+		Scopes:     []string{"job:read", common.ScopeRunCreate},
+		ExpireDays: 30,
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(resp.Token, common.AccessTokenPrefix))
+	assert.NotNil(t, resp.ExpiresAt)
+
+	token, err := storage.Auth.GetAccessTokenByHash(ctx, common.HashAccessToken(resp.Token))
+	assert.Nil(t, err)
+	assert.Equal(t, MockUser1, token.UserName)
+	assert.Equal(t, []string{"job:read", common.ScopeRunCreate}, token.Scopes)
+	_, err = storage.Auth.GetAccessTokenByHash(ctx, common.HashAccessToken(resp.Token+"0"))
+	assert.NotNil(t, err)
+
+	// root can list tokens of others, and the token itself is never returned
+	tokens, err := ListAccessToken(&logger.RequestContext{UserName: MockRootUser}, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(tokens.Tokens))
+	assert.Equal(t, resp.ID, tokens.Tokens[0].ID)
+	data, err := json.Marshal(tokens)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), tokens.Tokens[0].TokenHash))
+
+	err = DeleteAccessToken(ctx, MockUser1, resp.ID)
+	assert.Nil(t, err)
+	_, err = storage.Auth.GetAccessTokenByHash(ctx, common.HashAccessToken(resp.Token))
+	assert.NotNil(t, err)
+	err = DeleteAccessToken(ctx, MockUser1, resp.ID)
+	assert.NotNil(t, err)
+}
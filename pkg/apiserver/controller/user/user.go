@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	gormErrors "github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -170,7 +172,13 @@ func UpdateUser(ctx *logger.RequestContext, userName, password string) error {
 		ctx.ErrorCode = common.UserNotExist
 		return err
 	}
-	return nil
+	// access tokens and tokens issued before are revoked, as they are authorized by the old password
+	if err = storage.Auth.DeleteAccessTokenByUserName(ctx, userName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("update user's password failed. delete user's access tokens error:%s", err.Error())
+		return err
+	}
+	return revokeUserTokens(ctx, userName)
 }
 
 func DeleteUser(ctx *logger.RequestContext, userName string) error {
@@ -205,6 +213,31 @@ func DeleteUser(ctx *logger.RequestContext, userName string) error {
 		ctx.Logging().Errorf("models delete user failed. delete user's grant  error:%s", err.Error())
 		return err
 	}
+	if err := storage.Auth.DeleteAccessTokenByUserName(ctx, userName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("models delete user failed. delete user's access tokens error:%s", err.Error())
+		return err
+	}
+	// tokens of the user are revoked in case that a user with the same name is created later
+	return revokeUserTokens(ctx, userName)
+}
+
+// revokeUserTokens revokes all tokens of user issued before or within the current second.
+func revokeUserTokens(ctx *logger.RequestContext, userName string) error {
+	now := time.Now()
+	revocation := &model.TokenRevocation{
+		UserName:     userName,
+		IssuedBefore: now.Unix(),
+	}
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.ApiServer.TokenExpirationHour != -1 {
+		expiration := time.Duration(config.GlobalServerConfig.ApiServer.TokenExpirationHour) * time.Hour
+		revocation.ExpiresAt = now.Add(expiration).Unix()
+	}
+	if err := storage.Auth.RevokeToken(ctx, revocation); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("revoke tokens of user[%s] failed. error:%s", userName, err.Error())
+		return err
+	}
 	return nil
 }
 
@@ -52,6 +52,15 @@ func TestUpdateUser(t *testing.T) {
 	resp, err := CreateUser(ctx, MockUser1, MockPW)
 	assert.Nil(t, err)
 	t.Logf("response=%+v", resp)
+
+	// access tokens are revoked when password is changed
+	userCtx := &logger.RequestContext{UserName: MockUser1}
+	_, err = CreateAccessToken(userCtx, MockUser1, &CreateAccessTokenRequest{Name: "ci", Scopes: []string{"job:read"}})
+	assert.Nil(t, err)
+	assert.Nil(t, UpdateUser(userCtx, MockUser1, MockPW+"new"))
+	tokens, err := ListAccessToken(userCtx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(tokens.Tokens))
 }
 
 func TestListUser(t *testing.T) {
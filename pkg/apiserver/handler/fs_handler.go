@@ -17,10 +17,18 @@ limitations under the License.
 package handler
 
 import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	iofs "io/fs"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -34,8 +42,66 @@ const (
 	maxRetryCount = 3
 	// unit is time.Millisecond
 	sleepMillisecond = 100
+
+	// 文件内容摘要缓存的最大条目数
+	maxContentDigestMemoSize = 100000
 )
 
+// 文件内容摘要的缓存，以 fsID、路径、大小以及 mtime 作为 key，避免重复读取没有发生变化的大文件
+// 条目数超过上限后，淘汰最久没有被使用的条目
+type contentDigestMemo struct {
+	sync.Mutex
+	digests map[string]*list.Element
+	lru     *list.List
+	maxSize int
+}
+
+type contentDigestMemoItem struct {
+	key    string
+	digest string
+}
+
+var digestMemo = newContentDigestMemo(maxContentDigestMemoSize)
+
+func newContentDigestMemo(maxSize int) *contentDigestMemo {
+	return &contentDigestMemo{
+		digests: map[string]*list.Element{},
+		lru:     list.New(),
+		maxSize: maxSize,
+	}
+}
+
+func contentDigestMemoKey(fsID, path string, info iofs.FileInfo) string {
+	return fmt.Sprintf("%s:%s:%d:%d", fsID, path, info.Size(), info.ModTime().UnixNano())
+}
+
+func (m *contentDigestMemo) get(key string) (string, bool) {
+	m.Lock()
+	defer m.Unlock()
+	elem, ok := m.digests[key]
+	if !ok {
+		return "", false
+	}
+	m.lru.MoveToFront(elem)
+	return elem.Value.(*contentDigestMemoItem).digest, true
+}
+
+func (m *contentDigestMemo) set(key, digest string) {
+	m.Lock()
+	defer m.Unlock()
+	if elem, ok := m.digests[key]; ok {
+		elem.Value.(*contentDigestMemoItem).digest = digest
+		m.lru.MoveToFront(elem)
+		return
+	}
+	m.digests[key] = m.lru.PushFront(&contentDigestMemoItem{key: key, digest: digest})
+	for m.lru.Len() > m.maxSize {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.digests, oldest.Value.(*contentDigestMemoItem).key)
+	}
+}
+
 type FsServerEmptyError struct {
 }
 
@@ -234,3 +300,106 @@ func (fh *FsHandler) LastModTime(path string) (time.Time, error) {
 		}
 	}
 }
+
+// 获取与 pattern 匹配的所有文件的信息，pattern 的语法与 filepath.Match 相同
+// 只会遍历 pattern 中第一个通配符所在的目录，以及层级不超过 pattern 的子目录
+func (fh *FsHandler) GlobFileInfo(pattern string) (map[string]iofs.FileInfo, error) {
+	pattern = filepath.Clean(pattern)
+	root := pattern
+	if index := strings.IndexAny(pattern, "*?[\\"); index >= 0 {
+		root = pattern[:index]
+	}
+	root = filepath.Dir(root + "_")
+
+	exist, err := fh.fsClient.Exist(root)
+	if err != nil || !exist {
+		return map[string]iofs.FileInfo{}, err
+	}
+
+	depth := strings.Count(pattern, "/")
+	files := map[string]iofs.FileInfo{}
+	err = fh.fsClient.Walk(root, func(path string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && strings.Count(path, "/") >= depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if matched, _ := filepath.Match(pattern, path); matched {
+			files[path] = info
+		}
+		return nil
+	})
+	if err != nil {
+		fh.log.Debugf("cannot get the files matched pattern[%s] with fsId[%s]: %s", pattern, fh.fsID, err.Error())
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// 获取 path 下所有文件（包括path本身）内容的摘要，文件的 mtime 发生变化但内容不变时，摘要不会变化
+// 单个文件的摘要会按照其大小和 mtime 进行缓存，大小和 mtime 均未变化的文件不会被再次读取
+func (fh *FsHandler) ContentDigest(path string) (string, error) {
+	fileDigests := map[string]string{}
+	err := fh.fsClient.Walk(path, func(filePath string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		digest, err := fh.fileDigest(filePath, info)
+		if err != nil {
+			return err
+		}
+		// 使用相对于 path 的路径，使得摘要只与目录结构和文件内容有关
+		fileDigests[strings.TrimPrefix(filePath, path)] = digest
+		return nil
+	})
+	if err != nil {
+		fh.log.Debugf("cannot get the content digest of path[%s] with fsId[%s]: %s", path, fh.fsID, err.Error())
+		return "", err
+	}
+
+	paths := make([]string, 0, len(fileDigests))
+	for p := range fileDigests {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	for _, p := range paths {
+		hash.Write([]byte(fmt.Sprintf("%s:%s\n", p, fileDigests[p])))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (fh *FsHandler) fileDigest(path string, info iofs.FileInfo) (string, error) {
+	key := contentDigestMemoKey(fh.fsID, path, info)
+	if digest, ok := digestMemo.get(key); ok {
+		return digest, nil
+	}
+
+	reader, err := fh.fsClient.Open(path)
+	if err != nil {
+		fh.log.Errorf("open file[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, reader); err != nil {
+		fh.log.Errorf("read file[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return "", err
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	digestMemo.set(key, digest)
+	return digest, nil
+}
@@ -184,3 +184,24 @@ func TestLastModTime(t *testing.T) {
 	fi, err := os.Lstat("mock_fs_handler/test_path_time/path_time/time/a.txt")
 	assert.Equal(t, modTime.UnixNano(), fi.ModTime().UnixNano())
 }
+
+func TestContentDigestMemo(t *testing.T) {
+	memo := newContentDigestMemo(2)
+	memo.set("a", "digest-a")
+	memo.set("b", "digest-b")
+
+	// 访问过的条目不会被淘汰，淘汰的是最久没有被使用的条目
+	digest, ok := memo.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "digest-a", digest)
+	memo.set("c", "digest-c")
+
+	_, ok = memo.get("b")
+	assert.False(t, ok)
+	_, ok = memo.get("a")
+	assert.True(t, ok)
+	_, ok = memo.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, memo.lru.Len())
+	assert.Equal(t, 2, len(memo.digests))
+}
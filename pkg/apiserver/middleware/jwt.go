@@ -20,51 +20,157 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	jwtgo "github.com/dgrijalva/jwt-go"
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
-	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/user"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 )
 
+const (
+	UserNameParam = "userName"
+	// KeyIDHeader is the header of token to find the key which signs the token
+	KeyIDHeader = "kid"
+
+	tokenIssuer = "paddleflow"
+)
+
+// JWT signs tokens with the active key, and verifies tokens with any of keys so that keys can be rotated
+// without invalidating the tokens signed by the old ones.
 type JWT struct {
-	Sigkey []byte
+	keys        map[string][]byte
+	activeKeyID string
 }
 
-const SECERTKEY = "its my precious"
-const UserNameParam = "userName"
+var (
+	jwtObj  *JWT
+	jwtLock sync.RWMutex
+)
 
-var jwtObj *JWT
+// PaddleFlowClaims never contains credentials, as tokens are only signed but not encrypted
+type PaddleFlowClaims struct {
+	UserName string `json:"username"`
+	jwtgo.StandardClaims
+}
 
-func init() {
-	jwtObj = &JWT{
-		Sigkey: []byte(SECERTKEY),
+// InitJWT loads the signing keys from server config and the key file, it can be called again to reload keys.
+func InitJWT(conf *config.ApiServerConfig) error {
+	j, err := newJWT(conf)
+	if err != nil {
+		log.Errorf("init jwt failed: %v", err)
+		return err
 	}
+	jwtLock.Lock()
+	jwtObj = j
+	jwtLock.Unlock()
+	return nil
 }
 
-type PaddleFlowClaims struct {
-	UserName string `json:"username"`
-	Password string `json:"password"`
-	jwtgo.StandardClaims
+// getJWT returns the jwt initialized by InitJWT, or initializes it with the global server config
+func getJWT() (*JWT, error) {
+	jwtLock.RLock()
+	j := jwtObj
+	jwtLock.RUnlock()
+	if j != nil {
+		return j, nil
+	}
+	jwtLock.Lock()
+	defer jwtLock.Unlock()
+	if jwtObj == nil {
+		var conf *config.ApiServerConfig
+		if config.GlobalServerConfig != nil {
+			conf = &config.GlobalServerConfig.ApiServer
+		}
+		var err error
+		if jwtObj, err = newJWT(conf); err != nil {
+			log.Errorf("init jwt failed: %v", err)
+			return nil, err
+		}
+	}
+	return jwtObj, nil
+}
+
+func newJWT(conf *config.ApiServerConfig) (*JWT, error) {
+	var keys []config.TokenSigningKey
+	activeKeyID := ""
+	if conf != nil {
+		keys = append(keys, conf.TokenSigningKeys...)
+		if conf.TokenSigningKeyFile != "" {
+			fileKeys, err := readSigningKeyFile(conf.TokenSigningKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, fileKeys...)
+		}
+		activeKeyID = conf.TokenSigningKeyID
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no token signing key is configured, please set tokenSigningKeys or tokenSigningKeyFile of apiServer")
+	}
+	j := &JWT{keys: make(map[string][]byte)}
+	for _, key := range keys {
+		if key.ID == "" || key.Secret == "" {
+			return nil, fmt.Errorf("id and secret of token signing key can not be empty")
+		}
+		if _, ok := j.keys[key.ID]; ok {
+			return nil, fmt.Errorf("token signing key[%s] is duplicated", key.ID)
+		}
+		j.keys[key.ID] = []byte(key.Secret)
+	}
+	if activeKeyID == "" {
+		activeKeyID = keys[0].ID
+	}
+	if _, ok := j.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("token signing key[%s] is not found", activeKeyID)
+	}
+	j.activeKeyID = activeKeyID
+	return j, nil
+}
+
+func readSigningKeyFile(path string) ([]config.TokenSigningKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read token signing key file[%s] failed: %v", path, err)
+	}
+	var keys []config.TokenSigningKey
+	if err = yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("decode token signing key file[%s] failed: %v", path, err)
+	}
+	return keys, nil
 }
 
 func (j *JWT) CreateToken(claim PaddleFlowClaims) (string, error) {
 	token := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claim)
-	return token.SignedString(j.Sigkey)
+	token.Header[KeyIDHeader] = j.activeKeyID
+	return token.SignedString(j.keys[j.activeKeyID])
 }
 
 func (j *JWT) ParseToken(tokenString string) (*PaddleFlowClaims, error) {
 	token, err := jwtgo.ParseWithClaims(tokenString,
 		&PaddleFlowClaims{},
 		func(token *jwtgo.Token) (interface{}, error) {
-			return j.Sigkey, nil
+			if _, ok := token.Method.(*jwtgo.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+			}
+			kid, _ := token.Header[KeyIDHeader].(string)
+			key, ok := j.keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("token signing key[%s] is not found", kid)
+			}
+			return key, nil
 		})
 	if err != nil {
 		if ve, ok := err.(*jwtgo.ValidationError); ok {
@@ -75,30 +181,87 @@ func (j *JWT) ParseToken(tokenString string) (*PaddleFlowClaims, error) {
 				return nil, errors.New(common.AuthFailed)
 			}
 		}
+		return nil, errors.New(common.AuthInvalidToken)
 	}
 	if claims, ok := token.Claims.(*PaddleFlowClaims); ok && token.Valid {
-		log.Debugf("ParseToken succeed. token:[%s] userName:[%s]", tokenString, claims.UserName)
+		log.Debugf("ParseToken succeed. userName:[%s] tokenID:[%s]", claims.UserName, claims.Id)
 		return claims, nil
 	}
 	return nil, errors.New(common.AuthInvalidToken)
 }
 
-func GenerateToken(userName string, password string) (string, error) {
-	log.Debugf("GenerateToken userName:[%s] password:[%s]", userName, password)
+func parseToken(tokenString string) (*PaddleFlowClaims, error) {
+	j, err := getJWT()
+	if err != nil {
+		return nil, err
+	}
+	return j.ParseToken(tokenString)
+}
+
+func GenerateToken(userName string) (string, error) {
+	log.Debugf("GenerateToken userName:[%s]", userName)
+	now := time.Now()
 	claim := &PaddleFlowClaims{
 		UserName: userName,
-		Password: password,
 	}
-	if config.GlobalServerConfig.ApiServer.TokenExpirationHour != -1 {
-		expire := time.Now().Add(time.Duration(config.GlobalServerConfig.ApiServer.TokenExpirationHour) * time.Hour)
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.ApiServer.TokenExpirationHour != -1 {
+		expire := now.Add(time.Duration(config.GlobalServerConfig.ApiServer.TokenExpirationHour) * time.Hour)
 		claim.ExpiresAt = expire.Unix()
 	}
-	claim.NotBefore = int64(time.Now().Unix()) - 1000
-	claim.Issuer = "paddleflow"
-	token, err := jwtObj.CreateToken(*claim)
+	claim.Id = uuid.GenerateIDWithLength(common.PrefixToken, 32)
+	claim.IssuedAt = now.Unix()
+	claim.NotBefore = now.Unix() - 1000
+	claim.Issuer = tokenIssuer
+	j, err := getJWT()
 	if err != nil {
 		return "", errors.New(common.InternalError)
 	}
+	token, err := j.CreateToken(*claim)
+	if err != nil {
+		return "", errors.New(common.InternalError)
+	}
+	return token, nil
+}
+
+// RevokeToken adds the token to revocation list, so that it is rejected even if it is not expired.
+func RevokeToken(ctx *logger.RequestContext, tokenString string) error {
+	j, err := getJWT()
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	claims, err := j.ParseToken(tokenString)
+	if err != nil {
+		ctx.ErrorCode = common.AuthInvalidToken
+		return err
+	}
+	revocation := &model.TokenRevocation{
+		TokenID:   claims.Id,
+		UserName:  claims.UserName,
+		ExpiresAt: claims.ExpiresAt,
+	}
+	if err = storage.Auth.RevokeToken(ctx, revocation); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+// verifyAccessToken returns the token if it is valid, and the owner of token exists.
+func verifyAccessToken(ctx *logger.RequestContext, plain string) (*model.AccessToken, error) {
+	token, err := storage.Auth.GetAccessTokenByHash(ctx, common.HashAccessToken(plain))
+	if err != nil {
+		ctx.ErrorCode = common.AuthInvalidToken
+		return nil, errors.New("access token not found")
+	}
+	if token.IsExpired() {
+		ctx.ErrorCode = common.AuthInvalidToken
+		return nil, errors.New("access token is expired")
+	}
+	if _, err = storage.Auth.GetUserByName(ctx, token.UserName); err != nil {
+		ctx.ErrorCode = common.UserNotExist
+		return nil, err
+	}
 	return token, nil
 }
 
@@ -120,28 +283,56 @@ func BaseAuth(next http.Handler) http.Handler {
 			common.RenderErr(res, requestID, common.AuthWithoutToken)
 			return
 		}
-		claims, err := jwtObj.ParseToken(token)
-		if err != nil {
-			ctx.Logging().Errorf("BaseAuth invalid token[%s]", token)
-			common.RenderErr(res, requestID, common.AuthInvalidToken)
-			return
+		var authUserName string
+		if strings.HasPrefix(token, common.AccessTokenPrefix) {
+			accessToken, err := verifyAccessToken(&ctx, token)
+			if err != nil {
+				ctx.Logging().Errorf("BaseAuth invalid access token. error:%v", err)
+				common.RenderErr(res, requestID, ctx.ErrorCode)
+				return
+			}
+			routePath := strings.TrimPrefix(req.URL.Path, common.PaddleflowRouterPrefix+common.PaddleflowRouterVersionV1)
+			if !common.ScopesAllow(accessToken.Scopes, req.Method, routePath) {
+				ctx.Logging().Errorf("BaseAuth access token[%s] with scopes%v can not %s %s",
+					accessToken.ID, accessToken.Scopes, req.Method, routePath)
+				common.RenderErr(res, requestID, common.AccessDenied)
+				return
+			}
+			authUserName = accessToken.UserName
+		} else {
+			claims, err := parseToken(token)
+			if err != nil {
+				ctx.Logging().Errorf("BaseAuth invalid token. error:%v", err)
+				common.RenderErr(res, requestID, common.AuthInvalidToken)
+				return
+			}
+			if _, err = storage.Auth.GetUserByName(&ctx, claims.UserName); err != nil {
+				ctx.Logging().Errorf(
+					"BaseAuth user verify error. UserName:[%s]", claims.UserName)
+				common.RenderErr(res, requestID, common.UserNotExist)
+				return
+			}
+			revoked, err := storage.Auth.IsTokenRevoked(&ctx, claims.Id, claims.UserName, claims.IssuedAt)
+			if err != nil {
+				common.RenderErr(res, requestID, common.InternalError)
+				return
+			}
+			if revoked {
+				ctx.Logging().Errorf("BaseAuth token[%s] of user[%s] is revoked", claims.Id, claims.UserName)
+				common.RenderErr(res, requestID, common.AuthInvalidToken)
+				return
+			}
+			authUserName = claims.UserName
 		}
-		if !checkUserPermission(req, claims.UserName) {
+		if !checkUserPermission(req, authUserName) {
 			ctx.Logging().Errorf(
-				"BaseAuth user verify error. UserName:[%s] has no permission to operate other user", claims.UserName)
+				"BaseAuth user verify error. UserName:[%s] has no permission to operate other user", authUserName)
 			common.RenderErr(res, requestID, common.AuthIllegalUser)
 			return
 		}
-		_, err = user.Login(&ctx, claims.UserName, claims.Password, true)
-		if err != nil {
-			ctx.Logging().Errorf(
-				"BaseAuth user verify error. UserName:[%s]", claims.UserName)
-			common.RenderErr(res, requestID, ctx.ErrorCode)
-			return
-		}
 
-		ctx.Logging().Debugf("BaseAuth add user-name[%s]", claims.UserName)
-		req.Header.Set(common.HeaderKeyUserName, claims.UserName)
+		ctx.Logging().Debugf("BaseAuth add user-name[%s]", authUserName)
+		req.Header.Set(common.HeaderKeyUserName, authUserName)
 		next.ServeHTTP(res, req)
 	})
 }
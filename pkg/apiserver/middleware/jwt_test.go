@@ -0,0 +1,186 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package middleware
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/user"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func initTestJWT(t *testing.T, conf *config.ApiServerConfig) {
+	config.GlobalServerConfig = &config.ServerConfig{ApiServer: *conf}
+	assert.Equal(t, nil, InitJWT(conf))
+}
+
+func TestJWT_rotateKeys(t *testing.T) {
+	conf := &config.ApiServerConfig{
+		TokenExpirationHour: -1,
+		TokenSigningKeys:    []config.TokenSigningKey{{ID: "key-1", Secret: "secret-1"}},
+	}
+	initTestJWT(t, conf)
+	oldToken, err := GenerateToken("root")
+	assert.Equal(t, nil, err)
+
+	// the payload never contains credentials
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(oldToken, ".")[1])
+	assert.Equal(t, nil, err)
+	assert.False(t, strings.Contains(string(payload), "password"))
+
+	// keys in file are added, and new tokens are signed by the new key
+	keyFile := filepath.Join(t.TempDir(), "keys.yaml")
+	assert.Equal(t, nil, os.WriteFile(keyFile, []byte("- id: key-2\n  secret: secret-2\n"), 0600))
+	conf.TokenSigningKeyFile = keyFile
+	conf.TokenSigningKeyID = "key-2"
+	initTestJWT(t, conf)
+	newToken, err := GenerateToken("root")
+	assert.Equal(t, nil, err)
+	token, _, err := new(jwtgo.Parser).ParseUnverified(newToken, &PaddleFlowClaims{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "key-2", token.Header[KeyIDHeader])
+
+	claims, err := parseToken(oldToken)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "root", claims.UserName)
+	_, err = parseToken(newToken)
+	assert.Equal(t, nil, err)
+
+	// tokens of removed key are rejected
+	conf.TokenSigningKeys = nil
+	initTestJWT(t, conf)
+	_, err = parseToken(oldToken)
+	assert.NotEqual(t, nil, err)
+	_, err = parseToken(newToken)
+	assert.Equal(t, nil, err)
+
+	// tokens without key id are rejected
+	legacy := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, PaddleFlowClaims{UserName: "root"})
+	legacyToken, err := legacy.SignedString([]byte("secret-2"))
+	assert.Equal(t, nil, err)
+	_, err = parseToken(legacyToken)
+	assert.NotEqual(t, nil, err)
+
+	conf.TokenSigningKeyID = "key-3"
+	assert.NotEqual(t, nil, InitJWT(conf))
+
+	// server fails to start without signing keys
+	assert.NotEqual(t, nil, InitJWT(&config.ApiServerConfig{TokenExpirationHour: -1}))
+}
+
+func TestBaseAuth_revokeToken(t *testing.T) {
+	driver.InitMockDB()
+	initTestJWT(t, &config.ApiServerConfig{
+		TokenExpirationHour: 1,
+		TokenSigningKeys:    []config.TokenSigningKey{{ID: "key-1", Secret: "secret-1"}},
+	})
+	ctx := &logger.RequestContext{UserName: "root"}
+	assert.Equal(t, nil, storage.Auth.CreateUser(ctx, &model.User{UserInfo: model.UserInfo{Name: "test", Password: "x"}}))
+
+	handler := BaseAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/paddleflow/v1/queue", nil)
+		req.Header.Set(common.HeaderKeyAuthorization, token)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	token, err := GenerateToken("test")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, request(token))
+	other, err := GenerateToken("test")
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, nil, RevokeToken(ctx, token))
+	assert.NotEqual(t, http.StatusOK, request(token))
+	assert.Equal(t, http.StatusOK, request(other))
+
+	// all tokens of user issued before are revoked, including those issued in the same second
+	assert.Equal(t, nil, storage.Auth.RevokeToken(ctx, &model.TokenRevocation{
+		UserName:     "test",
+		IssuedBefore: time.Now().Unix(),
+	}))
+	assert.NotEqual(t, http.StatusOK, request(other))
+
+	// tokens of unknown users are rejected
+	unknown, err := GenerateToken("unknown")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, http.StatusOK, request(unknown))
+}
+
+func TestBaseAuth_accessToken(t *testing.T) {
+	driver.InitMockDB()
+	initTestJWT(t, &config.ApiServerConfig{
+		TokenExpirationHour: -1,
+		TokenSigningKeys:    []config.TokenSigningKey{{ID: "key-1", Secret: "secret-1"}},
+	})
+	ctx := &logger.RequestContext{UserName: "test"}
+	assert.Equal(t, nil, storage.Auth.CreateUser(ctx, &model.User{UserInfo: model.UserInfo{Name: "test", Password: "x"}}))
+	resp, err := user.CreateAccessToken(ctx, "test", &user.CreateAccessTokenRequest{
+		Name:   "ci",
+		Scopes: []string{"job:read", common.ScopeRunCreate},
+	})
+	assert.Equal(t, nil, err)
+	token, err := verifyAccessToken(ctx, resp.Token)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "test", token.UserName)
+	_, err = verifyAccessToken(ctx, resp.Token+"0")
+	assert.Equal(t, common.AuthInvalidToken, ctx.ErrorCode)
+
+	var authUser string
+	handler := BaseAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authUser = r.Header.Get(common.HeaderKeyUserName)
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(method, path, token string) int {
+		req := httptest.NewRequest(method, "/api/paddleflow/v1"+path, nil)
+		req.Header.Set(common.HeaderKeyAuthorization, token)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/job/job-0001", resp.Token))
+	assert.Equal(t, "test", authUser)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/run", resp.Token))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/job/job-0001", resp.Token))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/run/run-0001", resp.Token))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/user/test/token", resp.Token))
+	assert.NotEqual(t, http.StatusOK, request(http.MethodGet, "/job", common.AccessTokenPrefix+"unknown"))
+
+	// revoked token is rejected
+	assert.Equal(t, nil, user.DeleteAccessToken(ctx, "test", resp.ID))
+	assert.NotEqual(t, http.StatusOK, request(http.MethodGet, "/job/job-0001", resp.Token))
+}
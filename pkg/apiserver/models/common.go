@@ -72,6 +72,8 @@ func initMockDB() {
 		&Pipeline{},
 		&PipelineVersion{},
 		&Schedule{},
+		&Notifier{},
+		&NotificationDelivery{},
 		&RunCache{},
 		&ArtifactEvent{},
 		&model.User{},
@@ -81,6 +83,8 @@ func initMockDB() {
 		&Queue{},
 		&Flavour{},
 		&model.Grant{},
+		&model.TokenRevocation{},
+		&model.AccessToken{},
 		&Job{},
 		&JobTask{},
 		&JobLabel{},
@@ -299,6 +299,21 @@ func ListQueueInitJob(queueID string) []Job {
 	return jobs
 }
 
+// ListQueueActivatedJob lists jobs in queue which are still active or updated after the specified time
+func ListQueueActivatedJob(queueID string, since time.Time) []Job {
+	activeStatus := []schema.JobStatus{schema.StatusJobRunning, schema.StatusJobTerminating}
+	db := storage.DB.Table("job").Where("queue_id = ?", queueID).Where("activated_at is not null").
+		Where("(status in ? OR updated_at >= ?)", activeStatus, since).Where("deleted_at is null")
+
+	var jobs []Job
+	err := db.Find(&jobs).Error
+	if err != nil {
+		log.Errorf("list activated jobs in queue %s failed, err: %s", queueID, err.Error())
+		return []Job{}
+	}
+	return jobs
+}
+
 func ListClusterJob(clusterID string, status schema.JobStatus) []Job {
 	var jobs []Job
 	queues := ListQueuesByCluster(clusterID)
@@ -0,0 +1,336 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	NotifierTypeWebhook = "webhook"
+	NotifierTypeEmail   = "email"
+	NotifierTypeFile    = "file"
+
+	NotifyKindRun      = "run"
+	NotifyKindJob      = "job"
+	NotifyKindSchedule = "schedule"
+
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+var NotifierTypeList = []string{
+	NotifierTypeWebhook,
+	NotifierTypeEmail,
+	NotifierTypeFile,
+}
+
+var NotifyKindList = []string{
+	NotifyKindRun,
+	NotifyKindJob,
+	NotifyKindSchedule,
+}
+
+// Notifier 是用户配置的通知方式，PipelineID 为空时对该用户的所有 run/job/schedule 生效
+type Notifier struct {
+	Pk         int64          `gorm:"primaryKey;autoIncrement;not null" json:"-"`
+	ID         string         `gorm:"type:varchar(60);not null"         json:"notifierID"`
+	Name       string         `gorm:"type:varchar(60);not null"         json:"name"`
+	UserName   string         `gorm:"type:varchar(60);not null"         json:"username"`
+	PipelineID string         `gorm:"type:varchar(60);not null"         json:"pipelineID"`
+	Type       string         `gorm:"type:varchar(32);not null"         json:"type"`
+	KindsJson  string         `gorm:"column:kinds;type:varchar(256);not null" json:"-"`
+	Kinds      []string       `gorm:"-"                                 json:"kinds"`
+	ConfigJson string         `gorm:"column:config;type:text;size:65535;not null" json:"-"`
+	Config     NotifierConfig `gorm:"-"                                 json:"config"`
+	CreatedAt  time.Time      `                                         json:"-"`
+	UpdatedAt  time.Time      `                                         json:"-"`
+	DeletedAt  gorm.DeletedAt `                                         json:"-"`
+}
+
+func (Notifier) TableName() string {
+	return "notifier"
+}
+
+// NotifierConfig 存放不同类型通知方式的配置，密钥类字段在入库前加密
+type NotifierConfig struct {
+	// webhook
+	URL    string `json:"url,omitempty"`
+	Secret string `json:"secret,omitempty"`
+	// email
+	SMTPHost string   `json:"smtpHost,omitempty"`
+	SMTPPort int      `json:"smtpPort,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+	// file
+	Path string `json:"path,omitempty"`
+}
+
+func (n *Notifier) Encode() error {
+	kinds, err := json.Marshal(n.Kinds)
+	if err != nil {
+		return err
+	}
+	n.KindsJson = string(kinds)
+	config, err := json.Marshal(n.Config)
+	if err != nil {
+		return err
+	}
+	n.ConfigJson = string(config)
+	return nil
+}
+
+func (n *Notifier) Decode() error {
+	if n.KindsJson != "" {
+		if err := json.Unmarshal([]byte(n.KindsJson), &n.Kinds); err != nil {
+			return err
+		}
+	}
+	if n.ConfigJson != "" {
+		if err := json.Unmarshal([]byte(n.ConfigJson), &n.Config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe 判断通知方式是否关注该类对象
+func (n *Notifier) Subscribe(kind string) bool {
+	if len(n.Kinds) == 0 {
+		return true
+	}
+	return checkContains(kind, n.Kinds)
+}
+
+// NotificationDelivery 记录每次通知的投递结果，同一个对象的同一个状态只投递一次
+// 投递失败的记录保持 pending 状态，到 NextRetryAt 之后由后台重新投递，server 重启后同样会继续投递
+type NotificationDelivery struct {
+	Pk         int64  `gorm:"primaryKey;autoIncrement;not null" json:"-"`
+	NotifierID string `gorm:"type:varchar(60);not null;uniqueIndex:idx_delivery_object" json:"notifierID"`
+	Kind       string `gorm:"type:varchar(32);not null;uniqueIndex:idx_delivery_object" json:"kind"`
+	ObjectID   string `gorm:"type:varchar(60);not null;uniqueIndex:idx_delivery_object" json:"objectID"`
+	Status     string `gorm:"type:varchar(32);not null;uniqueIndex:idx_delivery_object" json:"status"`
+	Attempts   int    `gorm:"not null"                          json:"attempts"`
+	Result     string `gorm:"type:varchar(32);not null;index:idx_delivery_pending" json:"result"`
+	Message    string `gorm:"type:text;size:65535;not null"     json:"message"`
+	// Event 为投递的内容，用于重试
+	Event       string    `gorm:"type:text;size:65535"              json:"-"`
+	NextRetryAt time.Time `gorm:"index:idx_delivery_pending"        json:"-"`
+	CreatedAt   time.Time `                                         json:"createTime"`
+	UpdatedAt   time.Time `                                         json:"updateTime"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_delivery"
+}
+
+func CreateNotifier(logEntry *log.Entry, notifier *Notifier) error {
+	logEntry.Debugf("begin create notifier:%+v", notifier)
+	if err := notifier.Encode(); err != nil {
+		logEntry.Errorf("encode notifier failed. error:%v", err)
+		return err
+	}
+	return WithTransaction(storage.DB, func(tx *gorm.DB) error {
+		result := tx.Model(&Notifier{}).Create(notifier)
+		if result.Error != nil {
+			logEntry.Errorf("create notifier failed. notifier:%v, error:%s", notifier, result.Error.Error())
+			return result.Error
+		}
+		notifier.ID = common.PrefixNotifier + fmt.Sprintf("%06d", notifier.Pk)
+		logEntry.Debugf("created notifier with pk[%d], notifierID[%s]", notifier.Pk, notifier.ID)
+
+		// update ID
+		result = tx.Model(&Notifier{}).Where("pk = ?", notifier.Pk).Update("id", notifier.ID)
+		if result.Error != nil {
+			logEntry.Errorf("backfilling notifierID failed. pk[%d], error:%v", notifier.Pk, result.Error)
+			return result.Error
+		}
+		return nil
+	})
+}
+
+func GetNotifier(logEntry *log.Entry, notifierID string) (Notifier, error) {
+	logEntry.Debugf("begin to get notifier of ID[%s]", notifierID)
+	var notifier Notifier
+	result := storage.DB.Model(&Notifier{}).Where("id = ?", notifierID).First(&notifier)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			errMsg := fmt.Sprintf("notifier[%s] not found!", notifierID)
+			logEntry.Errorf(errMsg)
+			return Notifier{}, fmt.Errorf(errMsg)
+		}
+		return Notifier{}, result.Error
+	}
+	if err := notifier.Decode(); err != nil {
+		logEntry.Errorf("decode notifier[%s] failed. error:%v", notifierID, err)
+		return Notifier{}, err
+	}
+	return notifier, nil
+}
+
+// ListNotifier 列出用户的通知方式，userName 为空时列出所有用户的
+func ListNotifier(logEntry *log.Entry, userName string) ([]Notifier, error) {
+	logEntry.Debugf("begin list notifier of user[%s]", userName)
+	tx := storage.DB.Model(&Notifier{})
+	if userName != "" {
+		tx = tx.Where("user_name = ?", userName)
+	}
+	var notifiers []Notifier
+	if err := tx.Order("pk").Find(&notifiers).Error; err != nil {
+		logEntry.Errorf("list notifier failed. error:%s", err.Error())
+		return nil, err
+	}
+	for i := range notifiers {
+		if err := notifiers[i].Decode(); err != nil {
+			logEntry.Errorf("decode notifier[%s] failed. error:%v", notifiers[i].ID, err)
+			return nil, err
+		}
+	}
+	return notifiers, nil
+}
+
+// ListNotifierForObject 获取关注某个对象的通知方式，包括用户级别和 pipeline 级别的
+func ListNotifierForObject(logEntry *log.Entry, kind, userName, pipelineID string) ([]Notifier, error) {
+	tx := storage.DB.Model(&Notifier{}).Where("user_name = ?", userName)
+	if pipelineID != "" {
+		tx = tx.Where("pipeline_id IN (?)", []string{"", pipelineID})
+	} else {
+		tx = tx.Where("pipeline_id = ?", "")
+	}
+	var notifiers []Notifier
+	if err := tx.Order("pk").Find(&notifiers).Error; err != nil {
+		logEntry.Errorf("list notifier for %s of user[%s] failed. error:%s", kind, userName, err.Error())
+		return nil, err
+	}
+	res := make([]Notifier, 0, len(notifiers))
+	for _, notifier := range notifiers {
+		if err := notifier.Decode(); err != nil {
+			logEntry.Errorf("decode notifier[%s] failed. error:%v", notifier.ID, err)
+			continue
+		}
+		if notifier.Subscribe(kind) {
+			res = append(res, notifier)
+		}
+	}
+	return res, nil
+}
+
+func DeleteNotifier(logEntry *log.Entry, notifierID string) error {
+	logEntry.Debugf("begin delete notifier. notifierID:%s", notifierID)
+	return WithTransaction(storage.DB, func(tx *gorm.DB) error {
+		result := tx.Model(&Notifier{}).Where("id = ?", notifierID).Delete(&Notifier{})
+		if result.Error != nil {
+			logEntry.Errorf("delete notifier failed. notifierID:%s, error:%s", notifierID, result.Error.Error())
+			return result.Error
+		}
+		// 已经删除的通知方式不再重试投递
+		result = tx.Model(&NotificationDelivery{}).Where("notifier_id = ? AND result = ?", notifierID,
+			DeliveryStatusPending).Updates(map[string]interface{}{
+			"result":  DeliveryStatusFailed,
+			"message": "notifier is deleted",
+		})
+		if result.Error != nil {
+			logEntry.Errorf("fail pending deliveries of notifier[%s] failed. error:%s", notifierID, result.Error.Error())
+			return result.Error
+		}
+		return nil
+	})
+}
+
+// CreateDeliveryIfNotExist 创建投递记录，如果该通知方式已经投递过同一个对象的同一个状态，则返回 false
+// 由唯一索引保证并发创建时只有一个能成功
+func CreateDeliveryIfNotExist(logEntry *log.Entry, delivery *NotificationDelivery) (bool, error) {
+	result := storage.DB.Model(&NotificationDelivery{}).Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	if result.Error != nil {
+		logEntry.Errorf("create delivery of notifier[%s] for %s[%s] failed. error:%v",
+			delivery.NotifierID, delivery.Kind, delivery.ObjectID, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ClaimDelivery 占用一条等待投递的记录并增加投递次数，attempts 为读取记录时的投递次数，用于避免重复投递
+// 占用期间 NextRetryAt 被设置为 lease，如果在此之前没有更新结果，记录会被重新投递
+func ClaimDelivery(logEntry *log.Entry, pk int64, attempts int, lease time.Time) (bool, error) {
+	tx := storage.DB.Model(&NotificationDelivery{}).
+		Where("pk = ? AND result = ? AND attempts = ?", pk, DeliveryStatusPending, attempts).
+		Updates(map[string]interface{}{
+			"attempts":      gorm.Expr("attempts + 1"),
+			"next_retry_at": lease,
+		})
+	if tx.Error != nil {
+		logEntry.Errorf("claim delivery[%d] failed. error:%s", pk, tx.Error.Error())
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// UpdateDelivery 更新投递结果，result 为 pending 时在 nextRetryAt 之后重新投递
+func UpdateDelivery(logEntry *log.Entry, pk int64, result, message string, nextRetryAt time.Time) error {
+	tx := storage.DB.Model(&NotificationDelivery{}).Where("pk = ?", pk).Updates(map[string]interface{}{
+		"result":        result,
+		"message":       message,
+		"next_retry_at": nextRetryAt,
+	})
+	if tx.Error != nil {
+		logEntry.Errorf("update delivery[%d] failed. error:%s", pk, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// ListPendingDelivery 列出到了重试时间的等待投递的记录
+func ListPendingDelivery(logEntry *log.Entry, before time.Time, maxKeys int) ([]NotificationDelivery, error) {
+	tx := storage.DB.Model(&NotificationDelivery{}).Where("result = ?", DeliveryStatusPending).
+		Where("next_retry_at <= ?", before).Order("next_retry_at")
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var deliveries []NotificationDelivery
+	if err := tx.Find(&deliveries).Error; err != nil {
+		logEntry.Errorf("list pending delivery failed. error:%s", err.Error())
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ListDelivery 按创建时间倒序列出通知方式的投递记录
+func ListDelivery(logEntry *log.Entry, notifierID string, maxKeys int) ([]NotificationDelivery, error) {
+	tx := storage.DB.Model(&NotificationDelivery{}).Where("notifier_id = ?", notifierID).Order("pk desc")
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var deliveries []NotificationDelivery
+	if err := tx.Find(&deliveries).Error; err != nil {
+		logEntry.Errorf("list delivery of notifier[%s] failed. error:%s", notifierID, err.Error())
+		return nil, err
+	}
+	return deliveries, nil
+}
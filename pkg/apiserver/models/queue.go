@@ -37,6 +37,7 @@ const (
 	queueJoinCluster  = "join `cluster_info` on `cluster_info`.id = queue.cluster_id"
 	queueSelectColumn = `queue.pk as pk, queue.id as id, queue.name as name, queue.namespace as namespace, queue.cluster_id as cluster_id,
 cluster_info.name as cluster_name, queue.quota_type as quota_type, queue.max_resources as max_resources, queue.min_resources as min_resources, queue.location as location,
+queue.scheduling_policy as scheduling_policy, queue.scheduling_policy_args as scheduling_policy_args,
 queue.status as status, queue.created_at as created_at, queue.updated_at as updated_at, queue.deleted_at as deleted_at`
 )
 
@@ -55,10 +56,13 @@ type Queue struct {
 	RawLocation     string              `json:"-" gorm:"column:location;type:text;default:'{}'"`
 	Location        map[string]string   `json:"location" gorm:"-"`
 	// 任务调度策略
-	RawSchedulingPolicy string         `json:"-" gorm:"column:scheduling_policy"`
-	SchedulingPolicy    []string       `json:"schedulingPolicy,omitempty" gorm:"-"`
-	Status              string         `json:"status"`
-	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+	RawSchedulingPolicy string   `json:"-" gorm:"column:scheduling_policy"`
+	SchedulingPolicy    []string `json:"schedulingPolicy,omitempty" gorm:"-"`
+	// 任务调度策略参数, key为调度策略名称
+	RawSchedulingPolicyArgs string                       `json:"-" gorm:"column:scheduling_policy_args;type:text;default:'{}'"`
+	SchedulingPolicyArgs    map[string]map[string]string `json:"schedulingPolicyArgs,omitempty" gorm:"-"`
+	Status                  string                       `json:"status"`
+	DeletedAt               gorm.DeletedAt               `json:"-" gorm:"index"`
 
 	UsedResources *resources.Resource `json:"usedResources,omitempty" gorm:"-"`
 	IdleResources *resources.Resource `json:"idleResources,omitempty" gorm:"-"`
@@ -113,6 +117,13 @@ func (queue *Queue) AfterFind(*gorm.DB) error {
 			return err
 		}
 	}
+	if queue.RawSchedulingPolicyArgs != "" {
+		queue.SchedulingPolicyArgs = make(map[string]map[string]string)
+		if err := json.Unmarshal([]byte(queue.RawSchedulingPolicyArgs), &queue.SchedulingPolicyArgs); err != nil {
+			log.Errorf("json Unmarshal SchedulingPolicyArgs[%s] failed: %v", queue.RawSchedulingPolicyArgs, err)
+			return err
+		}
+	}
 	if queue.ClusterName == "" {
 		// only single query is necessary, function of list query by join table cluster_info
 		log.Debugf("queue[%s] ClusterName is nil, query db to get cluster", queue.Name)
@@ -166,6 +177,15 @@ func (queue *Queue) BeforeSave(*gorm.DB) error {
 		}
 		queue.RawSchedulingPolicy = string(schedulingPolicyJson)
 	}
+
+	if queue.SchedulingPolicyArgs != nil {
+		schedulingPolicyArgsJson, err := json.Marshal(queue.SchedulingPolicyArgs)
+		if err != nil {
+			log.Errorf("json Marshal schedulingPolicyArgs[%v] failed: %v", queue.SchedulingPolicyArgs, err)
+			return err
+		}
+		queue.RawSchedulingPolicyArgs = string(schedulingPolicyArgsJson)
+	}
 	log.Debugf("queue[%s] BeforeSave finished, queue:%#v", queue.Name, queue)
 
 	return nil
@@ -396,4 +416,5 @@ func DeepCopyQueue(queueSrc Queue, queueDesc *Queue) {
 	queueDesc.RawMaxResources = queueSrc.RawMaxResources
 	queueDesc.RawLocation = queueSrc.RawLocation
 	queueDesc.RawSchedulingPolicy = queueSrc.RawSchedulingPolicy
+	queueDesc.RawSchedulingPolicyArgs = queueSrc.RawSchedulingPolicyArgs
 }
@@ -58,6 +58,7 @@ type Run struct {
 	RunOptionsJson string                 `gorm:"type:text;size:65535;not null"     json:"-"`
 	RunCachedIDs   string                 `gorm:"type:text;size:65535;not null"     json:"runCachedIDs"`
 	ScheduledAt    sql.NullTime           `                                         json:"-"`
+	Trigger        string                 `gorm:"-"                                 json:"trigger,omitempty"` // 周期调度发起的 run 的触发原因
 	CreateTime     string                 `gorm:"-"                                 json:"createTime"`
 	ActivateTime   string                 `gorm:"-"                                 json:"activateTime"`
 	UpdateTime     string                 `gorm:"-"                                 json:"updateTime,omitempty"`
@@ -83,6 +84,18 @@ func (r *Run) GetRunCacheIDList() []string {
 	return res
 }
 
+// GetPipelineID 通过 pipeline 发起的 run，其 source 为 {pipelineID}-{pipelineVersionID}，其他 run 返回空字符串
+func (r *Run) GetPipelineID() string {
+	if !strings.HasPrefix(r.Source, common.PrefixPipeline) {
+		return ""
+	}
+	index := strings.LastIndex(r.Source, "-")
+	if index <= len(common.PrefixPipeline) {
+		return ""
+	}
+	return r.Source[:index]
+}
+
 func (r *Run) Encode() error {
 	// encode param
 	if r.Parameters != nil {
@@ -135,6 +148,7 @@ func (r *Run) decode() error {
 		return err
 	}
 	r.RunOptions = runOptions
+	r.Trigger = runOptions.Trigger
 
 	r.FsOptions.MainFS = r.WorkflowSource.FsOptions.MainFS
 
@@ -493,3 +507,24 @@ func ListRunsByStatus(logEntry *log.Entry, statusList []string) ([]Run, error) {
 	}
 	return runList, nil
 }
+
+// 获取 pipeline 的在 (updatedAfter, updatedBefore] 之间结束的 run，用于事件触发的周期调度
+func ListFinishedRunsOfPipeline(logEntry *log.Entry, pipelineID string, statusList []string,
+	updatedAfter, updatedBefore time.Time) ([]Run, error) {
+	logEntry.Debugf("begin list runs of pipeline[%s] with status[%v] updated in (%s, %s]", pipelineID, statusList,
+		updatedAfter, updatedBefore)
+	runList := make([]Run, 0)
+	// 通过 pipeline 发起的 run，其 source 为 {pipelineID}-{pipelineVersionID}
+	tx := storage.DB.Model(&Run{}).Where("source LIKE ?", pipelineID+"-%").Where("status IN (?)", statusList).
+		Where("updated_at > ?", updatedAfter).Where("updated_at <= ?", updatedBefore).Order("updated_at").Find(&runList)
+	if tx.Error != nil {
+		logEntry.Errorf("list runs of pipeline[%s] failed. error:%s", pipelineID, tx.Error.Error())
+		return runList, tx.Error
+	}
+	for i := range runList {
+		if err := runList[i].decode(); err != nil {
+			return nil, err
+		}
+	}
+	return runList, nil
+}
@@ -30,36 +30,39 @@ import (
 )
 
 type RunJob struct {
-	Pk             int64             `gorm:"primaryKey;autoIncrement;not null"  json:"-"`
-	ID             string            `gorm:"type:varchar(60);not null"          json:"jobID"`
-	RunID          string            `gorm:"type:varchar(60);not null"          json:"runID"`
-	ParentDagID    string            `gorm:"type:varchar(60);not null"          json:"parentDagID"`
-	Name           string            `gorm:"type:varchar(60);not null"          json:"name"`
-	StepName       string            `gorm:"type:varchar(60);not null"          json:"step_name"`
-	Command        string            `gorm:"type:text;size:65535;not null"      json:"command"`
-	Parameters     map[string]string `gorm:"-"                                  json:"parameters"`
-	ParametersJson string            `gorm:"type:text;size:65535;not null"      json:"-"`
-	Artifacts      schema.Artifacts  `gorm:"-"                                  json:"artifacts"`
-	ArtifactsJson  string            `gorm:"type:text;size:65535;not null"      json:"-"`
-	Env            map[string]string `gorm:"-"                                  json:"env"`
-	EnvJson        string            `gorm:"type:text;size:65535;not null"      json:"-"`
-	DockerEnv      string            `gorm:"type:varchar(128);not null"         json:"docker_env"`
-	LoopSeq        int               `gorm:"type:int;not null"                  json:"-"`
-	Status         schema.JobStatus  `gorm:"type:varchar(32);not null"          json:"status"`
-	Message        string            `gorm:"type:text;size:65535;not null"      json:"message"`
-	Cache          schema.Cache      `gorm:"-"                                  json:"cache"`
-	CacheJson      string            `gorm:"type:text;size:65535;not null"      json:"-"`
-	CacheRunID     string            `gorm:"type:varchar(60);not null"          json:"cacheRunID"`
-	CacheJobID     string            `gorm:"type:varchar(60);not null"          json:"cacheJobID"`
-	ExtraFS        []schema.FsMount  `gorm:"-"                                  json:"extraFs"`
-	ExtraFSJson    string            `gorm:"type:text;size:65535;not null"      json:"-"`
-	CreateTime     string            `gorm:"-"                                  json:"createTime"`
-	ActivateTime   string            `gorm:"-"                                  json:"activateTime"`
-	UpdateTime     string            `gorm:"-"                                  json:"updateTime,omitempty"`
-	CreatedAt      time.Time         `                                          json:"-"`
-	ActivatedAt    sql.NullTime      `                                          json:"-"`
-	UpdatedAt      time.Time         `                                          json:"-"`
-	DeletedAt      gorm.DeletedAt    `gorm:"index"                              json:"-"`
+	Pk             int64               `gorm:"primaryKey;autoIncrement;not null"  json:"-"`
+	ID             string              `gorm:"type:varchar(60);not null"          json:"jobID"`
+	RunID          string              `gorm:"type:varchar(60);not null"          json:"runID"`
+	ParentDagID    string              `gorm:"type:varchar(60);not null"          json:"parentDagID"`
+	Name           string              `gorm:"type:varchar(60);not null"          json:"name"`
+	StepName       string              `gorm:"type:varchar(60);not null"          json:"step_name"`
+	Command        string              `gorm:"type:text;size:65535;not null"      json:"command"`
+	Parameters     map[string]string   `gorm:"-"                                  json:"parameters"`
+	ParametersJson string              `gorm:"type:text;size:65535;not null"      json:"-"`
+	Artifacts      schema.Artifacts    `gorm:"-"                                  json:"artifacts"`
+	ArtifactsJson  string              `gorm:"type:text;size:65535;not null"      json:"-"`
+	Env            map[string]string   `gorm:"-"                                  json:"env"`
+	EnvJson        string              `gorm:"type:text;size:65535;not null"      json:"-"`
+	DockerEnv      string              `gorm:"type:varchar(128);not null"         json:"docker_env"`
+	LoopSeq        int                 `gorm:"type:int;not null"                  json:"-"`
+	Status         schema.JobStatus    `gorm:"type:varchar(32);not null"          json:"status"`
+	Message        string              `gorm:"type:text;size:65535;not null"      json:"message"`
+	Cache          schema.Cache        `gorm:"-"                                  json:"cache"`
+	CacheJson      string              `gorm:"type:text;size:65535;not null"      json:"-"`
+	CacheRunID     string              `gorm:"type:varchar(60);not null"          json:"cacheRunID"`
+	CacheJobID     string              `gorm:"type:varchar(60);not null"          json:"cacheJobID"`
+	ExtraFS        []schema.FsMount    `gorm:"-"                                  json:"extraFs"`
+	ExtraFSJson    string              `gorm:"type:text;size:65535;not null"      json:"-"`
+	Attempt        int                 `gorm:"type:int;not null;default:1"        json:"attempt"`
+	Attempts       []schema.JobAttempt `gorm:"-"                                json:"attempts,omitempty"`
+	AttemptsJson   string              `gorm:"type:text;size:65535"               json:"-"`
+	CreateTime     string              `gorm:"-"                                  json:"createTime"`
+	ActivateTime   string              `gorm:"-"                                  json:"activateTime"`
+	UpdateTime     string              `gorm:"-"                                  json:"updateTime,omitempty"`
+	CreatedAt      time.Time           `                                          json:"-"`
+	ActivatedAt    sql.NullTime        `                                          json:"-"`
+	UpdatedAt      time.Time           `                                          json:"-"`
+	DeletedAt      gorm.DeletedAt      `gorm:"index"                              json:"-"`
 }
 
 func CreateRunJob(logEntry *log.Entry, runJob *RunJob) (int64, error) {
@@ -155,6 +158,15 @@ func (rj *RunJob) Encode() error {
 	}
 	rj.ExtraFSJson = string(fsMountJson)
 
+	if len(rj.Attempts) > 0 {
+		attemptsJson, err := json.Marshal(rj.Attempts)
+		if err != nil {
+			logger.Logger().Errorf("encode run job attempts failed. error: %v", err)
+			return err
+		}
+		rj.AttemptsJson = string(attemptsJson)
+	}
+
 	if rj.ActivateTime != "" {
 		activatedAt := sql.NullTime{}
 		activatedAt.Time, err = time.ParseInLocation("2006-01-02 15:04:05", rj.ActivateTime, time.Local)
@@ -210,6 +222,14 @@ func (rj *RunJob) decode() error {
 		rj.ExtraFS = fsMount
 	}
 
+	if len(rj.AttemptsJson) > 0 {
+		attempts := []schema.JobAttempt{}
+		if err := json.Unmarshal([]byte(rj.AttemptsJson), &attempts); err != nil {
+			logger.Logger().Errorf("decode run job attempts failed. error: %v", err)
+		}
+		rj.Attempts = attempts
+	}
+
 	// format time
 	rj.CreateTime = rj.CreatedAt.Format("2006-01-02 15:04:05")
 	rj.UpdateTime = rj.UpdatedAt.Format("2006-01-02 15:04:05")
@@ -258,6 +278,8 @@ func (rj *RunJob) Trans2JobView() schema.JobView {
 		CacheRunID:  rj.CacheRunID,
 		CacheJobID:  rj.CacheJobID,
 		ExtraFS:     newFsMount,
+		Attempt:     rj.Attempt,
+		Attempts:    append([]schema.JobAttempt{}, rj.Attempts...),
 	}
 }
 
@@ -297,5 +319,7 @@ func ParseRunJob(jobView *schema.JobView) RunJob {
 		CacheJobID:   jobView.CacheJobID,
 		ActivateTime: jobView.StartTime,
 		ExtraFS:      newFsMount,
+		Attempt:      jobView.Attempt,
+		Attempts:     append([]schema.JobAttempt{}, jobView.Attempts...),
 	}
 }
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -40,6 +41,13 @@ const (
 	ScheduleStatusRunning    = "running"
 	ScheduleStatusFailed     = "failed"
 	ScheduleStatusTerminated = "terminated"
+
+	ScheduleTriggerCron          = "cron"
+	ScheduleTriggerFileArrival   = "fileArrival"
+	ScheduleTriggerRunCompletion = "runCompletion"
+
+	DefaultTriggerPollInterval = 60 // seconds
+	MinTriggerPollInterval     = 10 // seconds
 )
 
 var ConcurrencyPolicyList = []string{
@@ -48,6 +56,12 @@ var ConcurrencyPolicyList = []string{
 	ConcurrencyPolicySkip,
 }
 
+var ScheduleTriggerTypeList = []string{
+	ScheduleTriggerCron,
+	ScheduleTriggerFileArrival,
+	ScheduleTriggerRunCompletion,
+}
+
 var ScheduleStatusList = []string{
 	ScheduleStatusSuccess,
 	ScheduleStatusRunning,
@@ -75,6 +89,7 @@ type Schedule struct {
 	UserName          string         `gorm:"type:varchar(60);not null"         json:"username"`
 	FsConfig          string         `gorm:"type:varchar(1024);not null"       json:"fsConfig"`
 	Crontab           string         `gorm:"type:varchar(60);not null"         json:"crontab"`
+	TriggerConfig     string         `gorm:"type:mediumtext"                   json:"triggerConfig"`
 	Options           string         `gorm:"type:text;size:65535;not null"     json:"options"`
 	Message           string         `gorm:"type:text;size:65535;not null"     json:"scheduleMsg"`
 	Status            string         `gorm:"type:varchar(32);not null"         json:"status"`
@@ -186,6 +201,135 @@ func (so *ScheduleOptions) Encode(logEntry *log.Entry) (string, error) {
 	return string(strOptions), nil
 }
 
+// 周期调度的触发方式，默认为 cron，即根据 crontab 定时发起任务
+// 事件触发的周期调度，每隔 PollInterval 秒检查一次 StartAt 之后发生的事件，每个事件发起一次任务
+type ScheduleTrigger struct {
+	Type         string `json:"type"`
+	PollInterval int    `json:"pollInterval,omitempty"`
+
+	// fileArrival: fs 上出现了新的（或者被修改的）与 Pattern 匹配的文件
+	FsName  string `json:"fsName,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+
+	// runCompletion: 其他 pipeline 的 run 以 Status 中的状态结束
+	PipelineID string   `json:"pipelineID,omitempty"`
+	Status     []string `json:"status,omitempty"`
+
+	// 只处理 StartAt 之后发生的事件
+	StartAt time.Time `json:"startAt"`
+
+	// 上一次检查事件的时间
+	CheckedAt time.Time `json:"checkedAt"`
+
+	// fileArrival: 已经触发过 run 的文件，以及上一次检查时发现、但还没有触发 run 的文件
+	// 文件在连续两次检查中大小和 mtime 均没有变化时，才会触发 run，避免文件还在写入时就触发
+	SeenFiles    map[string]TriggerFileState `json:"seenFiles,omitempty"`
+	PendingFiles map[string]TriggerFileState `json:"pendingFiles,omitempty"`
+
+	// runCompletion: 已经触发过 run 的 run ID 及其结束时间，同一个 run 只会触发一次
+	HandledRuns map[string]time.Time `json:"handledRuns,omitempty"`
+}
+
+// fs 上文件的状态，大小或者 mtime 发生变化时，视为文件被修改
+type TriggerFileState struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+func (fs TriggerFileState) Equal(other TriggerFileState) bool {
+	return fs.Size == other.Size && fs.ModTime.Equal(other.ModTime)
+}
+
+func NewScheduleTrigger(logEntry *log.Entry, trigger ScheduleTrigger) (ScheduleTrigger, error) {
+	if trigger.Type == "" {
+		trigger.Type = ScheduleTriggerCron
+	}
+
+	if !checkContains(trigger.Type, ScheduleTriggerTypeList) {
+		errMsg := fmt.Sprintf("trigger type[%s] not supported", trigger.Type)
+		logEntry.Errorf(errMsg)
+		return ScheduleTrigger{}, fmt.Errorf(errMsg)
+	}
+
+	if trigger.Type == ScheduleTriggerCron {
+		return ScheduleTrigger{Type: ScheduleTriggerCron}, nil
+	}
+	trigger.ClearState()
+
+	// 校验 poll interval
+	if trigger.PollInterval == 0 {
+		trigger.PollInterval = DefaultTriggerPollInterval
+	}
+	if trigger.PollInterval < MinTriggerPollInterval {
+		errMsg := fmt.Sprintf("poll interval of trigger should not be less than %d", MinTriggerPollInterval)
+		logEntry.Errorf(errMsg)
+		return ScheduleTrigger{}, fmt.Errorf(errMsg)
+	}
+
+	var errMsg string
+	switch trigger.Type {
+	case ScheduleTriggerFileArrival:
+		if trigger.FsName == "" || trigger.Pattern == "" {
+			errMsg = fmt.Sprintf("fsName and pattern should be set for trigger[%s]", trigger.Type)
+		} else if _, err := filepath.Match(trigger.Pattern, ""); err != nil {
+			errMsg = fmt.Sprintf("pattern[%s] of trigger is invalid: %v", trigger.Pattern, err)
+		}
+		trigger.PipelineID, trigger.Status = "", nil
+	case ScheduleTriggerRunCompletion:
+		if trigger.PipelineID == "" {
+			errMsg = fmt.Sprintf("pipelineID should be set for trigger[%s]", trigger.Type)
+		}
+		if len(trigger.Status) == 0 {
+			trigger.Status = []string{common.StatusRunSucceeded}
+		}
+		for _, status := range trigger.Status {
+			if !checkContains(status, common.RunFinalStatus) {
+				errMsg = fmt.Sprintf("status[%s] of trigger should be one of %v", status, common.RunFinalStatus)
+			}
+		}
+		trigger.FsName, trigger.Pattern = "", ""
+	}
+	if errMsg != "" {
+		logEntry.Errorf(errMsg)
+		return ScheduleTrigger{}, fmt.Errorf(errMsg)
+	}
+
+	return trigger, nil
+}
+
+// 历史数据中 TriggerConfig 为空，此时为 cron 类型的周期调度
+func DecodeScheduleTrigger(strTrigger string) (st ScheduleTrigger, err error) {
+	if strTrigger == "" {
+		return ScheduleTrigger{Type: ScheduleTriggerCron}, nil
+	}
+
+	if err := json.Unmarshal([]byte(strTrigger), &st); err != nil {
+		errMsg := fmt.Sprintf("decode scheduleTrigger[%s] failed. error:%v", strTrigger, err)
+		return ScheduleTrigger{}, fmt.Errorf(errMsg)
+	}
+
+	return st, nil
+}
+
+func (st *ScheduleTrigger) Encode(logEntry *log.Entry) (string, error) {
+	strTrigger, err := json.Marshal(st)
+	if err != nil {
+		logEntry.Errorf("encode scheduleTrigger failed. error:%v", err)
+		return "", err
+	}
+
+	return string(strTrigger), nil
+}
+
+// ClearState 清除检查事件时记录的状态，这些状态只在周期调度内部使用
+func (st *ScheduleTrigger) ClearState() {
+	st.SeenFiles, st.PendingFiles, st.HandledRuns = nil, nil, nil
+}
+
+func (st *ScheduleTrigger) IsEventTrigger() bool {
+	return st.Type == ScheduleTriggerFileArrival || st.Type == ScheduleTriggerRunCompletion
+}
+
 func CreateSchedule(logEntry *log.Entry, schedule Schedule) (scheduleID string, err error) {
 	logEntry.Debugf("begin create schedule:%+v", schedule)
 	err = WithTransaction(storage.DB, func(tx *gorm.DB) error {
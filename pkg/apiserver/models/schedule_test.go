@@ -201,3 +201,42 @@ func TestGetUsedFsIDs(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 5, len(fsIDMap))
 }
+
+func TestNewScheduleTrigger(t *testing.T) {
+	logEntry := log.WithFields(log.Fields{})
+
+	// 默认为 cron
+	trigger, err := NewScheduleTrigger(logEntry, ScheduleTrigger{})
+	assert.Nil(t, err)
+	assert.Equal(t, ScheduleTriggerCron, trigger.Type)
+	assert.False(t, trigger.IsEventTrigger())
+
+	trigger, err = NewScheduleTrigger(logEntry, ScheduleTrigger{Type: ScheduleTriggerFileArrival, FsName: "fs", Pattern: "data/*.csv"})
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultTriggerPollInterval, trigger.PollInterval)
+	assert.True(t, trigger.IsEventTrigger())
+
+	_, err = NewScheduleTrigger(logEntry, ScheduleTrigger{Type: ScheduleTriggerFileArrival, FsName: "fs", Pattern: "data/[*.csv"})
+	assert.NotNil(t, err)
+
+	_, err = NewScheduleTrigger(logEntry, ScheduleTrigger{Type: ScheduleTriggerFileArrival, Pattern: "data/*.csv"})
+	assert.Equal(t, "fsName and pattern should be set for trigger[fileArrival]", err.Error())
+
+	_, err = NewScheduleTrigger(logEntry, ScheduleTrigger{Type: ScheduleTriggerFileArrival, FsName: "fs", Pattern: "*", PollInterval: 1})
+	assert.Equal(t, "poll interval of trigger should not be less than 10", err.Error())
+
+	trigger, err = NewScheduleTrigger(logEntry, ScheduleTrigger{Type: ScheduleTriggerRunCompletion, PipelineID: "ppl-000001"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"succeeded"}, trigger.Status)
+
+	_, err = NewScheduleTrigger(logEntry, ScheduleTrigger{Type: ScheduleTriggerRunCompletion, PipelineID: "ppl-000001", Status: []string{"running"}})
+	assert.NotNil(t, err)
+
+	_, err = NewScheduleTrigger(logEntry, ScheduleTrigger{Type: "webhook"})
+	assert.Equal(t, "trigger type[webhook] not supported", err.Error())
+
+	// 历史数据中没有 trigger 配置
+	trigger, err = DecodeScheduleTrigger("")
+	assert.Nil(t, err)
+	assert.Equal(t, ScheduleTriggerCron, trigger.Type)
+}
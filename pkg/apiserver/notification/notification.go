@@ -0,0 +1,238 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+const (
+	DefaultMaxAttempts          = 3
+	DefaultRetryIntervalSeconds = 10
+	DefaultTimeoutSeconds       = 10
+
+	// pendingDeliveryBatch 为每次重试的最大记录数
+	pendingDeliveryBatch = 100
+)
+
+// Event 是 run/job/schedule 到达终态时发送给通知方式的内容
+type Event struct {
+	Kind       string    `json:"kind"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	UserName   string    `json:"username"`
+	PipelineID string    `json:"pipelineID,omitempty"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+func (e *Event) String() string {
+	s := fmt.Sprintf("%s[%s] of user[%s] reached status[%s] at %s", e.Kind, e.ID, e.UserName, e.Status,
+		e.Time.Format("2006-01-02 15:04:05"))
+	if e.Name != "" {
+		s += fmt.Sprintf("\nname: %s", e.Name)
+	}
+	if e.PipelineID != "" {
+		s += fmt.Sprintf("\npipeline: %s", e.PipelineID)
+	}
+	if e.Message != "" {
+		s += fmt.Sprintf("\nmessage: %s", e.Message)
+	}
+	return s
+}
+
+// newSenderFunc 方便单测替换
+var newSenderFunc = NewSender
+
+// Notify 查找关注该事件的通知方式，并在后台投递，投递失败不影响调用方
+func Notify(event Event) {
+	logEntry := logger.Logger()
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	notifiers, err := models.ListNotifierForObject(logEntry, event.Kind, event.UserName, event.PipelineID)
+	if err != nil {
+		logEntry.Errorf("list notifiers for %s[%s] failed. error: %v", event.Kind, event.ID, err)
+		return
+	}
+	for i := range notifiers {
+		go deliver(logEntry, notifiers[i], event)
+	}
+}
+
+// JobListener 在 job 到达终态时被调用，例如释放 job 占用的队列配额
+type JobListener func(job *models.Job, status schema.JobStatus)
+
+var (
+	jobListenersLock sync.RWMutex
+	jobListeners     []JobListener
+)
+
+// AddJobListener 注册 job 终态事件的监听函数
+func AddJobListener(listener JobListener) {
+	jobListenersLock.Lock()
+	defer jobListenersLock.Unlock()
+	jobListeners = append(jobListeners, listener)
+}
+
+// NotifyJob 在 job 到达终态时通知监听函数，以及 job 所属用户配置的通知方式
+func NotifyJob(jobID string, status schema.JobStatus) {
+	if !schema.IsImmutableJobStatus(status) {
+		return
+	}
+	job, err := models.GetJobByID(jobID)
+	if err != nil {
+		log.Errorf("get job[%s] for notification failed. error: %v", jobID, err)
+		return
+	}
+	jobListenersLock.RLock()
+	for _, listener := range jobListeners {
+		listener(&job, status)
+	}
+	jobListenersLock.RUnlock()
+	Notify(Event{
+		Kind:     models.NotifyKindJob,
+		ID:       job.ID,
+		Name:     job.Name,
+		UserName: job.UserName,
+		Status:   string(status),
+		Message:  job.Message,
+	})
+}
+
+// deliver 创建投递记录并立即投递一次，同一个通知方式对同一个对象的同一个状态只投递一次
+func deliver(logEntry *log.Entry, notifier models.Notifier, event Event) {
+	content, err := json.Marshal(event)
+	if err != nil {
+		logEntry.Errorf("marshal %s[%s] for notifier[%s] failed. error: %v", event.Kind, event.ID, notifier.ID, err)
+		return
+	}
+	delivery := &models.NotificationDelivery{
+		NotifierID:  notifier.ID,
+		Kind:        event.Kind,
+		ObjectID:    event.ID,
+		Status:      event.Status,
+		Result:      models.DeliveryStatusPending,
+		Event:       string(content),
+		NextRetryAt: time.Now(),
+	}
+	created, err := models.CreateDeliveryIfNotExist(logEntry, delivery)
+	if err != nil || !created {
+		return
+	}
+	attemptDelivery(logEntry, &notifier, delivery)
+}
+
+// attemptDelivery 占用投递记录后投递一次，失败后记录下次重试的时间，由 RetryPendingDeliveries 重试，
+// 超过最大投递次数后置为失败
+func attemptDelivery(logEntry *log.Entry, notifier *models.Notifier, delivery *models.NotificationDelivery) {
+	maxAttempts, retryInterval, timeout := deliveryOptions()
+	// 投递超时之后没有更新结果的记录，说明 server 在投递过程中退出了，需要重新投递
+	claimed, err := models.ClaimDelivery(logEntry, delivery.Pk, delivery.Attempts, time.Now().Add(2*timeout))
+	if err != nil || !claimed {
+		return
+	}
+	delivery.Attempts++
+
+	event := Event{}
+	sender, err := newSenderFunc(notifier)
+	if err == nil {
+		err = json.Unmarshal([]byte(delivery.Event), &event)
+	}
+	if err != nil {
+		// 配置或者内容有误，重试也无法成功
+		logEntry.Errorf("deliver %s[%s] to notifier[%s] failed. error: %v", delivery.Kind, delivery.ObjectID,
+			notifier.ID, err)
+		models.UpdateDelivery(logEntry, delivery.Pk, models.DeliveryStatusFailed, err.Error(), time.Now())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	err = sender.Send(ctx, &event, delivery.Pk)
+	cancel()
+	switch {
+	case err == nil:
+		models.UpdateDelivery(logEntry, delivery.Pk, models.DeliveryStatusSucceeded, "", time.Now())
+	case delivery.Attempts >= maxAttempts:
+		logEntry.Errorf("deliver %s[%s] to notifier[%s] failed after %d attempts. error: %v",
+			event.Kind, event.ID, notifier.ID, delivery.Attempts, err)
+		models.UpdateDelivery(logEntry, delivery.Pk, models.DeliveryStatusFailed, err.Error(), time.Now())
+	default:
+		nextRetryAt := time.Now().Add(retryInterval * time.Duration(delivery.Attempts))
+		logEntry.Warningf("deliver %s[%s] to notifier[%s] failed at attempt %d, retry at %s. error: %v",
+			event.Kind, event.ID, notifier.ID, delivery.Attempts, nextRetryAt.Format("2006-01-02 15:04:05"), err)
+		models.UpdateDelivery(logEntry, delivery.Pk, models.DeliveryStatusPending, err.Error(), nextRetryAt)
+	}
+}
+
+// RetryPendingDeliveries 定期重新投递到了重试时间的记录，包括 server 重启之前没有投递完成的记录
+func RetryPendingDeliveries(stopCh <-chan struct{}) {
+	_, retryInterval, _ := deliveryOptions()
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			retryPendingDeliveries()
+		}
+	}
+}
+
+func retryPendingDeliveries() {
+	logEntry := logger.Logger()
+	deliveries, err := models.ListPendingDelivery(logEntry, time.Now(), pendingDeliveryBatch)
+	if err != nil {
+		return
+	}
+	for i := range deliveries {
+		notifier, err := models.GetNotifier(logEntry, deliveries[i].NotifierID)
+		if err != nil {
+			logEntry.Errorf("get notifier[%s] of delivery[%d] failed. error: %v", deliveries[i].NotifierID,
+				deliveries[i].Pk, err)
+			continue
+		}
+		go attemptDelivery(logEntry, &notifier, &deliveries[i])
+	}
+}
+
+func deliveryOptions() (maxAttempts int, retryInterval, timeout time.Duration) {
+	maxAttempts, retrySeconds, timeoutSeconds := DefaultMaxAttempts, DefaultRetryIntervalSeconds, DefaultTimeoutSeconds
+	conf := notificationConfig()
+	if conf.MaxAttempts > 0 {
+		maxAttempts = conf.MaxAttempts
+	}
+	if conf.RetryIntervalSeconds > 0 {
+		retrySeconds = conf.RetryIntervalSeconds
+	}
+	if conf.TimeoutSeconds > 0 {
+		timeoutSeconds = conf.TimeoutSeconds
+	}
+	return maxAttempts, time.Duration(retrySeconds) * time.Second, time.Duration(timeoutSeconds) * time.Second
+}
@@ -0,0 +1,350 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"errors"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func waitDelivery(t *testing.T, notifierID string, count int) []models.NotificationDelivery {
+	var deliveries []models.NotificationDelivery
+	for i := 0; i < 50; i++ {
+		var err error
+		deliveries, err = models.ListDelivery(logger.Logger(), notifierID, 0)
+		assert.Nil(t, err)
+		finished := 0
+		for _, d := range deliveries {
+			if d.Result != models.DeliveryStatusPending {
+				finished++
+			}
+		}
+		if finished >= count {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return deliveries
+}
+
+func TestValidateConfig(t *testing.T) {
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeWebhook, models.NotifierConfig{URL: "https://example.com/hook"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeWebhook, models.NotifierConfig{URL: "example.com/hook"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeEmail, models.NotifierConfig{SMTPHost: "smtp.example.com"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeEmail, models.NotifierConfig{SMTPHost: "smtp.example.com", SMTPPort: 25}))
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeEmail, models.NotifierConfig{SMTPHost: "smtp.example.com", SMTPPort: 25,
+		From: "pf@example.com", To: []string{"user1@example.com"}}))
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeFile, models.NotifierConfig{}))
+	assert.NotNil(t, ValidateConfig("root", models.NotifierTypeFile, models.NotifierConfig{Path: "relative/path"}))
+	assert.NotNil(t, ValidateConfig("user1", "slack", models.NotifierConfig{}))
+}
+
+func TestValidateConfig_webhookHost(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	// 本机、链路本地、私有网络以及集群内部的地址默认不允许
+	for _, u := range []string{"http://127.0.0.1:8080/hook", "http://[::1]/hook", "http://169.254.169.254/latest",
+		"http://10.0.0.1/hook", "http://192.168.1.1/hook", "http://localhost/hook", "http://svc.ns.svc/hook",
+		"http://svc.ns.svc.cluster.local./hook", "http://metadata.google.internal/hook"} {
+		assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeWebhook, models.NotifierConfig{URL: u}), u)
+	}
+
+	config.GlobalServerConfig.Notify.WebhookAllowList = []string{"10.1.0.0/16", "hook.ns.svc"}
+	config.GlobalServerConfig.Notify.WebhookDenyList = []string{"evil.example.com", "1.2.3.0/24"}
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeWebhook, models.NotifierConfig{URL: "http://10.1.2.3/hook"}))
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeWebhook, models.NotifierConfig{URL: "http://hook.ns.svc/hook"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeWebhook, models.NotifierConfig{URL: "http://evil.example.com/hook"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeWebhook, models.NotifierConfig{URL: "http://1.2.3.4/hook"}))
+
+	// 发送时校验域名解析后的地址
+	assert.NotNil(t, webhookDialControl("tcp", "127.0.0.1:80", nil))
+	assert.Nil(t, webhookDialControl("tcp", "10.1.0.1:80", nil))
+}
+
+func TestValidateConfig_email(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	conf := models.NotifierConfig{SMTPHost: "smtp.example.com", SMTPPort: 25, From: "pf@example.com",
+		To: []string{"user1@example.com"}}
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeEmail, conf))
+
+	// smtp 服务与 webhook 一样，不能指向内部地址
+	for _, host := range []string{"127.0.0.1", "10.0.0.1", "localhost", "smtp.ns.svc"} {
+		conf.SMTPHost = host
+		assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeEmail, conf), host)
+	}
+	config.GlobalServerConfig.Notify.WebhookAllowList = []string{"smtp.ns.svc"}
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeEmail, conf))
+
+	// 地址中不能包含换行符，避免注入邮件头
+	conf.From = "pf@example.com\r\nBcc: evil@example.com"
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeEmail, conf))
+	conf.From = "pf@example.com"
+	conf.To = []string{"user1@example.com\nBcc: evil@example.com"}
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeEmail, conf))
+}
+
+func TestEmailSend(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	// smtp 服务只接受连接，不返回任何数据
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, _, err := net.SplitHostPort(listener.Addr().String())
+	assert.Nil(t, err)
+	sender := &emailSender{addr: listener.Addr().String(), host: host, from: "pf@example.com",
+		to: []string{"user1@example.com"}}
+	event := &Event{Kind: "run", ID: "run-000001", Status: "succeeded"}
+
+	// 连接的地址为内部地址时，拒绝发送
+	err = sender.Send(context.Background(), event, 1)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "internal address")
+
+	// 超时后，通过连接的 deadline 结束发送
+	config.GlobalServerConfig.Notify.WebhookAllowList = []string{host}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = sender.Send(ctx, event, 1)
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 2*time.Second)
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout(), err.Error())
+}
+
+func TestValidateConfig_filePath(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	// 没有配置目录时，只有 root 用户可以设置路径
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeFile, models.NotifierConfig{Path: "/tmp/events.log"}))
+	assert.Nil(t, ValidateConfig("root", models.NotifierTypeFile, models.NotifierConfig{Path: "/tmp/events.log"}))
+
+	config.GlobalServerConfig.Notify.FileNotifierDir = "/var/log/paddleflow/notification"
+	assert.Nil(t, ValidateConfig("user1", models.NotifierTypeFile,
+		models.NotifierConfig{Path: "/var/log/paddleflow/notification/user1/events.log"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeFile,
+		models.NotifierConfig{Path: "/var/log/paddleflow/notification/../events.log"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeFile, models.NotifierConfig{Path: "/var/log/paddleflow/notification"}))
+	assert.NotNil(t, ValidateConfig("user1", models.NotifierTypeFile, models.NotifierConfig{Path: "/etc/passwd"}))
+}
+
+func TestWebhookNotify(t *testing.T) {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Notify = config.NotificationConfig{MaxAttempts: 3, RetryIntervalSeconds: 1, TimeoutSeconds: 1,
+		WebhookAllowList: []string{"127.0.0.1"}}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	var requests int32
+	var event Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次请求返回失败，验证重试
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, Sign("secret1", body), r.Header.Get(HeaderSignature))
+		assert.Equal(t, models.NotifyKindRun, r.Header.Get(HeaderEvent))
+		assert.Nil(t, json.Unmarshal(body, &event))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	secret, err := common.AesEncrypt("secret1", common.AESEncryptKey)
+	assert.Nil(t, err)
+	notifier := &models.Notifier{
+		Name:       "hook",
+		UserName:   "user1",
+		PipelineID: "ppl-000001",
+		Type:       models.NotifierTypeWebhook,
+		Kinds:      []string{models.NotifyKindRun},
+		Config:     models.NotifierConfig{URL: server.URL, Secret: secret},
+	}
+	assert.Nil(t, models.CreateNotifier(logger.Logger(), notifier))
+	// 第一次投递失败后保持 pending 状态，由后台按重试时间重新投递
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go RetryPendingDeliveries(stopCh)
+
+	// 其他 pipeline 和其他类型的对象不会触发通知
+	Notify(Event{Kind: models.NotifyKindRun, ID: "run-000001", UserName: "user1", PipelineID: "ppl-000002", Status: "failed"})
+	Notify(Event{Kind: models.NotifyKindSchedule, ID: "schedule-000001", UserName: "user1", PipelineID: "ppl-000001", Status: "success"})
+
+	runEvent := Event{Kind: models.NotifyKindRun, ID: "run-000002", UserName: "user1", PipelineID: "ppl-000001", Status: "succeeded"}
+	Notify(runEvent)
+	deliveries := waitDelivery(t, notifier.ID, 1)
+	assert.Equal(t, 1, len(deliveries))
+	assert.Equal(t, models.DeliveryStatusSucceeded, deliveries[0].Result)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Equal(t, "run-000002", event.ID)
+	assert.Equal(t, "succeeded", event.Status)
+
+	// 同一个状态只投递一次
+	Notify(runEvent)
+	time.Sleep(200 * time.Millisecond)
+	deliveries = waitDelivery(t, notifier.ID, 1)
+	assert.Equal(t, 1, len(deliveries))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestFileNotify(t *testing.T) {
+	driver.InitMockDB()
+	path := filepath.Join(os.TempDir(), "pf-notification-test", "events.log")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	notifier := &models.Notifier{
+		Name:     "file",
+		UserName: "user1",
+		Type:     models.NotifierTypeFile,
+		Config:   models.NotifierConfig{Path: path},
+	}
+	assert.Nil(t, models.CreateNotifier(logger.Logger(), notifier))
+	// pipeline 级别的事件也会通知用户级别的通知方式
+	Notify(Event{Kind: models.NotifyKindSchedule, ID: "schedule-000001", UserName: "user1", PipelineID: "ppl-000001", Status: "success"})
+	// 其他用户的对象不会通知
+	Notify(Event{Kind: models.NotifyKindJob, ID: "job-000001", UserName: "user2", Status: "failed"})
+
+	deliveries := waitDelivery(t, notifier.ID, 1)
+	assert.Equal(t, 1, len(deliveries))
+	assert.Equal(t, models.DeliveryStatusSucceeded, deliveries[0].Result)
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, 1, len(lines))
+	var event Event
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "schedule-000001", event.ID)
+	assert.Equal(t, models.NotifyKindSchedule, event.Kind)
+}
+
+func TestRetryPendingDeliveries(t *testing.T) {
+	driver.InitMockDB()
+	path := filepath.Join(os.TempDir(), "pf-notification-retry-test", "events.log")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	notifier := &models.Notifier{
+		Name:     "file",
+		UserName: "user1",
+		Type:     models.NotifierTypeFile,
+		Config:   models.NotifierConfig{Path: path},
+	}
+	assert.Nil(t, models.CreateNotifier(logger.Logger(), notifier))
+
+	// server 重启前没有投递完成的记录
+	content, err := json.Marshal(Event{Kind: models.NotifyKindRun, ID: "run-000001", UserName: "user1", Status: "failed"})
+	assert.Nil(t, err)
+	delivery := &models.NotificationDelivery{
+		NotifierID:  notifier.ID,
+		Kind:        models.NotifyKindRun,
+		ObjectID:    "run-000001",
+		Status:      "failed",
+		Attempts:    1,
+		Result:      models.DeliveryStatusPending,
+		Event:       string(content),
+		NextRetryAt: time.Now().Add(-time.Second),
+	}
+	created, err := models.CreateDeliveryIfNotExist(logger.Logger(), delivery)
+	assert.Nil(t, err)
+	assert.True(t, created)
+	// 同一个对象的同一个状态只有一条投递记录
+	created, err = models.CreateDeliveryIfNotExist(logger.Logger(), &models.NotificationDelivery{
+		NotifierID: notifier.ID,
+		Kind:       models.NotifyKindRun,
+		ObjectID:   "run-000001",
+		Status:     "failed",
+		Result:     models.DeliveryStatusPending,
+	})
+	assert.Nil(t, err)
+	assert.False(t, created)
+
+	retryPendingDeliveries()
+	deliveries := waitDelivery(t, notifier.ID, 1)
+	assert.Equal(t, 1, len(deliveries))
+	assert.Equal(t, models.DeliveryStatusSucceeded, deliveries[0].Result)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(data), "run-000001"))
+
+	// 已经投递完成的记录不会被重复投递
+	retryPendingDeliveries()
+	time.Sleep(100 * time.Millisecond)
+	data, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "run-000001"))
+}
+
+func TestNotifyJobListener(t *testing.T) {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	defer func() {
+		config.GlobalServerConfig = nil
+		jobListeners = nil
+	}()
+	patch := gomonkey.ApplyFunc(models.GetJobByID, func(jobID string) (models.Job, error) {
+		return models.Job{ID: jobID, UserName: "user1", QueueID: "queue-1"}, nil
+	})
+	defer patch.Reset()
+
+	var finished []string
+	AddJobListener(func(job *models.Job, status schema.JobStatus) {
+		finished = append(finished, job.QueueID+"/"+job.ID+"/"+string(status))
+	})
+	// 非终态不通知
+	NotifyJob("job-1", schema.StatusJobRunning)
+	assert.Equal(t, 0, len(finished))
+	NotifyJob("job-1", schema.StatusJobSucceeded)
+	assert.Equal(t, []string{"queue-1/job-1/succeeded"}, finished)
+}
@@ -0,0 +1,409 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+)
+
+const (
+	HeaderEvent     = "X-PaddleFlow-Event"
+	HeaderDelivery  = "X-PaddleFlow-Delivery"
+	HeaderSignature = "X-PaddleFlow-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// Sender 把事件投递到具体的通知渠道，超时由 ctx 控制
+type Sender interface {
+	Send(ctx context.Context, event *Event, deliveryID int64) error
+}
+
+// NewSender 根据通知方式的类型创建 Sender，通知方式中加密保存的密钥在这里解密
+func NewSender(notifier *models.Notifier) (Sender, error) {
+	conf := notifier.Config
+	switch notifier.Type {
+	case models.NotifierTypeWebhook:
+		secret, err := decryptSecret(conf.Secret)
+		if err != nil {
+			return nil, err
+		}
+		return &webhookSender{url: conf.URL, secret: secret}, nil
+	case models.NotifierTypeEmail:
+		password, err := decryptSecret(conf.Password)
+		if err != nil {
+			return nil, err
+		}
+		return &emailSender{
+			addr:     net.JoinHostPort(conf.SMTPHost, strconv.Itoa(conf.SMTPPort)),
+			host:     conf.SMTPHost,
+			username: conf.Username,
+			password: password,
+			from:     conf.From,
+			to:       conf.To,
+		}, nil
+	case models.NotifierTypeFile:
+		return &fileSender{path: conf.Path}, nil
+	default:
+		return nil, fmt.Errorf("notifier type[%s] not supported", notifier.Type)
+	}
+}
+
+// ValidateConfig 校验通知方式的配置，不会连接外部服务
+// webhook 以及 email 的 smtp 服务不能指向本机、链路本地、私有网络以及集群内部的地址，除非在白名单中；
+// 普通用户的 file 通知方式只能写到配置的目录下
+func ValidateConfig(userName, notifierType string, conf models.NotifierConfig) error {
+	switch notifierType {
+	case models.NotifierTypeWebhook:
+		u, err := url.Parse(conf.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url[%s] of webhook notifier is invalid", conf.URL)
+		}
+		if err := checkWebhookHost(u.Hostname()); err != nil {
+			return err
+		}
+	case models.NotifierTypeEmail:
+		if conf.SMTPHost == "" || conf.SMTPPort <= 0 {
+			return fmt.Errorf("smtpHost and smtpPort should be set for email notifier")
+		}
+		if conf.From == "" || len(conf.To) == 0 {
+			return fmt.Errorf("from and to should be set for email notifier")
+		}
+		if err := checkEmailAddresses(conf.From, conf.To); err != nil {
+			return err
+		}
+		if err := checkWebhookHost(conf.SMTPHost); err != nil {
+			return err
+		}
+	case models.NotifierTypeFile:
+		// path 为空时写到 server 日志中
+		if conf.Path == "" {
+			return nil
+		}
+		if !filepath.IsAbs(conf.Path) {
+			return fmt.Errorf("path[%s] of file notifier should be absolute", conf.Path)
+		}
+		if common.IsRootUser(userName) {
+			return nil
+		}
+		dir := notificationConfig().FileNotifierDir
+		if dir == "" {
+			return fmt.Errorf("only root user can set the path of file notifier")
+		}
+		if !isSubPath(filepath.Clean(dir), filepath.Clean(conf.Path)) {
+			return fmt.Errorf("path[%s] of file notifier should be under %s", conf.Path, dir)
+		}
+	default:
+		return fmt.Errorf("notifier type[%s] not supported, should be one of %v", notifierType, models.NotifierTypeList)
+	}
+	return nil
+}
+
+func notificationConfig() config.NotificationConfig {
+	if config.GlobalServerConfig == nil {
+		return config.NotificationConfig{}
+	}
+	return config.GlobalServerConfig.Notify
+}
+
+func isSubPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// internalHostSuffixes 是集群内部以及本机的域名
+var internalHostSuffixes = []string{"localhost", ".svc", ".cluster.local", ".internal"}
+
+// internalCIDRs 是默认禁止 webhook 访问的私有网络地址，本机、链路本地等地址由 net.IP 的方法判断
+var internalCIDRs = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"}
+
+// checkWebhookHost 校验 webhook 以及 smtp 服务的 host，host 为 ip 时校验 ip，为域名时校验域名，
+// 域名解析后的地址在发送时由 webhookDialControl 校验
+func checkWebhookHost(host string) error {
+	conf := notificationConfig()
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+	if matchHostList(conf.WebhookAllowList, host, ip) {
+		return nil
+	}
+	if matchHostList(conf.WebhookDenyList, host, ip) {
+		return fmt.Errorf("host[%s] of notifier is denied", host)
+	}
+	if ip != nil {
+		if isInternalIP(ip) {
+			return fmt.Errorf("host[%s] of notifier is an internal address", host)
+		}
+		return nil
+	}
+	for _, suffix := range internalHostSuffixes {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return fmt.Errorf("host[%s] of notifier is an internal address", host)
+		}
+	}
+	return nil
+}
+
+// matchHostList 判断 host 是否在列表中，列表中的元素为域名或者 CIDR
+func matchHostList(list []string, host string, ip net.IP) bool {
+	for _, item := range list {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == host {
+			return true
+		}
+		if _, ipNet, err := net.ParseCIDR(item); err == nil && ip != nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, cidr := range internalCIDRs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookDialControl 在建立连接前校验域名解析后的地址，避免通过域名访问内部地址
+func webhookDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	return checkWebhookHost(host)
+}
+
+// webhookClient 不使用代理，以便校验实际连接的地址
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   webhookDialControl,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	},
+}
+
+func decryptSecret(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	plain, err := common.AesDecrypt(secret, common.AESEncryptKey)
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret of notifier failed: %v", err)
+	}
+	return plain, nil
+}
+
+// Sign 计算 webhook 请求体的签名，接收方可以用相同的 secret 校验请求来源
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookSender struct {
+	url    string
+	secret string
+}
+
+func (s *webhookSender) Send(ctx context.Context, event *Event, deliveryID int64) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event.Kind)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(deliveryID, 10))
+	if s.secret != "" {
+		req.Header.Set(HeaderSignature, Sign(s.secret, body))
+	}
+	client := webhookClient
+	// 白名单中的域名可能解析为内部地址，此时不再校验连接的地址
+	if matchHostList(notificationConfig().WebhookAllowList, strings.ToLower(req.URL.Hostname()), nil) {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type emailSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// checkEmailAddresses 校验邮件地址，地址会被写入邮件头中，不能包含换行符
+func checkEmailAddresses(from string, to []string) error {
+	for _, addr := range append([]string{from}, to...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("address[%q] of email notifier should not contain CR or LF", addr)
+		}
+	}
+	return nil
+}
+
+func (s *emailSender) Send(ctx context.Context, event *Event, deliveryID int64) error {
+	if err := checkEmailAddresses(s.from, s.to); err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("[PaddleFlow] %s %s %s", event.Kind, event.ID, event.Status)
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("From: %s\r\n", s.from))
+	body.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(s.to, ",")))
+	body.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(event.String())
+	body.WriteString("\r\n")
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	// 白名单中的域名可能解析为内部地址，此时不再校验连接的地址
+	if !matchHostList(notificationConfig().WebhookAllowList, strings.ToLower(s.host), nil) {
+		dialer.Control = webhookDialControl
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// 整个发送过程受 ctx 的超时控制，超时后连接上的读写直接返回错误
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	return s.sendMail(conn, []byte(body.String()))
+}
+
+// sendMail 与 smtp.SendMail 的流程一致，区别在于使用已经建立好的连接
+func (s *emailSender) sendMail(conn net.Conn, msg []byte) error {
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server[%s] doesn't support AUTH", s.addr)
+		}
+		if err = c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(s.from); err != nil {
+		return err
+	}
+	for _, addr := range s.to {
+		if err = c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// fileSender 把事件以 json 行的形式追加到文件中，path 为空时写到日志中，主要用于测试
+type fileSender struct {
+	path string
+}
+
+var fileLock sync.Mutex
+
+func (s *fileSender) Send(ctx context.Context, event *Event, deliveryID int64) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if s.path == "" {
+		log.Infof("notification[%d]: %s", deliveryID, string(line))
+		return nil
+	}
+	fileLock.Lock()
+	defer fileLock.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -26,8 +26,8 @@ import (
 )
 
 const (
-	PaddleflowRouterPrefix    = "/api/paddleflow"
-	PaddleflowRouterVersionV1 = "/v1"
+	PaddleflowRouterPrefix    = common.PaddleflowRouterPrefix
+	PaddleflowRouterVersionV1 = common.PaddleflowRouterVersionV1
 
 	DefaultMaxKeys = 50
 	ListPageMax    = 1000
@@ -40,10 +40,12 @@ const (
 	ParamKeyPipelineID        = "pipelineID"
 	ParamKeyPipelineVersionID = "pipelineVersionID"
 	ParamKeyScheduleID        = "scheduleID"
+	ParamKeyNotifierID        = "notifierID"
 
 	QueryKeyAction    = "action"
 	QueryActionStop   = "stop"
 	QueryActionRetry  = "retry"
+	QueryActionRerun  = "rerun"
 	QueryActionDelete = "delete"
 	QueryActionCreate = "create"
 	QueryActionModify = "modify"
@@ -62,9 +64,13 @@ const (
 	QueryKeyRunFilter        = "runFilter"
 	QueryKeyTypeFilter       = "typeFilter"
 	QueryKeyPathFilter       = "pathFilter"
+	QueryKeyMaxDepth         = "maxDepth"
+	QueryKeyBaseRunID        = "baseRunID"
+	QueryKeyTargetRunID      = "targetRunID"
 	QueryKeyUser             = "user"
 	QueryKeyName             = "name"
 	QueryKeyUserName         = "username"
+	QueryKeyTokenID          = "tokenID"
 	QueryResourceType        = "resourceType"
 	QueryResourceID          = "resourceID"
 	QueryKeyStatus           = "status"
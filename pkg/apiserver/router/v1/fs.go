@@ -23,6 +23,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	log "github.com/sirupsen/logrus"
@@ -59,6 +60,8 @@ func (pr *PFSRouter) AddRouter(r chi.Router) {
 	r.Get("/fsCache/{fsName}", pr.getFSCacheConfig)
 	r.Delete("/fsCache/{fsName}", pr.deleteFSCacheConfig)
 	r.Post("/fsCache/report", pr.fsCacheReport)
+	r.Post("/fsCache/{fsName}/warmup", pr.createFSCacheWarmup)
+	r.Get("/fsCache/{fsName}/warmup", pr.listFSCacheWarmup)
 }
 
 var URLPrefix = map[string]bool{
@@ -69,6 +72,7 @@ var URLPrefix = map[string]bool{
 	fsCommon.MockType:      true,
 	fsCommon.CFSType:       true,
 	fsCommon.GlusterFSType: true,
+	fsCommon.TieredType:    true,
 }
 
 const FsNameMaxLen = 100
@@ -291,11 +295,74 @@ func checkProperties(fsType string, req *api.CreateFileSystemRequest) error {
 			return common.InvalidField(fsCommon.Namespace, "key[namespace] cannot be empty")
 		}
 		return nil
+	case fsCommon.TieredType:
+		return checkTieredProperties(req)
 	default:
 		return nil
 	}
 }
 
+// tierProperties 返回以prefix为前缀的冷层或热层属性，key去掉了前缀
+func tierProperties(properties map[string]string, prefix string) map[string]string {
+	tierProperties := make(map[string]string)
+	for k, v := range properties {
+		if strings.HasPrefix(k, prefix) {
+			tierProperties[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return tierProperties
+}
+
+// tierURL 按各类型url的格式拼出冷层或热层的地址，不支持的类型返回空
+func tierURL(tierType string, properties map[string]string) string {
+	subPath := strings.TrimPrefix(properties[fsCommon.SubPath], "/")
+	switch tierType {
+	case fsCommon.LocalType:
+		return tierType + "://" + subPath
+	case fsCommon.S3Type:
+		return tierType + "://" + properties[fsCommon.Bucket] + "/" + subPath
+	case fsCommon.HDFSType:
+		return tierType + "://" + properties[fsCommon.NameNodeAddress] + "/" + subPath
+	case fsCommon.SFTPType:
+		return tierType + "://" + properties[fsCommon.Address] + "/" + subPath
+	}
+	return ""
+}
+
+// checkTieredProperties 分别校验以hot.和cold.为前缀的热层、冷层属性，校验后的属性（如加密后的sk）写回请求
+func checkTieredProperties(req *api.CreateFileSystemRequest) error {
+	for _, prefix := range []string{fsCommon.HotTierPrefix, fsCommon.ColdTierPrefix} {
+		tierProperties := tierProperties(req.Properties, prefix)
+		tierType := tierProperties[fsCommon.Type]
+		if !URLPrefix[tierType] || tierType == fsCommon.TieredType || tierType == fsCommon.MockType {
+			return common.InvalidField("properties", fmt.Sprintf("key[%s%s] %s is not supported", prefix, fsCommon.Type, tierType))
+		}
+		// 本地盘和单独创建local类型的文件系统一样，要求debug模式，并且必须指定目录
+		if tierType == fsCommon.LocalType && tierProperties[fsCommon.SubPath] == "" {
+			return common.InvalidField("properties", fmt.Sprintf("key[%s%s] cannot be empty", prefix, fsCommon.SubPath))
+		}
+		if err := checkProperties(tierType, &api.CreateFileSystemRequest{Properties: tierProperties}); err != nil {
+			return err
+		}
+		for k, v := range tierProperties {
+			req.Properties[prefix+k] = v
+		}
+	}
+	if value := req.Properties[fsCommon.SizeThreshold]; value != "" {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return common.InvalidField(fsCommon.SizeThreshold, err.Error())
+		}
+	}
+	for _, key := range []string{fsCommon.HotAge, fsCommon.MigrateInterval} {
+		if value := req.Properties[key]; value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return common.InvalidField(key, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
 func checkPVCExist(pvc, namespace string) bool {
 	k8sClient, err := utils.GetK8sClient()
 	if err != nil {
@@ -318,7 +385,7 @@ func checkURLFormat(fsType, url string, properties map[string]string) error {
 			log.Errorf("%s url split error", fsType)
 			return common.InvalidField("url", fmt.Sprintf("%s url format is wrong", fsType))
 		}
-	case fsCommon.LocalType, fsCommon.MockType:
+	case fsCommon.LocalType, fsCommon.MockType, fsCommon.TieredType:
 		if len(urlSplit) < 3 {
 			log.Errorf("%s url split error", fsType)
 			return common.InvalidField("url", fmt.Sprintf("%s address format is wrong", fsType))
@@ -371,6 +438,20 @@ func checkFsDir(fsType, url string, properties map[string]string) error {
 	case fsCommon.S3Type:
 		inputIPs = strings.Split(properties[fsCommon.Endpoint], ",")
 		subPath = "/" + strings.SplitAfterN(url, "/", 4)[3]
+	case fsCommon.TieredType:
+		// 冷热层的存储目录在properties中，url仅用于命名，分别按各层的类型、地址和目录检查
+		for _, prefix := range []string{fsCommon.HotTierPrefix, fsCommon.ColdTierPrefix} {
+			tierProperties := tierProperties(properties, prefix)
+			tierType := tierProperties[fsCommon.Type]
+			url := tierURL(tierType, tierProperties)
+			if url == "" {
+				continue
+			}
+			if err := checkFsDir(tierType, url, tierProperties); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 	fsList, err := storage.Filesystem.GetSimilarityAddressList(fsType, inputIPs)
 	if err != nil {
@@ -91,6 +91,13 @@ func validateCacheConfigCreate(ctx *logger.RequestContext, req *api.UpdateFileSy
 		ctx.Logging().Errorf("validate fs cache config fsID[%s] err: %v", req.FsID, err)
 		return err
 	}
+	// Quota in bytes, 0 means limited only by free disk space
+	if req.Quota < 0 {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("fs data cache quota[%d] should not be negative", req.Quota)
+		ctx.Logging().Errorf("validate fs cache config fsID[%s] err: %v", req.FsID, err)
+		return err
+	}
 	// cacheDir must be absolute path or ""
 	if req.CacheDir != "" && !filepath.IsAbs(req.CacheDir) {
 		ctx.ErrorCode = common.InvalidArguments
@@ -201,7 +208,7 @@ func (pr *PFSRouter) deleteFSCacheConfig(w http.ResponseWriter, r *http.Request)
 // @Param fsName path string true "存储名称"
 // @Param username query string false "用户名"
 // @Param request body fs.CacheReportRequest true "request body"
-// @Success 200 {object}
+// @Success 200 {object} fs.CacheReportResponse "节点待执行的缓存预热任务"
 // @Failure 400 {object} common.ErrorResponse "400"
 // @Failure 500 {object} common.ErrorResponse "500"
 // @Router /fsCache/report [POST]
@@ -227,14 +234,14 @@ func (pr *PFSRouter) fsCacheReport(w http.ResponseWriter, r *http.Request) {
 
 	ctx.Logging().Debugf("report cache with req[%v]", request)
 
-	err = api.ReportCache(&ctx, request)
+	response, err := api.ReportCache(&ctx, request)
 	if err != nil {
 		ctx.Logging().Errorf("report cache with service error[%v]", err)
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
 	}
 
-	common.RenderStatus(w, http.StatusOK)
+	common.Render(w, http.StatusOK, response)
 }
 
 func validateFsCacheReport(ctx *logger.RequestContext, req *api.CacheReportRequest) error {
@@ -248,3 +255,71 @@ func validateFsCacheReport(ctx *logger.RequestContext, req *api.CacheReportReque
 	}
 	return nil
 }
+
+// createFSCacheWarmup
+// @Summary 预热文件系统缓存
+// @Description 在挂载了该文件系统缓存的节点上预热指定路径，任务由节点上的 cache-worker 执行，进度通过 /fsCache/report 上报
+// @Id createFSCacheWarmup
+// @tags FSCacheConfig
+// @Accept  json
+// @Produce json
+// @Param fsName path string true "存储名称"
+// @Param username query string false "用户名"
+// @Param request body fs.CreateCacheWarmupRequest true "request body"
+// @Success 201 {object} fs.CacheWarmupResponse "每个节点的预热任务"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsCache/{fsName}/warmup [POST]
+func (pr *PFSRouter) createFSCacheWarmup(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsName := chi.URLParam(r, util.QueryFsName)
+	username := r.URL.Query().Get(util.QueryKeyUserName)
+	var request api.CreateCacheWarmupRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("createFSCacheWarmup bindjson failed. err:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	request.FsID = common.ID(getRealUserName(&ctx, username), fsName)
+	if err := fsExistsForModify(&ctx, request.FsID); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := api.CreateCacheWarmup(&ctx, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listFSCacheWarmup
+// @Summary 列出文件系统缓存预热任务
+// @Description 列出文件系统在各个节点上的缓存预热任务及进度
+// @Id listFSCacheWarmup
+// @tags FSCacheConfig
+// @Accept  json
+// @Produce json
+// @Param fsName path string true "存储名称"
+// @Param username query string false "用户名"
+// @Success 200 {object} fs.CacheWarmupResponse "预热任务列表"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsCache/{fsName}/warmup [GET]
+func (pr *PFSRouter) listFSCacheWarmup(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsName := chi.URLParam(r, util.QueryFsName)
+	username := r.URL.Query().Get(util.QueryKeyUserName)
+	fsID := common.ID(getRealUserName(&ctx, username), fsName)
+	if err := fsExistsForModify(&ctx, fsID); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := api.ListCacheWarmup(&ctx, fsID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
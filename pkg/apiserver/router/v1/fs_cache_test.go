@@ -166,3 +166,123 @@ func TestFSCacheReportRouter(t *testing.T) {
 	assert.Equal(t, 1, len(cacheList))
 	assert.Equal(t, 200, cacheList[0].UsedSize)
 }
+
+func TestFSCacheWarmupRouter(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	mockFs := mockFS()
+	url := baseUrl + "/fsCache/" + mockFsName + "/warmup"
+	createReq := fs.CreateCacheWarmupRequest{Paths: []string{"data/../train"}}
+
+	// test create failure - no fs
+	result, err := PerformPostRequest(router, url, createReq)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+
+	// test create failure - no cache on any node
+	err = storage.Filesystem.CreatFileSystem(&mockFs)
+	assert.Nil(t, err)
+	result, err = PerformPostRequest(router, url, createReq)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	reportUrl := baseUrl + "/fsCache/report"
+	report := buildReportRequest()
+	result, err = PerformPostRequest(router, reportUrl, report)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+
+	// test create failure - invalid concurrency and node without cache
+	result, err = PerformPostRequest(router, url, fs.CreateCacheWarmupRequest{Paths: []string{"/"}, Concurrency: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+	result, err = PerformPostRequest(router, url, fs.CreateCacheWarmupRequest{Paths: []string{"/"}, NodeNames: []string{"none"}})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	// test create success
+	result, err = PerformPostRequest(router, url, createReq)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, result.Code)
+	warmupRsp := fs.CacheWarmupResponse{}
+	err = ParseBody(result.Body, &warmupRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(warmupRsp.WarmupList))
+	warmup := warmupRsp.WarmupList[0]
+	assert.Equal(t, report.NodeName, warmup.NodeName)
+	assert.Equal(t, []string{"/train"}, warmup.Paths)
+	assert.Equal(t, fs.DefaultWarmupConcurrency, warmup.Concurrency)
+	assert.Equal(t, model.WarmupStatusPending, warmup.Status)
+
+	// test dispatch by report
+	result, err = PerformPostRequest(router, reportUrl, report)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	reportRsp := fs.CacheReportResponse{}
+	err = ParseBody(result.Body, &reportRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(reportRsp.Warmups))
+	assert.Equal(t, warmup.WarmupID, reportRsp.Warmups[0].WarmupID)
+	assert.Equal(t, []string{"/train"}, reportRsp.Warmups[0].Paths)
+
+	// progress reported by another node is ignored
+	otherReport := buildReportRequest()
+	otherReport.NodeName = "other-node"
+	otherReport.Warmups = []fs.WarmupReport{{WarmupID: warmup.WarmupID, Status: model.WarmupStatusFailed}}
+	result, err = PerformPostRequest(router, reportUrl, otherReport)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+
+	// running task without progress report is dispatched again
+	err = storage.DB.Model(&model.FSCacheWarmup{}).Where("warmup_id = ?", warmup.WarmupID).
+		Update("updated_at", time.Now().Add(-fs.WarmupRedispatchTimeout-time.Minute)).Error
+	assert.Nil(t, err)
+	result, err = PerformPostRequest(router, reportUrl, report)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	reportRsp = fs.CacheReportResponse{}
+	err = ParseBody(result.Body, &reportRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(reportRsp.Warmups))
+	assert.Equal(t, warmup.WarmupID, reportRsp.Warmups[0].WarmupID)
+
+	// dispatched task is not returned again
+	report.Warmups = []fs.WarmupReport{{WarmupID: warmup.WarmupID, Status: model.WarmupStatusSucceeded, Files: 3, Bytes: 1024}}
+	result, err = PerformPostRequest(router, reportUrl, report)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	reportRsp = fs.CacheReportResponse{}
+	err = ParseBody(result.Body, &reportRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(reportRsp.Warmups))
+
+	// test list
+	result, err = PerformGetRequest(router, url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	warmupRsp = fs.CacheWarmupResponse{}
+	err = ParseBody(result.Body, &warmupRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(warmupRsp.WarmupList))
+	assert.Equal(t, model.WarmupStatusSucceeded, warmupRsp.WarmupList[0].Status)
+	assert.Equal(t, int64(3), warmupRsp.WarmupList[0].Files)
+	assert.Equal(t, int64(1024), warmupRsp.WarmupList[0].Bytes)
+
+	// running task without progress report for a long time is failed
+	result, err = PerformPostRequest(router, url, createReq)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, result.Code)
+	result, err = PerformPostRequest(router, reportUrl, buildReportRequest())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	err = storage.DB.Model(&model.FSCacheWarmup{}).Where("status = ?", model.WarmupStatusRunning).
+		Update("updated_at", time.Now().Add(-fs.WarmupExpireTimeout-time.Minute)).Error
+	assert.Nil(t, err)
+	result, err = PerformGetRequest(router, url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	warmupRsp = fs.CacheWarmupResponse{}
+	err = ParseBody(result.Body, &warmupRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(warmupRsp.WarmupList))
+	assert.Equal(t, model.WarmupStatusFailed, warmupRsp.WarmupList[1].Status)
+}
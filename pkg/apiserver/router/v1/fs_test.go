@@ -249,6 +249,56 @@ func Test_validateCreateFileSystem(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "tiered ok",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "tiered://dataset",
+					Properties: map[string]string{"hot.type": "local", "hot.subpath": "/ssd/dataset", "hot.debug": "true",
+						"cold.type": "s3", "cold.endpoint": "127.0.0.1", "cold.bucket": "bucket", "cold.accessKey": "ak",
+						"cold.secretKey": "sk", "tiered.sizeThreshold": "1048576", "tiered.hotAge": "12h"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tiered local tier without debug",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "tiered://dataset",
+					Properties: map[string]string{"hot.type": "local", "hot.subpath": "/ssd/dataset", "cold.type": "s3",
+						"cold.endpoint": "127.0.0.1", "cold.bucket": "bucket", "cold.accessKey": "ak", "cold.secretKey": "sk"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tiered cold tier missing sk",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "tiered://dataset",
+					Properties: map[string]string{"hot.type": "local", "hot.subpath": "/ssd/dataset", "cold.type": "s3",
+						"cold.endpoint": "127.0.0.1", "cold.bucket": "bucket", "cold.accessKey": "ak"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tiered nested tier",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "tiered://dataset",
+					Properties: map[string]string{"hot.type": "local", "hot.subpath": "/ssd/dataset", "cold.type": "tiered"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tiered wrong hotAge",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "tiered://dataset",
+					Properties: map[string]string{"hot.type": "local", "hot.subpath": "/ssd/dataset", "hot.debug": "true",
+						"cold.type": "local", "cold.subpath": "/hdd/dataset", "cold.debug": "true", "tiered.hotAge": "1 day"}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "username is wrong 1",
 			args: args{
@@ -353,6 +403,32 @@ func Test_checkFsDir(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "tiered",
+			args: args{
+				fsType: fsCommon.TieredType,
+				url:    "tiered://dataset",
+				properties: map[string]string{
+					"hot.type": fsCommon.LocalType, "hot.subpath": "/ssd/dataset",
+					"cold.type": fsCommon.S3Type, "cold.endpoint": "s3.xxx.com", "cold.bucket": "bucket",
+					"cold.subpath": "/datatest",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tiered cold tier nested",
+			args: args{
+				fsType: fsCommon.TieredType,
+				url:    "tiered://dataset",
+				properties: map[string]string{
+					"hot.type": fsCommon.LocalType, "hot.subpath": "/ssd/dataset",
+					"cold.type": fsCommon.HDFSType, "cold." + fsCommon.NameNodeAddress: "192.168.1.3:9000",
+					"cold.subpath": "/data/mypath/path",
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
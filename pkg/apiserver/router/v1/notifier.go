@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/notifier"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+type NotifierRouter struct{}
+
+func (nr *NotifierRouter) Name() string {
+	return "NotifierRouter"
+}
+
+func (nr *NotifierRouter) AddRouter(r chi.Router) {
+	log.Info("add notifier router")
+	r.Post("/notifier", nr.createNotifier)
+	r.Get("/notifier", nr.listNotifier)
+	r.Get("/notifier/{notifierID}", nr.getNotifier)
+	r.Delete("/notifier/{notifierID}", nr.deleteNotifier)
+	r.Get("/notifier/{notifierID}/delivery", nr.listDelivery)
+}
+
+// createNotifier
+// @Summary 创建通知方式
+// @Description run、job、schedule 到达终态时，通过 webhook、邮件或文件通知用户
+// @Id createNotifier
+// @tags Notifier
+// @Accept  json
+// @Produce json
+// @Param request body notifier.CreateNotifierRequest true "创建通知方式请求"
+// @Success 201 {object} models.Notifier "创建通知方式响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /notifier [POST]
+func (nr *NotifierRouter) createNotifier(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request notifier.CreateNotifierRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create notifier failed parsing request body. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	response, err := notifier.CreateNotifier(&ctx, &request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listNotifier
+// @Summary 列出通知方式
+// @Description 普通用户列出自己的通知方式，root 用户列出所有用户的
+// @Id listNotifier
+// @tags Notifier
+// @Accept  json
+// @Produce json
+// @Success 200 {object} notifier.ListNotifierResponse "通知方式列表"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /notifier [GET]
+func (nr *NotifierRouter) listNotifier(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	response, err := notifier.ListNotifier(&ctx)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getNotifier
+// @Summary 获取通知方式
+// @Description 获取通知方式，secret 和密码不会返回
+// @Id getNotifier
+// @tags Notifier
+// @Accept  json
+// @Produce json
+// @Param notifierID path string true "通知方式ID"
+// @Success 200 {object} models.Notifier "通知方式详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Router /notifier/{notifierID} [GET]
+func (nr *NotifierRouter) getNotifier(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	notifierID := chi.URLParam(r, util.ParamKeyNotifierID)
+	response, err := notifier.GetNotifier(&ctx, notifierID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteNotifier
+// @Summary 删除通知方式
+// @Description 删除通知方式
+// @Id deleteNotifier
+// @tags Notifier
+// @Accept  json
+// @Produce json
+// @Param notifierID path string true "通知方式ID"
+// @Success 200 {string} string "成功删除通知方式的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Router /notifier/{notifierID} [DELETE]
+func (nr *NotifierRouter) deleteNotifier(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	notifierID := chi.URLParam(r, util.ParamKeyNotifierID)
+	if err := notifier.DeleteNotifier(&ctx, notifierID); err != nil {
+		ctx.Logging().Errorf("delete notifier[%s] failed. error:%s", notifierID, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// listDelivery
+// @Summary 列出通知方式的投递记录
+// @Description 按时间倒序列出最近 maxKeys 条投递记录
+// @Id listDelivery
+// @tags Notifier
+// @Accept  json
+// @Produce json
+// @Param notifierID path string true "通知方式ID"
+// @Param maxKeys query int false "每页条数"
+// @Success 200 {object} notifier.ListDeliveryResponse "投递记录列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Router /notifier/{notifierID}/delivery [GET]
+func (nr *NotifierRouter) listDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	notifierID := chi.URLParam(r, util.ParamKeyNotifierID)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	response, err := notifier.ListDelivery(&ctx, notifierID, maxKeys)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
@@ -80,6 +80,7 @@ func prepareDBAndAPI(t *testing.T) (*chi.Mux, string) {
 	config.GlobalServerConfig = &config.ServerConfig{
 		ApiServer: config.ApiServerConfig{
 			TokenExpirationHour: -1,
+			TokenSigningKeys:    []config.TokenSigningKey{{ID: "key-1", Secret: "secret-1"}},
 		},
 	}
 
@@ -119,7 +120,7 @@ func CreateTestUser(ctx *logger.RequestContext, username, password string) (stri
 		fmt.Printf("CreateTestUser failed creating user. err:%v\n", err)
 		return "", err
 	}
-	token, err := middleware.GenerateToken(username, encrypted)
+	token, err := middleware.GenerateToken(username)
 	if err != nil {
 		fmt.Printf("CreateTestUser failed generating token. err:%v\n", err)
 	}
@@ -18,6 +18,7 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -32,6 +33,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/pipeline"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/trace_logger"
 )
 
@@ -88,6 +90,13 @@ func (rr *RunRouter) createRun(w http.ResponseWriter, r *http.Request) {
 			trace_logger.Key(response.RunID).Errorf(errMsg)
 		}
 		logger.LoggerForRequest(&ctx).Errorf(errMsg)
+		// 参数校验失败时，返回每个参数的校验错误
+		var paramErr *pplcommon.ParamValidationError
+		if errors.As(err, &paramErr) {
+			ctx.ErrorCode = common.InvalidArguments
+			common.RenderErrWithDetails(w, ctx.RequestID, ctx.ErrorCode, err.Error(), paramErr.Errors)
+			return
+		}
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
 	}
@@ -146,6 +155,12 @@ func (rr *RunRouter) createRunByJson(w http.ResponseWriter, r *http.Request) {
 		}
 		logger.LoggerForRequest(&ctx).Errorf(
 			"create run by json failed. error:%s", err.Error())
+		var paramErr *pplcommon.ParamValidationError
+		if errors.As(err, &paramErr) {
+			ctx.ErrorCode = common.InvalidArguments
+			common.RenderErrWithDetails(w, ctx.RequestID, ctx.ErrorCode, err.Error(), paramErr.Errors)
+			return
+		}
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
 	}
@@ -276,6 +291,8 @@ func (rr *RunRouter) updateRun(w http.ResponseWriter, r *http.Request) {
 		}
 	case util.QueryActionRetry:
 		runID, err = pipeline.RetryRun(&ctx, runID)
+	case util.QueryActionRerun:
+		runID, err = pipeline.RerunRun(&ctx, runID, request.Components)
 	default:
 		ctx.ErrorCode = common.InvalidURI
 		err = fmt.Errorf("invalid action[%s] for UpdateRun", action)
@@ -286,7 +303,7 @@ func (rr *RunRouter) updateRun(w http.ResponseWriter, r *http.Request) {
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
 	}
-	if action == util.QueryActionRetry {
+	if action == util.QueryActionRetry || action == util.QueryActionRerun {
 		rsp := pipeline.UpdateRunResponse{RunID: runID}
 		common.Render(w, http.StatusOK, rsp)
 	} else {
@@ -29,6 +29,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 )
 
 func getMockRun1() models.Run {
@@ -131,3 +132,31 @@ func TestListRunRouter(t *testing.T) {
 	assert.Equal(t, 2, len(runRsp.RunList))
 	assert.Equal(t, MockFsName1, runRsp.RunList[0].FsName)
 }
+
+func TestCreateRunWithInvalidParams(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+
+	paramErr := &pplcommon.ParamValidationError{Errors: []pplcommon.ParamFieldError{
+		{Field: "lr", Reason: "value[2] of param[lr] should be less than or equal to 1"},
+		{Field: "unknown", Reason: "parameter not exist"},
+	}}
+	p1 := gomonkey.ApplyFunc(pipeline.CreateRun, func(ctx logger.RequestContext, request *pipeline.CreateRunRequest,
+		extra map[string]string) (pipeline.CreateRunResponse, error) {
+		return pipeline.CreateRunResponse{}, paramErr
+	})
+	defer p1.Reset()
+
+	result, err := PerformPostRequest(router, baseUrl+"/run", pipeline.CreateRunRequest{FsName: MockFsName1})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	// 每个参数的校验错误以结构化的形式返回
+	errRsp := struct {
+		ErrorCode string                      `json:"code"`
+		Errors    []pplcommon.ParamFieldError `json:"errors"`
+	}{}
+	err = ParseBody(result.Body, &errRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, common.InvalidArguments, errRsp.ErrorCode)
+	assert.Equal(t, paramErr.Errors, errRsp.Errors)
+}
@@ -44,8 +44,13 @@ func (tr *TrackRouter) AddRouter(r chi.Router) {
 	r.Delete("/runCache/{runCacheID}", tr.deleteRunCache)
 	r.Get("/artifact", tr.listArtifactEvent)
 	r.Delete("/artifact", tr.deleteArtifactEvent)
+	r.Get("/artifact/lineage", tr.getArtifactLineage)
+	r.Get("/runDiff", tr.diffRun)
 }
 
+// defaultLineageMaxDepth is the default depth of consumers traversed in artifact lineage
+const defaultLineageMaxDepth = 10
+
 // getRunCache
 // @Summary 获取运行缓存
 // @Description 获取运行缓存
@@ -253,3 +258,80 @@ func (tr *TrackRouter) deleteArtifactEvent(w http.ResponseWriter, r *http.Reques
 	}
 	common.RenderStatus(w, http.StatusOK)
 }
+
+// getArtifactLineage
+// @Summary 获取运行产物血缘
+// @Description 获取产出该运行产物的step，以及直接或间接使用该运行产物的step
+// @Id getArtifactLineage
+// @tags ArtifactEvent
+// @Accept  json
+// @Produce json
+// @Param fsname query string true "存储名称"
+// @Param path query string true "路径"
+// @Param maxDepth query int false "下游血缘的最大深度，缺省值为10"
+// @Success 200 {object} pipeline.ArtifactLineageResponse "运行产物血缘"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /artifact/lineage [GET]
+func (tr *TrackRouter) getArtifactLineage(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsname, artifactPath := r.URL.Query().Get(util.QueryFsname), r.URL.Query().Get(util.QueryPath)
+	if fsname == "" || artifactPath == "" {
+		err := fmt.Errorf("fsname, path - shall not be empty")
+		ctx.ErrorCode = common.InvalidURI
+		ctx.Logging().Errorf("get artifact lineage failed. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	maxDepth := defaultLineageMaxDepth
+	if depth := r.URL.Query().Get(util.QueryKeyMaxDepth); depth != "" {
+		var err error
+		maxDepth, err = strconv.Atoi(depth)
+		if err != nil || maxDepth <= 0 || maxDepth > util.ListPageMax {
+			err := fmt.Errorf("invalid query maxDepth[%s]. should be an integer between 1~1000", depth)
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+	}
+	lineage, err := pipeline.GetArtifactLineage(&ctx, fsname, artifactPath, maxDepth)
+	if err != nil {
+		ctx.Logging().Errorf("get artifact lineage failed. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, lineage)
+}
+
+// diffRun
+// @Summary 对比运行
+// @Description 对比两个运行的参数、run yaml、cache命中情况以及输出产物
+// @Id diffRun
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param baseRunID query string true "对比基准的运行ID"
+// @Param targetRunID query string true "对比目标的运行ID"
+// @Success 200 {object} pipeline.RunDiffResponse "运行对比结果"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /runDiff [GET]
+func (tr *TrackRouter) diffRun(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	baseRunID, targetRunID := r.URL.Query().Get(util.QueryKeyBaseRunID), r.URL.Query().Get(util.QueryKeyTargetRunID)
+	if baseRunID == "" || targetRunID == "" {
+		err := fmt.Errorf("baseRunID, targetRunID - shall not be empty")
+		ctx.ErrorCode = common.InvalidURI
+		ctx.Logging().Errorf("diff run failed. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	runDiff, err := pipeline.DiffRun(&ctx, baseRunID, targetRunID)
+	if err != nil {
+		ctx.Logging().Errorf("diff run[%s] and run[%s] failed. error:%s", baseRunID, targetRunID, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, runDiff)
+}
@@ -38,10 +38,14 @@ func (ur *UserRouter) Name() string {
 func (ur *UserRouter) AddRouter(r chi.Router) {
 	log.Info("add user router")
 	r.Post("/login", ur.login)
+	r.Post("/logout", ur.logout)
 	r.Post("/user", ur.createUser)
 	r.Delete("/user/{username}", ur.deleteUser)
 	r.Put("/user/{username}", ur.updateUser)
 	r.Get("/user", ur.listUser)
+	r.Post("/user/{username}/token", ur.createAccessToken)
+	r.Get("/user/{username}/token", ur.listAccessToken)
+	r.Delete("/user/{username}/token/{tokenID}", ur.deleteAccessToken)
 
 }
 
@@ -74,7 +78,7 @@ func (ur *UserRouter) login(w http.ResponseWriter, r *http.Request) {
 		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
 		return
 	}
-	token, err := middleware.GenerateToken(u.Name, u.Password)
+	token, err := middleware.GenerateToken(u.Name)
 	if err != nil {
 		ctx.Logging().Errorf(
 			"generate token failed. username:%v error:%s", req.UserName, err.Error())
@@ -86,6 +90,28 @@ func (ur *UserRouter) login(w http.ResponseWriter, r *http.Request) {
 	common.Render(w, http.StatusOK, loginResp)
 }
 
+// logout
+// @Summary 用户登出
+// @Description 用户登出，当前token被吊销
+// @Id logout
+// @tags User
+// @Accept  json
+// @Produce json
+// @Success 200 {string} string "成功登出的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /logout [POST]
+func (ur *UserRouter) logout(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	token := r.Header.Get(common.HeaderKeyAuthorization)
+	if err := middleware.RevokeToken(&ctx, token); err != nil {
+		ctx.Logging().Errorf("user logout failed. userName:%s, error:%s", ctx.UserName, err.Error())
+		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
 // createUser
 // @Summary 创建用户
 // @Description 创建用户
@@ -220,3 +246,83 @@ func (ur *UserRouter) getUser(w http.ResponseWriter, r *http.Request, username s
 	}
 	common.Render(w, http.StatusOK, response)
 }
+
+// createAccessToken
+// @Summary 创建个人访问令牌
+// @Description 创建个人访问令牌，令牌明文仅在创建时返回
+// @Id createAccessToken
+// @tags User
+// @Accept  json
+// @Produce json
+// @Param username path string true "用户名称"
+// @Param request body user.CreateAccessTokenRequest true "创建令牌请求"
+// @Success 200 {object} user.CreateAccessTokenResponse "创建令牌响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/token [POST]
+func (ur *UserRouter) createAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	var req user.CreateAccessTokenRequest
+	if err := common.BindJSON(r, &req); err != nil {
+		ctx.Logging().Errorf("create access token bind json failed. error:%s", err.Error())
+		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
+		return
+	}
+	response, err := user.CreateAccessToken(&ctx, userName, &req)
+	if err != nil {
+		ctx.Logging().Errorf("create access token failed. userName:%s, error:%s", userName, err.Error())
+		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// listAccessToken
+// @Summary 获取个人访问令牌列表
+// @Description 获取个人访问令牌列表，不包含令牌明文
+// @Id listAccessToken
+// @tags User
+// @Accept  json
+// @Produce json
+// @Param username path string true "用户名称"
+// @Success 200 {object} user.ListAccessTokenResponse "令牌列表响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/token [GET]
+func (ur *UserRouter) listAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	response, err := user.ListAccessToken(&ctx, userName)
+	if err != nil {
+		ctx.Logging().Errorf("list access token failed. userName:%s, error:%s", userName, err.Error())
+		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteAccessToken
+// @Summary 吊销个人访问令牌
+// @Description 吊销个人访问令牌
+// @Id deleteAccessToken
+// @tags User
+// @Accept  json
+// @Produce json
+// @Param username path string true "用户名称"
+// @Param tokenID path string true "令牌ID"
+// @Success 200 {string} string "成功吊销令牌的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/token/{tokenID} [DELETE]
+func (ur *UserRouter) deleteAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	tokenID := chi.URLParam(r, util.QueryKeyTokenID)
+	if err := user.DeleteAccessToken(&ctx, userName, tokenID); err != nil {
+		ctx.Logging().Errorf("delete access token failed. userName:%s, error:%s", userName, err.Error())
+		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
@@ -19,6 +19,7 @@ func prepareDBAndAPIForUser(t *testing.T, userName string) (*chi.Mux, string) {
 	config.GlobalServerConfig = &config.ServerConfig{
 		ApiServer: config.ApiServerConfig{
 			TokenExpirationHour: -1,
+			TokenSigningKeys:    []config.TokenSigningKey{{ID: "key-1", Secret: "secret-1"}},
 		},
 	}
 	driver.InitMockDB()
@@ -49,6 +49,7 @@ type ServerConfig struct {
 	Fs        FsServerConf                   `yaml:"fs"`
 	ImageConf ImageConfig                    `yaml:"imageRepository"`
 	Monitor   PrometheusConfig               `yaml:"monitor"`
+	Notify    NotificationConfig             `yaml:"notification"`
 }
 
 type StorageConfig struct {
@@ -71,6 +72,17 @@ type ApiServerConfig struct {
 	Host                string `yaml:"host"`
 	Port                int    `yaml:"port"`
 	TokenExpirationHour int    `yaml:"tokenExpirationHour"`
+	// TokenSigningKeys are the keys to sign and verify tokens, new tokens are signed by TokenSigningKeyID
+	TokenSigningKeys []TokenSigningKey `yaml:"tokenSigningKeys"`
+	// TokenSigningKeyFile is a yaml file of a list of signing keys, which are added to TokenSigningKeys
+	TokenSigningKeyFile string `yaml:"tokenSigningKeyFile"`
+	// TokenSigningKeyID is the id of key to sign new tokens, the first key is used if it is empty
+	TokenSigningKeyID string `yaml:"tokenSigningKeyID"`
+}
+
+type TokenSigningKey struct {
+	ID     string `yaml:"id"`
+	Secret string `yaml:"secret" json:"-"`
 }
 
 type JobConfig struct {
@@ -86,6 +98,8 @@ type JobConfig struct {
 	// DefaultJobYamlDir is directory that stores default template yaml files for job
 	DefaultJobYamlDir string `yaml:"defaultJobYamlDir"`
 	IsSingleCluster   bool   `yaml:"isSingleCluster"`
+	// LocalJobDir is directory that stores working files and logs for jobs on local cluster
+	LocalJobDir string `yaml:"localJobDir"`
 }
 
 type FsServerConf struct {
@@ -113,6 +127,25 @@ type ImageConfig struct {
 	RemoveLocalImage bool   `yaml:"removeLocalImage"`
 }
 
+type NotificationConfig struct {
+	// MaxAttempts is the max times to deliver a notification, including the first one
+	MaxAttempts int `yaml:"maxAttempts"`
+	// RetryIntervalSeconds is the interval before the first retry, it grows linearly with attempts, pending
+	// deliveries are checked at the same interval
+	RetryIntervalSeconds int `yaml:"retryIntervalSeconds"`
+	// TimeoutSeconds is the timeout of a single delivery
+	TimeoutSeconds int `yaml:"timeoutSeconds"`
+	// FileNotifierDir is the dir which file notifiers of non-root users write under, only root users can set
+	// the path of file notifiers if it is empty
+	FileNotifierDir string `yaml:"fileNotifierDir"`
+	// WebhookAllowList are the hosts or CIDRs which webhooks and smtp servers of email notifiers can be,
+	// even if they are internal addresses
+	WebhookAllowList []string `yaml:"webhookAllowList"`
+	// WebhookDenyList are the hosts or CIDRs which webhooks and smtp servers of email notifiers can not be,
+	// besides the loopback, link-local, private and cluster internal addresses
+	WebhookDenyList []string `yaml:"webhookDenyList"`
+}
+
 type PrometheusConfig struct {
 	Server              string `yaml:"server"`
 	ExporterServicePort int    `yaml:"exporterServicePort"`
@@ -17,6 +17,8 @@ limitations under the License.
 package api
 
 import (
+	"io"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
@@ -62,6 +64,27 @@ type CacheReportParams struct {
 	CacheDir  string `json:"cacheDir"`
 	NodeName  string `json:"nodename"`
 	UsedSize  int    `json:"usedsize"`
+	// Warmups reports progress of warm-up tasks running on the node
+	Warmups []WarmupReport `json:"warmups,omitempty"`
+}
+
+type WarmupReport struct {
+	WarmupID string `json:"warmupID"`
+	Status   string `json:"status"`
+	Files    int64  `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	Message  string `json:"message"`
+}
+
+// WarmupTask is a warm-up task dispatched to the cache-worker, paths are relative to the root of fs
+type WarmupTask struct {
+	WarmupID    string   `json:"warmupID"`
+	Paths       []string `json:"paths"`
+	Concurrency int      `json:"concurrency"`
+}
+
+type CacheReportResponse struct {
+	Warmups []WarmupTask `json:"warmups"`
 }
 
 type LinksParams struct {
@@ -250,13 +273,17 @@ func FsMountDelete(req DeleteMountRequest, c *core.PaddleFlowClient) error {
 	return err
 }
 
-func CacheReportRequest(req CacheReportParams, c *core.PaddleFlowClient) error {
+func CacheReportRequest(req CacheReportParams, c *core.PaddleFlowClient) (*CacheReportResponse, error) {
+	resp := &CacheReportResponse{}
 	err := core.NewRequestBuilder(c).
 		WithHeader(common.HeaderKeyAuthorization, req.Token).
 		WithURL(CacheReportConfig).
-		WithBody(req).WithMethod(http.POST).Do()
-	if err != nil {
-		return err
+		WithBody(req).WithMethod(http.POST).
+		WithResult(resp).
+		Do()
+	// servers without warm-up support respond with an empty body
+	if err != nil && err != io.EOF {
+		return nil, err
 	}
-	return nil
+	return resp, nil
 }
@@ -42,6 +42,9 @@ var (
 	EQuotaGVK    = schema.GroupVersionKind{Group: "scheduling.volcano.sh", Version: "v1beta1", Kind: "ElasticResourceQuota"}
 	SparkAppGVK  = schema.GroupVersionKind{Group: "sparkoperator.k8s.io", Version: "v1beta2", Kind: "SparkApplication"}
 	PaddleJobGVK = schema.GroupVersionKind{Group: "batch.paddlepaddle.org", Version: "v1", Kind: "PaddleJob"}
+	// PyTorchJobGVK and TFJobGVK defines GVK for kubeflow training-operator jobs
+	PyTorchJobGVK = schema.GroupVersionKind{Group: "kubeflow.org", Version: "v1", Kind: "PyTorchJob"}
+	TFJobGVK      = schema.GroupVersionKind{Group: "kubeflow.org", Version: "v1", Kind: "TFJob"}
 	// ArgoWorkflowGVK defines GVK for argo Workflow
 	ArgoWorkflowGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Workflow"}
 
@@ -50,6 +53,8 @@ var (
 		VCJobGVK:        VCJobStatus,
 		SparkAppGVK:     SparkAppStatus,
 		PaddleJobGVK:    PaddleJobStatus,
+		PyTorchJobGVK:   PyTorchJobStatus,
+		TFJobGVK:        TFJobStatus,
 		PodGVK:          SingleJobStatus,
 		ArgoWorkflowGVK: ArgoWorkflowStatus,
 	}
@@ -68,6 +73,10 @@ func GetJobTypeAndFramework(gvk schema.GroupVersionKind) (commomschema.JobType,
 		return commomschema.TypeDistributed, commomschema.FrameworkSpark
 	case PaddleJobGVK:
 		return commomschema.TypeDistributed, commomschema.FrameworkPaddle
+	case PyTorchJobGVK:
+		return commomschema.TypeDistributed, commomschema.FrameworkPytorch
+	case TFJobGVK:
+		return commomschema.TypeDistributed, commomschema.FrameworkTF
 	default:
 		log.Errorf("GroupVersionKind %s is not support", gvk)
 		return "", ""
@@ -250,7 +259,11 @@ func getDistributedJobGVK(framework commomschema.Framework) (schema.GroupVersion
 		gvk = PaddleJobGVK
 	case commomschema.FrameworkSpark:
 		gvk = SparkAppGVK
-	case commomschema.FrameworkMPI, commomschema.FrameworkTF, commomschema.FrameworkPytorch:
+	case commomschema.FrameworkPytorch:
+		gvk = PyTorchJobGVK
+	case commomschema.FrameworkTF:
+		gvk = TFJobGVK
+	case commomschema.FrameworkMPI:
 		err = fmt.Errorf("framework %s is not implemented", framework)
 	default:
 		err = fmt.Errorf("framework %s is not supported", framework)
@@ -41,6 +41,14 @@ var DiscoveryHandlerFunc = http.HandlerFunc(func(w http.ResponseWriter, req *htt
 				{Name: "paddlejobs", Namespaced: true, Kind: "PaddleJob"},
 			},
 		}
+	case "/apis/kubeflow.org/v1":
+		obj = &metav1.APIResourceList{
+			GroupVersion: "kubeflow.org/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pytorchjobs", Namespaced: true, Kind: "PyTorchJob"},
+				{Name: "tfjobs", Namespaced: true, Kind: "TFJob"},
+			},
+		}
 	case "/apis/argoproj.io/v1alpha1":
 		obj = &metav1.APIResourceList{
 			GroupVersion: "argoproj.io/v1alpha1",
@@ -90,6 +98,12 @@ var DiscoveryHandlerFunc = http.HandlerFunc(func(w http.ResponseWriter, req *htt
 						{GroupVersion: "batch.paddlepaddle.org/v1", Version: "v1"},
 					},
 				},
+				{
+					Name: "kubeflow.org",
+					Versions: []metav1.GroupVersionForDiscovery{
+						{GroupVersion: "kubeflow.org/v1", Version: "v1"},
+					},
+				},
 				{
 					Name: "argoproj.io",
 					Versions: []metav1.GroupVersionForDiscovery{
@@ -29,6 +29,7 @@ import (
 	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
 
 	sparkoperatorv1beta2 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
+	kubeflowv1 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/training-operator/kubeflow.org/v1"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 )
 
@@ -41,6 +42,8 @@ func ConvertToStatus(obj interface{}, gvk k8sschema.GroupVersionKind) (interface
 		realStatus = &batchv1alpha1.JobStatus{}
 	case PaddleJobGVK:
 		realStatus = &paddlejobv1.PaddleJobStatus{}
+	case PyTorchJobGVK, TFJobGVK:
+		realStatus = &kubeflowv1.JobStatus{}
 	case ArgoWorkflowGVK:
 		realStatus = &wfv1.WorkflowStatus{}
 	case PodGVK:
@@ -231,6 +234,65 @@ func getPaddleJobStatus(phase paddlejobv1.PaddleJobPhase) (schema.JobStatus, str
 	return status, msg, nil
 }
 
+// PyTorchJobStatus get pytorch job status, message from interface{}, and covert to JobStatus
+func PyTorchJobStatus(obj interface{}) (StatusInfo, error) {
+	return kubeflowJobStatus(obj, PyTorchJobGVK, "pytorch job")
+}
+
+// TFJobStatus get tensorflow job status, message from interface{}, and covert to JobStatus
+func TFJobStatus(obj interface{}) (StatusInfo, error) {
+	return kubeflowJobStatus(obj, TFJobGVK, "tensorflow job")
+}
+
+func kubeflowJobStatus(obj interface{}, gvk k8sschema.GroupVersionKind, kind string) (StatusInfo, error) {
+	status, err := ConvertToStatus(obj, gvk)
+	if err != nil {
+		log.Errorf("convert %s status failed, err: %v", gvk.Kind, err)
+		return StatusInfo{}, err
+	}
+	jobStatus := status.(*kubeflowv1.JobStatus)
+	condType, state, msg, err := getKubeflowJobStatus(jobStatus, kind)
+	if err != nil {
+		log.Errorf("get %s status failed, err: %v", gvk.Kind, err)
+		return StatusInfo{}, err
+	}
+	log.Infof("%s status: %s", gvk.Kind, state)
+	return StatusInfo{
+		OriginStatus: string(condType),
+		Status:       state,
+		Message:      msg,
+	}, nil
+}
+
+// getKubeflowJobStatus converts the latest true condition of kubeflow job to JobStatus
+func getKubeflowJobStatus(jobStatus *kubeflowv1.JobStatus, kind string) (kubeflowv1.JobConditionType, schema.JobStatus, string, error) {
+	status := schema.JobStatus("")
+	msg := ""
+	var condType kubeflowv1.JobConditionType
+	for _, condition := range jobStatus.Conditions {
+		if condition.Status == v1.ConditionTrue {
+			condType = condition.Type
+			msg = condition.Message
+		}
+	}
+	switch condType {
+	case "", kubeflowv1.JobCreated:
+		status = schema.StatusJobPending
+	case kubeflowv1.JobRunning, kubeflowv1.JobRestarting:
+		status = schema.StatusJobRunning
+	case kubeflowv1.JobSucceeded:
+		status = schema.StatusJobSucceeded
+	case kubeflowv1.JobFailed:
+		status = schema.StatusJobFailed
+	default:
+		return condType, status, msg, fmt.Errorf("unexpected %s status: %s", kind, condType)
+	}
+	if msg == "" {
+		msg = fmt.Sprintf("%s is %s", kind, status)
+	}
+	return condType, status, msg, nil
+}
+
 // SingleJobStatus get single job status, message from interface{}, and covert to JobStatus
 func SingleJobStatus(obj interface{}) (StatusInfo, error) {
 	status, err := ConvertToStatus(obj, PodGVK)
@@ -22,11 +22,13 @@ import (
 
 	paddlejobv1 "github.com/paddleflow/paddle-operator/api/v1"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
 
 	sparkoperatorv1beta2 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
+	kubeflowv1 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/training-operator/kubeflow.org/v1"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 )
 
@@ -310,3 +312,101 @@ func TestPaddleJobStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestKubeflowJobStatus(t *testing.T) {
+	condition := func(condType kubeflowv1.JobConditionType, status corev1.ConditionStatus) kubeflowv1.JobCondition {
+		return kubeflowv1.JobCondition{Type: condType, Status: status}
+	}
+	tests := []struct {
+		name               string
+		status             *kubeflowv1.JobStatus
+		statusFunc         GetStatusFunc
+		expectError        error
+		expectStatus       schema.JobStatus
+		expectOriginStatus string
+	}{
+		{
+			name:               "pytorch job without conditions",
+			status:             &kubeflowv1.JobStatus{},
+			statusFunc:         PyTorchJobStatus,
+			expectStatus:       schema.StatusJobPending,
+			expectOriginStatus: "",
+		},
+		{
+			name: "pytorch job state Running",
+			status: &kubeflowv1.JobStatus{
+				Conditions: []kubeflowv1.JobCondition{
+					condition(kubeflowv1.JobCreated, corev1.ConditionTrue),
+					condition(kubeflowv1.JobRunning, corev1.ConditionTrue),
+				},
+			},
+			statusFunc:         PyTorchJobStatus,
+			expectStatus:       schema.StatusJobRunning,
+			expectOriginStatus: string(kubeflowv1.JobRunning),
+		},
+		{
+			name: "pytorch job state Succeeded",
+			status: &kubeflowv1.JobStatus{
+				Conditions: []kubeflowv1.JobCondition{
+					condition(kubeflowv1.JobCreated, corev1.ConditionTrue),
+					condition(kubeflowv1.JobRunning, corev1.ConditionFalse),
+					condition(kubeflowv1.JobSucceeded, corev1.ConditionTrue),
+				},
+			},
+			statusFunc:         PyTorchJobStatus,
+			expectStatus:       schema.StatusJobSucceeded,
+			expectOriginStatus: string(kubeflowv1.JobSucceeded),
+		},
+		{
+			name: "tensorflow job state Created",
+			status: &kubeflowv1.JobStatus{
+				Conditions: []kubeflowv1.JobCondition{
+					condition(kubeflowv1.JobCreated, corev1.ConditionTrue),
+				},
+			},
+			statusFunc:         TFJobStatus,
+			expectStatus:       schema.StatusJobPending,
+			expectOriginStatus: string(kubeflowv1.JobCreated),
+		},
+		{
+			name: "tensorflow job state Failed",
+			status: &kubeflowv1.JobStatus{
+				Conditions: []kubeflowv1.JobCondition{
+					condition(kubeflowv1.JobRunning, corev1.ConditionFalse),
+					condition(kubeflowv1.JobFailed, corev1.ConditionTrue),
+				},
+			},
+			statusFunc:         TFJobStatus,
+			expectStatus:       schema.StatusJobFailed,
+			expectOriginStatus: string(kubeflowv1.JobFailed),
+		},
+		{
+			name: "tensorflow job state unknown",
+			status: &kubeflowv1.JobStatus{
+				Conditions: []kubeflowv1.JobCondition{
+					condition(unknown, corev1.ConditionTrue),
+				},
+			},
+			statusFunc:         TFJobStatus,
+			expectError:        fmt.Errorf("unexpected tensorflow job status: %s", unknown),
+			expectStatus:       "",
+			expectOriginStatus: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(test.status)
+			assert.Equal(t, nil, err)
+			unstructuredObj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"status": obj,
+				},
+			}
+			statusInfo, err := test.statusFunc(unstructuredObj)
+			assert.Equal(t, test.expectError, err)
+			assert.Equal(t, test.expectStatus, statusInfo.Status)
+			assert.Equal(t, test.expectOriginStatus, statusInfo.OriginStatus)
+		})
+	}
+}
@@ -24,6 +24,7 @@ type Framework string
 type MemberRole string
 
 const (
+	EnvJobID          = "PF_JOB_ID"
 	EnvJobType        = "PF_JOB_TYPE"
 	EnvJobQueueName   = "PF_JOB_QUEUE_NAME"
 	EnvJobQueueID     = "PF_JOB_QUEUE_ID"
@@ -222,6 +223,8 @@ type Conf struct {
 	Image       string            `json:"image"`
 	Port        int               `json:"port,omitempty"`
 	Args        []string          `json:"args,omitempty"`
+	// 预计运行时长, 如2h, 用于队列回填调度
+	ExpectedDuration string `json:"expectedDuration,omitempty"`
 }
 
 // FileSystem indicate PaddleFlow
@@ -279,6 +282,14 @@ func (c *Conf) SetPriority(pc string) {
 	c.Priority = pc
 }
 
+func (c *Conf) GetExpectedDuration() string {
+	return c.ExpectedDuration
+}
+
+func (c *Conf) SetExpectedDuration(duration string) {
+	c.ExpectedDuration = duration
+}
+
 func (c *Conf) GetQueueName() string {
 	return c.QueueName
 }
@@ -432,6 +443,26 @@ func (c *Conf) preCheckEnv() {
 	}
 }
 
+// PPLJobConf is the conf of job created by pipeline step, members are required when the framework is distributed
+type PPLJobConf struct {
+	Conf
+	Framework Framework
+	Members   []PPLJobMember
+}
+
+// PPLJobMember is a role of distributed job created by pipeline step
+type PPLJobMember struct {
+	Role     MemberRole
+	Replicas int
+	Flavour  string
+	Command  string
+}
+
+// IsDistributed returns true if the job should be created as a distributed job
+func (c *PPLJobConf) IsDistributed() bool {
+	return c.Framework != "" && c.Framework != FrameworkStandalone
+}
+
 // GetAllFileSystem combine FileSystem and ExtraFileSystem to a slice
 func (c *Conf) GetAllFileSystem() []FileSystem {
 	var fileSystems []FileSystem
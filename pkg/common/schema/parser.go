@@ -133,6 +133,22 @@ func (p *Parser) ParseWorkflowSource(bodyMap map[string]interface{}, wfs *Workfl
 				return err
 			}
 			wfs.FsOptions = fsOptions
+		case "retry":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[retry] of workflow should be map[string]interface{} type")
+			}
+			retry := RetryPolicy{}
+			if err := p.ParseRetry(value, &retry); err != nil {
+				return fmt.Errorf("parse [retry] in workflow failed, error: %s", err.Error())
+			}
+			wfs.Retry = &retry
+		case "timeout":
+			value, ok := value.(int64)
+			if !ok || value < 0 {
+				return fmt.Errorf("[timeout] of workflow should be non-negative int type")
+			}
+			wfs.Timeout = int(value)
 		default:
 			return fmt.Errorf("workflow has no attribute [%s]", key)
 		}
@@ -329,6 +345,70 @@ func (p *Parser) ParseStep(params map[string]interface{}, step *WorkflowSourceSt
 			if valueLower != "step" {
 				return fmt.Errorf("set [type] as [%s] in step", value)
 			}
+		case "retry":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[retry] in step should be map type")
+			}
+			retry := RetryPolicy{}
+			if err := p.ParseRetry(value, &retry); err != nil {
+				return fmt.Errorf("parse retry in step failed, error: %s", err.Error())
+			}
+			step.Retry = &retry
+		case "timeout":
+			value, ok := value.(int64)
+			if !ok || value < 0 {
+				return fmt.Errorf("[timeout] in step should be non-negative int type")
+			}
+			step.Timeout = int(value)
+		case "parallelism":
+			value, ok := value.(int64)
+			if !ok || value < 0 {
+				return fmt.Errorf("[parallelism] in step should be non-negative int type")
+			}
+			step.Parallelism = int(value)
+		case "flavour":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[flavour] in step should be string type")
+			}
+			step.Flavour = value
+		case "queue":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[queue] in step should be string type")
+			}
+			step.Queue = value
+		case "priority":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[priority] in step should be string type")
+			}
+			step.Priority = value
+		case "framework":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[framework] in step should be string type")
+			}
+			step.Framework = value
+		case "members":
+			value, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("[members] in step should be list type")
+			}
+			members := []StepMember{}
+			for _, m := range value {
+				memberMap, ok := m.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("each member in [members] should be map type")
+				}
+				member := StepMember{}
+				if err := p.ParseStepMember(memberMap, &member); err != nil {
+					return fmt.Errorf("parse [members] in step failed, error: %s", err.Error())
+				}
+				members = append(members, member)
+			}
+			step.Members = members
 		default:
 			return fmt.Errorf("step has no attribute [%s]", key)
 		}
@@ -420,6 +500,28 @@ func (p *Parser) ParseDag(params map[string]interface{}, dagComp *WorkflowSource
 			if valueLower != "dag" {
 				return fmt.Errorf("set [type] as [%s] in dag", value)
 			}
+		case "retry":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[retry] in dag should be map type")
+			}
+			retry := RetryPolicy{}
+			if err := p.ParseRetry(value, &retry); err != nil {
+				return fmt.Errorf("parse retry in dag failed, error: %s", err.Error())
+			}
+			dagComp.Retry = &retry
+		case "timeout":
+			value, ok := value.(int64)
+			if !ok || value < 0 {
+				return fmt.Errorf("[timeout] in dag should be non-negative int type")
+			}
+			dagComp.Timeout = int(value)
+		case "parallelism":
+			value, ok := value.(int64)
+			if !ok || value < 0 {
+				return fmt.Errorf("[parallelism] in dag should be non-negative int type")
+			}
+			dagComp.Parallelism = int(value)
 		default:
 			return fmt.Errorf("dag has no attribute [%s]", key)
 		}
@@ -465,6 +567,12 @@ func (p *Parser) ParseCache(cacheMap map[string]interface{}, cache *Cache) error
 			}
 			// 这里这样使用append是为了让后解析的FsScope列表中的元素，排在前面
 			cache.FsScope = append(fsScopeList, cache.FsScope...)
+		case "strategy":
+			cacheValue, ok := cacheValue.(string)
+			if !ok {
+				return fmt.Errorf("[cache.strategy] should be string type")
+			}
+			cache.Strategy = cacheValue
 		default:
 			return fmt.Errorf("[cache] has no attribute [%s]", cacheKey)
 		}
@@ -472,6 +580,109 @@ func (p *Parser) ParseCache(cacheMap map[string]interface{}, cache *Cache) error
 	return nil
 }
 
+func (p *Parser) ParseRetry(retryMap map[string]interface{}, retry *RetryPolicy) error {
+	for retryKey, retryValue := range retryMap {
+		switch retryKey {
+		case "max_attempts":
+			retryValue, ok := retryValue.(int64)
+			if !ok {
+				return fmt.Errorf("[retry.max_attempts] should be int type")
+			}
+			retry.MaxAttempts = int(retryValue)
+		case "backoff":
+			retryValue, ok := retryValue.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[retry.backoff] should be map type")
+			}
+			if err := p.ParseBackoff(retryValue, &retry.Backoff); err != nil {
+				return err
+			}
+		case "retry_on":
+			retryValue, ok := retryValue.([]interface{})
+			if !ok {
+				return fmt.Errorf("[retry.retry_on] should be list type")
+			}
+			retryOn := []string{}
+			for _, status := range retryValue {
+				status, ok := status.(string)
+				if !ok {
+					return fmt.Errorf("[retry.retry_on] should be list of string type")
+				}
+				retryOn = append(retryOn, status)
+			}
+			retry.RetryOn = retryOn
+		default:
+			return fmt.Errorf("[retry] has no attribute [%s]", retryKey)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) ParseBackoff(backoffMap map[string]interface{}, backoff *Backoff) error {
+	for key, value := range backoffMap {
+		switch key {
+		case "duration":
+			value, ok := value.(int64)
+			if !ok {
+				return fmt.Errorf("[retry.backoff.duration] should be int type")
+			}
+			backoff.Duration = int(value)
+		case "factor":
+			switch value := value.(type) {
+			case int64:
+				backoff.Factor = float64(value)
+			case float64:
+				backoff.Factor = value
+			default:
+				return fmt.Errorf("[retry.backoff.factor] should be int/float type")
+			}
+		case "max_duration":
+			value, ok := value.(int64)
+			if !ok {
+				return fmt.Errorf("[retry.backoff.max_duration] should be int type")
+			}
+			backoff.MaxDuration = int(value)
+		default:
+			return fmt.Errorf("[retry.backoff] has no attribute [%s]", key)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) ParseStepMember(memberMap map[string]interface{}, member *StepMember) error {
+	for key, value := range memberMap {
+		switch key {
+		case "role":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[role] should be string type")
+			}
+			member.Role = value
+		case "replicas":
+			value, ok := value.(int64)
+			if !ok {
+				return fmt.Errorf("[replicas] should be int type")
+			}
+			member.Replicas = int(value)
+		case "flavour":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[flavour] should be string type")
+			}
+			member.Flavour = value
+		case "command":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[command] should be string type")
+			}
+			member.Command = value
+		default:
+			return fmt.Errorf("[members] has no attribute [%s]", key)
+		}
+	}
+	return nil
+}
+
 func (p *Parser) ParseFsScope(fsMap map[string]interface{}, fs *FsScope) error {
 	for key, value := range fsMap {
 		switch key {
@@ -616,6 +827,10 @@ func (p *Parser) TransJsonMap2Yaml(jsonMap map[string]interface{}) error {
 			}
 			jsonMap["fs_options"] = value
 			delete(jsonMap, "fsOptions")
+		case "retry":
+			if err := p.transJsonRetry2Yaml(value); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -639,6 +854,33 @@ func (p *Parser) transJsonCache2Yaml(value interface{}) error {
 	return nil
 }
 
+func (p *Parser) transJsonRetry2Yaml(value interface{}) error {
+	retryMap, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("[retry] should be map type")
+	}
+	for retryKey, retryValue := range retryMap {
+		switch retryKey {
+		case "maxAttempts":
+			retryMap["max_attempts"] = retryValue
+			delete(retryMap, "maxAttempts")
+		case "retryOn":
+			retryMap["retry_on"] = retryValue
+			delete(retryMap, "retryOn")
+		case "backoff":
+			backoffMap, ok := retryValue.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[retry.backoff] should be map type")
+			}
+			if maxDuration, ok := backoffMap["maxDuration"]; ok {
+				backoffMap["max_duration"] = maxDuration
+				delete(backoffMap, "maxDuration")
+			}
+		}
+	}
+	return nil
+}
+
 func (p *Parser) transJsonExtraFS2Yaml(value interface{}) error {
 	mountList, ok := value.([]interface{})
 	if !ok {
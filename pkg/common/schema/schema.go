@@ -51,6 +51,17 @@ type JobView struct {
 	JobMessage  string            `json:"jobMessage"`
 	CacheRunID  string            `json:"cacheRunID"`
 	CacheJobID  string            `json:"cacheJobID"`
+	Attempt     int               `json:"attempt"`
+	Attempts    []JobAttempt      `json:"attempts,omitempty"`
+}
+
+// JobAttempt is a finished attempt of job which has been retried according to the retry policy of step
+type JobAttempt struct {
+	JobID     string    `json:"jobID"`
+	Status    JobStatus `json:"status"`
+	Message   string    `json:"message"`
+	StartTime string    `json:"startTime"`
+	EndTime   string    `json:"endTime"`
 }
 
 func (j JobView) GetComponentName() string {
@@ -19,8 +19,10 @@ package schema
 import (
 	"encoding/base64"
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"gopkg.in/yaml.v2"
@@ -38,10 +40,14 @@ const (
 	CacheAttributeEnable         = "enable"
 	CacheAttributeMaxExpiredTime = "max_expired_time"
 	CacheAttributeFsScope        = "fs_scope"
+	CacheAttributeStrategy       = "strategy"
 
 	FailureStrategyFailFast = "fail_fast"
 	FailureStrategyContinue = "continue"
 
+	RetryOnFailed     = "failed"
+	RetryOnTerminated = "terminated"
+
 	EnvDockerEnv = "dockerEnv"
 
 	FsPrefix = "fs-"
@@ -145,6 +151,22 @@ type WorkflowSourceStep struct {
 	Cache        Cache                  `yaml:"cache"`
 	Reference    Reference              `yaml:"reference"`
 	ExtraFS      []FsMount              `yaml:"extra_fs"`
+	Retry        *RetryPolicy           `yaml:"retry,omitempty"`
+	Timeout      int                    `yaml:"timeout"`     // seconds, 0 means no timeout
+	Parallelism  int                    `yaml:"parallelism"` // max concurrent runs of loop, 0 means no limit
+	Flavour      string                 `yaml:"flavour"`
+	Queue        string                 `yaml:"queue"`
+	Priority     string                 `yaml:"priority"`
+	Framework    string                 `yaml:"framework"`
+	Members      []StepMember           `yaml:"members"`
+}
+
+// StepMember 为分布式 step 中某一角色的配置，flavour 和 command 为空时，使用 step 中的配置
+type StepMember struct {
+	Role     string `yaml:"role"     json:"role"`
+	Replicas int    `yaml:"replicas" json:"replicas"`
+	Flavour  string `yaml:"flavour"  json:"flavour"`
+	Command  string `yaml:"command"  json:"command"`
 }
 
 func (s *WorkflowSourceStep) GetName() string {
@@ -278,6 +300,11 @@ func (s *WorkflowSourceStep) DeepCopy() Component {
 
 	fsMount := append(s.ExtraFS, []FsMount{}...)
 
+	var members []StepMember
+	if s.Members != nil {
+		members = append([]StepMember{}, s.Members...)
+	}
+
 	ns := &WorkflowSourceStep{
 		Name:         s.Name,
 		LoopArgument: s.LoopArgument,
@@ -291,6 +318,14 @@ func (s *WorkflowSourceStep) DeepCopy() Component {
 		Cache:        s.Cache,
 		Reference:    s.Reference,
 		ExtraFS:      fsMount,
+		Retry:        s.Retry.DeepCopy(),
+		Timeout:      s.Timeout,
+		Parallelism:  s.Parallelism,
+		Flavour:      s.Flavour,
+		Queue:        s.Queue,
+		Priority:     s.Priority,
+		Framework:    s.Framework,
+		Members:      members,
 	}
 
 	return ns
@@ -305,6 +340,9 @@ type WorkflowSourceDag struct {
 	Deps         string                 `yaml:"deps"`
 	Artifacts    Artifacts              `yaml:"artifacts"`
 	EntryPoints  map[string]Component   `yaml:"entry_points"`
+	Retry        *RetryPolicy           `yaml:"retry,omitempty"`
+	Timeout      int                    `yaml:"timeout"`     // seconds, 0 means no timeout
+	Parallelism  int                    `yaml:"parallelism"` // max concurrent runs of loop, 0 means no limit
 }
 
 func (d *WorkflowSourceDag) GetName() string {
@@ -449,6 +487,9 @@ func (d *WorkflowSourceDag) DeepCopy() Component {
 		Deps:         d.Deps,
 		Artifacts:    *d.Artifacts.DeepCopy(),
 		EntryPoints:  ep,
+		Retry:        d.Retry.DeepCopy(),
+		Timeout:      d.Timeout,
+		Parallelism:  d.Parallelism,
 	}
 
 	return nd
@@ -457,6 +498,7 @@ func (d *WorkflowSourceDag) DeepCopy() Component {
 type RunOptions struct {
 	FSUsername string
 	StopForce  bool
+	Trigger    string
 }
 
 type Reference struct {
@@ -467,6 +509,7 @@ type Cache struct {
 	Enable         bool      `yaml:"enable"           json:"enable"`
 	MaxExpiredTime string    `yaml:"max_expired_time" json:"maxExpiredTime"` // seconds
 	FsScope        []FsScope `yaml:"fs_scope"         json:"fsScope"`        // seperated by ","
+	Strategy       string    `yaml:"strategy"         json:"strategy"`       // conservative or content
 }
 
 type FsScope struct {
@@ -479,6 +522,75 @@ type FailureOptions struct {
 	Strategy string `yaml:"strategy"     json:"strategy"`
 }
 
+// RetryPolicy 节点运行失败后的重试策略，可以在全局、dag 以及 step 中设置
+// dag 中的设置作用于其内部没有设置重试策略的 step，全局设置同理，dag 本身不会被重试
+type RetryPolicy struct {
+	MaxAttempts int      `yaml:"max_attempts" json:"maxAttempts"` // 最大运行次数，包括第一次运行
+	Backoff     Backoff  `yaml:"backoff"      json:"backoff"`
+	RetryOn     []string `yaml:"retry_on"     json:"retryOn"` // 可重试的终态，默认只有 failed
+}
+
+// Backoff 第 n 次重试前等待 duration * factor^(n-1) 秒，如果设置了 max_duration，则等待时间不超过 max_duration 秒
+type Backoff struct {
+	Duration    int     `yaml:"duration"     json:"duration"`
+	Factor      float64 `yaml:"factor"       json:"factor"`
+	MaxDuration int     `yaml:"max_duration" json:"maxDuration"`
+}
+
+func (rp *RetryPolicy) Validate() error {
+	if rp.MaxAttempts < 1 {
+		return fmt.Errorf("[retry.max_attempts] should be greater than 0, setted by [%d]", rp.MaxAttempts)
+	}
+	if rp.Backoff.Duration < 0 || rp.Backoff.MaxDuration < 0 {
+		return fmt.Errorf("[retry.backoff.duration] and [retry.backoff.max_duration] should not be negative")
+	}
+	if rp.Backoff.Factor != 0 && rp.Backoff.Factor < 1 {
+		return fmt.Errorf("[retry.backoff.factor] should not be less than 1, setted by [%v]", rp.Backoff.Factor)
+	}
+	for _, status := range rp.RetryOn {
+		if status != RetryOnFailed && status != RetryOnTerminated {
+			return fmt.Errorf("[retry.retry_on] should be [%s] or [%s], setted by [%s]",
+				RetryOnFailed, RetryOnTerminated, status)
+		}
+	}
+	return nil
+}
+
+// IsRetryable 判断处于终态 status 的节点是否可以重试
+func (rp *RetryPolicy) IsRetryable(status JobStatus) bool {
+	if len(rp.RetryOn) == 0 {
+		return status == StatusJobFailed
+	}
+	for _, retryOn := range rp.RetryOn {
+		if string(status) == retryOn {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBackoff 获取第 retried 次重试前需要等待的时间，retried 从 1 开始计算
+func (rp *RetryPolicy) GetBackoff(retried int) time.Duration {
+	factor := rp.Backoff.Factor
+	if factor == 0 {
+		factor = 1
+	}
+	seconds := float64(rp.Backoff.Duration) * math.Pow(factor, float64(retried-1))
+	if rp.Backoff.MaxDuration > 0 && seconds > float64(rp.Backoff.MaxDuration) {
+		seconds = float64(rp.Backoff.MaxDuration)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (rp *RetryPolicy) DeepCopy() *RetryPolicy {
+	if rp == nil {
+		return nil
+	}
+	nrp := *rp
+	nrp.RetryOn = append([]string{}, rp.RetryOn...)
+	return &nrp
+}
+
 type FsOptions struct {
 	MainFS  FsMount   `yaml:"main_fs"      json:"mainFS"`
 	ExtraFS []FsMount `yaml:"extra_fs"     json:"extraFS,omitempty"`
@@ -503,6 +615,8 @@ type WorkflowSource struct {
 	FailureOptions FailureOptions                 `yaml:"failure_options"`
 	PostProcess    map[string]*WorkflowSourceStep `yaml:"post_process"`
 	FsOptions      FsOptions                      `yaml:"fs_options"`
+	Retry          *RetryPolicy                   `yaml:"retry,omitempty"`
+	Timeout        int                            `yaml:"timeout"` // seconds, 0 means no timeout
 }
 
 func (wfs *WorkflowSource) GetDisabled() []string {
@@ -651,9 +765,32 @@ func GetWorkflowSourceByMap(yamlMap map[string]interface{}) (WorkflowSource, err
 			return WorkflowSource{}, err
 		}
 	}
+
+	// 全局重试策略替换
+	ProcessRetryPolicy(wfs.EntryPoints.EntryPoints, wfs.Retry)
+	ProcessRetryPolicy(postComponentsMap, wfs.Retry)
+	ProcessRetryPolicy(wfs.Components, wfs.Retry)
 	return wfs, nil
 }
 
+// 对没有设置重试策略的Step，使用其最近的祖先Dag或者全局的重试策略（节点Retry字段优先级大于Dag与全局Retry字段）
+func ProcessRetryPolicy(components map[string]Component, retry *RetryPolicy) {
+	for _, component := range components {
+		if dag, ok := component.(*WorkflowSourceDag); ok {
+			dagRetry := retry
+			if dag.Retry != nil {
+				dagRetry = dag.Retry
+			}
+			ProcessRetryPolicy(dag.EntryPoints, dagRetry)
+		} else if step, ok := component.(*WorkflowSourceStep); ok {
+			// Reference节点使用被引用节点的重试策略
+			if step.Retry == nil && step.Reference.Component == "" {
+				step.Retry = retry.DeepCopy()
+			}
+		}
+	}
+}
+
 // 对Step的DockerEnv、Cache进行全局替换
 func (wfs *WorkflowSource) ProcessRuntimeComponents(components map[string]Component, componentType string,
 	yamlMap map[string]interface{}, componentsMap map[string]interface{}) error {
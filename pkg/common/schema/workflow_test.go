@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -65,3 +66,190 @@ func TestDagDeepCopy(t *testing.T) {
 	fmt.Println("in loop:", loop.GetLoopArgument())
 	fmt.Println("in loop2:", loop2.GetLoopArgument())
 }
+
+func TestRetryPolicy(t *testing.T) {
+	runYaml := `
+name: retry
+docker_env: images/training.tgz
+retry:
+  max_attempts: 2
+entry_points:
+  step1:
+    command: "echo step1"
+    retry:
+      max_attempts: 3
+      backoff: {duration: 10, factor: 2, max_duration: 30}
+      retry_on: ["failed", "terminated"]
+  dag1:
+    retry:
+      max_attempts: 4
+    entry_points:
+      step2:
+        command: "echo step2"
+  step3:
+    command: "echo step3"
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+
+	step1 := wfs.EntryPoints.EntryPoints["step1"].(*WorkflowSourceStep)
+	assert.Equal(t, 3, step1.Retry.MaxAttempts)
+	assert.Nil(t, step1.Retry.Validate())
+	assert.True(t, step1.Retry.IsRetryable(StatusJobTerminated))
+	assert.Equal(t, 10*time.Second, step1.Retry.GetBackoff(1))
+	assert.Equal(t, 20*time.Second, step1.Retry.GetBackoff(2))
+	assert.Equal(t, 30*time.Second, step1.Retry.GetBackoff(3))
+
+	dag1 := wfs.EntryPoints.EntryPoints["dag1"].(*WorkflowSourceDag)
+	step2 := dag1.EntryPoints["step2"].(*WorkflowSourceStep)
+	assert.Equal(t, 4, step2.Retry.MaxAttempts)
+	step2Copy := step2.DeepCopy().(*WorkflowSourceStep)
+	step2Copy.Retry.MaxAttempts = 1
+	assert.Equal(t, 4, step2.Retry.MaxAttempts)
+
+	step3 := wfs.EntryPoints.EntryPoints["step3"].(*WorkflowSourceStep)
+	assert.Equal(t, 2, step3.Retry.MaxAttempts)
+	assert.True(t, step3.Retry.IsRetryable(StatusJobFailed))
+	assert.False(t, step3.Retry.IsRetryable(StatusJobTerminated))
+	assert.Equal(t, time.Duration(0), step3.Retry.GetBackoff(1))
+
+	invalid := &RetryPolicy{MaxAttempts: 2, RetryOn: []string{"succeeded"}}
+	assert.NotNil(t, invalid.Validate())
+	invalid = &RetryPolicy{MaxAttempts: 0}
+	assert.NotNil(t, invalid.Validate())
+
+	_, err = GetWorkflowSource([]byte(`
+name: retry
+entry_points:
+  step1:
+    command: "echo step1"
+    retry:
+      attempts: 3
+`))
+	assert.NotNil(t, err)
+}
+
+func TestTimeout(t *testing.T) {
+	runYaml := `
+name: timeout
+docker_env: images/training.tgz
+timeout: 3600
+entry_points:
+  step1:
+    command: "echo step1"
+    timeout: 60
+  dag1:
+    timeout: 600
+    entry_points:
+      step2:
+        command: "echo step2"
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+	assert.Equal(t, 3600, wfs.Timeout)
+
+	step1 := wfs.EntryPoints.EntryPoints["step1"].(*WorkflowSourceStep)
+	assert.Equal(t, 60, step1.Timeout)
+	assert.Equal(t, 60, step1.DeepCopy().(*WorkflowSourceStep).Timeout)
+
+	dag1 := wfs.EntryPoints.EntryPoints["dag1"].(*WorkflowSourceDag)
+	assert.Equal(t, 600, dag1.Timeout)
+	assert.Equal(t, 600, dag1.DeepCopy().(*WorkflowSourceDag).Timeout)
+	assert.Equal(t, 0, dag1.EntryPoints["step2"].(*WorkflowSourceStep).Timeout)
+
+	_, err = GetWorkflowSource([]byte(`
+name: timeout
+entry_points:
+  step1:
+    command: "echo step1"
+    timeout: -1
+`))
+	assert.NotNil(t, err)
+}
+
+func TestLoopParallelism(t *testing.T) {
+	runYaml := `
+name: parallelism
+docker_env: images/training.tgz
+entry_points:
+  step1:
+    command: "echo {{PF_LOOP_ARGUMENT}}"
+    loop_argument: [1, 2, 3]
+    parallelism: 2
+  dag1:
+    loop_argument: [1, 2, 3]
+    parallelism: 1
+    entry_points:
+      step2:
+        command: "echo step2"
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+
+	step1 := wfs.EntryPoints.EntryPoints["step1"].(*WorkflowSourceStep)
+	assert.Equal(t, 2, step1.Parallelism)
+	assert.Equal(t, 2, step1.DeepCopy().(*WorkflowSourceStep).Parallelism)
+
+	dag1 := wfs.EntryPoints.EntryPoints["dag1"].(*WorkflowSourceDag)
+	assert.Equal(t, 1, dag1.Parallelism)
+	assert.Equal(t, 1, dag1.DeepCopy().(*WorkflowSourceDag).Parallelism)
+	assert.Equal(t, 0, dag1.EntryPoints["step2"].(*WorkflowSourceStep).Parallelism)
+
+	_, err = GetWorkflowSource([]byte(`
+name: parallelism
+entry_points:
+  step1:
+    command: "echo step1"
+    parallelism: -1
+`))
+	assert.NotNil(t, err)
+}
+
+func TestStepJobConf(t *testing.T) {
+	runYaml := `
+name: distributed
+docker_env: images/training.tgz
+entry_points:
+  train:
+    command: "python train.py"
+    flavour: flavour1
+    queue: train-queue
+    priority: high
+    framework: paddle
+    members:
+      - role: pserver
+        replicas: 2
+        flavour: flavour-cpu
+      - role: pworker
+        replicas: 4
+        command: "python worker.py"
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+
+	step := wfs.EntryPoints.EntryPoints["train"].(*WorkflowSourceStep)
+	assert.Equal(t, "flavour1", step.Flavour)
+	assert.Equal(t, "train-queue", step.Queue)
+	assert.Equal(t, "high", step.Priority)
+	assert.Equal(t, "paddle", step.Framework)
+	assert.Equal(t, []StepMember{
+		{Role: "pserver", Replicas: 2, Flavour: "flavour-cpu"},
+		{Role: "pworker", Replicas: 4, Command: "python worker.py"},
+	}, step.Members)
+
+	stepCopy := step.DeepCopy().(*WorkflowSourceStep)
+	assert.Equal(t, step.Members, stepCopy.Members)
+	stepCopy.Members[0].Replicas = 3
+	assert.Equal(t, 2, step.Members[0].Replicas)
+
+	_, err = GetWorkflowSource([]byte(`
+name: distributed
+entry_points:
+  train:
+    command: "python train.py"
+    members:
+      - role: pserver
+        replicas: two
+`))
+	assert.NotNil(t, err)
+}
@@ -273,7 +273,8 @@ func (b *Buffer) readLoop(r ReaderProvider) {
 			break
 		}
 
-		if nread == 0 {
+		// page 写满后立即写入缓存，避免读完后马上关闭文件时丢掉这个 block
+		if nread == 0 || b.page.writeLength >= cap(b.page.buffer) {
 			b.page.ready = true
 			_ = b.reader.Close()
 			b.page.setCache()
@@ -17,15 +17,12 @@ limitations under the License.
 package cache
 
 import (
-	"fmt"
 	"io"
-	"path"
 	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
@@ -42,6 +39,13 @@ type DataCacheClient interface {
 	save(key string, buf []byte)
 	delete(key string)
 	clean()
+	close()
+	// indexed 返回 block 索引是否持久化，没有持久化时重启后无法复用之前的缓存
+	indexed() bool
+	// getFile、setFile、deleteFile 维护文件对应的 block key，客户端重启后仍然可以找到
+	getFile(name string) (fileMeta, bool)
+	setFile(name string, meta fileMeta)
+	deleteFile(name string)
 }
 
 func NewDataCache(config Config) DataCacheClient {
@@ -184,6 +188,10 @@ func (r *rCache) ReadAt(buf []byte, off int64) (n int, err error) {
 		log.Debugf("metrics cacheHits++:%d and index %v blockOff %v and nread %v", nReadFromCache, index, blockOff, nReadFromCache)
 		return nReadFromCache, nil
 	}
+	if r.store.client != nil {
+		cacheMiss.Inc()
+		cacheMissBytes.Add(float64(len(buf)))
+	}
 	err = r.readAhead(index)
 	log.Debugf("read buffers map %v", len(r.buffers))
 	if err == nil {
@@ -204,17 +212,7 @@ func (r *rCache) off(off int) int {
 }
 
 func (r *rCache) key(index int) string {
-	r.store.RLock()
-	keyID, ok := r.store.meta[r.id]
-	r.store.RUnlock()
-	if !ok {
-		r.store.Lock()
-		keyID = uuid.NewString()
-		r.store.meta[r.id] = keyID
-		r.store.Unlock()
-	}
-	hash := utils.KeyHash(keyID)
-	return path.Clean(fmt.Sprintf("blocks/%d/%v_%v", hash%256, keyID, index))
+	return r.store.key(r.store.keyID(r.id, r.length, r.ufs), index)
 }
 
 func (r *rCache) readCache(buf []byte, key string, off int) (int, bool) {
@@ -19,8 +19,11 @@ package cache
 import (
 	"bufio"
 	"bytes"
+	"container/list"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,34 +31,71 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
 )
 
 const (
 	FileClient = "fileClient"
 	CacheDir   = "datacache"
+	// IndexDir 保存 block 索引，客户端重启后可以继续使用之前缓存的 block
+	IndexDir = "dataindex"
+
+	indexFilePrefix  = "file:"
+	indexBlockPrefix = "block:"
 )
 
 var _ DataCacheClient = &fileDataCache{}
 
 type cacheItem struct {
+	key     string
 	size    int64
 	expTime time.Time
 }
 
+// fileMeta 记录文件对应的 block key，以及缓存时文件的长度和修改时间，用于重启后校验缓存是否有效
+type fileMeta struct {
+	KeyID  string `json:"keyID"`
+	Length int64  `json:"length"`
+	Mtime  uint64 `json:"mtime"`
+	// ExpTime 之后如果文件已经没有缓存的 block，索引会被清理
+	ExpTime time.Time `json:"expTime"`
+}
+
+type blockMeta struct {
+	Size    int64     `json:"size"`
+	ExpTime time.Time `json:"expTime"`
+}
+
+// fileDataCache 把 block 缓存在本地磁盘上，按访问顺序(LRU)淘汰。
+// 缓存的容量上限是 quota，磁盘剩余空间不足时同样会淘汰
 type fileDataCache struct {
-	sync.RWMutex
-	dir      string
-	capacity int64
+	sync.Mutex
+	dir    string
+	expire time.Duration
+	// quota 为 0 时只受磁盘剩余空间限制
+	quota    int64
 	used     int64
-	expire   time.Duration
-	keys     sync.Map
+	diskFree int64
+	// lru 队头是最近访问的 block，淘汰从队尾开始
+	lru   *list.List
+	items map[string]*list.Element
+	// index 为 nil 时索引只保存在内存中
+	index kv.Client
+	// removed 记录上次清理文件索引之后淘汰的 block 数
+	removed   int
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func newFileClient(config Config) DataCacheClient {
 	d := &fileDataCache{
 		dir:    config.CachePath,
 		expire: config.Expire,
+		quota:  config.Quota,
+		lru:    list.New(),
+		items:  make(map[string]*list.Element),
+		done:   make(chan struct{}),
 	}
 
 	if err := os.MkdirAll(config.CachePath, 0755); err != nil {
@@ -66,11 +106,20 @@ func newFileClient(config Config) DataCacheClient {
 		log.Errorf("newFileClient d.updateCapacity err: %v", err)
 		return nil
 	}
-	// 后续加stop channel
+	d.index = openIndex(config)
+	d.loadIndex()
+	// 清理索引中不存在的 block，这些 block 无法再被读到
+	d.cleanOrphans()
 	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
 		for {
-			d.clean()
-			time.Sleep(10 * time.Second)
+			select {
+			case <-ticker.C:
+				d.clean()
+			case <-d.done:
+				return
+			}
 		}
 	}()
 
@@ -82,24 +131,33 @@ func (c *fileDataCache) load(key string) (ReadCloser, bool) {
 		return nil, false
 	}
 
-	if !c.exist(key) {
-		return nil, false
-	}
-
-	path := c.cachePath(key)
-	_, err := os.Stat(path)
-
-	if os.IsNotExist(err) {
+	c.Lock()
+	elem, ok := c.items[key]
+	if !ok {
+		c.Unlock()
 		return nil, false
 	}
-
-	if err != nil {
-		log.Errorf("stat cache file[%s] failed: %v", path, err)
+	if time.Now().After(elem.Value.(*cacheItem).expTime) {
+		c.unlink(elem)
+		c.Unlock()
+		cacheEvicts.Inc()
+		c.removeFile(key)
 		return nil, false
 	}
+	c.lru.MoveToFront(elem)
+	c.Unlock()
 
+	path := c.cachePath(key)
 	f, err := os.Open(path)
 	if err != nil {
+		log.Errorf("open cache file[%s] failed: %v", path, err)
+		// 文件已经不存在，索引中的记录也一并删除
+		c.Lock()
+		if elem, ok := c.items[key]; ok {
+			c.unlink(elem)
+		}
+		c.Unlock()
+		c.removeFile(key)
 		return nil, false
 	}
 	return f, true
@@ -109,94 +167,356 @@ func (c *fileDataCache) save(key string, buf []byte) {
 	if c.dir == "" {
 		return
 	}
+	start := time.Now()
 	cacheSize := int64(len(buf))
-	if c.used+cacheSize >= c.capacity {
-		// todo：clean支持带参数，释放多少容量。
-		c.clean()
+	// 先占用容量再写文件，避免并发写入超过 quota
+	c.Lock()
+	reserved, evicted := c.reserve(cacheSize)
+	if !reserved {
+		c.Unlock()
+		c.removeFiles(evicted)
+		cacheDrops.Inc()
+		log.Debugf("data cache is full, skip caching key[%s] of size %d, used %d quota %d diskFree %d",
+			key, cacheSize, c.used, c.quota, c.diskFree)
+		return
 	}
-
-	// 清理之后还是没有足够的容量，则跳过
-	if c.used+cacheSize >= c.capacity {
+	c.used += cacheSize
+	c.diskFree -= cacheSize
+	c.Unlock()
+	c.removeFiles(evicted)
+
+	if err := c.writeFile(key, buf); err != nil {
+		c.Lock()
+		c.used -= cacheSize
+		c.diskFree += cacheSize
+		c.Unlock()
+		cacheDrops.Inc()
 		return
 	}
+
+	c.Lock()
+	// 同一个 key 被并发写入时只保留最后一次的记录，文件已经被覆盖
+	if elem, ok := c.items[key]; ok {
+		c.unlink(elem)
+	}
+	item := &cacheItem{
+		key:     key,
+		size:    cacheSize,
+		expTime: time.Now().Add(c.expire),
+	}
+	c.items[key] = c.lru.PushFront(item)
+	c.Unlock()
+	c.saveBlockMeta(item)
+
+	cacheWrites.Inc()
+	cacheWriteBytes.Add(float64(cacheSize))
+	cacheWriteHist.Observe(time.Since(start).Seconds())
+}
+
+func (c *fileDataCache) writeFile(key string, buf []byte) error {
 	path := c.cachePath(key)
 	c.createDir(filepath.Dir(path))
 	tmp := path + ".tmp"
 	f, err := os.Create(tmp)
 	if err != nil {
 		log.Errorf("open tmp file[%s] failed: %v", tmp, err)
-		return
+		return err
 	}
 	_, err = f.Write(buf)
 	if err != nil {
 		log.Errorf("write tmp file[%s] failed: %v", tmp, err)
 		_ = f.Close()
 		_ = os.Remove(tmp)
-		return
+		return err
 	}
 	err = f.Close()
 	if err != nil {
 		log.Errorf("close tmp file[%s] failed: %v", tmp, err)
 		_ = os.Remove(tmp)
-		return
+		return err
 	}
 	err = os.Rename(tmp, path)
 	if err != nil {
 		log.Errorf("rename file %s -> %s failed: %v", tmp, path, err)
 		_ = os.Remove(tmp)
-		return
+		return err
 	}
-
-	c.keys.Store(key, &cacheItem{
-		expTime: time.Now().Add(c.expire),
-		size:    cacheSize,
-	})
-	return
+	return nil
 }
 
 func (c *fileDataCache) delete(key string) {
+	c.Lock()
+	if elem, ok := c.items[key]; ok {
+		c.unlink(elem)
+	}
+	c.Unlock()
+	c.removeFile(key)
+}
+
+// reserve 从 LRU 队尾开始淘汰，直到可以放下 size 大小的 block，调用方需要持有锁
+// 被淘汰的 block 只从内存索引中删除，调用方需要在释放锁之后通过 removeFiles 删除返回的 key 对应的文件和持久化的索引
+func (c *fileDataCache) reserve(size int64) (bool, []string) {
+	if c.quota > 0 && size > c.quota {
+		return false, nil
+	}
+	var evicted []string
+	for (c.quota > 0 && c.used+size > c.quota) || c.diskFree < size {
+		back := c.lru.Back()
+		if back == nil {
+			return false, evicted
+		}
+		item := c.unlink(back)
+		log.Debugf("evict data cache key[%s] of size %d", item.key, item.size)
+		evicted = append(evicted, item.key)
+	}
+	return true, evicted
+}
+
+// unlink 从索引中删除 block 并释放容量，调用方需要持有锁
+func (c *fileDataCache) unlink(elem *list.Element) *cacheItem {
+	item := c.lru.Remove(elem).(*cacheItem)
+	delete(c.items, item.key)
+	c.removed++
+	c.used -= item.size
+	c.diskFree += item.size
+	return item
+}
+
+// removeFiles 删除被淘汰的 block 的文件和持久化的索引，调用方不能持有锁
+func (c *fileDataCache) removeFiles(keys []string) {
+	for _, key := range keys {
+		cacheEvicts.Inc()
+		c.removeFile(key)
+	}
+}
+
+func (c *fileDataCache) removeFile(key string) {
 	path := c.cachePath(key)
-	_, ok := c.load(key)
-	if ok {
-		c.keys.Delete(key)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Errorf("remove cache file[%s] failed: %v", path, err)
 	}
-	if path != "" {
-		go os.Remove(path)
+	if c.index != nil {
+		if err := c.index.Dels([]byte(indexBlockPrefix + key)); err != nil {
+			log.Errorf("delete data cache index of key[%s] failed: %v", key, err)
+		}
+	}
+}
+
+func openIndex(config Config) kv.Client {
+	// 内存类型的 kv 无法持久化，默认使用 leveldb
+	driver := config.Driver
+	if driver != kv.NutsDB {
+		driver = kv.LevelDB
+	}
+	path := filepath.Join(config.CachePath, IndexDir)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		log.Errorf("create data cache index dir[%s] failed: %v", path, err)
+		return nil
+	}
+	index, err := kv.OpenClient(driver, path)
+	if err != nil {
+		log.Errorf("open data cache index[%s] with driver[%s] failed, the index will not be persisted: %v",
+			path, driver, err)
+		return nil
 	}
+	return index
 }
 
+// loadIndex 加载重启前的 block 索引，过期或者文件已经不存在的 block 会被删除
+func (c *fileDataCache) loadIndex() {
+	if c.index == nil {
+		return
+	}
+	values, err := c.index.ScanValues([]byte(indexBlockPrefix))
+	if err != nil {
+		log.Errorf("scan data cache index failed: %v", err)
+		return
+	}
+	now := time.Now()
+	items := make([]*cacheItem, 0, len(values))
+	var invalid [][]byte
+	for k, v := range values {
+		key := strings.TrimPrefix(k, indexBlockPrefix)
+		meta := blockMeta{}
+		if err := json.Unmarshal(v, &meta); err != nil || now.After(meta.ExpTime) {
+			invalid = append(invalid, []byte(k))
+			continue
+		}
+		info, err := os.Stat(c.cachePath(key))
+		if err != nil || info.Size() != meta.Size {
+			invalid = append(invalid, []byte(k))
+			continue
+		}
+		items = append(items, &cacheItem{key: key, size: meta.Size, expTime: meta.ExpTime})
+	}
+	if len(invalid) > 0 {
+		if err := c.index.Dels(invalid...); err != nil {
+			log.Errorf("delete invalid data cache index failed: %v", err)
+		}
+	}
+	// 没有记录访问时间，按过期时间近似访问顺序，最晚过期的放在队头
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].expTime.Before(items[j].expTime)
+	})
+	c.Lock()
+	for _, item := range items {
+		c.items[item.key] = c.lru.PushFront(item)
+		c.used += item.size
+	}
+	// 重启后 quota 可能变小，超出的部分直接淘汰
+	_, evicted := c.reserve(0)
+	c.Unlock()
+	c.removeFiles(evicted)
+	c.pruneFiles()
+	log.Infof("data cache index loaded %d blocks, %d bytes", c.lru.Len(), c.used)
+}
+
+// pruneFiles 清理已经过期并且没有任何缓存 block 的文件索引，避免索引随着读过的文件不断增长
+func (c *fileDataCache) pruneFiles() {
+	if c.index == nil {
+		return
+	}
+	values, err := c.index.ScanValues([]byte(indexFilePrefix))
+	if err != nil {
+		log.Errorf("scan data cache index of files failed: %v", err)
+		return
+	}
+	c.Lock()
+	c.removed = 0
+	cached := make(map[string]struct{}, len(c.items))
+	for key := range c.items {
+		cached[keyIDOfBlock(key)] = struct{}{}
+	}
+	c.Unlock()
+	now := time.Now()
+	var stale [][]byte
+	for k, v := range values {
+		meta := fileMeta{}
+		if err := json.Unmarshal(v, &meta); err != nil {
+			stale = append(stale, []byte(k))
+			continue
+		}
+		if _, ok := cached[meta.KeyID]; !ok && now.After(meta.ExpTime) {
+			stale = append(stale, []byte(k))
+		}
+	}
+	if len(stale) > 0 {
+		if err := c.index.Dels(stale...); err != nil {
+			log.Errorf("delete stale data cache index of files failed: %v", err)
+		}
+	}
+}
+
+// keyIDOfBlock 从 block key(blocks/hash/keyID_index) 中解析出文件的 keyID
+func keyIDOfBlock(key string) string {
+	base := filepath.Base(key)
+	if i := strings.LastIndex(base, "_"); i > 0 {
+		return base[:i]
+	}
+	return base
+}
+
+func (c *fileDataCache) saveBlockMeta(item *cacheItem) {
+	if c.index == nil {
+		return
+	}
+	value, _ := json.Marshal(blockMeta{Size: item.size, ExpTime: item.expTime})
+	if err := c.index.Set([]byte(indexBlockPrefix+item.key), value); err != nil {
+		log.Errorf("save data cache index of key[%s] failed: %v", item.key, err)
+	}
+}
+
+func (c *fileDataCache) getFile(name string) (fileMeta, bool) {
+	meta := fileMeta{}
+	if c.index == nil {
+		return meta, false
+	}
+	value, ok := c.index.Get([]byte(indexFilePrefix + name))
+	if !ok {
+		return meta, false
+	}
+	if err := json.Unmarshal(value, &meta); err != nil {
+		log.Errorf("unmarshal data cache index of file[%s] failed: %v", name, err)
+		return meta, false
+	}
+	return meta, true
+}
+
+func (c *fileDataCache) indexed() bool {
+	return c.index != nil
+}
+
+func (c *fileDataCache) setFile(name string, meta fileMeta) {
+	if c.index == nil {
+		return
+	}
+	meta.ExpTime = time.Now().Add(c.expire)
+	value, _ := json.Marshal(meta)
+	if err := c.index.Set([]byte(indexFilePrefix+name), value); err != nil {
+		log.Errorf("save data cache index of file[%s] failed: %v", name, err)
+	}
+}
+
+func (c *fileDataCache) deleteFile(name string) {
+	if c.index == nil {
+		return
+	}
+	if err := c.index.Dels([]byte(indexFilePrefix + name)); err != nil {
+		log.Errorf("delete data cache index of file[%s] failed: %v", name, err)
+	}
+}
+
+// clean 清理过期的 block，并刷新磁盘剩余空间，只遍历内存中的索引
 func (c *fileDataCache) clean() {
-	// 1. 首先清理掉已过期文件
-	c.keys.Range(func(key, value interface{}) bool {
-		cache := value.(*cacheItem)
-		if time.Now().Sub(cache.expTime) >= 0 {
-			c.delete(key.(string))
+	now := time.Now()
+	var expired []string
+	c.Lock()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*cacheItem).expTime) {
+			expired = append(expired, c.unlink(elem).key)
+		}
+		elem = prev
+	}
+	removed := c.removed
+	c.Unlock()
+	c.removeFiles(expired)
+	if removed > 0 {
+		c.pruneFiles()
+	}
+	if err := c.updateCapacity(); err != nil {
+		log.Errorf("update data cache capacity failed: %v", err)
+	}
+}
+
+// close 停止后台清理，并关闭持久化的索引
+func (c *fileDataCache) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.index != nil {
+			if err := c.index.Close(); err != nil {
+				log.Errorf("close data cache index failed: %v", err)
+			}
 		}
-		return true
 	})
+}
 
+// cleanOrphans 清理目录下存在，但是索引中不存在的文件，包括没有写完的临时文件
+func (c *fileDataCache) cleanOrphans() {
 	if c.dir == "/" || c.dir == "" {
 		return
 	}
-	// 2. 清理目录下存在，但是c.keys中不存在的的文件,
 	filepath.Walk(filepath.Join(c.dir, CacheDir), func(path string, info os.FileInfo, err error) error {
-		if info == nil {
-			return nil
-		}
-		if info.IsDir() {
+		if info == nil || info.IsDir() {
 			return nil
 		}
-
-		key := c.getKeyFromCachePath(path)
-		_, ok := c.keys.Load(key)
+		c.Lock()
+		_, ok := c.items[c.getKeyFromCachePath(path)]
+		c.Unlock()
 		if ok {
 			return nil
 		}
-		if strings.HasSuffix(path, "tmp") {
-			return nil
-		}
-		err = os.Remove(path)
-		return err
+		return os.Remove(path)
 	})
 	c.updateCapacity()
 }
@@ -213,19 +533,6 @@ func (c *fileDataCache) createDir(dir string) {
 	os.MkdirAll(dir, 0755)
 }
 
-func (c *fileDataCache) exist(key string) bool {
-	value, ok := c.keys.Load(key)
-	if !ok {
-		return false
-	} else {
-		cache := value.(*cacheItem)
-		if cache.expTime.Sub(time.Now()) <= 0 {
-			return false
-		}
-	}
-	return true
-}
-
 func (c *fileDataCache) updateCapacity() error {
 	output, err := utils.ExecCmdWithTimeout("df", []string{"-k", c.dir})
 	if err != nil {
@@ -246,19 +553,13 @@ func (c *fileDataCache) updateCapacity() error {
 				continue
 			}
 
-			total, err := strconv.ParseInt(strSlice[1], 10, 64)
-			if err != nil {
-				log.Errorf("parse str[%s] failed: %v", strSlice[1], err)
-				return err
-			}
-			used, err := strconv.ParseInt(strSlice[2], 10, 64)
+			avail, err := strconv.ParseInt(strSlice[3], 10, 64)
 			if err != nil {
-				log.Errorf("parse str[%s] failed: %v", strSlice[2], err)
+				log.Errorf("parse str[%s] failed: %v", strSlice[3], err)
 				return err
 			}
 			c.Lock()
-			c.capacity = total * 1024
-			c.used = used * 1024
+			c.diskFree = avail * 1024
 			c.Unlock()
 			return nil
 		}
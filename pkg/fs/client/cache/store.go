@@ -23,6 +23,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
@@ -34,15 +35,29 @@ type Reader interface {
 	io.ReaderAt
 }
 
+// Writer stages written data locally, and uploads it to ufs asynchronously
 type Writer interface {
 	io.WriterAt
+	Truncate(size int64) error
+	// Flush commits written data and blocks until it is uploaded
+	Flush() error
+	// Close commits written data, which is uploaded in background
+	Close() error
 }
 
 type Store interface {
 	NewReader(name string, length int, flags uint32, ufs ufs.UnderFileStorage,
 		buffers ReadBufferMap, bufferPool *BufferPool, seqReadAmount uint64) Reader
-	NewWriter(name string, length int, ufsFh ufs.FileHandle) Writer
+	NewWriter(name, ufsPath string, length int, ufs ufs.UnderFileStorage) Writer
 	InvalidateCache(name string, length int) error
+	// WaitWriteBack blocks until data written back of name is uploaded
+	WaitWriteBack(name string) error
+	// RecoverWriteBack uploads data staged before the last exit
+	RecoverWriteBack(resolve ResolveFunc)
+	// DiscardWriteBack drops data written back of name which is removed
+	DiscardWriteBack(name string)
+	// Close stops background cleaning and closes the persisted index of data cache
+	Close()
 }
 
 type ReadCloser interface {
@@ -56,12 +71,19 @@ type Config struct {
 	BlockSize    int
 	MaxReadAhead int
 	Expire       time.Duration
+	// Quota limits the bytes of data cache on disk, 0 means limited only by free disk space
+	Quota int64
+	// WriteBack stages written data under CachePath, and uploads it to ufs asynchronously
+	WriteBack         bool
+	MaxDirtySize      int64
+	UploadConcurrency int
 }
 
 type store struct {
-	conf   Config
-	meta   map[string]string
-	client DataCacheClient
+	conf      Config
+	meta      map[string]string
+	client    DataCacheClient
+	writeBack *writeBack
 	sync.RWMutex
 }
 
@@ -74,6 +96,9 @@ func NewCacheStore(config Config) Store {
 		meta: make(map[string]string, 100),
 	}
 	cacheStore.client = NewDataCache(config)
+	if config.WriteBack {
+		cacheStore.writeBack = newWriteBack(config)
+	}
 	log.Debugf("metrics register NewCacheStore")
 	registerMetrics()
 	return cacheStore
@@ -85,35 +110,126 @@ func (store *store) NewReader(name string, length int, flags uint32, ufs ufs.Und
 		buffers: buffers, bufferPool: bufferPool, seqReadAmount: seqReadAmount}
 }
 
-func (store *store) NewWriter(name string, length int, fh ufs.FileHandle) Writer {
-	return nil
+func (store *store) NewWriter(name, ufsPath string, length int, ufs ufs.UnderFileStorage) Writer {
+	if store.writeBack == nil {
+		return nil
+	}
+	writer, err := store.writeBack.open(path.Clean(name), ufsPath, length, ufs)
+	if err != nil {
+		log.Errorf("open write-back writer of [%s] failed: %v", name, err)
+		return nil
+	}
+	return writer
+}
+
+func (store *store) WaitWriteBack(name string) error {
+	if store.writeBack == nil {
+		return nil
+	}
+	return store.writeBack.wait(path.Clean(name))
+}
+
+func (store *store) RecoverWriteBack(resolve ResolveFunc) {
+	if store.writeBack == nil {
+		return
+	}
+	store.writeBack.recover(resolve)
+}
+
+func (store *store) DiscardWriteBack(name string) {
+	if store.writeBack == nil {
+		return
+	}
+	store.writeBack.discard(path.Clean(name))
+}
+
+func (store *store) Close() {
+	if store.client != nil {
+		store.client.close()
+	}
 }
 
 func (store *store) InvalidateCache(name string, length int) error {
-	write := 0
-	index := 0
 	name = path.Clean(name)
 	store.RLock()
 	keyID, ok := store.meta[name]
 	store.RUnlock()
+	// 重启后还没有读过的文件只存在于持久化的索引中
+	if !ok && store.client != nil {
+		if meta, found := store.client.getFile(name); found {
+			keyID, ok = meta.KeyID, true
+			if int(meta.Length) > length {
+				length = int(meta.Length)
+			}
+		}
+	}
 	if !ok {
 		return nil
 	}
 	store.Lock()
 	delete(store.meta, name)
 	store.Unlock()
-	go func() {
-		for write <= length {
-			key := store.key(keyID, index)
-			log.Debugf("cache del key is %s and keyID %s", key, keyID)
-			if store.client != nil {
-				store.client.delete(key)
+	if store.client != nil {
+		store.client.deleteFile(name)
+	}
+	go store.deleteBlocks(keyID, length)
+	return nil
+}
+
+// keyID 返回文件 block key 的前缀。重启前缓存过的文件，长度和修改时间都没有变化时继续使用之前的 block
+func (store *store) keyID(name string, length int, underFS ufs.UnderFileStorage) string {
+	store.RLock()
+	keyID, ok := store.meta[name]
+	store.RUnlock()
+	if ok {
+		return keyID
+	}
+
+	var mtime uint64
+	// 索引没有持久化时不需要校验修改时间，避免每次打开文件都访问一次 ufs
+	if store.client != nil && store.client.indexed() {
+		if underFS != nil {
+			if info, err := underFS.GetAttr(name); err == nil {
+				mtime = info.Mtime
 			}
-			write += store.conf.BlockSize
-			index += 1
 		}
-	}()
-	return nil
+		if meta, found := store.client.getFile(name); found {
+			if mtime != 0 && meta.Mtime == mtime && meta.Length == int64(length) {
+				keyID = meta.KeyID
+			} else {
+				log.Debugf("data cache of file[%s] is stale, length %d mtime %d, cached length %d mtime %d",
+					name, length, mtime, meta.Length, meta.Mtime)
+				store.deleteBlocks(meta.KeyID, int(meta.Length))
+			}
+		}
+	}
+
+	store.Lock()
+	if existing, ok := store.meta[name]; ok {
+		store.Unlock()
+		return existing
+	}
+	restored := keyID != ""
+	if !restored {
+		keyID = uuid.NewString()
+	}
+	store.meta[name] = keyID
+	store.Unlock()
+	if store.client != nil && !restored {
+		store.client.setFile(name, fileMeta{KeyID: keyID, Length: int64(length), Mtime: mtime})
+	}
+	return keyID
+}
+
+func (store *store) deleteBlocks(keyID string, length int) {
+	if store.client == nil {
+		return
+	}
+	for off, index := 0, 0; off <= length; off, index = off+store.conf.BlockSize, index+1 {
+		key := store.key(keyID, index)
+		log.Debugf("cache del key is %s and keyID %s", key, keyID)
+		store.client.delete(key)
+	}
 }
 
 func (store *store) key(keyID string, index int) string {
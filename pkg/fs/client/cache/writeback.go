@@ -0,0 +1,628 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
+)
+
+const (
+	WriteBackDir = "writeback"
+
+	DefaultMaxDirtySize      = 1 << 30
+	DefaultUploadConcurrency = 4
+
+	journalSuffix        = ".json"
+	uploadChunkSize      = 4 << 20
+	uploadRetryPeriod    = time.Second
+	maxUploadRetryPeriod = time.Minute
+	// flushUploadAttempts is the number of upload attempts flush waits for before it reports the failure,
+	// the upload is still retried in background after that
+	flushUploadAttempts = 3
+)
+
+// ResolveFunc finds the under file storage and the path in it of a file name in fuse
+type ResolveFunc func(name string) (ufs.UnderFileStorage, string)
+
+type wbRange struct {
+	Off int64 `json:"off"`
+	Len int64 `json:"len"`
+}
+
+// wbJournal is persisted next to the staging file, so that committed but not uploaded data
+// can be uploaded again after the fuse client restarts
+type wbJournal struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Length   int64     `json:"length"`
+	Truncate bool      `json:"truncate"`
+	Ranges   []wbRange `json:"ranges"`
+}
+
+// writeBack stages written data in local files and uploads them to ufs asynchronously
+type writeBack struct {
+	sync.Mutex
+	dir         string
+	maxDirty    int64
+	dirty       int64
+	files       map[string]*wbFile
+	pending     []*wbFile
+	recovered   []*wbFile
+	cond        *sync.Cond
+	workCond    *sync.Cond
+	concurrency int
+}
+
+type wbFile struct {
+	wb    *writeBack
+	id    string
+	name  string
+	path  string
+	ufs   ufs.UnderFileStorage
+	stage *os.File
+
+	// fields below are protected by wb lock
+	refs      int
+	length    int64
+	truncate  bool
+	written   []wbRange
+	committed []wbRange
+	bytes     int64
+	version   uint64
+	uploaded  uint64
+	queued    bool
+	uploading bool
+	retries   int
+	err       error
+	// discarded file is removed from ufs, its staged data is dropped instead of uploaded
+	discarded bool
+}
+
+type wbWriter struct {
+	file   *wbFile
+	closed bool
+}
+
+func newWriteBack(config Config) *writeBack {
+	if config.CachePath == "" || config.CachePath == "/" {
+		log.Errorf("write-back needs a data cache path, but it is [%s]", config.CachePath)
+		return nil
+	}
+	dir := filepath.Join(config.CachePath, config.FsID, WriteBackDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Errorf("newWriteBack os.MkdirAll [%s] err: %v", dir, err)
+		return nil
+	}
+	wb := &writeBack{
+		dir:         dir,
+		maxDirty:    config.MaxDirtySize,
+		files:       make(map[string]*wbFile),
+		concurrency: config.UploadConcurrency,
+	}
+	if wb.maxDirty <= 0 {
+		wb.maxDirty = DefaultMaxDirtySize
+	}
+	if wb.concurrency <= 0 {
+		wb.concurrency = DefaultUploadConcurrency
+	}
+	wb.cond = sync.NewCond(&wb.Mutex)
+	wb.workCond = sync.NewCond(&wb.Mutex)
+	wb.loadJournals()
+	for i := 0; i < wb.concurrency; i++ {
+		go wb.uploadWorker()
+	}
+	return wb
+}
+
+func fileID(name string) string {
+	sum := md5.Sum([]byte(name))
+	return hex.EncodeToString(sum[:])
+}
+
+func (wb *writeBack) stagePath(id string) string {
+	return filepath.Join(wb.dir, id)
+}
+
+func (wb *writeBack) journalPath(id string) string {
+	return filepath.Join(wb.dir, id+journalSuffix)
+}
+
+// loadJournals loads files which were not uploaded before the last exit, they are uploaded after
+// recover resolves their ufs
+func (wb *writeBack) loadJournals() {
+	entries, err := ioutil.ReadDir(wb.dir)
+	if err != nil {
+		log.Errorf("read write-back dir[%s] failed: %v", wb.dir, err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), journalSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), journalSuffix)
+		data, err := ioutil.ReadFile(wb.journalPath(id))
+		if err != nil {
+			log.Errorf("read write-back journal[%s] failed: %v", entry.Name(), err)
+			continue
+		}
+		journal := wbJournal{}
+		if err = json.Unmarshal(data, &journal); err != nil {
+			log.Errorf("unmarshal write-back journal[%s] failed: %v", entry.Name(), err)
+			continue
+		}
+		stage, err := os.OpenFile(wb.stagePath(id), os.O_RDWR, 0600)
+		if err != nil {
+			log.Errorf("open write-back stage file of [%s] failed: %v", journal.Name, err)
+			_ = os.Remove(wb.journalPath(id))
+			continue
+		}
+		f := &wbFile{
+			wb:        wb,
+			id:        id,
+			name:      journal.Name,
+			path:      journal.Path,
+			stage:     stage,
+			length:    journal.Length,
+			truncate:  journal.Truncate,
+			committed: journal.Ranges,
+			bytes:     rangesBytes(journal.Ranges),
+			version:   1,
+		}
+		wb.files[f.name] = f
+		wb.recovered = append(wb.recovered, f)
+		wb.dirty += f.bytes
+		log.Infof("write-back file[%s] is recovered with %d dirty bytes", f.name, f.bytes)
+	}
+}
+
+func (wb *writeBack) recover(resolve ResolveFunc) {
+	wb.Lock()
+	defer wb.Unlock()
+	for _, f := range wb.recovered {
+		underFS, ufsPath := resolve(f.name)
+		if underFS == nil {
+			log.Errorf("write-back file[%s] has no ufs, drop it", f.name)
+			wb.dirty -= f.bytes
+			f.committed, f.bytes = nil, 0
+			f.uploaded = f.version
+			wb.cleanup(f)
+			continue
+		}
+		f.ufs, f.path = underFS, ufsPath
+		wb.enqueue(f)
+	}
+	wb.recovered = nil
+	wb.cond.Broadcast()
+}
+
+func (wb *writeBack) open(name, ufsPath string, length int, underFS ufs.UnderFileStorage) (Writer, error) {
+	wb.Lock()
+	defer wb.Unlock()
+	f, ok := wb.files[name]
+	if !ok {
+		id := fileID(name)
+		stage, err := os.OpenFile(wb.stagePath(id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, err
+		}
+		f = &wbFile{
+			wb:     wb,
+			id:     id,
+			name:   name,
+			path:   ufsPath,
+			ufs:    underFS,
+			stage:  stage,
+			length: int64(length),
+		}
+		wb.files[name] = f
+	} else if f.discarded {
+		// the file is created again after removed
+		f.discarded = false
+		f.ufs, f.path = underFS, ufsPath
+		f.length, f.truncate = int64(length), false
+	} else if f.ufs == nil {
+		f.ufs, f.path = underFS, ufsPath
+	}
+	f.refs++
+	return &wbWriter{file: f}, nil
+}
+
+// wait blocks until all data committed to the file of name is uploaded
+func (wb *writeBack) wait(name string) error {
+	wb.Lock()
+	defer wb.Unlock()
+	f, ok := wb.files[name]
+	if !ok {
+		return nil
+	}
+	return wb.flush(f)
+}
+
+// discard drops the data staged for the file of name which is removed from ufs
+func (wb *writeBack) discard(name string) {
+	wb.Lock()
+	defer wb.Unlock()
+	f, ok := wb.files[name]
+	if !ok {
+		return
+	}
+	f.discarded = true
+	wb.drop(f)
+	wb.cleanup(f)
+	wb.cond.Broadcast()
+}
+
+// drop releases the dirty bytes of file without uploading them
+func (wb *writeBack) drop(f *wbFile) {
+	if f.uploading {
+		// ranges in uploading are dropped by upload worker
+		return
+	}
+	if len(f.committed) > 0 || len(f.written) > 0 {
+		log.Infof("drop %d dirty bytes of write-back file[%s]", f.bytes, f.name)
+	}
+	wb.dirty -= f.bytes
+	f.written, f.committed, f.bytes = nil, nil, 0
+	f.uploaded = f.version
+	f.retries, f.err = 0, nil
+}
+
+// inflight returns whether there is any data queued or in uploading
+func (wb *writeBack) inflight() bool {
+	if len(wb.pending) > 0 {
+		return true
+	}
+	for _, f := range wb.files {
+		if f.queued || f.uploading {
+			return true
+		}
+	}
+	return false
+}
+
+func (wb *writeBack) flush(f *wbFile) error {
+	wb.commit(f)
+	target := f.version
+	for f.uploaded < target && (f.err == nil || f.retries < flushUploadAttempts) {
+		wb.cond.Wait()
+	}
+	if f.uploaded >= target {
+		return nil
+	}
+	return f.err
+}
+
+// commit makes written data of file visible to upload workers and records it in journal
+func (wb *writeBack) commit(f *wbFile) {
+	if len(f.written) == 0 {
+		return
+	}
+	f.committed = append(f.committed, f.written...)
+	f.written = nil
+	f.version++
+	if err := wb.saveJournal(f); err != nil {
+		log.Errorf("save write-back journal of [%s] failed: %v", f.name, err)
+	}
+	wb.enqueue(f)
+}
+
+func (wb *writeBack) enqueue(f *wbFile) {
+	if f.queued || f.uploading {
+		return
+	}
+	f.queued = true
+	wb.pending = append(wb.pending, f)
+	wb.workCond.Signal()
+}
+
+func (wb *writeBack) saveJournal(f *wbFile) error {
+	data, err := json.Marshal(wbJournal{
+		Name:     f.name,
+		Path:     f.path,
+		Length:   f.length,
+		Truncate: f.truncate,
+		Ranges:   mergeRanges(f.committed),
+	})
+	if err != nil {
+		return err
+	}
+	journalPath := wb.journalPath(f.id)
+	tmp := journalPath + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, journalPath)
+}
+
+// cleanup removes the staging file of file if it is not used and all data is uploaded
+func (wb *writeBack) cleanup(f *wbFile) {
+	if f.refs > 0 || f.queued || f.uploading || len(f.written) > 0 || len(f.committed) > 0 {
+		return
+	}
+	if wb.files[f.name] == f {
+		delete(wb.files, f.name)
+	}
+	_ = f.stage.Close()
+	_ = os.Remove(wb.stagePath(f.id))
+	_ = os.Remove(wb.journalPath(f.id))
+}
+
+func (wb *writeBack) uploadWorker() {
+	wb.Lock()
+	defer wb.Unlock()
+	for {
+		for len(wb.pending) == 0 {
+			wb.workCond.Wait()
+		}
+		f := wb.pending[0]
+		wb.pending = wb.pending[1:]
+		f.queued = false
+		if f.discarded {
+			wb.drop(f)
+			wb.cleanup(f)
+			wb.cond.Broadcast()
+			continue
+		}
+		if f.ufs == nil {
+			// ufs of recovered file is unknown until recover is called
+			continue
+		}
+		f.uploading = true
+		version := f.version
+		count := len(f.committed)
+		ranges := mergeRanges(f.committed[:count])
+		bytes := rangesBytes(f.committed[:count])
+		length, truncate := f.length, f.truncate
+		wb.Unlock()
+
+		err := f.upload(ranges, length, truncate)
+
+		wb.Lock()
+		f.uploading = false
+		if f.discarded {
+			wb.drop(f)
+			wb.cleanup(f)
+			wb.cond.Broadcast()
+			continue
+		}
+		if err != nil {
+			// staged data and journal are kept, and uploaded again until it succeeds or the file is removed
+			f.retries++
+			delay := uploadRetryDelay(f.retries)
+			log.Warningf("upload write-back file[%s] failed %d times, retry in %v: %v", f.name, f.retries, delay, err)
+			f.err = err
+			f.queued = true
+			go func() {
+				time.Sleep(delay)
+				wb.Lock()
+				wb.pending = append(wb.pending, f)
+				wb.workCond.Signal()
+				wb.Unlock()
+			}()
+			wb.cond.Broadcast()
+			continue
+		}
+		f.retries, f.err = 0, nil
+		f.committed = f.committed[count:]
+		f.bytes -= bytes
+		wb.dirty -= bytes
+		f.uploaded = version
+		if len(f.committed) > 0 || f.version > version {
+			if err := wb.saveJournal(f); err != nil {
+				log.Errorf("save write-back journal of [%s] failed: %v", f.name, err)
+			}
+			wb.enqueue(f)
+		} else {
+			wb.cleanup(f)
+		}
+		wb.cond.Broadcast()
+	}
+}
+
+// uploadRetryDelay doubles the delay for each failure, and the delay is at most maxUploadRetryPeriod
+func uploadRetryDelay(retries int) time.Duration {
+	delay := uploadRetryPeriod
+	for i := 1; i < retries && delay < maxUploadRetryPeriod; i++ {
+		delay *= 2
+	}
+	if delay > maxUploadRetryPeriod {
+		delay = maxUploadRetryPeriod
+	}
+	return delay
+}
+
+// upload writes the dirty ranges of staging file into ufs
+func (f *wbFile) upload(ranges []wbRange, length int64, truncate bool) error {
+	log.Debugf("upload write-back file[%s] ranges %v, length %d", f.name, ranges, length)
+	fh, err := f.ufs.Open(f.path, syscall.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	defer fh.Release()
+	ufsHandle := ufs.NewFileHandle(fh)
+	buf := make([]byte, uploadChunkSize)
+	for _, r := range ranges {
+		end := r.Off + r.Len
+		if end > length {
+			end = length
+		}
+		for off := r.Off; off < end; {
+			n := end - off
+			if n > uploadChunkSize {
+				n = uploadChunkSize
+			}
+			read, err := f.stage.ReadAt(buf[:n], off)
+			if err != nil && err != io.EOF {
+				return err
+			}
+			// the hole of staging file is zero
+			for i := read; i < int(n); i++ {
+				buf[i] = 0
+			}
+			if _, err = ufsHandle.WriteAt(buf[:n], off); err != nil {
+				return err
+			}
+			off += n
+		}
+	}
+	if truncate {
+		if err = statusError(fh.Truncate(uint64(length))); err != nil {
+			return err
+		}
+	}
+	return statusError(fh.Flush())
+}
+
+func statusError(status fuse.Status) error {
+	if status != fuse.OK {
+		return syscall.Errno(status)
+	}
+	return nil
+}
+
+func (w *wbWriter) WriteAt(p []byte, off int64) (int, error) {
+	f := w.file
+	wb := f.wb
+	size := int64(len(p))
+	wb.Lock()
+	// a single write is always allowed, when nothing is dirty
+	for wb.dirty > 0 && wb.dirty+size > wb.maxDirty {
+		// other open files may hold written data too, commit all of them so that uploading releases dirty bytes
+		for _, file := range wb.files {
+			wb.commit(file)
+		}
+		if !wb.inflight() {
+			// nothing will release dirty bytes, such as recovered files whose ufs is unknown yet
+			break
+		}
+		wb.cond.Wait()
+	}
+	// dirty bytes are reserved before writing, the range is recorded after it is staged, so that
+	// upload workers never read a range which is not written into staging file yet
+	wb.dirty += size
+	wb.Unlock()
+
+	n, err := f.stage.WriteAt(p, off)
+
+	wb.Lock()
+	defer wb.Unlock()
+	if int64(n) < size {
+		// release the dirty bytes reserved but not written
+		wb.dirty -= size - int64(n)
+		wb.cond.Broadcast()
+	}
+	if n > 0 {
+		f.bytes += int64(n)
+		f.written = append(f.written, wbRange{Off: off, Len: int64(n)})
+		if off+int64(n) > f.length {
+			f.length = off + int64(n)
+		}
+	}
+	return n, err
+}
+
+func (w *wbWriter) Truncate(size int64) error {
+	f := w.file
+	wb := f.wb
+	if err := f.stage.Truncate(size); err != nil {
+		return err
+	}
+	wb.Lock()
+	defer wb.Unlock()
+	if size < f.length {
+		// the range cut off becomes zero when the file grows again
+		f.written = append(f.written, wbRange{Off: size, Len: f.length - size})
+		f.bytes += f.length - size
+		wb.dirty += f.length - size
+	} else {
+		f.written = append(f.written, wbRange{Off: size, Len: 0})
+	}
+	f.length = size
+	f.truncate = true
+	return nil
+}
+
+func (w *wbWriter) Flush() error {
+	wb := w.file.wb
+	wb.Lock()
+	defer wb.Unlock()
+	return wb.flush(w.file)
+}
+
+func (w *wbWriter) Close() error {
+	f := w.file
+	wb := f.wb
+	wb.Lock()
+	defer wb.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	wb.commit(f)
+	f.refs--
+	wb.cleanup(f)
+	return nil
+}
+
+func rangesBytes(ranges []wbRange) int64 {
+	var bytes int64
+	for _, r := range ranges {
+		bytes += r.Len
+	}
+	return bytes
+}
+
+// mergeRanges sorts ranges and merges the overlapped or adjacent ones
+func mergeRanges(ranges []wbRange) []wbRange {
+	sorted := make([]wbRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Len > 0 {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Off < sorted[j].Off
+	})
+	merged := make([]wbRange, 0, len(sorted))
+	for _, r := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].Off+merged[last].Len >= r.Off {
+			if end := r.Off + r.Len; end > merged[last].Off+merged[last].Len {
+				merged[last].Len = end - merged[last].Off
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -47,7 +47,13 @@ var (
 	BlockSize       = 0
 	MaxReadAheadNum = 0
 	DataCacheExpire = 0 * time.Second
-	DataCachePath   = "/var/cache/pfs_data_cache"
+	// 0 means data cache is limited only by free disk space
+	DataCacheQuota = int64(0)
+	DataCachePath  = "/var/cache/pfs_data_cache"
+	// write-back is disabled by default
+	DataCacheWriteBack         = false
+	DataCacheMaxDirtySize      = int64(0)
+	DataCacheUploadConcurrency = 0
 
 	Driver           = meta.DefaultName
 	MetaCacheExpire  = 0 * time.Second
@@ -66,7 +72,11 @@ func SetDataCache(config cache.Config) {
 	BlockSize = config.BlockSize
 	MaxReadAheadNum = config.MaxReadAhead
 	DataCacheExpire = config.Expire
+	DataCacheQuota = config.Quota
 	DataCachePath = config.CachePath
+	DataCacheWriteBack = config.WriteBack
+	DataCacheMaxDirtySize = config.MaxDirtySize
+	DataCacheUploadConcurrency = config.UploadConcurrency
 }
 
 type FSClient interface {
@@ -198,11 +198,12 @@ func (f *File) Close() error {
 	}
 	ctx := meta.NewEmptyContext()
 	err := f.fs.vfs.Flush(ctx, f.inode, f.fh, uint64(0))
+	// flush失败时同样释放句柄，避免写回数据一直被引用
+	f.fs.vfs.Release(ctx, f.inode, f.fh)
 	if utils.IsError(err) {
 		log.Errorf("file close: [%s] vfs.Flush err: %v", f.attr.path, err)
 		return err
 	}
-	f.fs.vfs.Release(ctx, f.inode, f.fh)
 
 	if f.fs.cache != nil {
 		// 文件可能有改变，close的时候设置attr缓存失效
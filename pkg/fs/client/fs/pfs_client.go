@@ -66,10 +66,14 @@ func NewFSClientForTest(fsMeta fsCommon.FSMeta) (*PFSClient, error) {
 			BlockSize:    BlockSize,
 			MaxReadAhead: MaxReadAheadNum,
 			Expire:       DataCacheExpire,
+			Quota:        DataCacheQuota,
 			Config: kv.Config{
 				Driver:    kv.NutsDB,
 				CachePath: DataCachePath,
 			},
+			WriteBack:         DataCacheWriteBack,
+			MaxDirtySize:      DataCacheMaxDirtySize,
+			UploadConcurrency: DataCacheUploadConcurrency,
 		}),
 		vfs.WithMetaConfig(meta.Config{
 			AttrCacheExpire:  MetaCacheExpire,
@@ -130,10 +134,14 @@ func (c *PFSClient) initPFS(fsMeta fsCommon.FSMeta, links map[string]fsCommon.FS
 			BlockSize:    BlockSize,
 			MaxReadAhead: MaxReadAheadNum,
 			Expire:       DataCacheExpire,
+			Quota:        DataCacheQuota,
 			Config: kv.Config{
 				Driver:    kv.NutsDB,
 				CachePath: DataCachePath,
 			},
+			WriteBack:         DataCacheWriteBack,
+			MaxDirtySize:      DataCacheMaxDirtySize,
+			UploadConcurrency: DataCacheUploadConcurrency,
 		}),
 		vfs.WithMetaConfig(meta.Config{
 			AttrCacheExpire:  MetaCacheExpire,
@@ -17,6 +17,8 @@ limitations under the License.
 package fs
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -42,6 +44,10 @@ import (
 )
 
 func getTestFSClient(t *testing.T) FSClient {
+	return getTestFSClientWithCache(t, "./mock-cache")
+}
+
+func getTestFSClientWithCache(t *testing.T, cachePath string) FSClient {
 	os.MkdirAll("./mock", 0755)
 	testFsMeta := common.FSMeta{
 		UfsType: common.LocalType,
@@ -61,7 +67,7 @@ func getTestFSClient(t *testing.T) FSClient {
 		},
 		SubPath: "/data",
 	}**/
-	DataCachePath = "./mock-cache"
+	DataCachePath = cachePath
 	fsclient, err := NewFSClientForTest(testFsMeta)
 	assert.Equal(t, nil, err)
 	return fsclient
@@ -1319,3 +1325,290 @@ func TestFSClient_read_with_small_block_1_not_enough_mem(t *testing.T) {
 
 	assert.Equal(t, string(bufExpect[0:nExpect]), string(buf1)+string(buf2))
 }
+
+func TestFSClient_writeBack(t *testing.T) {
+	os.RemoveAll("./mock")
+	os.RemoveAll("./mock-cache")
+	defer os.RemoveAll("./mock-cache")
+	defer os.RemoveAll("./mock")
+	d := cache.Config{
+		BlockSize:    1024,
+		MaxReadAhead: 4,
+		Expire:       600 * time.Second,
+		Config: kv.Config{
+			Driver:    kv.NutsDB,
+			CachePath: "./mock-cache",
+		},
+		WriteBack:    true,
+		MaxDirtySize: 8,
+	}
+	SetDataCache(d)
+	defer func() {
+		DataCacheWriteBack = false
+	}()
+	client := getTestFSClient(t)
+
+	path := "testWriteBack"
+	writer, err := client.Create(path)
+	assert.Equal(t, nil, err)
+	// each write exceeds max dirty size, and waits for the previous upload
+	writeString := "test String for write-back"
+	for i := 0; i < len(writeString); i += 10 {
+		end := i + 10
+		if end > len(writeString) {
+			end = len(writeString)
+		}
+		_, err = writer.Write([]byte(writeString[i:end]))
+		assert.Equal(t, nil, err)
+	}
+	assert.Equal(t, nil, writer.Close())
+
+	data, err := ioutil.ReadFile("./mock/" + path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, writeString, string(data))
+
+	buf := make([]byte, 100)
+	n, err := openAndRead(client, path, buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, writeString, string(buf[:n]))
+
+	// staging files are removed after uploaded
+	files, err := ioutil.ReadDir(filepath.Join("./mock-cache", cache.WriteBackDir))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestFSClient_writeBackRetry(t *testing.T) {
+	os.RemoveAll("./mock")
+	os.RemoveAll("./mock-cache")
+	defer os.RemoveAll("./mock-cache")
+	defer os.RemoveAll("./mock")
+	d := cache.Config{
+		BlockSize:    1024,
+		MaxReadAhead: 4,
+		Expire:       600 * time.Second,
+		Config: kv.Config{
+			Driver:    kv.NutsDB,
+			CachePath: "./mock-cache",
+		},
+		WriteBack: true,
+	}
+	SetDataCache(d)
+	defer func() {
+		DataCacheWriteBack = false
+	}()
+	client := getTestFSClient(t)
+
+	// flush waits for the retries, and succeeds once the upload recovers
+	recoverPath := "testWriteBackRetryRecover"
+	writer, err := client.Create(recoverPath)
+	assert.Equal(t, nil, err)
+	_, err = writer.Write([]byte("recover"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, os.Remove("./mock/"+recoverPath))
+	assert.Equal(t, nil, os.Mkdir("./mock/"+recoverPath, 0755))
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		_ = os.Remove("./mock/" + recoverPath)
+		_ = ioutil.WriteFile("./mock/"+recoverPath, nil, 0644)
+	}()
+	assert.Equal(t, nil, writer.Close())
+	data, err := ioutil.ReadFile("./mock/" + recoverPath)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "recover", string(data))
+
+	path := "testWriteBackRetry"
+	writer, err = client.Create(path)
+	assert.Equal(t, nil, err)
+	_, err = writer.Write([]byte("retry"))
+	assert.Equal(t, nil, err)
+	// upload fails while the path in ufs is not a file
+	assert.Equal(t, nil, os.Remove("./mock/"+path))
+	assert.Equal(t, nil, os.Mkdir("./mock/"+path, 0755))
+	assert.NotEqual(t, nil, writer.Close())
+
+	// staged data is kept after failures
+	dir := filepath.Join("./mock-cache", cache.WriteBackDir)
+	time.Sleep(1500 * time.Millisecond)
+	files, err := ioutil.ReadDir(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(files))
+
+	assert.Equal(t, nil, os.Remove("./mock/"+path))
+	assert.Equal(t, nil, ioutil.WriteFile("./mock/"+path, nil, 0644))
+	assert.Eventually(t, func() bool {
+		data, err := ioutil.ReadFile("./mock/" + path)
+		return err == nil && string(data) == "retry"
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		files, err := ioutil.ReadDir(dir)
+		return err == nil && len(files) == 0
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestFSClient_writeBackRecover(t *testing.T) {
+	os.RemoveAll("./mock")
+	os.RemoveAll("./mock-cache")
+	defer os.RemoveAll("./mock-cache")
+	defer os.RemoveAll("./mock")
+	d := cache.Config{
+		BlockSize:    1024,
+		MaxReadAhead: 4,
+		Expire:       600 * time.Second,
+		Config: kv.Config{
+			Driver:    kv.NutsDB,
+			CachePath: "./mock-cache",
+		},
+		WriteBack: true,
+	}
+	SetDataCache(d)
+	defer func() {
+		DataCacheWriteBack = false
+	}()
+
+	// data staged but not uploaded before the last exit
+	os.MkdirAll("./mock", 0755)
+	assert.Equal(t, nil, ioutil.WriteFile("./mock/testRecover", []byte("0123456789"), 0644))
+	dir := filepath.Join("./mock-cache", cache.WriteBackDir)
+	os.MkdirAll(dir, 0755)
+	sum := md5.Sum([]byte("/testRecover"))
+	id := hex.EncodeToString(sum[:])
+	stage := make([]byte, 10)
+	copy(stage[4:], "abcd")
+	assert.Equal(t, nil, ioutil.WriteFile(filepath.Join(dir, id), stage, 0600))
+	journal := `{"name":"/testRecover","path":"testRecover","length":10,"truncate":false,"ranges":[{"off":4,"len":4}]}`
+	assert.Equal(t, nil, ioutil.WriteFile(filepath.Join(dir, id+".json"), []byte(journal), 0600))
+
+	client := getTestFSClient(t)
+	buf := make([]byte, 100)
+	n, err := openAndRead(client, "testRecover", buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "0123abcd89", string(buf[:n]))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestFSClient_diskCache_Quota(t *testing.T) {
+	os.RemoveAll("./mock")
+	os.RemoveAll("./mock-cache-quota")
+	defer os.RemoveAll("./mock")
+	defer os.RemoveAll("./mock-cache-quota")
+	d := cache.Config{
+		BlockSize:    5,
+		MaxReadAhead: 4,
+		Expire:       10 * time.Second,
+		Quota:        10,
+		Config: kv.Config{
+			Driver:    kv.NutsDB,
+			CachePath: "./mock-cache-quota",
+		},
+	}
+	SetDataCache(d)
+	defer SetDataCache(cache.Config{})
+	client := getTestFSClientWithCache(t, "./mock-cache-quota")
+	defer closeTestFSClient(client)
+	path := "testRead"
+	writer, err := client.Create(path)
+	assert.Equal(t, nil, err)
+	writeString := "test String for Client"
+	_, err = writer.Write([]byte(writeString))
+	assert.Equal(t, nil, err)
+	writer.Close()
+
+	for i := 0; i < 2; i++ {
+		reader, err := client.Open(path)
+		assert.Equal(t, nil, err)
+		buf := make([]byte, len([]byte(writeString)))
+		n, err := reader.Read(buf)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, n, len(buf))
+		assert.Equal(t, string(buf), writeString)
+		// 超过 quota 的 block 被淘汰，磁盘上最多保留 quota/blockSize 个 block
+		assert.Eventually(t, func() bool {
+			cachedSize := cachedBlockSize(t, "./mock-cache-quota")
+			return cachedSize > 0 && cachedSize <= int64(10)
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, nil, reader.Close())
+	}
+	assert.LessOrEqual(t, cachedBlockSize(t, "./mock-cache-quota"), int64(10))
+}
+
+// 各用例使用独立的缓存目录，避免之前用例后台预读写入的 block 影响统计
+func cachedBlockSize(t *testing.T, cachePath string) int64 {
+	var size int64
+	filepath.Walk(cachePath, func(path string, info os.FileInfo, err error) error {
+		if info != nil && !info.IsDir() && strings.Contains(path, cache.CacheDir) {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func TestFSClient_diskCache_Restart(t *testing.T) {
+	os.RemoveAll("./mock")
+	os.RemoveAll("./mock-cache-restart")
+	defer os.RemoveAll("./mock")
+	defer os.RemoveAll("./mock-cache-restart")
+	d := cache.Config{
+		BlockSize:    5,
+		MaxReadAhead: 4,
+		Expire:       60 * time.Second,
+		Config: kv.Config{
+			Driver:    kv.NutsDB,
+			CachePath: "./mock-cache-restart",
+		},
+	}
+	SetDataCache(d)
+	defer SetDataCache(cache.Config{})
+	client := getTestFSClientWithCache(t, "./mock-cache-restart")
+	path := "testRead"
+	writer, err := client.Create(path)
+	assert.Equal(t, nil, err)
+	writeString := "test String for Client"
+	_, err = writer.Write([]byte(writeString))
+	assert.Equal(t, nil, err)
+	writer.Close()
+
+	read := func(client FSClient, expected string) {
+		reader, err := client.Open(path)
+		assert.Equal(t, nil, err)
+		buf := make([]byte, len(expected))
+		n, err := reader.Read(buf)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, len(buf), n)
+		assert.Equal(t, expected, string(buf))
+		// 等待 block 全部写入缓存后再关闭
+		assert.Eventually(t, func() bool {
+			return cachedBlockSize(t, "./mock-cache-restart") == int64(len(expected))
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, nil, reader.Close())
+	}
+	read(client, writeString)
+	cached := cachedBlockSize(t, "./mock-cache-restart")
+
+	// 重启后之前缓存的 block 仍然有效
+	closeTestFSClient(client)
+	client = getTestFSClientWithCache(t, "./mock-cache-restart")
+	assert.Equal(t, cached, cachedBlockSize(t, "./mock-cache-restart"))
+	read(client, writeString)
+
+	// 源文件修改后，重启前缓存的 block 失效
+	newString := "TEST STRING FOR CLIENT"
+	assert.Nil(t, ioutil.WriteFile("./mock/"+path, []byte(newString), 0644))
+	mtime := time.Now().Add(time.Hour)
+	assert.Nil(t, os.Chtimes("./mock/"+path, mtime, mtime))
+	closeTestFSClient(client)
+	client = getTestFSClientWithCache(t, "./mock-cache-restart")
+	defer closeTestFSClient(client)
+	read(client, newString)
+}
+
+// closeTestFSClient 模拟客户端退出，停止数据缓存的后台清理并关闭索引
+func closeTestFSClient(client FSClient) {
+	if store := client.(*PFSClient).pfs.vfs.Store; store != nil {
+		store.Close()
+	}
+}
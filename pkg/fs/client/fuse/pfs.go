@@ -166,11 +166,21 @@ func (fs *PFS) Link(cancel <-chan struct{}, input *fuse.LinkIn, filename string,
 }
 
 func (fs *PFS) Symlink(cancel <-chan struct{}, header *fuse.InHeader, pointedTo string, linkName string, out *fuse.EntryOut) fuse.Status {
-	return fuse.ENOSYS
+	log.Debugf("pfs POSIX Symlink: header[%+v] pointedTo[%s] linkName[%s]", *header, pointedTo, linkName)
+	ctx := meta.NewContext(cancel, header.Uid, header.Pid, header.Gid)
+	entry, code := vfs.GetVFS().Symlink(ctx, pointedTo, vfs.Ino(header.NodeId), linkName)
+	if code != 0 {
+		return fuse.Status(code)
+	}
+	fs.replyEntry(entry, out)
+	return fuse.OK
 }
 
 func (fs *PFS) Readlink(cancel <-chan struct{}, header *fuse.InHeader) (out []byte, code fuse.Status) {
-	return out, fuse.ENOSYS
+	log.Debugf("pfs POSIX Readlink: header[%+v]", *header)
+	ctx := meta.NewContext(cancel, header.Uid, header.Pid, header.Gid)
+	path, errno := vfs.GetVFS().Readlink(ctx, vfs.Ino(header.NodeId))
+	return path, fuse.Status(errno)
 }
 
 func (fs *PFS) Access(cancel <-chan struct{}, input *fuse.AccessIn) fuse.Status {
@@ -267,15 +277,33 @@ func (fs *PFS) Read(cancel <-chan struct{}, input *fuse.ReadIn, buf []byte) (fus
 
 // File locking
 func (fs *PFS) GetLk(cancel <-chan struct{}, input *fuse.LkIn, out *fuse.LkOut) (code fuse.Status) {
-	return fuse.ENOSYS
+	log.Debugf("pfs POSIX GetLk: input [%+v]", input)
+	ctx := meta.NewContext(cancel, input.Uid, input.Pid, input.Gid)
+	l := input.Lk
+	errno := vfs.GetVFS().GetLk(ctx, vfs.Ino(input.NodeId), input.Fh, input.Owner, &l.Start, &l.End, &l.Typ, &l.Pid)
+	if errno == 0 {
+		out.Lk = l
+	}
+	return fuse.Status(errno)
 }
 
 func (fs *PFS) SetLk(cancel <-chan struct{}, input *fuse.LkIn) (code fuse.Status) {
-	return fuse.ENOSYS
+	log.Debugf("pfs POSIX SetLk: input [%+v]", input)
+	return fs.setLk(cancel, input, false)
 }
 
 func (fs *PFS) SetLkw(cancel <-chan struct{}, input *fuse.LkIn) (code fuse.Status) {
-	return fuse.ENOSYS
+	log.Debugf("pfs POSIX SetLkw: input [%+v]", input)
+	return fs.setLk(cancel, input, true)
+}
+
+func (fs *PFS) setLk(cancel <-chan struct{}, input *fuse.LkIn, block bool) (code fuse.Status) {
+	ctx := meta.NewContext(cancel, input.Uid, input.Pid, input.Gid)
+	if input.LkFlags&fuse.FUSE_LK_FLOCK != 0 {
+		return fuse.Status(vfs.GetVFS().Flock(ctx, vfs.Ino(input.NodeId), input.Fh, input.Owner, input.Lk.Typ, block))
+	}
+	l := input.Lk
+	return fuse.Status(vfs.GetVFS().SetLk(ctx, vfs.Ino(input.NodeId), input.Fh, input.Owner, l.Start, l.End, l.Typ, l.Pid, block))
 }
 
 func (fs *PFS) Write(cancel <-chan struct{}, input *fuse.WriteIn, data []byte) (uint32, fuse.Status) {
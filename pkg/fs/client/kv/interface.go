@@ -1,11 +1,14 @@
 package kv
 
+import "fmt"
+
 type Client interface {
 	Name() string
 	Get(key []byte) ([]byte, bool)
 	Set(key, value []byte) error
 	Dels(keys ...[]byte) error
 	ScanValues(prefix []byte) (map[string][]byte, error)
+	Close() error
 }
 
 type Config struct {
@@ -13,3 +16,16 @@ type Config struct {
 	Driver    string
 	CachePath string
 }
+
+// OpenClient opens an existing kv store at path without cleaning it, which is used by tools working
+// on the cache of a stopped client.
+func OpenClient(driver, path string) (Client, error) {
+	switch driver {
+	case LevelDB:
+		return OpenLevelDBClient(path)
+	case NutsDB:
+		return OpenNutsClient(path)
+	default:
+		return nil, fmt.Errorf("driver[%s] can not be opened from path", driver)
+	}
+}
@@ -75,6 +75,10 @@ func (l levelDBClient) ScanValues(prefix []byte) (map[string][]byte, error) {
 	return result, nil
 }
 
+func (l levelDBClient) Close() error {
+	return l.db.Close()
+}
+
 func NewLevelDBClient(config Config) (Client, error) {
 	cachePath := filepath.Join(config.CachePath, config.FsID+"_"+utils.GetRandID(5)+".db")
 	os.RemoveAll(cachePath)
@@ -85,4 +89,13 @@ func NewLevelDBClient(config Config) (Client, error) {
 	return &levelDBClient{db: db}, nil
 }
 
+// OpenLevelDBClient opens the leveldb at path as it is, the existing data is kept.
+func OpenLevelDBClient(path string) (Client, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &levelDBClient{path: path, db: db}, nil
+}
+
 var _ Client = &levelDBClient{}
@@ -90,6 +90,10 @@ func (l *memClient) ScanValues(prefix []byte) (map[string][]byte, error) {
 	return ret, nil
 }
 
+func (l *memClient) Close() error {
+	return nil
+}
+
 func NewMemClient(config Config) (Client, error) {
 	client := &memClient{db: btree.New(2), item: &kvItem{}}
 	return client, nil
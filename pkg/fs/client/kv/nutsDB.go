@@ -110,6 +110,10 @@ func (l nutsDBClient) ScanValues(prefix []byte) (map[string][]byte, error) {
 	return result, nil
 }
 
+func (l nutsDBClient) Close() error {
+	return l.db.Close()
+}
+
 func NewNutsClient(config Config) (Client, error) {
 	opt := nutsdb.DefaultOptions
 	os.RemoveAll(config.CachePath)
@@ -124,4 +128,16 @@ func NewNutsClient(config Config) (Client, error) {
 	return &nutsDBClient{db: db}, nil
 }
 
+// OpenNutsClient opens the nutsdb at path as it is, the existing data is kept.
+func OpenNutsClient(path string) (Client, error) {
+	opt := nutsdb.DefaultOptions
+	opt.Dir = path
+	opt.EntryIdxMode = nutsdb.HintKeyValAndRAMIdxMode
+	db, err := nutsdb.Open(opt)
+	if err != nil {
+		return nil, err
+	}
+	return &nutsDBClient{db: db}, nil
+}
+
 var _ Client = &nutsDBClient{}
@@ -0,0 +1,328 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	pathlib "path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+// DumpVersion is the version of dumped meta format, it should be increased when the format is changed
+const DumpVersion = 1
+
+// DumpedMeta is the dumped meta cache. Entries and attrs are keyed by path instead of inode, as inodes
+// are allocated again after mounted.
+type DumpedMeta struct {
+	Version  int                   `json:"version"`
+	Driver   string                `json:"driver"`
+	DumpTime int64                 `json:"dumpTime"`
+	Links    map[string]DumpedLink `json:"links,omitempty"`
+	Entries  []DumpedEntry         `json:"entries"`
+	Attrs    []DumpedAttr          `json:"attrs"`
+}
+
+// DumpedLink is the link of fs, properties are not dumped as there may be credentials in them.
+type DumpedLink struct {
+	FsID          string `json:"fsID"`
+	FsName        string `json:"fsName"`
+	UfsType       string `json:"ufsType"`
+	ServerAddress string `json:"serverAddress"`
+	SubPath       string `json:"subPath"`
+}
+
+// DumpedEntry is an entry in directory parent, the entry whose path equals parent marks the listing of
+// the directory is cached when done is true.
+type DumpedEntry struct {
+	Parent string `json:"parent"`
+	Path   string `json:"path"`
+	Inode  Ino    `json:"inode"`
+	Mode   uint32 `json:"mode"`
+	Expire int64  `json:"expire"`
+	Done   bool   `json:"done"`
+}
+
+type DumpedAttr struct {
+	Path   string `json:"path"`
+	Attr   Attr   `json:"attr"`
+	Expire int64  `json:"expire"`
+}
+
+func newDumpedMeta(driver string) *DumpedMeta {
+	return &DumpedMeta{
+		Version:  DumpVersion,
+		Driver:   driver,
+		DumpTime: time.Now().Unix(),
+		Entries:  []DumpedEntry{},
+		Attrs:    []DumpedAttr{},
+	}
+}
+
+func (dm *DumpedMeta) write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dm)
+}
+
+func readDumpedMeta(r io.Reader) (*DumpedMeta, error) {
+	dm := &DumpedMeta{}
+	if err := json.NewDecoder(r).Decode(dm); err != nil {
+		return nil, fmt.Errorf("decode dumped meta failed: %v", err)
+	}
+	if dm.Version != DumpVersion {
+		return nil, fmt.Errorf("dumped meta version[%d] is not supported, expected version[%d]", dm.Version, DumpVersion)
+	}
+	return dm, nil
+}
+
+func toDumpedLink(fsMeta common.FSMeta) DumpedLink {
+	return DumpedLink{
+		FsID:          fsMeta.ID,
+		FsName:        fsMeta.Name,
+		UfsType:       fsMeta.UfsType,
+		ServerAddress: fsMeta.ServerAddress,
+		SubPath:       fsMeta.SubPath,
+	}
+}
+
+func (m *DefaultMeta) dumpLinks() map[string]DumpedLink {
+	m.ufsMapLock.RLock()
+	defer m.ufsMapLock.RUnlock()
+	if len(m.links) == 0 {
+		return nil
+	}
+	links := make(map[string]DumpedLink, len(m.links))
+	for prefix, fsMeta := range m.links {
+		links[pathlib.Clean(prefix)] = toDumpedLink(fsMeta)
+	}
+	return links
+}
+
+// DumpKvMeta dumps the meta cache in kv client, it is used to dump the cache of a stopped client.
+func DumpKvMeta(client kv.Client, w io.Writer) error {
+	m := &kvMeta{client: client}
+	return m.DumpMeta(w)
+}
+
+// saveLinks persists the link table of fs in cache, so that the cache dumped after the client is stopped
+// has the links too.
+func (m *kvMeta) saveLinks(links map[string]DumpedLink) {
+	data, err := json.Marshal(links)
+	if err != nil {
+		log.Errorf("marshal links of fs failed: %v", err)
+		return
+	}
+	if err = m.client.Set([]byte(LinkKey), data); err != nil {
+		log.Errorf("save links of fs in meta cache failed: %v", err)
+	}
+}
+
+// loadLinks returns the link table persisted in cache.
+func (m *kvMeta) loadLinks() (map[string]DumpedLink, error) {
+	data, ok := m.client.Get([]byte(LinkKey))
+	if !ok {
+		return nil, nil
+	}
+	var links map[string]DumpedLink
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("unmarshal links of fs failed: %v", err)
+	}
+	return links, nil
+}
+
+// DumpMeta dumps the entries and attrs in cache, and the link table of fs.
+func (m *kvMeta) DumpMeta(w io.Writer) error {
+	dm := newDumpedMeta(m.client.Name())
+	if defaultMeta, ok := m.defaultMeta.(*DefaultMeta); ok {
+		dm.Links = defaultMeta.dumpLinks()
+	} else {
+		links, err := m.loadLinks()
+		if err != nil {
+			return err
+		}
+		dm.Links = links
+	}
+
+	entries, err := m.client.ScanValues([]byte(EntryKey))
+	if err != nil {
+		return fmt.Errorf("scan entries failed: %v", err)
+	}
+	for key, value := range entries {
+		parent, path, ok := splitEntryKey(strings.TrimPrefix(key, EntryKey))
+		if !ok || len(value) < entryCacheSize {
+			log.Warnf("dump meta: skip invalid entry with key[%s]", key)
+			continue
+		}
+		entry := &entryCacheItem{}
+		m.parseEntry(value, entry)
+		dm.Entries = append(dm.Entries, DumpedEntry{
+			Parent: parent,
+			Path:   path,
+			Inode:  entry.ino,
+			Mode:   entry.mode,
+			Expire: entry.expire,
+			Done:   entry.done == entryDone,
+		})
+	}
+	sort.Slice(dm.Entries, func(i, j int) bool {
+		if dm.Entries[i].Path != dm.Entries[j].Path {
+			return dm.Entries[i].Path < dm.Entries[j].Path
+		}
+		return dm.Entries[i].Parent < dm.Entries[j].Parent
+	})
+
+	attrs, err := m.client.ScanValues([]byte(AttrKey))
+	if err != nil {
+		return fmt.Errorf("scan attrs failed: %v", err)
+	}
+	for key, value := range attrs {
+		if len(value) < attrCacheSize {
+			log.Warnf("dump meta: skip invalid attr with key[%s]", key)
+			continue
+		}
+		attr := &attrCacheItem{}
+		m.parseAttr(value, attr)
+		dm.Attrs = append(dm.Attrs, DumpedAttr{
+			Path:   strings.TrimPrefix(key, AttrKey),
+			Attr:   attr.attr,
+			Expire: attr.expire,
+		})
+	}
+	sort.Slice(dm.Attrs, func(i, j int) bool {
+		return dm.Attrs[i].Path < dm.Attrs[j].Path
+	})
+	return dm.write(w)
+}
+
+// LoadMeta loads the dumped entries and attrs into cache before the mount is served. The expired ones are
+// skipped, and so are the ones under links which are changed since dumped. Inodes are allocated again
+// for the dumped paths.
+func (m *kvMeta) LoadMeta(r io.Reader) error {
+	dm, err := readDumpedMeta(r)
+	if err != nil {
+		return err
+	}
+	var inodeHandle *InodeHandle
+	var changedLinks []string
+	if defaultMeta, ok := m.defaultMeta.(*DefaultMeta); ok {
+		inodeHandle = defaultMeta.inodeHandle
+		changedLinks = diffLinks(dm.Links, defaultMeta.dumpLinks())
+	}
+	skipped := func(path string, expire int64) bool {
+		if expire < time.Now().Unix() {
+			return true
+		}
+		for _, prefix := range changedLinks {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	attrTypes := make(map[string]uint8, len(dm.Attrs))
+	attrCount := 0
+	for _, a := range dm.Attrs {
+		attrTypes[a.Path] = a.Attr.Type
+		if skipped(a.Path, a.Expire) {
+			continue
+		}
+		value := m.marshalAttr(&attrCacheItem{attr: a.Attr, expire: a.Expire})
+		if err = m.client.Set(m.attrKey(a.Path), value); err != nil {
+			return fmt.Errorf("load attr of path[%s] failed: %v", a.Path, err)
+		}
+		attrCount++
+	}
+
+	// parents are loaded before children, so that inodes are created in order
+	sort.Slice(dm.Entries, func(i, j int) bool {
+		return strings.Count(dm.Entries[i].Path, "/") < strings.Count(dm.Entries[j].Path, "/")
+	})
+	entryCount := 0
+	for _, e := range dm.Entries {
+		if skipped(e.Path, e.Expire) {
+			continue
+		}
+		entry := &entryCacheItem{ino: e.Inode, mode: e.Mode, expire: e.Expire}
+		if e.Done {
+			entry.done = entryDone
+		}
+		if inodeHandle != nil {
+			isDir := e.Parent == e.Path || e.Mode&syscall.S_IFMT == syscall.S_IFDIR ||
+				attrTypes[e.Path] == TypeDirectory
+			node := inodeHandle.CreatePathInode(e.Path, isDir)
+			if node == nil || node.IsDir() != isDir {
+				log.Warnf("load meta: skip entry[%s] conflicting with the inode tree", e.Path)
+				continue
+			}
+			entry.ino = node.inode
+		}
+		if err = m.client.Set(m.entryKey(e.Parent, e.Path), m.marshalEntry(entry)); err != nil {
+			return fmt.Errorf("load entry of path[%s] failed: %v", e.Path, err)
+		}
+		entryCount++
+	}
+	log.Infof("load meta dumped at %s: %d/%d entries and %d/%d attrs are loaded",
+		time.Unix(dm.DumpTime, 0).Format(time.RFC3339), entryCount, len(dm.Entries), attrCount, len(dm.Attrs))
+	return nil
+}
+
+// diffLinks returns the prefixes of links which are added, removed or changed since dumped.
+func diffLinks(dumped, current map[string]DumpedLink) []string {
+	var changed []string
+	for prefix, link := range dumped {
+		if c, ok := current[prefix]; !ok || c != link {
+			changed = append(changed, prefix)
+		}
+	}
+	for prefix := range current {
+		if _, ok := dumped[prefix]; !ok {
+			changed = append(changed, prefix)
+		}
+	}
+	return changed
+}
+
+// splitEntryKey splits the entry key, which is parent path followed by entry path without separator.
+// The entry path is either the parent path itself or the path of a child in parent.
+func splitEntryKey(key string) (parent, path string, ok bool) {
+	for i := 1; i < len(key); i++ {
+		parent, path = key[:i], key[i:]
+		if parent == path {
+			return parent, path, true
+		}
+		prefix := parent + "/"
+		if parent == "/" {
+			prefix = parent
+		}
+		if len(path) > len(prefix) && strings.HasPrefix(path, prefix) &&
+			!strings.Contains(path[len(prefix):], "/") {
+			return parent, path, true
+		}
+	}
+	return "", "", false
+}
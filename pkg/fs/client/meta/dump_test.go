@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+func newTestKvMeta(t *testing.T, root string) Meta {
+	fsMeta := common.FSMeta{
+		ID:      "fs-root-testdump",
+		Name:    "testdump",
+		UfsType: common.LocalType,
+		SubPath: root,
+	}
+	links := map[string]common.FSMeta{
+		"/link": {
+			ID:         "fs-root-link",
+			UfsType:    common.LocalType,
+			SubPath:    root,
+			Properties: map[string]string{"secret": "secret-value"},
+		},
+	}
+	inodeHandle := NewInodeHandle()
+	inodeHandle.InitRootNode()
+	m, err := NewMeta(fsMeta, links, inodeHandle, &Config{
+		AttrCacheExpire:  time.Hour,
+		EntryCacheExpire: time.Hour,
+		Config:           kv.Config{Driver: kv.Mem, FsID: fsMeta.ID},
+	})
+	assert.Equal(t, nil, err)
+	return m
+}
+
+func TestKvMeta_DumpAndLoad(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, nil, os.MkdirAll(filepath.Join(root, "a"), 0755))
+	assert.Equal(t, nil, os.WriteFile(filepath.Join(root, "a", "b.txt"), []byte("0123456789"), 0644))
+
+	ctx := NewEmptyContext()
+	m := newTestKvMeta(t, root)
+	dirIno, _, errno := m.Lookup(ctx, rootInodeID, "a")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	_, attr, errno := m.Lookup(ctx, dirIno, "b.txt")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	assert.Equal(t, uint64(10), attr.Size)
+
+	buf := &bytes.Buffer{}
+	assert.Equal(t, nil, m.DumpMeta(buf))
+	assert.False(t, strings.Contains(buf.String(), "secret-value"))
+	dm := &DumpedMeta{}
+	assert.Equal(t, nil, json.Unmarshal(buf.Bytes(), dm))
+	assert.Equal(t, DumpVersion, dm.Version)
+	assert.Equal(t, kv.Mem, dm.Driver)
+	assert.Equal(t, "fs-root-link", dm.Links["/link"].FsID)
+	assert.Equal(t, 2, len(dm.Entries))
+	assert.Equal(t, "/a", dm.Entries[1].Parent)
+	assert.Equal(t, "/a/b.txt", dm.Entries[1].Path)
+	assert.Equal(t, 2, len(dm.Attrs))
+
+	// the loaded entries are served from cache, even if the file is removed from ufs
+	assert.Equal(t, nil, os.RemoveAll(filepath.Join(root, "a")))
+	loaded := newTestKvMeta(t, root)
+	assert.Equal(t, nil, loaded.LoadMeta(bytes.NewReader(buf.Bytes())))
+	dirIno, _, errno = loaded.Lookup(ctx, rootInodeID, "a")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	ino, attr, errno := loaded.Lookup(ctx, dirIno, "b.txt")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	assert.Equal(t, uint64(10), attr.Size)
+	assert.Equal(t, "/a/b.txt", loaded.InoToPath(ino))
+
+	// dumps of other versions are rejected
+	dm.Version = DumpVersion + 1
+	data, err := json.Marshal(dm)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, loaded.LoadMeta(bytes.NewReader(data)))
+}
+
+func TestDumpKvMeta_links(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, nil, os.MkdirAll(filepath.Join(root, "a"), 0755))
+
+	ctx := NewEmptyContext()
+	m := newTestKvMeta(t, root)
+	linkIno, _, errno := m.Lookup(ctx, rootInodeID, "link")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	_, _, errno = m.Lookup(ctx, linkIno, "a")
+	assert.EqualValues(t, syscall.F_OK, errno)
+
+	// the cache dumped offline has the links of fs, so entries under links are loaded
+	buf := &bytes.Buffer{}
+	assert.Equal(t, nil, DumpKvMeta(m.(*kvMeta).client, buf))
+	dm, err := readDumpedMeta(bytes.NewReader(buf.Bytes()))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fs-root-link", dm.Links["/link"].FsID)
+
+	assert.Equal(t, nil, os.RemoveAll(filepath.Join(root, "a")))
+	loaded := newTestKvMeta(t, root)
+	assert.Equal(t, nil, loaded.LoadMeta(buf))
+	linkIno, _, errno = loaded.Lookup(ctx, rootInodeID, "link")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	ino, _, errno := loaded.Lookup(ctx, linkIno, "a")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	assert.Equal(t, "/link/a", loaded.InoToPath(ino))
+
+	// links updated after mounted are saved too
+	assert.Equal(t, nil, m.(*kvMeta).defaultMeta.(*DefaultMeta).UpdateUFSMap(nil))
+	buf.Reset()
+	assert.Equal(t, nil, DumpKvMeta(m.(*kvMeta).client, buf))
+	dm, err = readDumpedMeta(buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(dm.Links))
+}
+
+func TestSplitEntryKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		parent string
+		path   string
+		ok     bool
+	}{
+		{key: "//", parent: "/", path: "/", ok: true},
+		{key: "//a", parent: "/", path: "/a", ok: true},
+		{key: "/a/a", parent: "/a", path: "/a", ok: true},
+		{key: "/a/a/b", parent: "/a", path: "/a/b", ok: true},
+		{key: "/a/a/a/a/x", parent: "/a/a", path: "/a/a/x", ok: true},
+		{key: "/a/b", ok: false},
+	}
+	for _, tt := range tests {
+		parent, path, ok := splitEntryKey(tt.key)
+		assert.Equal(t, tt.ok, ok, tt.key)
+		assert.Equal(t, tt.parent, parent, tt.key)
+		assert.Equal(t, tt.path, path, tt.key)
+	}
+}
@@ -145,6 +145,27 @@ func (m *InodeHandle) PathToInode(path string) *Inode {
 
 }
 
+// CreatePathInode returns the inode of path, the missing inodes along the path are created and the
+// parents of path are taken as directories. It returns nil if any parent of path is not a directory.
+func (m *InodeHandle) CreatePathInode(path string, isDir bool) *Inode {
+	node := m.toInode(rootInodeID)
+	ss := strings.Split(path, "/")
+	for i, name := range ss {
+		if len(name) == 0 {
+			continue
+		}
+		if node == nil || !node.IsDir() {
+			return nil
+		}
+		if child := node.GetChild(name); child != 0 {
+			node = m.toInode(child)
+			continue
+		}
+		node = node.NewChild(name, isDir || i < len(ss)-1)
+	}
+	return node
+}
+
 func (n *Inode) NewChild(name string, isDir bool) *Inode {
 	node := n.inodeHandle.NewInode(name, isDir)
 	node.parent = n
@@ -0,0 +1,309 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+)
+
+const (
+	FlockKey = "F"
+	PlockKey = "L"
+
+	// lockRetryInterval is the max interval to check the lock again when waiting, in case the lock is
+	// released by others which does not notify this client
+	lockRetryInterval = time.Second
+)
+
+// Locker manages advisory locks of files, flock locks the whole file and plock locks a range of file.
+// The lock type is one of syscall.F_RDLCK, syscall.F_WRLCK and syscall.F_UNLCK.
+type Locker interface {
+	// Flock sets or releases a lock of whole file for owner.
+	Flock(ctx *Context, inode Ino, owner uint64, ltype uint32, block bool) syscall.Errno
+	// Getlk returns the first lock conflicting with the given range lock.
+	Getlk(ctx *Context, inode Ino, owner uint64, ltype *uint32, start, end *uint64, pid *uint32) syscall.Errno
+	// Setlk sets or releases a lock of range [start, end] for owner.
+	Setlk(ctx *Context, inode Ino, owner uint64, block bool, ltype uint32, start, end uint64, pid uint32) syscall.Errno
+}
+
+type flockRecord struct {
+	Owner uint64 `json:"owner"`
+	Type  uint32 `json:"type"`
+}
+
+type plockRecord struct {
+	Owner uint64 `json:"owner"`
+	Pid   uint32 `json:"pid"`
+	Type  uint32 `json:"type"`
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+// kvLocker holds locks in kv store, and it is correct within the clients sharing the locker.
+type kvLocker struct {
+	sync.Mutex
+	client kv.Client
+	// changed is closed and replaced when any lock is released
+	changed chan struct{}
+}
+
+var _ Locker = &kvLocker{}
+
+func NewKvLocker(client kv.Client) Locker {
+	l := &kvLocker{
+		client:  client,
+		changed: make(chan struct{}),
+	}
+	l.clean()
+	return l
+}
+
+// clean removes locks left by the last mount, they are stale as inodes are allocated again after mounted
+func (l *kvLocker) clean() {
+	for _, prefix := range []string{FlockKey, PlockKey} {
+		values, err := l.client.ScanValues([]byte(prefix))
+		if err != nil {
+			log.Errorf("scan locks with prefix[%s] failed: %v", prefix, err)
+			continue
+		}
+		keys := make([][]byte, 0, len(values))
+		for key := range values {
+			keys = append(keys, []byte(key))
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		if err = l.client.Dels(keys...); err != nil {
+			log.Errorf("remove stale locks with prefix[%s] failed: %v", prefix, err)
+		}
+	}
+}
+
+func (l *kvLocker) flockKey(inode Ino) []byte {
+	return []byte(fmt.Sprintf("%s%d", FlockKey, inode))
+}
+
+func (l *kvLocker) plockKey(inode Ino) []byte {
+	return []byte(fmt.Sprintf("%s%d", PlockKey, inode))
+}
+
+func (l *kvLocker) load(key []byte, records interface{}) error {
+	data, ok := l.client.Get(key)
+	if !ok || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, records)
+}
+
+func (l *kvLocker) save(key []byte, records interface{}, empty bool) error {
+	if empty {
+		return l.client.Dels(key)
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return l.client.Set(key, data)
+}
+
+// notify wakes up the waiters, it must be called with lock held
+func (l *kvLocker) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// wait waits until locks changed, it returns EINTR if the request is interrupted. It must be called with
+// lock held, and the lock is held again when it returns.
+func (l *kvLocker) wait(ctx *Context) syscall.Errno {
+	changed := l.changed
+	l.Unlock()
+	defer l.Lock()
+	var cancel <-chan struct{}
+	if ctx != nil {
+		cancel = ctx.cancel
+	}
+	select {
+	case <-changed:
+	case <-cancel:
+		return syscall.EINTR
+	case <-time.After(lockRetryInterval):
+	}
+	return syscall.F_OK
+}
+
+func (l *kvLocker) Flock(ctx *Context, inode Ino, owner uint64, ltype uint32, block bool) syscall.Errno {
+	if ltype != syscall.F_RDLCK && ltype != syscall.F_WRLCK && ltype != syscall.F_UNLCK {
+		return syscall.EINVAL
+	}
+	key := l.flockKey(inode)
+	l.Lock()
+	defer l.Unlock()
+	for {
+		var records []flockRecord
+		if err := l.load(key, &records); err != nil {
+			log.Errorf("load flock of inode[%d] failed: %v", inode, err)
+			return syscall.EIO
+		}
+		conflict := false
+		kept := make([]flockRecord, 0, len(records)+1)
+		for _, r := range records {
+			if r.Owner == owner {
+				continue
+			}
+			if ltype == syscall.F_WRLCK || r.Type == syscall.F_WRLCK {
+				conflict = true
+			}
+			kept = append(kept, r)
+		}
+		if ltype != syscall.F_UNLCK && conflict {
+			if !block {
+				return syscall.EAGAIN
+			}
+			if err := l.wait(ctx); err != syscall.F_OK {
+				return err
+			}
+			continue
+		}
+		if ltype != syscall.F_UNLCK {
+			kept = append(kept, flockRecord{Owner: owner, Type: ltype})
+		}
+		if err := l.save(key, kept, len(kept) == 0); err != nil {
+			log.Errorf("save flock of inode[%d] failed: %v", inode, err)
+			return syscall.EIO
+		}
+		if len(kept) < len(records) || ltype == syscall.F_RDLCK {
+			// lock is released or downgraded
+			l.notify()
+		}
+		return syscall.F_OK
+	}
+}
+
+func (l *kvLocker) Getlk(ctx *Context, inode Ino, owner uint64, ltype *uint32, start, end *uint64, pid *uint32) syscall.Errno {
+	if *ltype == syscall.F_UNLCK {
+		*start, *end, *pid = 0, 0, 0
+		return syscall.F_OK
+	}
+	l.Lock()
+	defer l.Unlock()
+	var records []plockRecord
+	if err := l.load(l.plockKey(inode), &records); err != nil {
+		log.Errorf("load plock of inode[%d] failed: %v", inode, err)
+		return syscall.EIO
+	}
+	for _, r := range records {
+		if isConflictPlock(r, owner, *ltype, *start, *end) {
+			*ltype, *start, *end, *pid = r.Type, r.Start, r.End, r.Pid
+			return syscall.F_OK
+		}
+	}
+	*ltype = syscall.F_UNLCK
+	*start, *end, *pid = 0, 0, 0
+	return syscall.F_OK
+}
+
+func (l *kvLocker) Setlk(ctx *Context, inode Ino, owner uint64, block bool, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
+	if ltype != syscall.F_RDLCK && ltype != syscall.F_WRLCK && ltype != syscall.F_UNLCK {
+		return syscall.EINVAL
+	}
+	if start > end {
+		return syscall.EINVAL
+	}
+	key := l.plockKey(inode)
+	l.Lock()
+	defer l.Unlock()
+	for {
+		var records []plockRecord
+		if err := l.load(key, &records); err != nil {
+			log.Errorf("load plock of inode[%d] failed: %v", inode, err)
+			return syscall.EIO
+		}
+		conflict := false
+		for _, r := range records {
+			if isConflictPlock(r, owner, ltype, start, end) {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			if !block {
+				return syscall.EAGAIN
+			}
+			if err := l.wait(ctx); err != syscall.F_OK {
+				return err
+			}
+			continue
+		}
+		updated, released := updatePlocks(records, plockRecord{Owner: owner, Pid: pid, Type: ltype, Start: start, End: end})
+		if err := l.save(key, updated, len(updated) == 0); err != nil {
+			log.Errorf("save plock of inode[%d] failed: %v", inode, err)
+			return syscall.EIO
+		}
+		if released {
+			l.notify()
+		}
+		return syscall.F_OK
+	}
+}
+
+func isConflictPlock(r plockRecord, owner uint64, ltype uint32, start, end uint64) bool {
+	if ltype == syscall.F_UNLCK || r.Owner == owner || r.End < start || r.Start > end {
+		return false
+	}
+	return ltype == syscall.F_WRLCK || r.Type == syscall.F_WRLCK
+}
+
+// updatePlocks replaces the range of owner's locks with the new lock, the locks of owner are split if they
+// overlap with the new one partially. It returns whether any lock is released or downgraded.
+func updatePlocks(records []plockRecord, lock plockRecord) ([]plockRecord, bool) {
+	updated := make([]plockRecord, 0, len(records)+2)
+	released := false
+	for _, r := range records {
+		if r.Owner != lock.Owner || r.End < lock.Start || r.Start > lock.End {
+			updated = append(updated, r)
+			continue
+		}
+		if r.Type == syscall.F_WRLCK && lock.Type != syscall.F_WRLCK {
+			released = true
+		}
+		if r.Start < lock.Start {
+			left := r
+			left.End = lock.Start - 1
+			updated = append(updated, left)
+		}
+		if r.End > lock.End {
+			right := r
+			right.Start = lock.End + 1
+			updated = append(updated, right)
+		}
+		if lock.Type == syscall.F_UNLCK {
+			released = true
+		}
+	}
+	if lock.Type != syscall.F_UNLCK {
+		updated = append(updated, lock)
+	}
+	return updated, released
+}
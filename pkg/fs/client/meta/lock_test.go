@@ -0,0 +1,145 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+)
+
+func newTestLocker(t *testing.T) Locker {
+	client, err := kv.NewMemClient(kv.Config{})
+	assert.Equal(t, nil, err)
+	return NewKvLocker(client)
+}
+
+func TestKvLocker_Flock(t *testing.T) {
+	l := newTestLocker(t)
+	ctx := NewEmptyContext()
+	var ino Ino = 2
+
+	// shared locks
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, ino, 1, syscall.F_RDLCK, false))
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, ino, 2, syscall.F_RDLCK, false))
+	assert.Equal(t, syscall.EAGAIN, l.Flock(ctx, ino, 3, syscall.F_WRLCK, false))
+	// upgrade conflicts with the other reader
+	assert.Equal(t, syscall.EAGAIN, l.Flock(ctx, ino, 1, syscall.F_WRLCK, false))
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, ino, 2, syscall.F_UNLCK, false))
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, ino, 1, syscall.F_WRLCK, false))
+	// other files are not locked
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, ino+1, 3, syscall.F_WRLCK, false))
+
+	// blocked until the lock is released
+	done := make(chan syscall.Errno)
+	go func() {
+		done <- l.Flock(ctx, ino, 3, syscall.F_WRLCK, true)
+	}()
+	select {
+	case <-done:
+		t.Fatal("flock should be blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, ino, 1, syscall.F_UNLCK, false))
+	select {
+	case errno := <-done:
+		assert.EqualValues(t, syscall.F_OK, errno)
+	case <-time.After(time.Second):
+		t.Fatal("flock should be granted")
+	}
+
+	// waiting is interrupted
+	cancel := make(chan struct{})
+	go func() {
+		done <- l.Flock(NewContext(cancel, 0, 0, 0), ino, 4, syscall.F_RDLCK, true)
+	}()
+	close(cancel)
+	assert.Equal(t, syscall.EINTR, <-done)
+}
+
+func TestKvLocker_Plock(t *testing.T) {
+	l := newTestLocker(t)
+	ctx := NewEmptyContext()
+	var ino Ino = 2
+
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, ino, 1, false, syscall.F_WRLCK, 0, 99, 10))
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, ino, 2, false, syscall.F_WRLCK, 100, 199, 20))
+	assert.Equal(t, syscall.EAGAIN, l.Setlk(ctx, ino, 2, false, syscall.F_RDLCK, 50, 150, 20))
+	assert.Equal(t, syscall.EINVAL, l.Setlk(ctx, ino, 2, false, syscall.F_RDLCK, 10, 5, 20))
+
+	ltype, start, end, pid := uint32(syscall.F_RDLCK), uint64(50), uint64(60), uint32(0)
+	assert.EqualValues(t, syscall.F_OK, l.Getlk(ctx, ino, 2, &ltype, &start, &end, &pid))
+	assert.Equal(t, uint32(syscall.F_WRLCK), ltype)
+	assert.Equal(t, uint64(0), start)
+	assert.Equal(t, uint64(99), end)
+	assert.Equal(t, uint32(10), pid)
+
+	// unlock the middle of range, the lock is split
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, ino, 1, false, syscall.F_UNLCK, 40, 59, 10))
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, ino, 2, false, syscall.F_WRLCK, 40, 59, 20))
+	assert.Equal(t, syscall.EAGAIN, l.Setlk(ctx, ino, 2, false, syscall.F_RDLCK, 30, 39, 20))
+	assert.Equal(t, syscall.EAGAIN, l.Setlk(ctx, ino, 2, false, syscall.F_RDLCK, 60, 60, 20))
+
+	ltype, start, end = syscall.F_WRLCK, 40, 59
+	assert.EqualValues(t, syscall.F_OK, l.Getlk(ctx, ino, 3, &ltype, &start, &end, &pid))
+	assert.Equal(t, uint32(syscall.F_WRLCK), ltype)
+	assert.Equal(t, uint32(20), pid)
+
+	// downgrade to read lock, and other readers are allowed
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, ino, 1, false, syscall.F_RDLCK, 0, 39, 10))
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, ino, 3, false, syscall.F_RDLCK, 0, 39, 30))
+
+	// blocked until the range is released
+	done := make(chan syscall.Errno)
+	go func() {
+		done <- l.Setlk(ctx, ino, 3, true, syscall.F_WRLCK, 150, 160, 30)
+	}()
+	select {
+	case <-done:
+		t.Fatal("setlk should be blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, ino, 2, false, syscall.F_UNLCK, 0, 0x7FFFFFFFFFFFFFFF, 20))
+	select {
+	case errno := <-done:
+		assert.EqualValues(t, syscall.F_OK, errno)
+	case <-time.After(time.Second):
+		t.Fatal("setlk should be granted")
+	}
+
+	ltype, start, end = syscall.F_WRLCK, 40, 59
+	assert.EqualValues(t, syscall.F_OK, l.Getlk(ctx, ino, 3, &ltype, &start, &end, &pid))
+	assert.Equal(t, uint32(syscall.F_UNLCK), ltype)
+}
+
+func TestKvLocker_cleanStaleLocks(t *testing.T) {
+	client, err := kv.NewMemClient(kv.Config{})
+	assert.Equal(t, nil, err)
+	l := NewKvLocker(client)
+	ctx := NewEmptyContext()
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, 2, 1, syscall.F_WRLCK, false))
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, 2, 1, false, syscall.F_WRLCK, 0, 10, 1))
+
+	// locks of the last mount are removed
+	l = NewKvLocker(client)
+	assert.EqualValues(t, syscall.F_OK, l.Flock(ctx, 2, 2, syscall.F_WRLCK, false))
+	assert.EqualValues(t, syscall.F_OK, l.Setlk(ctx, 2, 2, false, syscall.F_WRLCK, 0, 10, 2))
+}
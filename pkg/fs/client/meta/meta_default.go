@@ -31,6 +31,7 @@ import (
 
 	apicommon "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/base"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
 	ufslib "github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/utils"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
@@ -44,12 +45,16 @@ type DefaultMeta struct {
 	name        string
 	defaultUfs  ufslib.UnderFileStorage
 	ufsMap      *sync.Map
+	links       map[string]common.FSMeta
 	ufsMapLock  sync.RWMutex
 	ufsMapUT    int64
 	inodeHandle *InodeHandle
 	setOwner    bool
 	uid         uint32
 	gid         uint32
+	locker      Locker
+	// linksUpdated is called with the new links after the links of fs are updated
+	linksUpdated func(links map[string]DumpedLink)
 }
 
 var _ Meta = &DefaultMeta{}
@@ -68,6 +73,12 @@ func InitDefaultMeta(fsMeta common.FSMeta, links map[string]common.FSMeta, inode
 	if err != nil {
 		return nil, err
 	}
+	// default meta has no kv store, so locks are held in memory
+	lockClient, err := kv.NewMemClient(kv.Config{FsID: fsMeta.ID})
+	if err != nil {
+		return nil, err
+	}
+	meta.locker = NewKvLocker(lockClient)
 	return meta, nil
 }
 
@@ -267,12 +278,30 @@ func (m *DefaultMeta) Fallocate(ctx *Context, inode Ino, mode uint8, off uint64,
 
 // ReadLink returns the target of a symlink.
 func (m *DefaultMeta) ReadLink(ctx *Context, inode Ino, path *[]byte) syscall.Errno {
-	return syscall.ENOSYS
+	name := m.inodeHandle.InoToPath(inode)
+	ufs, _, _, ufsPath := m.GetUFS(name)
+	target, err := ufs.Readlink(ufsPath)
+	if err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	*path = []byte(target)
+	return syscall.F_OK
 }
 
 // Symlink creates a symlink in a directory with given name.
 func (m *DefaultMeta) Symlink(ctx *Context, parent Ino, name string, path string, inode *Ino, attr *Attr) syscall.Errno {
-	return syscall.ENOSYS
+	pnode := m.inodeHandle.toInode(parent)
+	linkPath := m.inodeHandle.ParentInodeToPath(pnode, name)
+	ufs, _, _, newPath := m.GetUFS(linkPath)
+	if err := ufs.Symlink(path, newPath); err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	node := pnode.NewChild(name, false)
+	*inode = node.inode
+	if err := m.getAttr(linkPath, attr); utils.IsError(err) {
+		return err
+	}
+	return syscall.F_OK
 }
 
 // Mknod creates a node in a directory with given name, type and permissions.
@@ -560,25 +589,33 @@ func (m *DefaultMeta) RemoveXattr(ctx *Context, inode Ino, attribute string) sys
 
 // Flock tries to put a lock on given file.
 func (m *DefaultMeta) Flock(ctx *Context, inode Ino, owner uint64, ltype uint32, block bool) syscall.Errno {
-	return syscall.ENOSYS
+	return m.locker.Flock(ctx, inode, owner, ltype, block)
 }
 
 // Getlk returns the current lock owner for a range on a file.
 func (m *DefaultMeta) Getlk(ctx *Context, inode Ino, owner uint64, ltype *uint32, start, end *uint64, pid *uint32) syscall.Errno {
-	return syscall.ENOSYS
+	return m.locker.Getlk(ctx, inode, owner, ltype, start, end, pid)
 }
 
 // Setlk sets a file range lock on given file.
 func (m *DefaultMeta) Setlk(ctx *Context, inode Ino, owner uint64, block bool, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
-	return syscall.ENOSYS
+	return m.locker.Setlk(ctx, inode, owner, block, ltype, start, end, pid)
 }
 
+// DumpMeta dumps the link table only, as default meta has no cache.
 func (m *DefaultMeta) DumpMeta(w io.Writer) error {
-	return syscall.ENOSYS
+	dm := newDumpedMeta(m.name)
+	dm.Links = m.dumpLinks()
+	return dm.write(w)
 }
 
+// LoadMeta checks the dumped meta, there is nothing to load as default meta has no cache.
 func (m *DefaultMeta) LoadMeta(r io.Reader) error {
-	return syscall.ENOSYS
+	if _, err := readDumpedMeta(r); err != nil {
+		return err
+	}
+	log.Infof("meta driver[%s] has no cache, dumped entries are ignored", m.name)
+	return nil
 }
 
 func (m *DefaultMeta) LinksMetaUpdateHandler(stopChan chan struct{}, interval int, linkMetaDirPrefix string) error {
@@ -662,7 +699,11 @@ func (m *DefaultMeta) UpdateUFSMap(fsMetas map[string]common.FSMeta) error {
 	}
 	m.ufsMapLock.Lock()
 	m.ufsMap = &ufsMap
+	m.links = fsMetas
 	m.ufsMapLock.Unlock()
+	if m.linksUpdated != nil {
+		m.linksUpdated(m.dumpLinks())
+	}
 	return nil
 }
 
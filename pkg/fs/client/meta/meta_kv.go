@@ -19,7 +19,6 @@ package meta
 import (
 	"bytes"
 	"fmt"
-	"io"
 	"path/filepath"
 	"syscall"
 	"time"
@@ -36,6 +35,8 @@ import (
 const (
 	AttrKey  = "A"
 	EntryKey = "P"
+	// LinkKey is the key of links of fs, which are dumped with the cache
+	LinkKey = "L"
 	// attrCacheSize struct size
 	attrCacheSize = 97
 	// entryCacheSize struct size
@@ -51,6 +52,7 @@ type kvMeta struct {
 	defaultMeta  Meta
 	attrTimeOut  time.Duration
 	entryTimeOut time.Duration
+	locker       Locker
 }
 
 type entryCacheItem struct {
@@ -88,6 +90,11 @@ func newKvMeta(defaultMeta Meta, config Config) (Meta, error) {
 		defaultMeta:  defaultMeta,
 		attrTimeOut:  config.AttrCacheExpire,
 		entryTimeOut: config.EntryCacheExpire,
+		locker:       NewKvLocker(client),
+	}
+	if defaultMeta, ok := defaultMeta.(*DefaultMeta); ok {
+		m.saveLinks(defaultMeta.dumpLinks())
+		defaultMeta.linksUpdated = m.saveLinks
 	}
 	return m, nil
 }
@@ -380,7 +387,22 @@ func (m *kvMeta) ReadLink(ctx *Context, inode Ino, path *[]byte) syscall.Errno {
 
 // Symlink creates a symlink in a directory with given name.
 func (m *kvMeta) Symlink(ctx *Context, parent Ino, name string, path string, inode *Ino, attr *Attr) syscall.Errno {
-	return m.defaultMeta.Symlink(ctx, parent, name, path, inode, attr)
+	if err := m.defaultMeta.Symlink(ctx, parent, name, path, inode, attr); utils.IsError(err) {
+		return err
+	}
+	now := time.Now()
+	attrExpire := now.Add(m.attrTimeOut).Unix()
+	entryExpire := now.Add(m.entryTimeOut).Unix()
+	attrCache_ := &attrCacheItem{attr: *attr}
+	fullPath := m.InoToPath(*inode)
+	m.putAttr(fullPath, *attrCache_, attrExpire)
+
+	entryCache_ := &entryCacheItem{
+		ino:  *inode,
+		mode: attr.Mode,
+	}
+	m.putEntry(m.InoToPath(parent), *entryCache_, entryExpire)
+	return syscall.F_OK
 }
 
 // Mknod creates a node in a directory with given name, type and permissions.
@@ -660,25 +682,17 @@ func (m *kvMeta) RemoveXattr(ctx *Context, inode Ino, name string) syscall.Errno
 
 // Flock tries to put a lock on given file.
 func (m *kvMeta) Flock(ctx *Context, inode Ino, owner uint64, ltype uint32, block bool) syscall.Errno {
-	return m.defaultMeta.Flock(ctx, inode, owner, ltype, block)
+	return m.locker.Flock(ctx, inode, owner, ltype, block)
 }
 
 // Getlk returns the current lock owner for a range on a file.
 func (m *kvMeta) Getlk(ctx *Context, inode Ino, owner uint64, ltype *uint32, start, end *uint64, pid *uint32) syscall.Errno {
-	return m.defaultMeta.Getlk(ctx, inode, owner, ltype, start, end, pid)
+	return m.locker.Getlk(ctx, inode, owner, ltype, start, end, pid)
 }
 
 // Setlk sets a file range lock on given file.
 func (m *kvMeta) Setlk(ctx *Context, inode Ino, owner uint64, block bool, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
-	return m.defaultMeta.Setlk(ctx, inode, owner, block, ltype, start, end, pid)
-}
-
-func (m *kvMeta) DumpMeta(w io.Writer) error {
-	return syscall.ENOSYS
-}
-
-func (m *kvMeta) LoadMeta(r io.Reader) error {
-	return syscall.ENOSYS
+	return m.locker.Setlk(ctx, inode, owner, block, ltype, start, end, pid)
 }
 
 func (m *kvMeta) LinksMetaUpdateHandler(stopChan chan struct{}, interval int, linkMetaDirPrefix string) error {
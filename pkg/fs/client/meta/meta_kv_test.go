@@ -0,0 +1,62 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKvMeta_Symlink(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, nil, os.WriteFile(filepath.Join(root, "target"), []byte("0123456789"), 0644))
+
+	ctx := NewEmptyContext()
+	m := newTestKvMeta(t, root)
+	var ino Ino
+	attr := &Attr{}
+	errno := m.Symlink(ctx, rootInodeID, "slink", "target", &ino, attr)
+	assert.EqualValues(t, syscall.F_OK, errno)
+	assert.Equal(t, uint8(TypeSymlink), attr.Type)
+	assert.Equal(t, uint32(syscall.S_IFLNK), attr.Mode&syscall.S_IFMT)
+	errno = m.Symlink(ctx, rootInodeID, "slink", "target", &ino, attr)
+	assert.EqualValues(t, syscall.EEXIST, errno)
+
+	value, err := os.Readlink(filepath.Join(root, "slink"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "target", value)
+
+	lookupIno, lookupAttr, errno := m.Lookup(ctx, rootInodeID, "slink")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	assert.Equal(t, ino, lookupIno)
+	assert.Equal(t, uint32(syscall.S_IFLNK), lookupAttr.Mode&syscall.S_IFMT)
+
+	var path []byte
+	errno = m.ReadLink(ctx, ino, &path)
+	assert.EqualValues(t, syscall.F_OK, errno)
+	assert.Equal(t, "target", string(path))
+
+	// 普通文件不是软链接
+	targetIno, _, errno := m.Lookup(ctx, rootInodeID, "target")
+	assert.EqualValues(t, syscall.F_OK, errno)
+	errno = m.ReadLink(ctx, targetIno, &path)
+	assert.NotEqualValues(t, syscall.F_OK, errno)
+}
@@ -27,6 +27,7 @@ import (
 const (
 	TypeFile      = 1 // type for regular file
 	TypeDirectory = 2 // type for directory
+	TypeSymlink   = 3 // type for symlink
 )
 
 // under file storage interface, copy from pathfs.FileSystem,
@@ -107,16 +107,9 @@ func (fs *localFileSystem) SetXAttr(name string, attr string, data []byte, flags
 }
 
 func (fs *localFileSystem) Utimens(name string, Atime *time.Time, Mtime *time.Time) error {
-	var tv []syscall.Timeval
-
-	tv = append(tv, syscall.Timeval{
-		Sec:  int64(Atime.Second()),
-		Usec: Atime.Unix(),
-	})
-
-	tv = append(tv, syscall.Timeval{
-		Sec:  int64(Mtime.Second()),
-		Usec: Mtime.Unix(),
-	})
+	tv := []syscall.Timeval{
+		syscall.NsecToTimeval(Atime.UnixNano()),
+		syscall.NsecToTimeval(Mtime.UnixNano()),
+	}
 	return syscall.Utimes(fs.GetPath(name), tv)
 }
@@ -18,7 +18,10 @@ package ufs
 
 import (
 	"bytes"
+	"container/list"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -26,6 +29,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -63,6 +67,20 @@ const (
 	MPUMaxPartNum   = 10000                  // s3: between 1~10,000
 	DefaultDirMode  = 0755
 	DefaultFileMode = 0644
+	// symlink is emulated by a small object with the target as content, and marked by user metadata
+	S3MetaSymlink  = "Pfs-Symlink"
+	SymlinkMaxSize = 4096
+	// extended attributes are stored as user metadata, user metadata keys are case-insensitive,
+	// so the attribute name is hex encoded in key, and the value is base64 encoded
+	S3MetaXAttrPrefix = "Pfs-Xattr-"
+	S3MaxMetaSize     = 2 * 1024               // s3: user-defined metadata is limited to 2 KB
+	MaxCopySize       = 5 * 1024 * 1024 * 1024 // s3: copy object in a single operation upto 5 GB
+	// concurrency of head objects to find symlinks when reading dir
+	symlinkHeadConcurrency = 16
+	// max number of objects whose symlink check result is cached
+	maxSymlinkCacheEntries = 100000
+	xattrCreate            = 0x1
+	xattrReplace           = 0x2
 )
 
 var Owner string
@@ -78,10 +96,64 @@ type s3FileSystem struct {
 	defaultTime time.Time
 	sync.Mutex
 	chunkPool *sync.Pool
+	// symlinks is nil unless symlink emulation is enabled by the fs properties
+	symlinks *symlinkCache
 }
 
 var _ UnderFileStorage = &s3FileSystem{}
 
+// symlinkCache remembers whether small objects are symlinks, so that reading a dir does not head the same
+// objects again. A cached result is valid until the mtime or size of the object changes, and the least
+// recently used results are evicted when the cache is full
+type symlinkCache struct {
+	sync.Mutex
+	maxSize int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type symlinkCacheEntry struct {
+	key     string
+	mtime   int64
+	size    uint64
+	symlink bool
+}
+
+func newSymlinkCache(maxSize int) *symlinkCache {
+	return &symlinkCache{maxSize: maxSize, lru: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *symlinkCache) get(key string, mtime int64, size uint64) (symlink bool, ok bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	entry := elem.Value.(*symlinkCacheEntry)
+	if entry.mtime != mtime || entry.size != size {
+		return false, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.symlink, true
+}
+
+func (c *symlinkCache) set(key string, mtime int64, size uint64, symlink bool) {
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &symlinkCacheEntry{key: key, mtime: mtime, size: size, symlink: symlink}
+		c.lru.MoveToFront(elem)
+		return
+	}
+	for c.lru.Len() >= c.maxSize {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*symlinkCacheEntry).key)
+	}
+	c.entries[key] = c.lru.PushFront(&symlinkCacheEntry{key: key, mtime: mtime, size: size, symlink: symlink})
+}
+
 // Used for pretty printing.
 func (fs *s3FileSystem) String() string {
 	return fsCommon.S3Type
@@ -334,12 +406,18 @@ func (fs *s3FileSystem) isDirExist(name string) error {
 // c/b/ -> non-empty directory
 // c/b/c/ -> empty directory
 func (fs *s3FileSystem) GetAttr(name string) (*base.FileInfo, error) {
+	finfo, _, err := fs.getAttr(name)
+	return finfo, err
+}
+
+// getAttr returns the user metadata of the object as well, which is nil for dirs without object
+func (fs *s3FileSystem) getAttr(name string) (*base.FileInfo, map[string]*string, error) {
 	log.Tracef("s3 getAttr: name[%s]", name)
 	name = toS3Path(name)
 	path := fs.getFullPath(name)
 
 	if path == "" {
-		return fs.getRootDirAttr(), nil
+		return fs.getRootDirAttr(), nil, nil
 	}
 
 	request := &s3.HeadObjectInput{
@@ -354,9 +432,10 @@ func (fs *s3FileSystem) GetAttr(name string) (*base.FileInfo, error) {
 		log.Debugf("s3 getAttr: name[%s] s3.HeadObject failed. err: %v", name, err)
 		if isNotExistErr(err) {
 			// compatible with case where s3 dir can have no key
-			return fs.getDefaultDirAttr(name)
+			finfo, err := fs.getDefaultDirAttr(name)
+			return finfo, nil, err
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	aTime := fuse.UtimeToTimespec(response.LastModified)
@@ -369,6 +448,8 @@ func (fs *s3FileSystem) GetAttr(name string) (*base.FileInfo, error) {
 	if isDir {
 		size = 4096
 		mode = syscall.S_IFDIR | fs.dirMode
+	} else if fs.symlinks != nil && isSymlink(response.Metadata) {
+		mode = syscall.S_IFLNK | 0777
 	}
 
 	uid := uint32(utils.LookupUser(Owner))
@@ -385,7 +466,7 @@ func (fs *s3FileSystem) GetAttr(name string) (*base.FileInfo, error) {
 		Group: Group,
 		Mode:  utils.StatModeToFileMode(mode),
 		Sys:   st,
-	}, nil
+	}, response.Metadata, nil
 }
 
 // These should update the file's ctime too.
@@ -719,21 +800,211 @@ func (fs *s3FileSystem) Unlink(name string) error {
 	return err
 }
 
+func isSymlink(metadata map[string]*string) bool {
+	for k, v := range metadata {
+		if strings.EqualFold(k, S3MetaSymlink) && v != nil && *v == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+func xattrMetaKey(attr string) string {
+	return S3MetaXAttrPrefix + hex.EncodeToString([]byte(attr))
+}
+
+// xattrFromMetaKey returns the attribute name of user metadata key, ok is false if it is not an attribute
+func xattrFromMetaKey(key string) (attr string, ok bool) {
+	if len(key) <= len(S3MetaXAttrPrefix) || !strings.EqualFold(key[:len(S3MetaXAttrPrefix)], S3MetaXAttrPrefix) {
+		return "", false
+	}
+	name, err := hex.DecodeString(strings.ToLower(key[len(S3MetaXAttrPrefix):]))
+	if err != nil {
+		return "", false
+	}
+	return string(name), true
+}
+
+// xattrMetadata returns the user metadata of extended attributes
+func xattrMetadata(metadata map[string]*string) map[string]*string {
+	xattrs := make(map[string]*string)
+	for k, v := range metadata {
+		if attr, ok := xattrFromMetaKey(k); ok {
+			xattrs[xattrMetaKey(attr)] = v
+		}
+	}
+	return xattrs
+}
+
+func metadataSize(metadata map[string]*string) int {
+	size := 0
+	for k, v := range metadata {
+		size += len(k)
+		if v != nil {
+			size += len(*v)
+		}
+	}
+	return size
+}
+
+// headXAttrObject returns the key and head output of the object which keeps extended attributes of name.
+// A directory without object is reported with nil output, its object is created when setting attributes.
+func (fs *s3FileSystem) headXAttrObject(name string) (string, *s3.HeadObjectOutput, error) {
+	name = toS3Path(name)
+	keys := []string{fs.getFullPath(name)}
+	if !strings.HasSuffix(name, Delimiter) {
+		keys = append(keys, fs.getFullPath(toDirPath(name)))
+	}
+	for _, key := range keys {
+		if key == "" || key == Delimiter {
+			continue
+		}
+		response, err := fs.s3.HeadObject(&s3.HeadObjectInput{
+			Bucket: &fs.bucket,
+			Key:    aws.String(key),
+		})
+		if err == nil {
+			return key, response, nil
+		}
+		if !isNotExistErr(err) {
+			log.Errorf("s3 headXAttrObject: name[%s] s3.HeadObject[%s] err: %v", name, key, err)
+			return "", nil, err
+		}
+	}
+	if err := fs.isDirExist(name); err != nil {
+		return "", nil, err
+	}
+	return fs.getFullPath(toDirPath(name)), nil, nil
+}
+
+// replaceMetadata replaces user metadata of object by copying it to itself
+func (fs *s3FileSystem) replaceMetadata(key string, head *s3.HeadObjectOutput, metadata map[string]*string) error {
+	if metadataSize(metadata) > S3MaxMetaSize {
+		return syscall.ENOSPC
+	}
+	if head == nil {
+		// directory without object
+		_, err := fs.s3.PutObject(&s3.PutObjectInput{
+			Bucket:   &fs.bucket,
+			Key:      aws.String(key),
+			Metadata: metadata,
+		})
+		if err != nil {
+			log.Errorf("s3 replaceMetadata: s3.PutObject[%s] err: %v", key, err)
+		}
+		return err
+	}
+	if head.ContentLength != nil && *head.ContentLength > MaxCopySize {
+		log.Errorf("s3 replaceMetadata: object[%s] size[%d] is too large to copy", key, *head.ContentLength)
+		return syscall.EFBIG
+	}
+	source := fs.bucket + Delimiter + key
+	_, err := fs.s3.CopyObject(&s3.CopyObjectInput{
+		Bucket:            &fs.bucket,
+		Key:               aws.String(key),
+		CopySource:        &source,
+		ContentType:       head.ContentType,
+		Metadata:          metadata,
+		MetadataDirective: aws.String(s3.MetadataDirectiveReplace),
+	})
+	if err != nil {
+		log.Errorf("s3 replaceMetadata: s3.CopyObject[%s] err: %v", key, err)
+	}
+	return err
+}
+
 // // Extended attributes.
 func (fs *s3FileSystem) GetXAttr(name string, attribute string) (data []byte, err error) {
-	return nil, syscall.ENOSYS
+	log.Tracef("s3 getXAttr: name[%s] attribute[%s]", name, attribute)
+	_, head, err := fs.headXAttrObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, syscall.ENODATA
+	}
+	value, ok := xattrMetadata(head.Metadata)[xattrMetaKey(attribute)]
+	if !ok || value == nil {
+		return nil, syscall.ENODATA
+	}
+	data, err = base64.StdEncoding.DecodeString(*value)
+	if err != nil {
+		log.Errorf("s3 getXAttr: name[%s] attribute[%s] decode err: %v", name, attribute, err)
+		return nil, syscall.EIO
+	}
+	return data, nil
 }
 
 func (fs *s3FileSystem) ListXAttr(name string) (attributes []string, err error) {
-	return nil, syscall.ENOSYS
+	log.Tracef("s3 listXAttr: name[%s]", name)
+	_, head, err := fs.headXAttrObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, nil
+	}
+	for k := range head.Metadata {
+		if attr, ok := xattrFromMetaKey(k); ok {
+			attributes = append(attributes, attr)
+		}
+	}
+	sort.Strings(attributes)
+	return attributes, nil
 }
 
 func (fs *s3FileSystem) RemoveXAttr(name string, attr string) error {
-	return syscall.ENOSYS
+	log.Tracef("s3 removeXAttr: name[%s] attr[%s]", name, attr)
+	key, head, err := fs.headXAttrObject(name)
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		return syscall.ENODATA
+	}
+	metadata := make(map[string]*string, len(head.Metadata))
+	found := false
+	for k, v := range head.Metadata {
+		if a, ok := xattrFromMetaKey(k); ok && a == attr {
+			found = true
+			continue
+		}
+		metadata[k] = v
+	}
+	if !found {
+		return syscall.ENODATA
+	}
+	return fs.replaceMetadata(key, head, metadata)
 }
 
 func (fs *s3FileSystem) SetXAttr(name string, attr string, data []byte, flags int) error {
-	return syscall.ENOSYS
+	log.Tracef("s3 setXAttr: name[%s] attr[%s] flags[%d]", name, attr, flags)
+	if attr == "" {
+		return syscall.EINVAL
+	}
+	key, head, err := fs.headXAttrObject(name)
+	if err != nil {
+		return err
+	}
+	metadata := make(map[string]*string)
+	found := false
+	if head != nil {
+		for k, v := range head.Metadata {
+			if a, ok := xattrFromMetaKey(k); ok && a == attr {
+				found = true
+				continue
+			}
+			metadata[k] = v
+		}
+	}
+	if found && flags&xattrCreate != 0 {
+		return syscall.EEXIST
+	}
+	if !found && flags&xattrReplace != 0 {
+		return syscall.ENODATA
+	}
+	metadata[xattrMetaKey(attr)] = aws.String(base64.StdEncoding.EncodeToString(data))
+	return fs.replaceMetadata(key, head, metadata)
 }
 
 func (fs *s3FileSystem) getOpenFlags(name string, flags uint32) int {
@@ -763,7 +1034,7 @@ func (fs *s3FileSystem) Open(name string, flags uint32) (fd base.FileHandle, err
 	}
 
 	// read only
-	finfo, err := fs.GetAttr(name)
+	finfo, metadata, err := fs.getAttr(name)
 	if err != nil {
 		return nil, err
 	}
@@ -778,7 +1049,9 @@ func (fs *s3FileSystem) Open(name string, flags uint32) (fd base.FileHandle, err
 	}
 
 	if flags&syscall.O_ACCMODE == syscall.O_RDWR || flags&syscall.O_ACCMODE == syscall.O_WRONLY {
-		err := fs.openForWrite(fh)
+		// keep extended attributes when the object is rewritten
+		fh.metadata = xattrMetadata(metadata)
+		err = fs.openForWrite(fh)
 		if err != nil {
 			return nil, err
 		}
@@ -888,6 +1161,7 @@ func (fs *s3FileSystem) ReadDir(name string) ([]DirEntry, error) {
 		return nil, err
 	}
 	stream := make([]DirEntry, 0)
+	var symlinkCandidates []int
 	for finfo := range ch {
 		if finfo.Name == "." {
 			continue
@@ -905,6 +1179,10 @@ func (fs *s3FileSystem) ReadDir(name string) ([]DirEntry, error) {
 		uid := uint32(utils.LookupUser(Owner))
 		gid := uint32(utils.LookupGroup(Group))
 		subName := strings.TrimSuffix(finfo.Name, Delimiter)
+		if fs.symlinks != nil && !isDir && size > 0 && size < SymlinkMaxSize {
+			// only small objects can be symlinks
+			symlinkCandidates = append(symlinkCandidates, len(stream))
+		}
 		stream = append(stream, DirEntry{
 			Attr: &Attr{
 				Type:      fileType,
@@ -920,16 +1198,120 @@ func (fs *s3FileSystem) ReadDir(name string) ([]DirEntry, error) {
 			Name: subName,
 		})
 	}
+	if len(symlinkCandidates) > 0 {
+		fs.fillSymlinks(name, stream, symlinkCandidates)
+	}
 	return stream, nil
 }
 
+// fillSymlinks marks the symlinks among the candidate entries of dir with S_IFLNK mode. Only the candidates
+// not checked before, or changed since then, are headed. A candidate failed to head is left as a regular file
+func (fs *s3FileSystem) fillSymlinks(dir string, entries []DirEntry, candidates []int) {
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, symlinkHeadConcurrency)
+	for _, idx := range candidates {
+		entry := &entries[idx]
+		key := fs.getFullPath(dir + entry.Name)
+		if symlink, ok := fs.symlinks.get(key, entry.Attr.Mtime, entry.Attr.Size); ok {
+			if symlink {
+				markSymlink(entry)
+			}
+			continue
+		}
+		limit <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			response, err := fs.s3.HeadObject(&s3.HeadObjectInput{
+				Bucket: &fs.bucket,
+				Key:    aws.String(key),
+			})
+			if err != nil {
+				if !isNotExistErr(err) {
+					log.Warningf("s3 readDir: s3.HeadObject[%s] err: %v, take it as a regular file", key, err)
+				}
+				return
+			}
+			symlink := isSymlink(response.Metadata)
+			fs.symlinks.set(key, entry.Attr.Mtime, entry.Attr.Size, symlink)
+			if symlink {
+				markSymlink(entry)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func markSymlink(entry *DirEntry) {
+	entry.Attr.Type = TypeSymlink
+	entry.Attr.Mode = syscall.S_IFLNK | 0777
+}
+
 // Symlinks.
 func (fs *s3FileSystem) Symlink(value string, linkName string) error {
-	return syscall.ENOSYS
+	log.Tracef("s3 symlink: value[%s] linkName[%s]", value, linkName)
+	if fs.symlinks == nil {
+		return syscall.ENOSYS
+	}
+	if len(value) == 0 {
+		return syscall.ENOENT
+	}
+	if len(value) >= SymlinkMaxSize {
+		return syscall.ENAMETOOLONG
+	}
+	linkName = toS3Path(linkName)
+	fs.Lock()
+	defer fs.Unlock()
+	exist, err := fs.exists(linkName)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return syscall.EEXIST
+	}
+	path := fs.getFullPath(linkName)
+	_, err = fs.s3.PutObject(&s3.PutObjectInput{
+		Bucket:   &fs.bucket,
+		Key:      aws.String(path),
+		Body:     strings.NewReader(value),
+		Metadata: map[string]*string{S3MetaSymlink: aws.String("1")},
+	})
+	if err != nil {
+		log.Errorf("s3 symlink: s3.PutObject[%s] err: %v", path, err)
+	}
+	return err
 }
 
 func (fs *s3FileSystem) Readlink(name string) (string, error) {
-	return "", syscall.ENOSYS
+	log.Tracef("s3 readlink: name[%s]", name)
+	if fs.symlinks == nil {
+		return "", syscall.ENOSYS
+	}
+	path := fs.getFullPath(toS3Path(name))
+	response, err := fs.s3.GetObject(&s3.GetObjectInput{
+		Bucket: &fs.bucket,
+		Key:    aws.String(path),
+	})
+	if err != nil {
+		if isNotExistErr(err) {
+			return "", syscall.ENOENT
+		}
+		log.Errorf("s3 readlink: s3.GetObject[%s] err: %v", path, err)
+		return "", err
+	}
+	defer response.Body.Close()
+	if !isSymlink(response.Metadata) {
+		return "", syscall.EINVAL
+	}
+	target, err := ioutil.ReadAll(io.LimitReader(response.Body, SymlinkMaxSize))
+	if err != nil {
+		log.Errorf("s3 readlink: name[%s] read err: %v", name, err)
+		return "", err
+	}
+	return string(target), nil
 }
 
 func (fs *s3FileSystem) Get(name string, flags uint32, off, limit int64) (io.ReadCloser, error) {
@@ -994,6 +1376,8 @@ type s3FileHandle struct {
 	fs             *s3FileSystem
 	mu             sync.RWMutex
 	writeDirty     bool
+	// user metadata of extended attributes, kept when the object is rewritten
+	metadata map[string]*string
 }
 
 var _ base.FileHandle = &s3FileHandle{}
@@ -1277,9 +1661,10 @@ func (fh *s3FileHandle) putFile(fileSize int64) error {
 		return err
 	}
 	request := &s3.PutObjectInput{
-		Bucket: &fh.bucket,
-		Key:    aws.String(fh.path),
-		Body:   fh.writeTmpfile,
+		Bucket:   &fh.bucket,
+		Key:      aws.String(fh.path),
+		Body:     fh.writeTmpfile,
+		Metadata: fh.metadata,
 	}
 	_, err = fh.fs.s3.PutObject(request)
 	if err != nil {
@@ -1486,6 +1871,9 @@ func NewS3FileSystem(properties map[string]interface{}) (UnderFileStorage, error
 			return make([]byte, MPUChunkSize)
 		}},
 	}
+	if properties[fsCommon.S3Symlink] == "true" {
+		fs.symlinks = newSymlinkCache(maxSymlinkCacheEntries)
+	}
 
 	exist, err := fs.isBucketExists(bucket)
 	if err != nil {
@@ -1547,8 +1935,9 @@ func (fh *s3FileHandle) multipartCreate() error {
 	log.Tracef("s3 mpu create: fh.name[%s]", fh.name)
 
 	mpu := s3.CreateMultipartUploadInput{
-		Bucket: &fh.bucket,
-		Key:    aws.String(fh.path),
+		Bucket:   &fh.bucket,
+		Key:      aws.String(fh.path),
+		Metadata: fh.metadata,
 	}
 	log.Debugf("s3 mpu create: fh.name[%s], create param: %v ", fh.name, mpu)
 
@@ -17,15 +17,26 @@ limitations under the License.
 package ufs
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/hanwen/go-fuse/v2/fuse"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
+	apiCommon "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 )
 
@@ -341,3 +352,379 @@ func TestS3Rename_ReNameOk(t *testing.T) {
 	assert.Equal(t, "testfile", list[0].Name)
 	cleanS3TestDir(fs, rename6)
 }
+
+// =================== local s3 stand-in ===================//
+
+type standInObject struct {
+	data     []byte
+	metadata http.Header
+	modTime  time.Time
+}
+
+// s3StandIn is a minimal in-memory s3 server with path style requests, it supports the object operations
+// used by s3FileSystem except multipart upload
+type s3StandIn struct {
+	sync.Mutex
+	bucket  string
+	objects map[string]*standInObject
+	// heads 记录 HEAD 请求数，failHeads 中的 key 返回 403
+	heads     int
+	failHeads map[string]bool
+}
+
+func newS3StandInFSForTest(t *testing.T, extraProperties ...string) (UnderFileStorage, *s3StandIn) {
+	standIn := &s3StandIn{bucket: "standin", objects: make(map[string]*standInObject)}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	sk, err := apiCommon.AesEncrypt("sk", apiCommon.AESEncryptKey)
+	assert.NoError(t, err)
+	properties := map[string]interface{}{
+		common.Endpoint:         server.URL,
+		common.Region:           "",
+		common.Bucket:           standIn.bucket,
+		common.AccessKey:        "ak",
+		common.SecretKey:        sk,
+		common.SubPath:          "pfs",
+		common.S3ForcePathStyle: "true",
+	}
+	for i := 0; i+1 < len(extraProperties); i += 2 {
+		properties[extraProperties[i]] = extraProperties[i+1]
+	}
+	fs, err := NewS3FileSystem(properties)
+	assert.NoError(t, err)
+	return fs, standIn
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+s.bucket), "/")
+	if key == "" {
+		if r.Method == http.MethodGet {
+			s.list(w, r)
+		}
+		return
+	}
+	if r.Method == http.MethodHead {
+		s.heads++
+		if s.failHeads[key] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+	switch r.Method {
+	case http.MethodHead, http.MethodGet:
+		obj, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+			}
+			return
+		}
+		for k, v := range obj.metadata {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodPut:
+		obj := &standInObject{metadata: make(http.Header), modTime: time.Now()}
+		if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+			source, _ = url.PathUnescape(source)
+			src, ok := s.objects[strings.TrimPrefix(strings.TrimPrefix(source, "/"), s.bucket+"/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+				return
+			}
+			obj.data = src.data
+			obj.metadata = src.metadata
+			if r.Header.Get("X-Amz-Metadata-Directive") == s3.MetadataDirectiveReplace {
+				obj.metadata = userMetadata(r.Header)
+			}
+			s.objects[key] = obj
+			fmt.Fprintf(w, "<CopyObjectResult><ETag>\"etag\"</ETag></CopyObjectResult>")
+			return
+		}
+		obj.data, _ = ioutil.ReadAll(r.Body)
+		obj.metadata = userMetadata(r.Header)
+		s.objects[key] = obj
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func userMetadata(header http.Header) http.Header {
+	metadata := make(http.Header)
+	for k, v := range header {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") {
+			metadata[k] = v
+		}
+	}
+	return metadata
+}
+
+func (s *s3StandIn) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	delimiter := r.URL.Query().Get("delimiter")
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var contents, prefixes strings.Builder
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				common := key[:len(prefix)+i+1]
+				if !seen[common] {
+					seen[common] = true
+					fmt.Fprintf(&prefixes, "<CommonPrefixes><Prefix>%s</Prefix></CommonPrefixes>", common)
+				}
+				continue
+			}
+		}
+		obj := s.objects[key]
+		fmt.Fprintf(&contents, "<Contents><Key>%s</Key><LastModified>%s</LastModified><Size>%d</Size>"+
+			"<ETag>\"etag\"</ETag><StorageClass>STANDARD</StorageClass></Contents>",
+			key, obj.modTime.UTC().Format(time.RFC3339), len(obj.data))
+	}
+	fmt.Fprintf(w, "<ListBucketResult><Name>%s</Name><Prefix>%s</Prefix><IsTruncated>false</IsTruncated>%s%s</ListBucketResult>",
+		s.bucket, prefix, contents.String(), prefixes.String())
+}
+
+// =================== local s3 stand-in ends ===================//
+
+func TestS3XAttr(t *testing.T) {
+	fs, standIn := newS3StandInFSForTest(t)
+	file := "xattr/file"
+	err := createS3TestFile(fs, file, "content")
+	assert.NoError(t, err)
+
+	// 不存在的属性
+	_, err = fs.GetXAttr(file, "user.a")
+	assert.Equal(t, syscall.ENODATA, err)
+	err = fs.SetXAttr(file, "user.a", []byte("x"), xattrReplace)
+	assert.Equal(t, syscall.ENODATA, err)
+	attrs, err := fs.ListXAttr(file)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(attrs))
+
+	// 属性名大小写和特殊字符、二进制属性值都能保留
+	err = fs.SetXAttr(file, "user.Mime_Type", []byte("text/plain"), 0)
+	assert.NoError(t, err)
+	err = fs.SetXAttr(file, "security.capability", []byte{0, 1, 2, 255}, xattrCreate)
+	assert.NoError(t, err)
+	err = fs.SetXAttr(file, "security.capability", []byte{3}, xattrCreate)
+	assert.Equal(t, syscall.EEXIST, err)
+	data, err := fs.GetXAttr(file, "user.Mime_Type")
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", string(data))
+	data, err = fs.GetXAttr(file, "security.capability")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2, 255}, data)
+	_, err = fs.GetXAttr(file, "user.mime_type")
+	assert.Equal(t, syscall.ENODATA, err)
+	attrs, err = fs.ListXAttr(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"security.capability", "user.Mime_Type"}, attrs)
+
+	// 修改属性不影响文件内容
+	err = fs.SetXAttr(file, "user.Mime_Type", []byte("text/html"), xattrReplace)
+	assert.NoError(t, err)
+	data, err = fs.GetXAttr(file, "user.Mime_Type")
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html", string(data))
+	assert.Equal(t, "content", string(standIn.objects["pfs/"+file].data))
+
+	// 重写文件和重命名后属性仍然保留
+	fh, err := fs.Open(file, uint32(os.O_WRONLY))
+	assert.NoError(t, err)
+	fh.Write([]byte("new content"), 0)
+	fh.Flush()
+	fh.Release()
+	err = fs.Rename(file, "xattr/renamed")
+	assert.NoError(t, err)
+	data, err = fs.GetXAttr("xattr/renamed", "user.Mime_Type")
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html", string(data))
+	assert.Equal(t, "new content", string(standIn.objects["pfs/xattr/renamed"].data))
+
+	err = fs.RemoveXAttr("xattr/renamed", "user.Mime_Type")
+	assert.NoError(t, err)
+	err = fs.RemoveXAttr("xattr/renamed", "user.Mime_Type")
+	assert.Equal(t, syscall.ENODATA, err)
+	attrs, err = fs.ListXAttr("xattr/renamed")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"security.capability"}, attrs)
+
+	// 超过 s3 user metadata 大小限制
+	err = fs.SetXAttr("xattr/renamed", "user.big", make([]byte, S3MaxMetaSize), 0)
+	assert.Equal(t, syscall.ENOSPC, err)
+
+	// 没有对象的目录设置属性时创建目录对象
+	err = fs.SetXAttr("xattr", "user.dir", []byte("d"), 0)
+	assert.NoError(t, err)
+	data, err = fs.GetXAttr("xattr/", "user.dir")
+	assert.NoError(t, err)
+	assert.Equal(t, "d", string(data))
+	finfo, err := fs.GetAttr("xattr")
+	assert.NoError(t, err)
+	assert.True(t, finfo.IsDir)
+
+	_, err = fs.GetXAttr("not_exist", "user.a")
+	assert.Equal(t, syscall.ENOENT, err)
+}
+
+func TestS3Symlink(t *testing.T) {
+	fs, standIn := newS3StandInFSForTest(t, common.S3Symlink, "true")
+	err := createS3TestFile(fs, "link/target", "content")
+	assert.NoError(t, err)
+	err = createS3TestFile(fs, "link/small", "abc")
+	assert.NoError(t, err)
+
+	err = fs.Symlink("target", "link/to_target")
+	assert.NoError(t, err)
+	err = fs.Symlink("../other/dir", "/link/to_dir")
+	assert.NoError(t, err)
+	err = fs.Symlink("target", "link/to_target")
+	assert.Equal(t, syscall.EEXIST, err)
+	err = fs.Symlink(strings.Repeat("a", SymlinkMaxSize), "link/too_long")
+	assert.Equal(t, syscall.ENAMETOOLONG, err)
+
+	value, err := fs.Readlink("link/to_target")
+	assert.NoError(t, err)
+	assert.Equal(t, "target", value)
+	value, err = fs.Readlink("/link/to_dir")
+	assert.NoError(t, err)
+	assert.Equal(t, "../other/dir", value)
+	_, err = fs.Readlink("link/small")
+	assert.Equal(t, syscall.EINVAL, err)
+	_, err = fs.Readlink("link/not_exist")
+	assert.Equal(t, syscall.ENOENT, err)
+
+	finfo, err := fs.GetAttr("link/to_target")
+	assert.NoError(t, err)
+	assert.False(t, finfo.IsDir)
+	assert.Equal(t, int64(len("target")), finfo.Size)
+	assert.Equal(t, os.ModeSymlink, finfo.Mode&os.ModeType)
+	finfo, err = fs.GetAttr("link/small")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0), finfo.Mode&os.ModeType)
+
+	entries, err := fs.ReadDir("link")
+	assert.NoError(t, err)
+	modes := make(map[string]uint32)
+	for _, entry := range entries {
+		modes[entry.Name] = entry.Attr.Mode & syscall.S_IFMT
+	}
+	assert.Equal(t, map[string]uint32{
+		"target":    syscall.S_IFREG,
+		"small":     syscall.S_IFREG,
+		"to_target": syscall.S_IFLNK,
+		"to_dir":    syscall.S_IFLNK,
+	}, modes)
+
+	// 再次读目录时不会重复 head 没有变化的文件
+	standIn.Lock()
+	heads := standIn.heads
+	standIn.Unlock()
+	entries, err = fs.ReadDir("link")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(entries))
+	standIn.Lock()
+	assert.Equal(t, heads, standIn.heads)
+	standIn.Unlock()
+
+	// head 失败的文件当作普通文件，不影响读目录
+	err = fs.Symlink("target", "link/head_failed")
+	assert.NoError(t, err)
+	standIn.Lock()
+	standIn.failHeads = map[string]bool{"pfs/link/head_failed": true}
+	standIn.Unlock()
+	entries, err = fs.ReadDir("link")
+	assert.NoError(t, err)
+	modes = make(map[string]uint32)
+	for _, entry := range entries {
+		modes[entry.Name] = entry.Attr.Mode & syscall.S_IFMT
+	}
+	assert.Equal(t, uint32(syscall.S_IFREG), modes["head_failed"])
+	assert.Equal(t, uint32(syscall.S_IFLNK), modes["to_target"])
+	standIn.Lock()
+	standIn.failHeads = nil
+	standIn.Unlock()
+	err = fs.Unlink("link/head_failed")
+	assert.NoError(t, err)
+
+	// 重命名后仍然是软链接
+	err = fs.Rename("link/to_target", "link/renamed")
+	assert.NoError(t, err)
+	value, err = fs.Readlink("link/renamed")
+	assert.NoError(t, err)
+	assert.Equal(t, "target", value)
+	err = fs.Unlink("link/renamed")
+	assert.NoError(t, err)
+	_, err = fs.GetAttr("link/renamed")
+	assert.Equal(t, syscall.ENOENT, err)
+}
+
+func TestS3SymlinkDisabled(t *testing.T) {
+	fs, standIn := newS3StandInFSForTest(t)
+	err := createS3TestFile(fs, "link/small", "abc")
+	assert.NoError(t, err)
+	err = fs.Symlink("small", "link/to_small")
+	assert.Equal(t, syscall.ENOSYS, err)
+	_, err = fs.Readlink("link/small")
+	assert.Equal(t, syscall.ENOSYS, err)
+
+	// 未开启软链接时读目录不需要 head 文件
+	standIn.Lock()
+	heads := standIn.heads
+	standIn.Unlock()
+	entries, err := fs.ReadDir("link")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, uint32(syscall.S_IFREG), entries[0].Attr.Mode&syscall.S_IFMT)
+	standIn.Lock()
+	assert.Equal(t, heads, standIn.heads)
+	standIn.Unlock()
+}
+
+func TestSymlinkCache(t *testing.T) {
+	c := newSymlinkCache(2)
+	c.set("a", 1, 10, true)
+	c.set("b", 1, 10, false)
+	symlink, ok := c.get("a", 1, 10)
+	assert.True(t, ok)
+	assert.True(t, symlink)
+	// 文件变化后缓存失效
+	_, ok = c.get("a", 2, 10)
+	assert.False(t, ok)
+
+	// 缓存满时淘汰最久未使用的 b
+	c.set("c", 1, 10, true)
+	_, ok = c.get("b", 1, 10)
+	assert.False(t, ok)
+	_, ok = c.get("a", 1, 10)
+	assert.True(t, ok)
+	_, ok = c.get("c", 1, 10)
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.lru.Len())
+	assert.Equal(t, 2, len(c.entries))
+
+	// 更新已有的缓存不会淘汰其他缓存
+	c.set("a", 2, 10, false)
+	symlink, ok = c.get("a", 2, 10)
+	assert.True(t, ok)
+	assert.False(t, symlink)
+	_, ok = c.get("c", 1, 10)
+	assert.True(t, ok)
+}
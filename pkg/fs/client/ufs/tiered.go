@@ -0,0 +1,1009 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/base"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	DefaultHotAge          = 24 * time.Hour
+	DefaultMigrateInterval = 10 * time.Minute
+
+	// 迁移时先写入临时文件再rename，避免读到不完整的文件；临时文件不对外展示
+	tieredTmpPrefix  = ".pfs-tiered-"
+	tieredCopyBuffer = 4 * 1024 * 1024
+	tieredDirMode    = 0755
+
+	// 记录访问时间的文件数上限，超过后淘汰最久未访问的记录，被淘汰的文件以mtime作为最近访问时间
+	tieredMaxAccessed = 100000
+	// 访问记录按文件名分片加锁，读文件时不需要获取全局锁
+	tieredAccessShards = 64
+)
+
+// TieredMigrate 是否在当前进程中运行冷热分层的后台迁移，默认关闭。迁移只能感知本进程中的读写，
+// 多个客户端共享同一个热层时，只能在一个进程中开启，如通过挂载参数--tiered-migrate指定一个客户端
+var TieredMigrate bool
+
+var errTierChanged = errors.New("file changed during tier migration")
+
+// tierPolicy 冷热分层的放置规则
+type tierPolicy struct {
+	// 大于该值的文件迁移到冷层，冷层中大于该值的文件不会被提升到热层，0表示不限制
+	sizeThreshold int64
+	// 该前缀下的文件固定在热层
+	hotPrefixes []string
+	// 该前缀下的文件固定在冷层
+	coldPrefixes []string
+	// 热层文件超过该时长未被访问则迁移到冷层，0表示不按访问时间迁移
+	hotAge time.Duration
+	// 后台迁移的周期，0表示不启动后台迁移，只在开启了TieredMigrate的进程中生效
+	migrateInterval time.Duration
+}
+
+// tieredFileSystem 由热层（如本地SSD）和冷层（如对象存储）两个UnderFileStorage组成，
+// 对外提供合并后的统一视图。同名文件以热层为准，新文件默认写入热层，
+// 后台按访问时间在两层间迁移文件。提升到热层的文件在冷层保留一份，冷层始终是持久的副本。
+type tieredFileSystem struct {
+	hot    UnderFileStorage
+	cold   UnderFileStorage
+	policy tierPolicy
+
+	// 当前进程是否运行后台迁移，不迁移时不需要记录文件的访问
+	migrating bool
+	// 文件最近一次访问时间，以及被访问过、下一轮迁移时尝试提升到热层的冷层文件
+	accessed *tierAccessTracker
+
+	sync.Mutex
+	// 正在写的文件，迁移时跳过
+	writing map[string]int
+	// 正在切换所在层的文件，写打开需要等待切换完成
+	moving map[string]struct{}
+	moved  *sync.Cond
+}
+
+var _ UnderFileStorage = &tieredFileSystem{}
+
+// tieredFileHandle 在Release时清理写打开计数
+type tieredFileHandle struct {
+	base.FileHandle
+	once    sync.Once
+	release func()
+}
+
+func (fh *tieredFileHandle) Release() {
+	fh.FileHandle.Release()
+	fh.once.Do(fh.release)
+}
+
+type tierAccess struct {
+	name    string
+	at      time.Time
+	promote bool
+}
+
+type tierAccessShard struct {
+	sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// tierAccessTracker 记录文件的最近访问时间，按文件名分片，每个分片按LRU淘汰，总数不超过上限
+type tierAccessTracker struct {
+	shards      []*tierAccessShard
+	maxPerShard int
+}
+
+func newTierAccessTracker(maxSize int) *tierAccessTracker {
+	t := &tierAccessTracker{
+		shards:      make([]*tierAccessShard, tieredAccessShards),
+		maxPerShard: (maxSize + tieredAccessShards - 1) / tieredAccessShards,
+	}
+	for i := range t.shards {
+		t.shards[i] = &tierAccessShard{lru: list.New(), entries: make(map[string]*list.Element)}
+	}
+	return t
+}
+
+func (t *tierAccessTracker) shard(name string) *tierAccessShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return t.shards[h.Sum32()%uint32(len(t.shards))]
+}
+
+// set 记录访问时间，promote为true时标记为待提升，已有的待提升标记不会被清除
+func (t *tierAccessTracker) set(name string, at time.Time, promote bool) {
+	shard := t.shard(name)
+	shard.Lock()
+	defer shard.Unlock()
+	if elem, ok := shard.entries[name]; ok {
+		access := elem.Value.(*tierAccess)
+		access.at = at
+		access.promote = access.promote || promote
+		shard.lru.MoveToFront(elem)
+		return
+	}
+	for shard.lru.Len() >= t.maxPerShard {
+		oldest := shard.lru.Back()
+		shard.lru.Remove(oldest)
+		delete(shard.entries, oldest.Value.(*tierAccess).name)
+	}
+	shard.entries[name] = shard.lru.PushFront(&tierAccess{name: name, at: at, promote: promote})
+}
+
+func (t *tierAccessTracker) get(name string) (time.Time, bool) {
+	shard := t.shard(name)
+	shard.Lock()
+	defer shard.Unlock()
+	elem, ok := shard.entries[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	return elem.Value.(*tierAccess).at, true
+}
+
+func (t *tierAccessTracker) remove(name string) {
+	shard := t.shard(name)
+	shard.Lock()
+	defer shard.Unlock()
+	if elem, ok := shard.entries[name]; ok {
+		shard.lru.Remove(elem)
+		delete(shard.entries, name)
+	}
+}
+
+// takePromotes 返回并清除所有待提升的文件
+func (t *tierAccessTracker) takePromotes() []string {
+	var names []string
+	for _, shard := range t.shards {
+		shard.Lock()
+		for name, elem := range shard.entries {
+			if access := elem.Value.(*tierAccess); access.promote {
+				access.promote = false
+				names = append(names, name)
+			}
+		}
+		shard.Unlock()
+	}
+	return names
+}
+
+// rename 将oldName及其子路径的访问记录转移到newName下
+func (t *tierAccessTracker) rename(oldName, newName string) {
+	var renamed []tierAccess
+	for _, shard := range t.shards {
+		shard.Lock()
+		for name, elem := range shard.entries {
+			if name == oldName || strings.HasPrefix(name, oldName+"/") {
+				access := *elem.Value.(*tierAccess)
+				access.name = newName + strings.TrimPrefix(name, oldName)
+				renamed = append(renamed, access)
+				shard.lru.Remove(elem)
+				delete(shard.entries, name)
+			}
+		}
+		shard.Unlock()
+	}
+	for _, access := range renamed {
+		t.set(access.name, access.at, access.promote)
+	}
+}
+
+func tierNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
+
+func tierName(name string) string {
+	return strings.Trim(path.Clean("/"+name), "/")
+}
+
+func matchTierPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix == "" || name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isTierTmp(name string) bool {
+	return strings.HasPrefix(path.Base(name), tieredTmpPrefix)
+}
+
+func (fs *tieredFileSystem) String() string {
+	return common.TieredType
+}
+
+// placement 新建文件所在的层，固定在冷层的前缀写入冷层，其余写入热层
+func (fs *tieredFileSystem) placement(name string) UnderFileStorage {
+	if matchTierPrefix(tierName(name), fs.policy.coldPrefixes) {
+		return fs.cold
+	}
+	return fs.hot
+}
+
+func (fs *tieredFileSystem) other(tier UnderFileStorage) UnderFileStorage {
+	if tier == fs.hot {
+		return fs.cold
+	}
+	return fs.hot
+}
+
+// locate 返回文件所在的层，两层都存在时以热层为准
+func (fs *tieredFileSystem) locate(name string) (UnderFileStorage, *base.FileInfo, error) {
+	info, err := fs.hot.GetAttr(name)
+	if err == nil {
+		return fs.hot, info, nil
+	}
+	if !tierNotExist(err) {
+		return nil, nil, err
+	}
+	info, err = fs.cold.GetAttr(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fs.cold, info, nil
+}
+
+// holders 返回所有包含该文件的层
+func (fs *tieredFileSystem) holders(name string) ([]UnderFileStorage, error) {
+	var tiers []UnderFileStorage
+	for _, tier := range []UnderFileStorage{fs.hot, fs.cold} {
+		_, err := tier.GetAttr(name)
+		if err == nil {
+			tiers = append(tiers, tier)
+		} else if !tierNotExist(err) {
+			return nil, err
+		}
+	}
+	if len(tiers) == 0 {
+		return nil, syscall.ENOENT
+	}
+	return tiers, nil
+}
+
+func (fs *tieredFileSystem) each(name string, op func(tier UnderFileStorage) error) error {
+	tiers, err := fs.holders(name)
+	if err != nil {
+		return err
+	}
+	for _, tier := range tiers {
+		if err := op(tier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureDir 在指定层中逐级创建目录
+func (fs *tieredFileSystem) ensureDir(tier UnderFileStorage, dir string, mode uint32) error {
+	dir = tierName(dir)
+	if dir == "" {
+		return nil
+	}
+	if _, err := tier.GetAttr(dir); err == nil {
+		return nil
+	} else if !tierNotExist(err) {
+		return err
+	}
+	if err := fs.ensureDir(tier, path.Dir(dir), mode); err != nil {
+		return err
+	}
+	if err := tier.Mkdir(dir, mode); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+func (fs *tieredFileSystem) touch(name string, fromCold bool) {
+	if !fs.migrating {
+		return
+	}
+	fs.accessed.set(tierName(name), time.Now(), fromCold)
+}
+
+func (fs *tieredFileSystem) forget(name string) {
+	fs.accessed.remove(tierName(name))
+}
+
+func (fs *tieredFileSystem) startWrite(name string) func() {
+	name = tierName(name)
+	fs.Lock()
+	// 迁移完成后文件所在的层可能已经变化，等待迁移完成后再定位文件
+	for {
+		if _, ok := fs.moving[name]; !ok {
+			break
+		}
+		fs.moved.Wait()
+	}
+	fs.writing[name]++
+	fs.Unlock()
+	return func() {
+		fs.Lock()
+		defer fs.Unlock()
+		if fs.writing[name] <= 1 {
+			delete(fs.writing, name)
+		} else {
+			fs.writing[name]--
+		}
+	}
+}
+
+func (fs *tieredFileSystem) isWriting(name string) bool {
+	fs.Lock()
+	defer fs.Unlock()
+	return fs.writing[tierName(name)] > 0
+}
+
+func (fs *tieredFileSystem) lastAccess(name string, info *base.FileInfo) time.Time {
+	last := time.Unix(int64(info.Mtime), 0)
+	if t, ok := fs.accessed.get(tierName(name)); ok && t.After(last) {
+		last = t
+	}
+	return last
+}
+
+func (fs *tieredFileSystem) GetAttr(name string) (*base.FileInfo, error) {
+	_, info, err := fs.locate(name)
+	return info, err
+}
+
+func (fs *tieredFileSystem) Chmod(name string, mode uint32) error {
+	return fs.each(name, func(tier UnderFileStorage) error {
+		return tier.Chmod(name, mode)
+	})
+}
+
+func (fs *tieredFileSystem) Chown(name string, uid uint32, gid uint32) error {
+	return fs.each(name, func(tier UnderFileStorage) error {
+		return tier.Chown(name, uid, gid)
+	})
+}
+
+func (fs *tieredFileSystem) Utimens(name string, Atime *time.Time, Mtime *time.Time) error {
+	return fs.each(name, func(tier UnderFileStorage) error {
+		return tier.Utimens(name, Atime, Mtime)
+	})
+}
+
+func (fs *tieredFileSystem) Truncate(name string, size uint64) error {
+	done := fs.startWrite(name)
+	defer done()
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return err
+	}
+	return tier.Truncate(name, size)
+}
+
+func (fs *tieredFileSystem) Access(name string, mode, callerUid, callerGid uint32) error {
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return err
+	}
+	return tier.Access(name, mode, callerUid, callerGid)
+}
+
+func (fs *tieredFileSystem) Link(oldName string, newName string) error {
+	tier, _, err := fs.locate(oldName)
+	if err != nil {
+		return err
+	}
+	if err := fs.ensureDir(tier, path.Dir(tierName(newName)), tieredDirMode); err != nil {
+		return err
+	}
+	return tier.Link(oldName, newName)
+}
+
+func (fs *tieredFileSystem) Mkdir(name string, mode uint32) error {
+	if _, _, err := fs.locate(name); err == nil {
+		return syscall.EEXIST
+	} else if !tierNotExist(err) {
+		return err
+	}
+	tier := fs.placement(name)
+	if err := fs.ensureDir(tier, path.Dir(tierName(name)), mode); err != nil {
+		return err
+	}
+	return tier.Mkdir(name, mode)
+}
+
+func (fs *tieredFileSystem) Mknod(name string, mode uint32, dev uint32) error {
+	tier := fs.placement(name)
+	if err := fs.ensureDir(tier, path.Dir(tierName(name)), tieredDirMode); err != nil {
+		return err
+	}
+	return tier.Mknod(name, mode, dev)
+}
+
+// Rename 在每个包含oldName的层中各自rename，并清理另一层中同名的旧文件，
+// 避免被热层遮盖或在热层删除后重新出现
+func (fs *tieredFileSystem) Rename(oldName string, newName string) error {
+	tiers, err := fs.holders(oldName)
+	if err != nil {
+		return err
+	}
+	for _, tier := range tiers {
+		if err := fs.ensureDir(tier, path.Dir(tierName(newName)), tieredDirMode); err != nil {
+			return err
+		}
+		if err := tier.Rename(oldName, newName); err != nil {
+			return err
+		}
+	}
+	if len(tiers) == 1 {
+		other := fs.other(tiers[0])
+		if info, err := other.GetAttr(newName); err == nil {
+			if info.IsDir {
+				err = other.Rmdir(newName)
+			} else {
+				err = other.Unlink(newName)
+			}
+			if err != nil && !tierNotExist(err) {
+				log.Errorf("tiered rename: remove stale %s in %s failed: %v", newName, other.String(), err)
+				return err
+			}
+		}
+	}
+
+	fs.accessed.rename(tierName(oldName), tierName(newName))
+	return nil
+}
+
+func (fs *tieredFileSystem) Rmdir(name string) error {
+	entries, err := fs.ReadDir(name)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return syscall.ENOTEMPTY
+	}
+	return fs.each(name, func(tier UnderFileStorage) error {
+		// 清理迁移中断遗留的临时文件
+		if entries, err := tier.ReadDir(name); err == nil {
+			for _, entry := range entries {
+				if isTierTmp(entry.Name) {
+					_ = tier.Unlink(path.Join(name, entry.Name))
+				}
+			}
+		}
+		return tier.Rmdir(name)
+	})
+}
+
+func (fs *tieredFileSystem) Unlink(name string) error {
+	err := fs.each(name, func(tier UnderFileStorage) error {
+		return tier.Unlink(name)
+	})
+	if err == nil {
+		fs.forget(name)
+	}
+	return err
+}
+
+func (fs *tieredFileSystem) GetXAttr(name string, attribute string) (data []byte, err error) {
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return nil, err
+	}
+	return tier.GetXAttr(name, attribute)
+}
+
+func (fs *tieredFileSystem) ListXAttr(name string) (attributes []string, err error) {
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return nil, err
+	}
+	return tier.ListXAttr(name)
+}
+
+func (fs *tieredFileSystem) RemoveXAttr(name string, attr string) error {
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return err
+	}
+	return tier.RemoveXAttr(name, attr)
+}
+
+func (fs *tieredFileSystem) SetXAttr(name string, attr string, data []byte, flags int) error {
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return err
+	}
+	return tier.SetXAttr(name, attr, data, flags)
+}
+
+func isWriteFlags(flags uint32) bool {
+	return flags&uint32(os.O_WRONLY|os.O_RDWR|os.O_TRUNC|os.O_APPEND) != 0
+}
+
+func (fs *tieredFileSystem) Open(name string, flags uint32) (fd base.FileHandle, err error) {
+	done := func() {}
+	if isWriteFlags(flags) {
+		// 先登记写打开，保证迁移不会删除即将被写的文件
+		done = fs.startWrite(name)
+	}
+	tier, _, err := fs.locate(name)
+	if err == nil {
+		fd, err = tier.Open(name, flags)
+	}
+	if err != nil {
+		done()
+		return nil, err
+	}
+	fs.touch(name, tier == fs.cold)
+	if isWriteFlags(flags) {
+		return &tieredFileHandle{FileHandle: fd, release: done}, nil
+	}
+	return fd, nil
+}
+
+func (fs *tieredFileSystem) Create(name string, flags uint32, mode uint32) (fd base.FileHandle, err error) {
+	if flags&uint32(os.O_EXCL) != 0 {
+		if _, _, err := fs.locate(name); err == nil {
+			return nil, syscall.EEXIST
+		} else if !tierNotExist(err) {
+			return nil, err
+		}
+	}
+	done := fs.startWrite(name)
+	tier := fs.placement(name)
+	if err = fs.ensureDir(tier, path.Dir(tierName(name)), tieredDirMode); err == nil {
+		fd, err = tier.Create(name, flags, mode)
+	}
+	if err != nil {
+		done()
+		return nil, err
+	}
+	// 新文件覆盖另一层中的同名文件
+	other := fs.other(tier)
+	if info, err := other.GetAttr(name); err == nil && !info.IsDir {
+		if err := other.Unlink(name); err != nil && !tierNotExist(err) {
+			log.Errorf("tiered create: remove stale %s in %s failed: %v", name, other.String(), err)
+		}
+	}
+	fs.touch(name, false)
+	return &tieredFileHandle{FileHandle: fd, release: done}, nil
+}
+
+// ReadDir 合并两层的目录项，同名时以热层为准
+func (fs *tieredFileSystem) ReadDir(name string) (stream []DirEntry, err error) {
+	hotEntries, hotErr := fs.hot.ReadDir(name)
+	if hotErr != nil && !tierNotExist(hotErr) {
+		return nil, hotErr
+	}
+	coldEntries, coldErr := fs.cold.ReadDir(name)
+	if coldErr != nil && !tierNotExist(coldErr) {
+		return nil, coldErr
+	}
+	if hotErr != nil && coldErr != nil {
+		return nil, hotErr
+	}
+
+	seen := make(map[string]struct{}, len(hotEntries))
+	stream = make([]DirEntry, 0, len(hotEntries)+len(coldEntries))
+	for _, entries := range [][]DirEntry{hotEntries, coldEntries} {
+		for _, entry := range entries {
+			if isTierTmp(entry.Name) {
+				continue
+			}
+			if _, ok := seen[entry.Name]; ok {
+				continue
+			}
+			seen[entry.Name] = struct{}{}
+			stream = append(stream, entry)
+		}
+	}
+	return stream, nil
+}
+
+func (fs *tieredFileSystem) Symlink(value string, linkName string) error {
+	if _, _, err := fs.locate(linkName); err == nil {
+		return syscall.EEXIST
+	} else if !tierNotExist(err) {
+		return err
+	}
+	tier := fs.placement(linkName)
+	if err := fs.ensureDir(tier, path.Dir(tierName(linkName)), tieredDirMode); err != nil {
+		return err
+	}
+	return tier.Symlink(value, linkName)
+}
+
+func (fs *tieredFileSystem) Readlink(name string) (string, error) {
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return "", err
+	}
+	return tier.Readlink(name)
+}
+
+// StatFs 返回冷层（容量层）的统计信息
+func (fs *tieredFileSystem) StatFs(name string) *base.StatfsOut {
+	if out := fs.cold.StatFs(name); out != nil {
+		return out
+	}
+	return fs.hot.StatFs(name)
+}
+
+func (fs *tieredFileSystem) Get(name string, flags uint32, off, limit int64) (io.ReadCloser, error) {
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := tier.Get(name, flags, off, limit)
+	if err != nil {
+		return nil, err
+	}
+	fs.touch(name, tier == fs.cold)
+	return reader, nil
+}
+
+func (fs *tieredFileSystem) Put(name string, reader io.Reader) error {
+	done := fs.startWrite(name)
+	defer done()
+	tier, _, err := fs.locate(name)
+	if err != nil {
+		if !tierNotExist(err) {
+			return err
+		}
+		tier = fs.placement(name)
+	}
+	return tier.Put(name, reader)
+}
+
+func (fs *tieredFileSystem) migrateLoop() {
+	ticker := time.NewTicker(fs.policy.migrateInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		fs.migrate()
+	}
+}
+
+// migrate 将热层中长时间未访问、超过大小阈值或属于冷层前缀的文件迁移到冷层，
+// 并将最近访问过的冷层文件提升到热层
+func (fs *tieredFileSystem) migrate() {
+	start := time.Now()
+	demoted := fs.demoteDir("")
+	promoted := fs.promote()
+	log.Debugf("tiered migrate: demoted %d files, promoted %d files, cost %v",
+		demoted, promoted, time.Since(start))
+}
+
+func (fs *tieredFileSystem) shouldDemote(name string, info *base.FileInfo) bool {
+	if matchTierPrefix(name, fs.policy.hotPrefixes) {
+		return false
+	}
+	if matchTierPrefix(name, fs.policy.coldPrefixes) {
+		return true
+	}
+	if fs.policy.sizeThreshold > 0 && info.Size > fs.policy.sizeThreshold {
+		return true
+	}
+	return fs.policy.hotAge > 0 && time.Since(fs.lastAccess(name, info)) > fs.policy.hotAge
+}
+
+func (fs *tieredFileSystem) shouldPromote(name string, info *base.FileInfo) bool {
+	if matchTierPrefix(name, fs.policy.coldPrefixes) {
+		return false
+	}
+	if matchTierPrefix(name, fs.policy.hotPrefixes) {
+		return true
+	}
+	if fs.policy.sizeThreshold > 0 && info.Size > fs.policy.sizeThreshold {
+		return false
+	}
+	return fs.policy.hotAge <= 0 || time.Since(fs.lastAccess(name, info)) <= fs.policy.hotAge
+}
+
+func (fs *tieredFileSystem) demoteDir(dir string) int {
+	entries, err := fs.hot.ReadDir(dir)
+	if err != nil {
+		log.Errorf("tiered migrate: read hot dir[%s] failed: %v", dir, err)
+		return 0
+	}
+	count := 0
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name)
+		if isTierTmp(name) {
+			continue
+		}
+		info, err := fs.hot.GetAttr(name)
+		if err != nil {
+			continue
+		}
+		if info.IsDir {
+			count += fs.demoteDir(name)
+			continue
+		}
+		if !info.Mode.IsRegular() || fs.isWriting(name) || !fs.shouldDemote(name, info) {
+			continue
+		}
+		if err := fs.move(fs.hot, fs.cold, name, false); err != nil {
+			log.Warnf("tiered migrate: demote %s failed: %v", name, err)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func (fs *tieredFileSystem) promote() int {
+	names := fs.accessed.takePromotes()
+	count := 0
+	for _, name := range names {
+		if _, err := fs.hot.GetAttr(name); err == nil {
+			continue
+		}
+		info, err := fs.cold.GetAttr(name)
+		if err != nil || !info.Mode.IsRegular() || !fs.shouldPromote(name, info) {
+			continue
+		}
+		if err := fs.move(fs.cold, fs.hot, name, true); err != nil {
+			log.Warnf("tiered migrate: promote %s failed: %v", name, err)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// move 将文件从src层拷贝到dst层，先写临时文件再rename，避免不完整的文件遮盖热层或覆盖冷层中已有的副本。
+// keepSrc为false时，确认dst中已有完整的文件后再删除src中的文件。拷贝期间文件被写或被修改则放弃本次迁移。
+func (fs *tieredFileSystem) move(src, dst UnderFileStorage, name string, keepSrc bool) error {
+	if fs.isWriting(name) {
+		return errTierChanged
+	}
+	info, err := src.GetAttr(name)
+	if err != nil {
+		return err
+	}
+	// dst中已有相同的文件（如提升后没有修改过的文件）时不需要再拷贝
+	target := ""
+	if existing, err := dst.GetAttr(name); err != nil || !sameTierFile(info, existing) {
+		dir := path.Dir(name)
+		if err := fs.ensureDir(dst, dir, tieredDirMode); err != nil {
+			return err
+		}
+		target = path.Join(dir, fmt.Sprintf("%s%s-%d", tieredTmpPrefix, path.Base(name), time.Now().UnixNano()))
+		if err := copyTierFile(src, dst, name, target, uint32(info.Mode.Perm())); err != nil {
+			_ = dst.Unlink(target)
+			return err
+		}
+	}
+
+	if !fs.beginMove(name) {
+		if target != "" {
+			_ = dst.Unlink(target)
+		}
+		return errTierChanged
+	}
+	defer fs.endMove(name)
+	// 登记之后新的写打开会等待，这里确认登记之前文件没有被修改
+	after, err := src.GetAttr(name)
+	if err != nil || !sameTierFile(info, after) {
+		if target != "" {
+			_ = dst.Unlink(target)
+		}
+		if err != nil {
+			return err
+		}
+		return errTierChanged
+	}
+	if target != "" {
+		if err := dst.Rename(target, name); err != nil {
+			_ = dst.Unlink(target)
+			return err
+		}
+		mtime := time.Unix(int64(info.Mtime), 0)
+		if err := dst.Utimens(name, &mtime, &mtime); err != nil {
+			log.Debugf("tiered migrate: keep mtime of %s failed: %v", name, err)
+		}
+	}
+	if keepSrc {
+		return nil
+	}
+	moved, err := dst.GetAttr(name)
+	if err != nil {
+		return err
+	}
+	if moved.Size != info.Size {
+		return fmt.Errorf("tiered migrate: size of %s in %s is %d, expect %d", name, dst.String(), moved.Size, info.Size)
+	}
+	return src.Unlink(name)
+}
+
+func sameTierFile(a, b *base.FileInfo) bool {
+	return !b.IsDir && a.Size == b.Size && a.Mtime == b.Mtime
+}
+
+// beginMove 在没有写打开时登记迁移，返回false表示文件正在被写
+func (fs *tieredFileSystem) beginMove(name string) bool {
+	name = tierName(name)
+	fs.Lock()
+	defer fs.Unlock()
+	if fs.writing[name] > 0 {
+		return false
+	}
+	fs.moving[name] = struct{}{}
+	return true
+}
+
+func (fs *tieredFileSystem) endMove(name string) {
+	name = tierName(name)
+	fs.Lock()
+	defer fs.Unlock()
+	delete(fs.moving, name)
+	fs.moved.Broadcast()
+}
+
+func copyTierFile(src, dst UnderFileStorage, name, target string, mode uint32) error {
+	reader, err := src.Get(name, uint32(os.O_RDONLY), 0, 0)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	fh, err := dst.Create(target, uint32(os.O_WRONLY|os.O_CREATE|os.O_TRUNC), mode)
+	if err != nil {
+		return err
+	}
+	defer fh.Release()
+
+	buf := make([]byte, tieredCopyBuffer)
+	var off int64
+	for {
+		n, readErr := io.ReadFull(reader, buf)
+		if n > 0 {
+			written, code := fh.Write(buf[:n], off)
+			if code != fuse.OK {
+				return syscall.Errno(code)
+			}
+			if int(written) != n {
+				return io.ErrShortWrite
+			}
+			off += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if code := fh.Flush(); code != fuse.OK {
+		return syscall.Errno(code)
+	}
+	return nil
+}
+
+func tierProperties(properties map[string]interface{}, prefix string) map[string]interface{} {
+	tier := make(map[string]interface{})
+	for k, v := range properties {
+		if strings.HasPrefix(k, prefix) {
+			tier[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return tier
+}
+
+func newTier(properties map[string]interface{}, prefix string) (UnderFileStorage, error) {
+	tierProps := tierProperties(properties, prefix)
+	tierType, _ := tierProps[common.Type].(string)
+	if tierType == "" || tierType == common.TieredType {
+		return nil, fmt.Errorf("tiered: %s%s[%s] is not supported", prefix, common.Type, tierType)
+	}
+	if tierType == common.HDFSType && tierProps[common.KeyTabData] != nil {
+		tierType = common.HDFSWithKerberosType
+	}
+	if _, ok := tierProps[common.SubPath].(string); !ok {
+		tierProps[common.SubPath] = ""
+	}
+	return NewUFS(tierType, tierProps)
+}
+
+func splitTierPrefixes(value interface{}) []string {
+	s, _ := value.(string)
+	var prefixes []string
+	for _, prefix := range strings.Split(s, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, tierName(prefix))
+		}
+	}
+	return prefixes
+}
+
+func parseTierDuration(properties map[string]interface{}, key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := properties[key].(string)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("tiered: %s[%s] is invalid: %v", key, value, err)
+	}
+	return d, nil
+}
+
+func parseTierPolicy(properties map[string]interface{}) (policy tierPolicy, err error) {
+	if value, ok := properties[common.SizeThreshold].(string); ok && value != "" {
+		policy.sizeThreshold, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return policy, fmt.Errorf("tiered: %s[%s] is invalid: %v", common.SizeThreshold, value, err)
+		}
+	}
+	policy.hotPrefixes = splitTierPrefixes(properties[common.HotPrefixes])
+	policy.coldPrefixes = splitTierPrefixes(properties[common.ColdPrefixes])
+	if policy.hotAge, err = parseTierDuration(properties, common.HotAge, DefaultHotAge); err != nil {
+		return policy, err
+	}
+	policy.migrateInterval, err = parseTierDuration(properties, common.MigrateInterval, DefaultMigrateInterval)
+	return policy, err
+}
+
+// NewTieredFileSystem 热层和冷层的属性分别以hot.和cold.为前缀，如hot.type=local、hot.subpath=/ssd/data、
+// cold.type=s3、cold.bucket=xxx，各层的subpath即该层存储的根目录
+func NewTieredFileSystem(properties map[string]interface{}) (UnderFileStorage, error) {
+	policy, err := parseTierPolicy(properties)
+	if err != nil {
+		return nil, err
+	}
+	hot, err := newTier(properties, common.HotTierPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cold, err := newTier(properties, common.ColdTierPrefix)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("new tiered fs hot[%s] cold[%s] policy[%+v]", hot.String(), cold.String(), policy)
+
+	fs := &tieredFileSystem{
+		hot:       hot,
+		cold:      cold,
+		policy:    policy,
+		migrating: TieredMigrate,
+		accessed:  newTierAccessTracker(tieredMaxAccessed),
+		writing:   make(map[string]int),
+		moving:    make(map[string]struct{}),
+	}
+	fs.moved = sync.NewCond(&fs.Mutex)
+	if fs.migrating && policy.migrateInterval > 0 {
+		go fs.migrateLoop()
+	}
+	return fs, nil
+}
+
+func init() {
+	RegisterUFS(common.TieredType, NewTieredFileSystem)
+}
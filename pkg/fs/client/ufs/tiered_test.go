@@ -0,0 +1,309 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/base"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+func newTestTieredFS(t *testing.T, rules map[string]interface{}) (*tieredFileSystem, string, string) {
+	root := t.TempDir()
+	hotDir, coldDir := filepath.Join(root, "hot"), filepath.Join(root, "cold")
+	properties := map[string]interface{}{
+		common.HotTierPrefix + common.Type:     common.LocalType,
+		common.HotTierPrefix + common.SubPath:  hotDir,
+		common.ColdTierPrefix + common.Type:    common.LocalType,
+		common.ColdTierPrefix + common.SubPath: coldDir,
+		common.MigrateInterval:                 "0",
+	}
+	for k, v := range rules {
+		properties[k] = v
+	}
+	// 测试中直接调用迁移，需要记录文件的访问
+	TieredMigrate = true
+	defer func() {
+		TieredMigrate = false
+	}()
+	fs, err := NewUFS(common.TieredType, properties)
+	assert.NoError(t, err)
+	return fs.(*tieredFileSystem), hotDir, coldDir
+}
+
+func writeTieredFile(t *testing.T, fs UnderFileStorage, name, content string) {
+	fh, err := fs.Create(name, uint32(os.O_WRONLY|os.O_CREATE|os.O_TRUNC), 0644)
+	assert.NoError(t, err)
+	fh.Write([]byte(content), 0)
+	fh.Flush()
+	fh.Release()
+}
+
+func readTieredFile(t *testing.T, fs UnderFileStorage, name string) string {
+	reader, err := fs.Get(name, uint32(os.O_RDONLY), 0, 0)
+	assert.NoError(t, err)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func tieredDirNames(t *testing.T, fs UnderFileStorage, name string) []string {
+	entries, err := fs.ReadDir(name)
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestNewTieredFileSystem(t *testing.T) {
+	_, err := NewTieredFileSystem(map[string]interface{}{
+		common.HotTierPrefix + common.Type: common.LocalType,
+	})
+	assert.Error(t, err)
+
+	_, err = NewTieredFileSystem(map[string]interface{}{
+		common.HotTierPrefix + common.Type:  common.LocalType,
+		common.ColdTierPrefix + common.Type: common.TieredType,
+	})
+	assert.Error(t, err)
+
+	_, err = NewTieredFileSystem(map[string]interface{}{common.HotAge: "1 day"})
+	assert.Error(t, err)
+
+	fs, _, _ := newTestTieredFS(t, map[string]interface{}{
+		common.SizeThreshold: "1024",
+		common.HotPrefixes:   "/models/, ckpt",
+		common.HotAge:        "1h",
+	})
+	assert.Equal(t, common.TieredType, fs.String())
+	assert.Equal(t, int64(1024), fs.policy.sizeThreshold)
+	assert.Equal(t, []string{"models", "ckpt"}, fs.policy.hotPrefixes)
+	assert.Equal(t, time.Hour, fs.policy.hotAge)
+	assert.Equal(t, time.Duration(0), fs.policy.migrateInterval)
+	assert.True(t, fs.migrating)
+
+	// 默认不在当前进程中迁移，也不记录文件的访问
+	root := t.TempDir()
+	ufs, err := NewTieredFileSystem(map[string]interface{}{
+		common.HotTierPrefix + common.Type:     common.LocalType,
+		common.HotTierPrefix + common.SubPath:  filepath.Join(root, "hot"),
+		common.ColdTierPrefix + common.Type:    common.LocalType,
+		common.ColdTierPrefix + common.SubPath: filepath.Join(root, "cold"),
+	})
+	assert.NoError(t, err)
+	fs = ufs.(*tieredFileSystem)
+	assert.False(t, fs.migrating)
+	assert.Equal(t, DefaultMigrateInterval, fs.policy.migrateInterval)
+	writeTieredFile(t, fs, "file", "content")
+	assert.Equal(t, "content", readTieredFile(t, fs, "file"))
+	_, ok := fs.accessed.get("file")
+	assert.False(t, ok)
+}
+
+func TestTierAccessTracker(t *testing.T) {
+	tracker := newTierAccessTracker(tieredAccessShards)
+	assert.Equal(t, 1, tracker.maxPerShard)
+	now := time.Now()
+	tracker.set("dir/a", now, true)
+	tracker.set("dir/b", now, false)
+	tracker.set("dir/a", now.Add(time.Second), false)
+	at, ok := tracker.get("dir/a")
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Second), at)
+
+	// rename 转移访问记录和待提升标记
+	tracker.rename("dir", "data")
+	_, ok = tracker.get("dir/a")
+	assert.False(t, ok)
+	at, ok = tracker.get("data/a")
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Second), at)
+	assert.Equal(t, []string{"data/a"}, tracker.takePromotes())
+	assert.Equal(t, 0, len(tracker.takePromotes()))
+
+	// 记录数有上限，超过后淘汰同一分片中最久未访问的记录
+	for i := 0; i < 10*tieredAccessShards; i++ {
+		tracker.set(fmt.Sprintf("file-%d", i), now, true)
+	}
+	total := 0
+	for _, shard := range tracker.shards {
+		assert.Equal(t, len(shard.entries), shard.lru.Len())
+		assert.True(t, shard.lru.Len() <= tracker.maxPerShard)
+		total += shard.lru.Len()
+	}
+	assert.True(t, total <= tieredAccessShards)
+	assert.Equal(t, total, len(tracker.takePromotes()))
+
+	tracker.remove("data/a")
+	_, ok = tracker.get("data/a")
+	assert.False(t, ok)
+}
+
+func TestTieredFileSystem_MergedView(t *testing.T) {
+	fs, hotDir, coldDir := newTestTieredFS(t, map[string]interface{}{
+		common.ColdPrefixes: "archive",
+	})
+
+	// 新文件写入热层，冷层前缀下的文件写入冷层
+	assert.NoError(t, fs.Mkdir("data", 0755))
+	writeTieredFile(t, fs, "data/new", "hot")
+	writeTieredFile(t, fs, "archive/old", "cold")
+	assert.FileExists(t, filepath.Join(hotDir, "data/new"))
+	assert.FileExists(t, filepath.Join(coldDir, "archive/old"))
+
+	// 直接放在冷层中的文件同样可见
+	assert.NoError(t, os.MkdirAll(filepath.Join(coldDir, "data"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(coldDir, "data/cold"), []byte("cold file"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(coldDir, "data/new"), []byte("stale"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(hotDir, "data/.pfs-tiered-tmp-1"), []byte("tmp"), 0644))
+
+	assert.Equal(t, []string{"archive", "data"}, tieredDirNames(t, fs, ""))
+	assert.Equal(t, []string{"cold", "new"}, tieredDirNames(t, fs, "data"))
+	info, err := fs.GetAttr("data/new")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), info.Size)
+	assert.Equal(t, "cold file", readTieredFile(t, fs, "data/cold"))
+	_, err = fs.GetAttr("data/none")
+	assert.True(t, tierNotExist(err))
+	assert.Equal(t, syscall.EEXIST, fs.Mkdir("data", 0755))
+
+	// rename 冷层文件时清理热层中的同名文件
+	assert.NoError(t, fs.Rename("data/cold", "data/new"))
+	assert.Equal(t, "cold file", readTieredFile(t, fs, "data/new"))
+	assert.NoFileExists(t, filepath.Join(hotDir, "data/new"))
+	assert.Equal(t, []string{"new"}, tieredDirNames(t, fs, "data"))
+
+	// 目录rename和删除作用于两层
+	writeTieredFile(t, fs, "data/hot", "hot")
+	assert.NoError(t, fs.Rename("data", "dataset"))
+	assert.Equal(t, []string{"hot", "new"}, tieredDirNames(t, fs, "dataset"))
+	assert.Error(t, fs.Rmdir("dataset"))
+	assert.NoError(t, fs.Unlink("dataset/hot"))
+	assert.NoError(t, fs.Unlink("dataset/new"))
+	assert.NoError(t, fs.Rmdir("dataset"))
+	_, err = fs.GetAttr("dataset")
+	assert.True(t, tierNotExist(err))
+	assert.True(t, tierNotExist(fs.Unlink("dataset/new")))
+}
+
+func TestTieredFileSystem_Migrate(t *testing.T) {
+	fs, hotDir, coldDir := newTestTieredFS(t, map[string]interface{}{
+		common.SizeThreshold: "8",
+		common.HotPrefixes:   "pinned",
+		common.HotAge:        "1h",
+	})
+
+	writeTieredFile(t, fs, "dir/small", "small")
+	writeTieredFile(t, fs, "dir/large", "large content")
+	writeTieredFile(t, fs, "dir/idle", "idle")
+	writeTieredFile(t, fs, "pinned/large", "pinned large content")
+	writeTieredFile(t, fs, "dir/writing", "writing")
+	idle := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(hotDir, "dir/idle"), idle, idle))
+	fs.accessed.remove("dir/idle")
+	fh, err := fs.Open("dir/writing", uint32(os.O_WRONLY))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chtimes(filepath.Join(hotDir, "dir/writing"), idle, idle))
+	fs.accessed.remove("dir/writing")
+
+	// 超过大小阈值和长时间未访问的文件迁移到冷层，固定在热层和正在写的文件不迁移
+	assert.Equal(t, 2, fs.demoteDir(""))
+	assert.FileExists(t, filepath.Join(hotDir, "dir/small"))
+	assert.FileExists(t, filepath.Join(hotDir, "pinned/large"))
+	assert.FileExists(t, filepath.Join(hotDir, "dir/writing"))
+	assert.FileExists(t, filepath.Join(coldDir, "dir/large"))
+	assert.FileExists(t, filepath.Join(coldDir, "dir/idle"))
+	assert.NoFileExists(t, filepath.Join(hotDir, "dir/large"))
+	assert.NoFileExists(t, filepath.Join(hotDir, "dir/idle"))
+	info, err := fs.GetAttr("dir/idle")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(idle.Unix()), info.Mtime)
+	assert.Equal(t, []string{"idle", "large", "small", "writing"}, tieredDirNames(t, fs, "dir"))
+	fh.Release()
+
+	// 访问过的冷层文件拷贝到热层，冷层保留持久的副本，超过大小阈值的保留在冷层
+	assert.Equal(t, "idle", readTieredFile(t, fs, "dir/idle"))
+	assert.Equal(t, "large content", readTieredFile(t, fs, "dir/large"))
+	assert.Equal(t, 1, fs.promote())
+	assert.FileExists(t, filepath.Join(hotDir, "dir/idle"))
+	assert.FileExists(t, filepath.Join(coldDir, "dir/idle"))
+	assert.FileExists(t, filepath.Join(coldDir, "dir/large"))
+	assert.NoFileExists(t, filepath.Join(hotDir, "dir/large"))
+	assert.Equal(t, "idle", readTieredFile(t, fs, "dir/idle"))
+	assert.Equal(t, 0, fs.promote())
+	assert.Equal(t, []string{"idle", "large", "small", "writing"}, tieredDirNames(t, fs, "dir"))
+
+	// 提升后没有修改过的文件再次迁移时不再拷贝，直接删除热层副本
+	fs.accessed.remove("dir/idle")
+	// 冷层副本的权限与热层不同，被重新拷贝覆盖时权限会变化
+	assert.NoError(t, os.Chmod(filepath.Join(coldDir, "dir/idle"), 0400))
+	assert.Equal(t, 2, fs.demoteDir(""))
+	assert.NoFileExists(t, filepath.Join(hotDir, "dir/idle"))
+	assert.NoFileExists(t, filepath.Join(hotDir, "dir/writing"))
+	assert.Equal(t, "idle", readTieredFile(t, fs, "dir/idle"))
+	coldInfo, err := os.Stat(filepath.Join(coldDir, "dir/idle"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0400), coldInfo.Mode().Perm())
+}
+
+func TestTieredFileSystem_MoveWaitsForWrite(t *testing.T) {
+	fs, hotDir, coldDir := newTestTieredFS(t, map[string]interface{}{})
+	writeTieredFile(t, fs, "file", "content")
+
+	// 迁移登记之后的写打开等待迁移完成，再打开迁移后所在的层
+	assert.True(t, fs.beginMove("file"))
+	opened := make(chan base.FileHandle, 1)
+	go func() {
+		fh, err := fs.Open("file", uint32(os.O_WRONLY))
+		assert.NoError(t, err)
+		opened <- fh
+	}()
+	assert.NoError(t, copyTierFile(fs.hot, fs.cold, "file", "file", 0644))
+	assert.NoError(t, fs.hot.Unlink("file"))
+	select {
+	case <-opened:
+		t.Fatal("open for write should wait for the move")
+	case <-time.After(100 * time.Millisecond):
+	}
+	fs.endMove("file")
+	fh := <-opened
+	fh.Release()
+	assert.NoFileExists(t, filepath.Join(hotDir, "file"))
+	assert.FileExists(t, filepath.Join(coldDir, "file"))
+
+	// 正在写的文件不能登记迁移
+	fh, err := fs.Open("file", uint32(os.O_WRONLY))
+	assert.NoError(t, err)
+	assert.False(t, fs.beginMove("file"))
+	fh.Release()
+	assert.True(t, fs.beginMove("file"))
+	fs.endMove("file")
+}
@@ -20,8 +20,19 @@ import (
 	"sync"
 	"syscall"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/meta"
 	ufslib "github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/utils"
+)
+
+const (
+	flockHeld = 1 << iota
+	plockHeld
+
+	// maxLockEnd is the end of lock which covers the whole file
+	maxLockEnd = 0x7FFFFFFFFFFFFFFF
 )
 
 type handle struct {
@@ -32,6 +43,12 @@ type handle struct {
 	writer   FileWriter
 	children []*meta.Entry
 
+	// locks held by the file, flockOwner is the owner of flock,
+	// plockOwners are the owners of posix locks set through the handle
+	locks       uint8
+	flockOwner  uint64
+	plockOwners map[uint64]struct{}
+
 	// internal files
 	off  uint64
 	data []byte
@@ -53,6 +70,29 @@ func (v *VFS) findAllHandle(inode Ino) []*handle {
 	return v.handleMap[inode]
 }
 
+// releasePlocks releases posix locks of owner on inode, posix locks belong to (inode, owner)
+// and are released when any file descriptor of the file is closed.
+func (v *VFS) releasePlocks(ctx *meta.Context, inode Ino, owner uint64) {
+	held := false
+	for _, h := range v.findAllHandle(inode) {
+		h.Lock()
+		if _, ok := h.plockOwners[owner]; ok {
+			held = true
+			delete(h.plockOwners, owner)
+			if len(h.plockOwners) == 0 {
+				h.locks &^= plockHeld
+			}
+		}
+		h.Unlock()
+	}
+	if !held {
+		return
+	}
+	if err := v.Meta.Setlk(ctx, inode, owner, false, syscall.F_UNLCK, 0, maxLockEnd, 0); utils.IsError(err) {
+		log.Errorf("release plock of inode[%d] owner[%d] err: %v", inode, owner, err)
+	}
+}
+
 func (v *VFS) findHandle(inode Ino, fh uint64) *handle {
 	v.handleLock.RLock()
 	defer v.handleLock.RUnlock()
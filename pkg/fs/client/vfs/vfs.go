@@ -134,6 +134,12 @@ func InitVFS(fsMeta common.FSMeta, links map[string]common.FSMeta, global bool,
 		blockSize = config.Cache.BlockSize
 	}
 	vfs.Store = store
+	if store != nil {
+		store.RecoverWriteBack(func(name string) (ufslib.UnderFileStorage, string) {
+			ufs, _, _, path := vfsMeta.GetUFS(name)
+			return ufs, path
+		})
+	}
 	vfs.reader = NewDataReader(vfs.Meta, blockSize, store)
 	vfs.writer = NewDataWriter(vfs.Meta, blockSize, store)
 	vfs.handleMap = make(map[Ino][]*handle)
@@ -155,6 +161,17 @@ func GetVFS() *VFS {
 	return vfsop
 }
 
+// waitWriteBack waits for the pending upload of file, so that it is not uploaded to the old path
+// after the file is removed or renamed
+func (v *VFS) waitWriteBack(name string) {
+	if v.Store == nil {
+		return
+	}
+	if err := v.Store.WaitWriteBack(name); err != nil {
+		log.Errorf("wait write-back of [%s] err: %v", name, err)
+	}
+}
+
 func (v *VFS) getUFS(name string) (ufslib.UnderFileStorage, bool, string, string) {
 	return v.Meta.GetUFS(name)
 }
@@ -262,7 +279,13 @@ func (v *VFS) Mkdir(ctx *meta.Context, parent Ino, name string, mode uint32) (en
 }
 
 func (v *VFS) Unlink(ctx *meta.Context, parent Ino, name string) (err syscall.Errno) {
+	path := v.Meta.InoToPath(parent) + "/" + name
+	v.waitWriteBack(path)
 	err = v.Meta.Unlink(ctx, parent, name)
+	if !utils.IsError(err) && v.Store != nil {
+		// data failed to upload is not needed any more
+		v.Store.DiscardWriteBack(path)
+	}
 	return err
 }
 
@@ -282,6 +305,7 @@ func (v *VFS) Rmdir(ctx *meta.Context, parent Ino, name string) (err syscall.Err
 func (v *VFS) Rename(ctx *meta.Context, parent Ino, name string, newparent Ino, newname string, flags uint32) (err syscall.Errno) {
 	var ino Ino
 	attr := &Attr{}
+	v.waitWriteBack(v.Meta.InoToPath(parent) + "/" + name)
 	err = v.Meta.Rename(ctx, parent, name, newparent, newname, flags, &ino, attr)
 	if utils.IsError(err) {
 		return err
@@ -310,11 +334,20 @@ func (v *VFS) Link(ctx *meta.Context, ino Ino, newparent Ino, newname string) (e
 }
 
 func (v *VFS) Symlink(ctx *meta.Context, path string, parent Ino, name string) (entry *meta.Entry, err syscall.Errno) {
-	return nil, syscall.ENOSYS
+	var ino Ino
+	attr := &Attr{}
+	err = v.Meta.Symlink(ctx, parent, name, path, &ino, attr)
+	entry = &meta.Entry{Ino: ino, Attr: attr}
+	return
 }
 
 func (v *VFS) Readlink(ctx *meta.Context, ino Ino) (path []byte, err syscall.Errno) {
-	return nil, syscall.ENOSYS
+	if IsSpecialNode(ino) {
+		err = syscall.EINVAL
+		return
+	}
+	err = v.Meta.ReadLink(ctx, ino, &path)
+	return
 }
 
 func (v *VFS) Access(ctx *meta.Context, ino Ino, mask uint32) (err syscall.Errno) {
@@ -427,6 +460,10 @@ func (v *VFS) Open(ctx *meta.Context, ino Ino, flags uint32) (entry *meta.Entry,
 			return
 		}
 	}
+	if flags&syscall.O_ACCMODE != syscall.O_WRONLY {
+		// data written back may be not uploaded yet
+		v.waitWriteBack(v.Meta.InoToPath(ino))
+	}
 	ufs, path, err := v.Meta.Open(ctx, ino, flags, attr)
 	if utils.IsError(err) {
 		return
@@ -487,15 +524,60 @@ func (v *VFS) Read(ctx *meta.Context, ino Ino, buf []byte, off uint64, fh uint64
 
 // File locking
 func (v *VFS) GetLk(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, len *uint64, typ *uint32, pid *uint32) (err syscall.Errno) {
-	return syscall.ENOSYS
+	if IsSpecialNode(ino) {
+		return syscall.EPERM
+	}
+	if v.findHandle(ino, fh) == nil {
+		return syscall.EBADF
+	}
+	return v.Meta.Getlk(ctx, ino, owner, typ, start, len, pid)
 }
 
 func (v *VFS) SetLk(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end uint64, typ uint32, pid uint32, block bool) (err syscall.Errno) {
-	return syscall.ENOSYS
+	if IsSpecialNode(ino) {
+		return syscall.EPERM
+	}
+	h := v.findHandle(ino, fh)
+	if h == nil {
+		return syscall.EBADF
+	}
+	err = v.Meta.Setlk(ctx, ino, owner, block, typ, start, end, pid)
+	if err == syscall.F_OK && typ != syscall.F_UNLCK {
+		h.Lock()
+		h.locks |= plockHeld
+		if h.plockOwners == nil {
+			h.plockOwners = make(map[uint64]struct{})
+		}
+		h.plockOwners[owner] = struct{}{}
+		h.Unlock()
+	}
+	return err
 }
 
 func (v *VFS) SetLkw(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end uint64, typ uint32, pid uint32, block bool) (err syscall.Errno) {
-	return syscall.ENOSYS
+	return v.SetLk(ctx, ino, fh, owner, start, end, typ, pid, true)
+}
+
+func (v *VFS) Flock(ctx *meta.Context, ino Ino, fh uint64, owner uint64, typ uint32, block bool) (err syscall.Errno) {
+	if IsSpecialNode(ino) {
+		return syscall.EPERM
+	}
+	h := v.findHandle(ino, fh)
+	if h == nil {
+		return syscall.EBADF
+	}
+	err = v.Meta.Flock(ctx, ino, owner, typ, block)
+	if err == syscall.F_OK {
+		h.Lock()
+		defer h.Unlock()
+		if typ == syscall.F_UNLCK {
+			h.locks &^= flockHeld
+		} else {
+			h.locks |= flockHeld
+			h.flockOwner = owner
+		}
+	}
+	return err
 }
 
 func (v *VFS) Write(ctx *meta.Context, ino Ino, buf []byte, off, fh uint64) (err syscall.Errno) {
@@ -542,6 +624,8 @@ func (v *VFS) Flush(ctx *meta.Context, ino Ino, fh uint64, lockOwner uint64) (er
 	if h.writer != nil {
 		err = h.writer.Flush()
 	}
+	// posix locks of owner are released when any file descriptor of the file is closed
+	v.releasePlocks(ctx, ino, lockOwner)
 	return err
 }
 
@@ -624,6 +708,23 @@ func (v *VFS) Release(ctx *meta.Context, ino Ino, fh uint64) {
 		return
 	}
 	if fh > 0 {
+		if h := v.findHandle(ino, fh); h != nil {
+			if h.locks&flockHeld != 0 {
+				if err := v.Meta.Flock(ctx, ino, h.flockOwner, syscall.F_UNLCK, false); utils.IsError(err) {
+					log.Errorf("release flock of inode[%d] owner[%d] err: %v", ino, h.flockOwner, err)
+				}
+			}
+			// posix locks not released by flush, e.g. flush is not called with the lock owner
+			h.Lock()
+			owners := make([]uint64, 0, len(h.plockOwners))
+			for owner := range h.plockOwners {
+				owners = append(owners, owner)
+			}
+			h.Unlock()
+			for _, owner := range owners {
+				v.releasePlocks(ctx, ino, owner)
+			}
+		}
 		v.releaseFileHandle(ino, fh)
 		log.Debugf("release inode %v", ino)
 		return
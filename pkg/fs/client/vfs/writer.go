@@ -63,6 +63,8 @@ type fileWriter struct {
 	path   string
 	length uint64
 	ufs    ufslib.UnderFileStorage
+	// cacheWriter is not nil when write-back is enabled, and fd is not opened then
+	cacheWriter cache.Writer
 
 	// TODO: 先用base.FileHandle跑通流程，后续修改ufs接口
 	fd base.FileHandle
@@ -71,11 +73,13 @@ type fileWriter struct {
 func (f *fileWriter) Fallocate(size int64, off int64, mode uint32) syscall.Errno {
 	f.Lock()
 	defer f.Unlock()
+	if f.cacheWriter != nil {
+		return syscall.ENOTSUP
+	}
 	return syscall.Errno(f.fd.Allocate(uint64(off), uint64(size), mode))
 }
 
 func (f *fileWriter) Write(data []byte, offset uint64) syscall.Errno {
-	ufsHandle := ufslib.NewFileHandle(f.fd)
 	f.Lock()
 	defer f.Unlock()
 	var err error
@@ -88,6 +92,14 @@ func (f *fileWriter) Write(data []byte, offset uint64) syscall.Errno {
 			return syscall.EBADF
 		}
 	}
+	if f.cacheWriter != nil {
+		if _, err = f.cacheWriter.WriteAt(data, int64(offset)); err != nil {
+			log.Errorf("write-back write err: %v", err)
+			return syscall.EIO
+		}
+		return syscall.F_OK
+	}
+	ufsHandle := ufslib.NewFileHandle(f.fd)
 	_, err = ufsHandle.WriteAt(data, int64(offset))
 	if err != nil {
 		log.Errorf("ufs write err: %v", err)
@@ -107,6 +119,9 @@ func (f *fileWriter) Flush() syscall.Errno {
 			return syscall.EBADF
 		}
 	}
+	if f.cacheWriter != nil {
+		return f.flushCacheWriter()
+	}
 	// todo:: 需要加一个超时和重试
 	err := f.fd.Flush()
 	return syscall.Errno(err)
@@ -115,21 +130,44 @@ func (f *fileWriter) Flush() syscall.Errno {
 func (f *fileWriter) Fsync(fd int) syscall.Errno {
 	f.Lock()
 	defer f.Unlock()
+	if f.cacheWriter != nil {
+		return f.flushCacheWriter()
+	}
 	// todo:: 需要加一个超时和重试
 	err := f.fd.Fsync(fd)
 	return syscall.Errno(err)
 }
 
+// flushCacheWriter blocks until written data is uploaded to ufs
+func (f *fileWriter) flushCacheWriter() syscall.Errno {
+	if err := f.cacheWriter.Flush(); err != nil {
+		log.Errorf("write-back flush[%s] err: %v", f.name, err)
+		return syscall.EIO
+	}
+	return syscall.F_OK
+}
+
 func (f *fileWriter) Close() {
 	f.release()
 }
 
 func (f *fileWriter) release() {
 	delete(f.writer.files, f.inode)
+	if f.cacheWriter != nil {
+		_ = f.cacheWriter.Close()
+		return
+	}
 	f.fd.Release()
 }
 
 func (f *fileWriter) Truncate(size uint64) syscall.Errno {
+	if f.cacheWriter != nil {
+		if err := f.cacheWriter.Truncate(int64(size)); err != nil {
+			log.Errorf("write-back truncate[%s] err: %v", f.name, err)
+			return syscall.EIO
+		}
+		return syscall.F_OK
+	}
 	return syscall.Errno(f.fd.Truncate(size))
 }
 
@@ -144,10 +182,6 @@ type dataWriter struct {
 
 func (w *dataWriter) Open(inode Ino, length uint64, ufs ufslib.UnderFileStorage, path string) (FileWriter, error) {
 	name := w.m.InoToPath(inode)
-	fd, err := ufs.Open(path, syscall.O_WRONLY)
-	if err != nil {
-		return nil, err
-	}
 	f := &fileWriter{
 		writer: w,
 		inode:  inode,
@@ -155,7 +189,16 @@ func (w *dataWriter) Open(inode Ino, length uint64, ufs ufslib.UnderFileStorage,
 		path:   path,
 		length: length,
 		ufs:    ufs,
-		fd:     fd,
+	}
+	if w.store != nil {
+		f.cacheWriter = w.store.NewWriter(name, path, int(length), ufs)
+	}
+	if f.cacheWriter == nil {
+		fd, err := ufs.Open(path, syscall.O_WRONLY)
+		if err != nil {
+			return nil, err
+		}
+		f.fd = fd
 	}
 	w.Lock()
 	w.files[inode] = f
@@ -32,6 +32,7 @@ const (
 	MockType             = "mock"
 	CFSType              = "cfs"
 	GlusterFSType        = "glusterfs"
+	TieredType           = "tiered"
 
 	// common
 	Owner = "owner"
@@ -66,6 +67,8 @@ const (
 	S3ForcePathStyle   = "s3ForcePathStyle"
 	DirMode            = "dirMode"
 	FileMode           = "fileMode"
+	// symlinks are emulated only when enabled, because reading a dir has to head the small objects to find them
+	S3Symlink = "symlink"
 
 	// sftp properties
 	Address  = "address"
@@ -75,6 +78,15 @@ const (
 	PVC       = "pvc"
 	Namespace = "namespace"
 
+	// tiered properties，热层和冷层各自的属性分别以hot.和cold.为前缀，如hot.type、cold.bucket
+	HotTierPrefix   = "hot."
+	ColdTierPrefix  = "cold."
+	SizeThreshold   = "tiered.sizeThreshold"
+	HotPrefixes     = "tiered.hotPrefixes"
+	ColdPrefixes    = "tiered.coldPrefixes"
+	HotAge          = "tiered.hotAge"
+	MigrateInterval = "tiered.migrateInterval"
+
 	// FSMeta类型
 	FSType   = "fs"
 	LinkType = "link"
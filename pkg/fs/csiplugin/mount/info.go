@@ -91,6 +91,9 @@ func GetOptions(mountInfo Info, readOnly bool) []string {
 		if mountInfo.CacheConfig.BlockSize > 0 {
 			options = append(options, fmt.Sprintf("--%s=%d", "block-size", mountInfo.CacheConfig.BlockSize))
 		}
+		if mountInfo.CacheConfig.Quota > 0 {
+			options = append(options, fmt.Sprintf("--%s=%d", "data-cache-quota", mountInfo.CacheConfig.Quota))
+		}
 		if mountInfo.CacheConfig.CacheDir != "" {
 			options = append(options, fmt.Sprintf("--%s=%s", "data-cache-path", mountInfo.CacheConfig.CacheDir))
 		}
@@ -135,6 +138,9 @@ func GetOptions(mountInfo Info, readOnly bool) []string {
 		if mountInfo.CacheConfig.BlockSize > 0 {
 			options = append(options, fmt.Sprintf("--%s=%d", "block-size", mountInfo.CacheConfig.BlockSize))
 		}
+		if mountInfo.CacheConfig.Quota > 0 {
+			options = append(options, fmt.Sprintf("--%s=%d", "data-cache-quota", mountInfo.CacheConfig.Quota))
+		}
 		if mountInfo.CacheConfig.CacheDir != "" {
 			options = append(options, fmt.Sprintf("--%s=%s", "data-cache-path", FusePodCachePath+DataCacheDir))
 		}
@@ -202,6 +208,7 @@ func (mountInfo *Info) CacheWorkerCmd() string {
 		"--nodename=" + csiconfig.NodeName,
 		"--clusterID=" + csiconfig.ClusterID,
 		"--podCachePath=" + FusePodCachePath,
+		"--mountPoint=" + FusePodMountPoint,
 		"--cacheDir=" + mountInfo.CacheConfig.CacheDir,
 		"--fsID=" + mountInfo.FS.ID,
 	}
@@ -205,7 +205,7 @@ func buildMountPod(volumeID string, mountInfo Info) (*k8sCore.Pod, error) {
 	pod.Spec.Containers = append(pod.Spec.Containers, mountContainer)
 
 	if mountInfo.CacheConfig.CacheDir != "" {
-		cacheContainer := buildCacheWorkerContainer(mountInfo.CacheWorkerCmd())
+		cacheContainer := buildCacheWorkerContainer(mountInfo)
 		pod.Spec.Containers = append(pod.Spec.Containers, cacheContainer)
 	}
 
@@ -405,11 +405,20 @@ func getCacheVolumes(cache model.FSCacheConfig) ([]k8sCore.Volume, []k8sCore.Vol
 	return volumes, volumeMounts
 }
 
-func buildCacheWorkerContainer(cmd string) k8sCore.Container {
+func buildCacheWorkerContainer(mountInfo Info) k8sCore.Container {
 	cacheContainer := getBaseContainer(ContainerNameCacheWorker)
-	cacheContainer.Command = []string{"sh", "-c", cmd}
+	cacheContainer.Command = []string{"sh", "-c", mountInfo.CacheWorkerCmd()}
 	mp := k8sCore.MountPropagationBidirectional
+	// warm-up reads files through the fuse mount point of the mount container,
+	// so the same sub path of the host mount dir must be mounted
+	mpMount := k8sCore.MountPropagationHostToContainer
 	volumeMounts := []k8sCore.VolumeMount{
+		{
+			Name:             VolumesKeyMount,
+			MountPath:        schema.FusePodMntDir,
+			SubPath:          mountInfo.FS.ID,
+			MountPropagation: &mpMount,
+		},
 		{
 			Name:             VolumesKeyDataCache,
 			MountPath:        FusePodCachePath + DataCacheDir,
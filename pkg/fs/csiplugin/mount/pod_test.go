@@ -19,6 +19,7 @@ package mount
 import (
 	"encoding/base64"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -228,3 +229,45 @@ func Test_addRef(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildMountPodCacheWorker(t *testing.T) {
+	csiconfig.Namespace = "default"
+	csiconfig.NodeName = "node1"
+	info := Info{
+		CacheConfig: model.FSCacheConfig{
+			FsID:       "fs-root-testfs",
+			CacheDir:   "/data/paddleflow-FS/mnt",
+			MetaDriver: "leveldb",
+		},
+		FS: model.FileSystem{
+			Model:    model.Model{ID: "fs-root-testfs"},
+			UserName: "root",
+			Name:     "testfs",
+			Type:     "s3",
+		},
+		TargetPath: testTargetPath,
+	}
+	pod, err := buildMountPod("aaaaa", info)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(pod.Spec.Containers))
+
+	mountContainer, workerContainer := pod.Spec.Containers[0], pod.Spec.Containers[1]
+	assert.Equal(t, ContainerNameCacheWorker, workerContainer.Name)
+	assert.Contains(t, workerContainer.Command[2], "--mountPoint="+FusePodMountPoint)
+
+	getMount := func(c k8sCore.Container) k8sCore.VolumeMount {
+		for _, vm := range c.VolumeMounts {
+			if vm.Name == VolumesKeyMount {
+				return vm
+			}
+		}
+		t.Fatalf("volume mount[%s] of container[%s] not found", VolumesKeyMount, c.Name)
+		return k8sCore.VolumeMount{}
+	}
+	// cache worker reads the fuse mount point through the same host path as the mount container
+	mountVM, workerVM := getMount(mountContainer), getMount(workerContainer)
+	assert.Equal(t, info.FS.ID, workerVM.SubPath)
+	assert.Equal(t, mountVM.SubPath, workerVM.SubPath)
+	assert.Equal(t, mountVM.MountPath, workerVM.MountPath)
+	assert.True(t, strings.HasPrefix(FusePodMountPoint, workerVM.MountPath+"/"))
+}
@@ -27,9 +27,14 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/core"
 )
 
-func ReportCacheLoop(cacheReport api.CacheReportParams, podCachePath string, httpClient *core.PaddleFlowClient) error {
+// ReportCacheLoop reports the cache usage periodically, and runs the warm-up tasks in the response through
+// mountPoint, whose progress is reported in the following reports
+func ReportCacheLoop(cacheReport api.CacheReportParams, podCachePath, mountPoint string,
+	httpClient *core.PaddleFlowClient) error {
 	var err, errStat error
 	var usageStat *disk.UsageStat
+	var resp *api.CacheReportResponse
+	runner := newWarmupRunner(mountPoint)
 	for {
 		usageStat, errStat = disk.Usage(podCachePath)
 		if errStat != nil {
@@ -38,9 +43,13 @@ func ReportCacheLoop(cacheReport api.CacheReportParams, podCachePath string, htt
 			continue
 		}
 		cacheReport.UsedSize = int(usageStat.Used / 1024)
-		err = api.CacheReportRequest(cacheReport, httpClient)
+		cacheReport.Warmups = runner.reports()
+		resp, err = api.CacheReportRequest(cacheReport, httpClient)
 		if err != nil {
 			log.Errorf("cache report failed with params[%+v] and err[%v]", cacheReport, err)
+		} else {
+			runner.reported(cacheReport.Warmups)
+			runner.start(resp.Warmups)
 		}
 		select {
 		case <-time.After(time.Duration(15+rand.Intn(10)) * time.Second):
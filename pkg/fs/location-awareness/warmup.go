@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package location_awareness
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
+)
+
+const (
+	warmupStatusRunning   = "running"
+	warmupStatusSucceeded = "succeeded"
+	warmupStatusFailed    = "failed"
+)
+
+type warmupState struct {
+	progress utils.WarmupProgress
+	status   string
+	message  string
+}
+
+// warmupRunner runs warm-up tasks dispatched by server through the mount point of the fuse client
+type warmupRunner struct {
+	sync.Mutex
+	mountPoint string
+	tasks      map[string]*warmupState
+}
+
+func newWarmupRunner(mountPoint string) *warmupRunner {
+	return &warmupRunner{
+		mountPoint: mountPoint,
+		tasks:      make(map[string]*warmupState),
+	}
+}
+
+func (r *warmupRunner) start(tasks []api.WarmupTask) {
+	r.Lock()
+	defer r.Unlock()
+	for _, task := range tasks {
+		if _, ok := r.tasks[task.WarmupID]; ok {
+			continue
+		}
+		state := &warmupState{status: warmupStatusRunning}
+		r.tasks[task.WarmupID] = state
+		if r.mountPoint == "" {
+			state.status, state.message = warmupStatusFailed, "mount point of cache-worker is not set"
+			continue
+		}
+		log.Infof("warmup[%s] started with paths %v", task.WarmupID, task.Paths)
+		go r.run(task, state)
+	}
+}
+
+func (r *warmupRunner) run(task api.WarmupTask, state *warmupState) {
+	err := utils.Warmup(context.Background(), r.mountPoint, task.Paths, task.Concurrency, &state.progress)
+	files, bytes, _ := state.progress.Load()
+	r.Lock()
+	defer r.Unlock()
+	if err != nil {
+		log.Errorf("warmup[%s] failed after %d files %d bytes: %v", task.WarmupID, files, bytes, err)
+		state.status, state.message = warmupStatusFailed, err.Error()
+		return
+	}
+	log.Infof("warmup[%s] succeeded with %d files %d bytes", task.WarmupID, files, bytes)
+	state.status = warmupStatusSucceeded
+}
+
+// reports returns progress of all tasks, the finished tasks are returned until they are reported successfully
+func (r *warmupRunner) reports() []api.WarmupReport {
+	r.Lock()
+	defer r.Unlock()
+	reports := make([]api.WarmupReport, 0, len(r.tasks))
+	for id, state := range r.tasks {
+		files, bytes, _ := state.progress.Load()
+		reports = append(reports, api.WarmupReport{
+			WarmupID: id,
+			Status:   state.status,
+			Files:    files,
+			Bytes:    bytes,
+			Message:  state.message,
+		})
+	}
+	return reports
+}
+
+// reported removes the finished tasks which have been reported
+func (r *warmupRunner) reported(reports []api.WarmupReport) {
+	r.Lock()
+	defer r.Unlock()
+	for _, report := range reports {
+		if report.Status != warmupStatusRunning {
+			delete(r.tasks, report.WarmupID)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const warmupBufferSize = 1 << 20
+
+// WarmupProgress counts files and bytes read by Warmup, and files or paths that failed to be read or walked,
+// it is safe to be read while warming up
+type WarmupProgress struct {
+	Files  int64
+	Bytes  int64
+	Failed int64
+}
+
+func (p *WarmupProgress) Load() (files, bytes, failed int64) {
+	return atomic.LoadInt64(&p.Files), atomic.LoadInt64(&p.Bytes), atomic.LoadInt64(&p.Failed)
+}
+
+// Warmup reads all files under paths of a mount point with concurrency goroutines, so that the data is
+// filled into the data cache of the fuse client. The paths are relative to the mount point.
+func Warmup(ctx context.Context, mountPoint string, paths []string, concurrency int, progress *WarmupProgress) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	files := make(chan string, concurrency*2)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, warmupBufferSize)
+			for file := range files {
+				n, err := readFile(ctx, file, buf)
+				atomic.AddInt64(&progress.Bytes, n)
+				if err != nil {
+					log.Errorf("warmup file[%s] failed: %v", file, err)
+					atomic.AddInt64(&progress.Failed, 1)
+					continue
+				}
+				atomic.AddInt64(&progress.Files, 1)
+			}
+		}()
+	}
+
+	var walkErr error
+	for _, p := range paths {
+		root := filepath.Join(mountPoint, filepath.Clean("/"+p))
+		walkErr = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				// a path that can not be walked is counted as failed, and the others are still warmed up
+				log.Errorf("warmup walk path[%s] failed: %v", path, err)
+				atomic.AddInt64(&progress.Failed, 1)
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				files <- path
+			}
+			return nil
+		})
+		if walkErr != nil {
+			break
+		}
+	}
+	close(files)
+	wg.Wait()
+	if walkErr != nil {
+		return walkErr
+	}
+	if _, _, failed := progress.Load(); failed > 0 {
+		return fmt.Errorf("%d files or paths failed to warm up", failed)
+	}
+	return nil
+}
+
+func readFile(ctx context.Context, path string, buf []byte) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var total int64
+	for {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		n, err := f.Read(buf)
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
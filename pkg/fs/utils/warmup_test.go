@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmup(t *testing.T) {
+	mountPoint := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(mountPoint, "data/sub"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(mountPoint, "data/a"), make([]byte, 3<<20), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(mountPoint, "data/sub/b"), []byte("hello"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(mountPoint, "other"), []byte("world"), 0644))
+
+	var progress WarmupProgress
+	err := Warmup(context.Background(), mountPoint, []string{"data"}, 2, &progress)
+	assert.Nil(t, err)
+	files, bytes, failed := progress.Load()
+	assert.Equal(t, int64(2), files)
+	assert.Equal(t, int64(3<<20+5), bytes)
+	assert.Equal(t, int64(0), failed)
+
+	// paths can not escape the mount point
+	progress = WarmupProgress{}
+	err = Warmup(context.Background(), filepath.Join(mountPoint, "data"), []string{"../other"}, 1, &progress)
+	assert.NotNil(t, err)
+	files, _, failed = progress.Load()
+	assert.Equal(t, int64(0), files)
+	assert.Equal(t, int64(1), failed)
+
+	// paths failed to be walked are counted, and the other paths are still warmed up
+	progress = WarmupProgress{}
+	err = Warmup(context.Background(), mountPoint, []string{"not-exist", "data/sub"}, 1, &progress)
+	assert.NotNil(t, err)
+	files, bytes, failed = progress.Load()
+	assert.Equal(t, int64(1), files)
+	assert.Equal(t, int64(5), bytes)
+	assert.Equal(t, int64(1), failed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Warmup(ctx, mountPoint, []string{"/"}, 1, &WarmupProgress{})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -18,8 +18,10 @@ package api
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
@@ -74,6 +76,11 @@ type PFJob struct {
 	Tags   []string
 	LogUrl string
 
+	// ExpectedDuration is the estimated running time of job, and zero means unknown
+	ExpectedDuration time.Duration
+	// gangResource caches the resources returned by GangResource
+	gangResource *resources.Resource
+
 	WaitingTime *time.Duration
 	CreateTime  time.Time
 	StartTime   time.Time
@@ -100,13 +107,131 @@ func NewJobInfo(job *models.Job) (*PFJob, error) {
 		Labels:            make(map[string]string),
 		Annotations:       make(map[string]string),
 		Resource:          job.Resource,
+		Priority:          JobPriority(job.Config.GetPriority()),
+		MinAvailable:      minAvailable(job),
+		ExpectedDuration:  expectedDuration(job.Config),
 		Tasks:             job.Members,
 		ExtensionTemplate: job.ExtensionTemplate,
+		CreateTime:        job.CreatedAt,
 	}
 	log.Debugf("gererated pfjob is: %#v", pfjob)
 	return pfjob, nil
 }
 
+// JobPriority converts the priority name of job to a number, and job with bigger number has higher priority
+func JobPriority(priority string) int32 {
+	switch strings.ToUpper(priority) {
+	case schema.EnvJobVeryLowPriority:
+		return 1
+	case schema.EnvJobLowPriority:
+		return 2
+	case schema.EnvJobHighPriority:
+		return 4
+	case schema.EnvJobVeryHighPriority:
+		return 5
+	default:
+		return 3
+	}
+}
+
+// minAvailable returns the number of replicas which must be scheduled together. It is read from the framework
+// spec of job, such as spec.minAvailable of vcjob or schedulingPolicy.minAvailable of paddlejob and kubeflow jobs,
+// and all replicas of job are required when it is not set
+func minAvailable(job *models.Job) int32 {
+	if len(job.Members) == 0 {
+		return 1
+	}
+	var replicas int32
+	for _, member := range job.Members {
+		replicas += int32(member.Replicas)
+	}
+	if specified := specMinAvailable(job.ExtensionTemplate); specified > 0 && specified < replicas {
+		return specified
+	}
+	return replicas
+}
+
+var minAvailablePaths = [][]string{
+	{"spec", "minAvailable"},
+	{"spec", "schedulingPolicy", "minAvailable"},
+	{"spec", "runPolicy", "schedulingPolicy", "minAvailable"},
+}
+
+// specMinAvailable returns the minAvailable set in the framework spec of job, and zero if it is not set
+func specMinAvailable(template string) int32 {
+	if template == "" {
+		return 0
+	}
+	spec := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(template), &spec); err != nil {
+		log.Warningf("parse minAvailable from job spec failed, err: %v", err)
+		return 0
+	}
+	for _, keys := range minAvailablePaths {
+		var value interface{} = spec
+		for _, key := range keys {
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = fields[key]
+		}
+		if number, ok := value.(float64); ok && number > 0 {
+			return int32(number)
+		}
+	}
+	return 0
+}
+
+// expectedDuration parses the expected duration of job, and returns zero if it is not set or invalid
+func expectedDuration(conf *schema.Conf) time.Duration {
+	if conf == nil || conf.GetExpectedDuration() == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(conf.GetExpectedDuration())
+	if err != nil || duration < 0 {
+		log.Warningf("expected duration %s of job is invalid, err: %v", conf.GetExpectedDuration(), err)
+		return 0
+	}
+	return duration
+}
+
+// GangResource returns the resources of replicas which must be scheduled together,
+// which are the first MinAvailable replicas of job members. The result is cached in job
+func (pfj *PFJob) GangResource() *resources.Resource {
+	if pfj.gangResource == nil {
+		pfj.gangResource = pfj.gangResourceOfTasks()
+	}
+	return pfj.gangResource
+}
+
+func (pfj *PFJob) gangResourceOfTasks() *resources.Resource {
+	if len(pfj.Tasks) == 0 || pfj.MinAvailable <= 0 {
+		return pfj.Resource
+	}
+	gangResource := resources.EmptyResource()
+	remaining := int(pfj.MinAvailable)
+	for _, task := range pfj.Tasks {
+		if remaining <= 0 {
+			break
+		}
+		replicas := task.Replicas
+		if replicas > remaining {
+			replicas = remaining
+		}
+		taskResource, err := resources.NewResourceFromMap(task.Flavour.ToMap())
+		if err != nil {
+			log.Warningf("get resources of job %s member failed, err: %v", pfj.ID, err)
+			return pfj.Resource
+		}
+		taskResource.Multi(replicas)
+		gangResource.Add(taskResource)
+		remaining -= replicas
+	}
+	return gangResource
+}
+
 func (pfj *PFJob) UpdateLabels(labels map[string]string) {
 	if labels == nil {
 		return
@@ -18,6 +18,7 @@ package api
 
 import (
 	"sync"
+	"time"
 )
 
 type JobQueue struct {
@@ -26,13 +27,16 @@ type JobQueue struct {
 	Queue    *QueueInfo
 	jobExist sync.Map
 	Jobs     *PriorityQueue
+	// Quota tracks the allocated resources of queue
+	Quota *QueueQuota
 }
 
-func NewJobQueue(q *QueueInfo) *JobQueue {
+func NewJobQueue(q *QueueInfo, quotaSyncPeriod time.Duration) *JobQueue {
 	return &JobQueue{
 		StopCh: make(chan struct{}),
 		Queue:  q,
 		Jobs:   NewPriorityQueue(q.JobOrderFn),
+		Quota:  NewQueueQuota(q, quotaSyncPeriod),
 	}
 }
 
@@ -44,6 +48,26 @@ func (qj *JobQueue) GetName() string {
 	return name
 }
 
+// UpdateQueue updates the quota of queue, and rebuilds the sort policies of queue when the names or
+// arguments of them are changed, the jobs in queue are reordered by the new policies
+func (qj *JobQueue) UpdateQueue(q *QueueInfo) {
+	if q == nil || qj.Queue == nil {
+		return
+	}
+	qj.Quota.UpdateQueue(q)
+	qj.Lock()
+	defer qj.Unlock()
+	if !qj.Queue.SortPolicyChanged(q) {
+		return
+	}
+	qj.Queue.SortPolicyNames = q.SortPolicyNames
+	qj.Queue.SortPolicyArgs = q.SortPolicyArgs
+	qj.Queue.SortPolicies = NewRegistry(q.SortPolicyNames, q.SortPolicyArgs)
+	if qj.Jobs != nil {
+		qj.Jobs.Reorder()
+	}
+}
+
 func (qj *JobQueue) Insert(job *PFJob) {
 	if qj.Jobs != nil && job != nil {
 		qj.Lock()
@@ -68,6 +92,31 @@ func (qj *JobQueue) GetJob() (*PFJob, bool) {
 	return nil, false
 }
 
+// TopJobs returns the first n jobs in queue in order, the jobs are kept in queue
+func (qj *JobQueue) TopJobs(n int) []*PFJob {
+	var jobs []*PFJob
+	if qj.Jobs != nil {
+		qj.RLock()
+		defer qj.RUnlock()
+		for _, item := range qj.Jobs.Top(n) {
+			jobs = append(jobs, item.(*PFJob))
+		}
+	}
+	return jobs
+}
+
+// Remove removes the job from queue, it is called when job reaches a final status before it is submitted
+func (qj *JobQueue) Remove(jobID string) {
+	if qj.Jobs != nil {
+		qj.Lock()
+		defer qj.Unlock()
+		qj.Jobs.Remove(func(item interface{}) bool {
+			return item.(*PFJob).ID == jobID
+		})
+	}
+	qj.jobExist.Delete(jobID)
+}
+
 func (qj *JobQueue) DeleteMark(jobID string) {
 	qj.jobExist.Delete(jobID)
 }
@@ -50,6 +50,34 @@ func (q *PriorityQueue) Pop() interface{} {
 	return heap.Pop(&q.queue)
 }
 
+// Reorder rebuilds the priority Queue, it is called after the lessFn is changed
+func (q *PriorityQueue) Reorder() {
+	heap.Init(&q.queue)
+}
+
+// Top returns the first n elements in order without removing them from the priority Queue
+func (q *PriorityQueue) Top(n int) []interface{} {
+	items := make([]interface{}, len(q.queue.items))
+	copy(items, q.queue.items)
+	pq := &priorityQueue{items: items, lessFn: q.queue.lessFn}
+	var result []interface{}
+	for len(result) < n && pq.Len() > 0 {
+		result = append(result, heap.Pop(pq))
+	}
+	return result
+}
+
+// Remove removes the first element matched in the priority Queue, and returns false if no element is matched
+func (q *PriorityQueue) Remove(match func(interface{}) bool) bool {
+	for idx, item := range q.queue.items {
+		if match(item) {
+			heap.Remove(&q.queue, idx)
+			return true
+		}
+	}
+	return false
+}
+
 // Empty check if queue is empty
 func (q *PriorityQueue) Empty() bool {
 	return q.queue.Len() == 0
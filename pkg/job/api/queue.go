@@ -18,6 +18,9 @@ package api
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -46,8 +49,12 @@ type QueueInfo struct {
 
 	// SortPolicy for queue job
 	SortPolicyNames []string
+	SortPolicyArgs  map[string]map[string]string
 	SortPolicies    []SortPolicy
 
+	// Backfill is the configuration of backfill scheduling, and nil means backfill is disabled
+	Backfill *BackfillConf
+
 	// SchedulerName for queue job
 	SchedulerName string
 
@@ -67,7 +74,12 @@ func NewQueueInfo(q models.Queue) *QueueInfo {
 		ClusterID:       ClusterID(q.ClusterId),
 		Status:          q.Status,
 		SortPolicyNames: q.SchedulingPolicy,
-		SortPolicies:    NewRegistry(q.SchedulingPolicy),
+		SortPolicyArgs:  q.SchedulingPolicyArgs,
+		SortPolicies:    NewRegistry(q.SchedulingPolicy, q.SchedulingPolicyArgs),
+		Backfill:        newQueueBackfillConf(q),
+		Max:             q.MaxResources,
+		Min:             q.MinResources,
+		Used:            resources.EmptyResource(),
 	}
 }
 
@@ -87,6 +99,15 @@ func (q *QueueInfo) JobOrderFn(l, r interface{}) bool {
 	return lv.CreateTime.Before(rv.CreateTime)
 }
 
+// SortPolicyChanged returns true if the names or arguments of sort policies are different from queue q
+func (q *QueueInfo) SortPolicyChanged(other *QueueInfo) bool {
+	if other == nil {
+		return false
+	}
+	return !reflect.DeepEqual(q.SortPolicyNames, other.SortPolicyNames) ||
+		!reflect.DeepEqual(q.SortPolicyArgs, other.SortPolicyArgs)
+}
+
 type SortPolicy interface {
 	Name() string
 	OrderFn(interface{}, interface{}) int
@@ -95,6 +116,115 @@ type SortPolicy interface {
 // Arguments map
 type Arguments map[string]string
 
+// GetFloat64 parses the value of key to float64, and ptr is unchanged if key is not found
+func (a Arguments) GetFloat64(ptr *float64, key string) error {
+	value, find := a[key]
+	if ptr == nil || !find || value == "" {
+		return nil
+	}
+	fv, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("argument %s=%s is not a float, err: %v", key, value, err)
+	}
+	*ptr = fv
+	return nil
+}
+
+// GetInt parses the value of key to a positive int, and ptr is unchanged if key is not found
+func (a Arguments) GetInt(ptr *int, key string) error {
+	value, find := a[key]
+	if ptr == nil || !find || value == "" {
+		return nil
+	}
+	iv, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("argument %s=%s is not an integer, err: %v", key, value, err)
+	}
+	if iv <= 0 {
+		return fmt.Errorf("argument %s=%s must be positive", key, value)
+	}
+	*ptr = iv
+	return nil
+}
+
+// GetDuration parses the value of key to time.Duration, and ptr is unchanged if key is not found
+func (a Arguments) GetDuration(ptr *time.Duration, key string) error {
+	value, find := a[key]
+	if ptr == nil || !find || value == "" {
+		return nil
+	}
+	dv, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("argument %s=%s is not a duration, err: %v", key, value, err)
+	}
+	if dv <= 0 {
+		return fmt.Errorf("argument %s=%s must be positive", key, value)
+	}
+	*ptr = dv
+	return nil
+}
+
+const (
+	// BackfillPolicyName is the scheduling policy of queue to enable backfill scheduling,
+	// which is not a sort policy
+	BackfillPolicyName = "backfill"
+	// BackfillDefaultDurationArg is the argument of expected duration for jobs without expected duration, such as 1h
+	BackfillDefaultDurationArg = "defaultDuration"
+	// BackfillScanDepthArg is the argument of max number of jobs behind the reserved job checked for backfill
+	BackfillScanDepthArg = "scanDepth"
+	// DefaultBackfillScanDepth is the default max number of jobs checked for backfill
+	DefaultBackfillScanDepth = 100
+)
+
+// BackfillConf defines the configuration of backfill scheduling for queue. When the job at the head of queue
+// can not be submitted, the resources it needs are reserved, and the following jobs are submitted only if they
+// finish before the reservation starts or they fit the resources left over at that time.
+type BackfillConf struct {
+	// DefaultDuration is the expected duration of jobs which do not set it, and zero means unknown
+	DefaultDuration time.Duration
+	// ScanDepth is the max number of jobs behind the reserved job checked for backfill
+	ScanDepth int
+}
+
+// NewBackfillConf returns the backfill configuration with arguments
+func NewBackfillConf(arguments Arguments) (*BackfillConf, error) {
+	conf := &BackfillConf{ScanDepth: DefaultBackfillScanDepth}
+	if err := arguments.GetDuration(&conf.DefaultDuration, BackfillDefaultDurationArg); err != nil {
+		return nil, err
+	}
+	if err := arguments.GetInt(&conf.ScanDepth, BackfillScanDepthArg); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// JobDuration returns the expected duration of job, and the default duration is used if job does not set it
+func (bc *BackfillConf) JobDuration(duration time.Duration) time.Duration {
+	if duration <= 0 && bc != nil {
+		return bc.DefaultDuration
+	}
+	return duration
+}
+
+func newQueueBackfillConf(q models.Queue) *BackfillConf {
+	enabled := false
+	for _, name := range q.SchedulingPolicy {
+		if name == BackfillPolicyName {
+			enabled = true
+			break
+		}
+	}
+	if !enabled {
+		return nil
+	}
+	conf, err := NewBackfillConf(q.SchedulingPolicyArgs[BackfillPolicyName])
+	if err != nil {
+		logrus.Warningf("new backfill conf for queue %s failed, disable backfill, err: %v", q.Name, err)
+		return nil
+	}
+	return conf
+}
+
 // PolicyFactory is a function that builds a sort policy.
 type PolicyFactory = func(configuration Arguments) (SortPolicy, error)
 
@@ -124,17 +254,24 @@ func (r Registry) Unregister(name string) error {
 // QueueSortPolicies global queue sort policies
 var QueueSortPolicies = make(Registry)
 
-// NewRegistry registry sort policy for queue
-func NewRegistry(policyNames []string) []SortPolicy {
+// NewRegistry registry sort policy for queue, policyArgs contains the arguments of each sort policy
+func NewRegistry(policyNames []string, policyArgs map[string]map[string]string) []SortPolicy {
 	var policies []SortPolicy
 
 	for _, name := range policyNames {
+		if name == BackfillPolicyName {
+			continue
+		}
 		policyNew, find := QueueSortPolicies[name]
 		if !find {
 			logrus.Warningf("queue sort policy[%s] is not found.", name)
 			continue
 		}
-		policy, err := policyNew(Arguments{})
+		arguments := Arguments{}
+		for key, value := range policyArgs[name] {
+			arguments[key] = value
+		}
+		policy, err := policyNew(arguments)
 		if err != nil {
 			logrus.Warningf("new sort policy[%s] failed, err: %v", name, err)
 			continue
@@ -0,0 +1,307 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+// allocatedJobStatus is the status of jobs which hold the resources of queue
+var allocatedJobStatus = []schema.JobStatus{
+	schema.StatusJobPending,
+	schema.StatusJobRunning,
+	schema.StatusJobTerminating,
+}
+
+// allocation records the resources allocated by job and its expected end time
+type allocation struct {
+	resource *resources.Resource
+	// endTime is zero when the duration of job is unknown
+	endTime time.Time
+}
+
+// QueueQuota tracks the resources allocated by jobs in queue, and admits jobs whose resources fit the max resources
+// of queue. The used resources are also recorded in QueueInfo.Used
+type QueueQuota struct {
+	sync.Mutex
+	queue *QueueInfo
+	// allocated records the resources of jobs which are submitted to cluster
+	allocated map[string]*allocation
+	used      *resources.Resource
+
+	syncPeriod time.Duration
+	syncTime   time.Time
+	listJobs   func(queueID string, status []schema.JobStatus) []models.Job
+}
+
+func NewQueueQuota(queue *QueueInfo, syncPeriod time.Duration) *QueueQuota {
+	return &QueueQuota{
+		queue:      queue,
+		allocated:  make(map[string]*allocation),
+		used:       resources.EmptyResource(),
+		syncPeriod: syncPeriod,
+		listJobs:   models.ListQueueJob,
+	}
+}
+
+// UpdateQueue updates the max resources and backfill configuration of queue
+func (qq *QueueQuota) UpdateQueue(q *QueueInfo) {
+	if q == nil {
+		return
+	}
+	qq.Lock()
+	defer qq.Unlock()
+	qq.queue.Max = q.Max
+	qq.queue.Backfill = q.Backfill
+}
+
+// BackfillEnabled returns true if backfill scheduling is enabled for queue
+func (qq *QueueQuota) BackfillEnabled() bool {
+	qq.Lock()
+	defer qq.Unlock()
+	return qq.queue.Backfill != nil
+}
+
+// BackfillScanDepth returns the max number of jobs checked for backfill, and zero if backfill is disabled
+func (qq *QueueQuota) BackfillScanDepth() int {
+	qq.Lock()
+	defer qq.Unlock()
+	if qq.queue.Backfill == nil {
+		return 0
+	}
+	return qq.queue.Backfill.ScanDepth
+}
+
+// Used returns a copy of the resources used by jobs in queue
+func (qq *QueueQuota) Used() *resources.Resource {
+	qq.Lock()
+	defer qq.Unlock()
+	qq.syncIfExpired()
+	return qq.used.Clone()
+}
+
+// Allocate allocates the resources of job from queue quota, and returns false when the quota is not enough
+func (qq *QueueQuota) Allocate(job *PFJob) bool {
+	qq.Lock()
+	defer qq.Unlock()
+	qq.syncIfExpired()
+	if _, find := qq.allocated[job.ID]; find {
+		return true
+	}
+	if !qq.fit(job.Resource) {
+		return false
+	}
+	qq.allocate(job.ID, job.Resource, qq.endTime(time.Now(), job.ExpectedDuration))
+	qq.queue.Used = qq.used.Clone()
+	return true
+}
+
+// ExceedMax returns true if the resources of job exceed the max resources of queue, such job can never be admitted
+func (qq *QueueQuota) ExceedMax(job *PFJob) bool {
+	qq.Lock()
+	defer qq.Unlock()
+	max := qq.queue.Max
+	if max == nil || len(max.Resources) == 0 || job.Resource == nil {
+		return false
+	}
+	return !job.Resource.LessEqual(max)
+}
+
+// MaxResources returns a copy of the max resources of queue
+func (qq *QueueQuota) MaxResources() *resources.Resource {
+	qq.Lock()
+	defer qq.Unlock()
+	if qq.queue.Max == nil {
+		return nil
+	}
+	return qq.queue.Max.Clone()
+}
+
+// Release releases the resources allocated by job
+func (qq *QueueQuota) Release(jobID string) {
+	qq.Lock()
+	defer qq.Unlock()
+	if alloc, find := qq.allocated[jobID]; find {
+		qq.used.Sub(alloc.resource)
+		delete(qq.allocated, jobID)
+		qq.queue.Used = qq.used.Clone()
+	}
+}
+
+// Sync rebuilds the allocated resources from pending and running jobs in database. The resources of finished
+// jobs are released on their final status events, and Sync corrects the allocations in case of missed events
+func (qq *QueueQuota) Sync() {
+	qq.Lock()
+	defer qq.Unlock()
+	qq.sync()
+}
+
+// Reservation records the resources reserved for the job at the head of queue
+type Reservation struct {
+	JobID string
+	// StartTime is the earliest time when the gang resources of job are available, and zero means unknown
+	StartTime time.Time
+	// Extra is the resources left over at StartTime after the reservation
+	Extra *resources.Resource
+}
+
+// Reserve reserves the gang resources of job, the start time of reservation is calculated by releasing
+// the allocated resources in order of their expected end time
+func (qq *QueueQuota) Reserve(job *PFJob, now time.Time) *Reservation {
+	qq.Lock()
+	defer qq.Unlock()
+	qq.syncIfExpired()
+	reservation := &Reservation{
+		JobID: job.ID,
+		Extra: resources.EmptyResource(),
+	}
+	max := qq.queue.Max
+	if max == nil || len(max.Resources) == 0 {
+		reservation.StartTime = now
+		return reservation
+	}
+	request := job.GangResource()
+	free := max.Clone()
+	free.Sub(qq.used)
+	if request.LessEqual(free) {
+		reservation.StartTime = now
+	} else {
+		allocations := make([]*allocation, 0, len(qq.allocated))
+		for _, alloc := range qq.allocated {
+			allocations = append(allocations, alloc)
+		}
+		sort.Slice(allocations, func(i, j int) bool {
+			if allocations[i].endTime.IsZero() || allocations[j].endTime.IsZero() {
+				return !allocations[i].endTime.IsZero()
+			}
+			return allocations[i].endTime.Before(allocations[j].endTime)
+		})
+		for _, alloc := range allocations {
+			if alloc.endTime.IsZero() {
+				// the resources of jobs with unknown duration can not be counted on
+				break
+			}
+			free.Add(alloc.resource)
+			if request.LessEqual(free) {
+				reservation.StartTime = alloc.endTime
+				if reservation.StartTime.Before(now) {
+					reservation.StartTime = now
+				}
+				break
+			}
+		}
+	}
+	if !reservation.StartTime.IsZero() {
+		free.Sub(request)
+		reservation.Extra = free
+	}
+	log.Debugf("reserve %s for job %s in queue %s, start time: %s, extra: %s", request, job.ID,
+		qq.queue.Name, reservation.StartTime, reservation.Extra)
+	return reservation
+}
+
+// Backfill allocates the resources of job which is behind the reserved job. The job is admitted only if
+// it fits the quota of queue now, and it is expected to finish before the reservation starts or it fits the
+// extra resources of reservation, so that the reserved job is not delayed.
+func (qq *QueueQuota) Backfill(job *PFJob, reservation *Reservation, now time.Time) bool {
+	qq.Lock()
+	defer qq.Unlock()
+	if reservation == nil || reservation.StartTime.IsZero() {
+		return false
+	}
+	if _, find := qq.allocated[job.ID]; find {
+		return true
+	}
+	if !qq.fit(job.Resource) {
+		return false
+	}
+	endTime := qq.endTime(now, job.ExpectedDuration)
+	if !endTime.IsZero() && !endTime.After(reservation.StartTime) {
+		log.Debugf("backfill job %s which finishes before the reservation of job %s", job.ID, reservation.JobID)
+	} else if job.Resource.LessEqual(reservation.Extra) {
+		log.Debugf("backfill job %s with the extra resources of reservation for job %s", job.ID, reservation.JobID)
+		reservation.Extra.Sub(job.Resource)
+	} else {
+		return false
+	}
+	qq.allocate(job.ID, job.Resource, endTime)
+	qq.queue.Used = qq.used.Clone()
+	return true
+}
+
+func (qq *QueueQuota) fit(request *resources.Resource) bool {
+	max := qq.queue.Max
+	if max == nil || len(max.Resources) == 0 {
+		// queue without max resources has no quota limit
+		return true
+	}
+	remaining := max.Clone()
+	remaining.Sub(qq.used)
+	return request.LessEqual(remaining)
+}
+
+// endTime returns the expected end time of job starting at startTime, and zero if duration is unknown
+func (qq *QueueQuota) endTime(startTime time.Time, duration time.Duration) time.Time {
+	duration = qq.queue.Backfill.JobDuration(duration)
+	if duration <= 0 {
+		return time.Time{}
+	}
+	return startTime.Add(duration)
+}
+
+func (qq *QueueQuota) allocate(jobID string, res *resources.Resource, endTime time.Time) {
+	if res == nil {
+		res = resources.EmptyResource()
+	}
+	qq.allocated[jobID] = &allocation{
+		resource: res.Clone(),
+		endTime:  endTime,
+	}
+	qq.used.Add(res)
+}
+
+func (qq *QueueQuota) syncIfExpired() {
+	if time.Since(qq.syncTime) >= qq.syncPeriod {
+		qq.sync()
+	}
+}
+
+func (qq *QueueQuota) sync() {
+	now := time.Now()
+	jobs := qq.listJobs(string(qq.queue.UID), allocatedJobStatus)
+	qq.allocated = make(map[string]*allocation, len(jobs))
+	qq.used = resources.EmptyResource()
+	for _, job := range jobs {
+		// pending job is expected to start now
+		startTime := now
+		if job.ActivatedAt.Valid {
+			startTime = job.ActivatedAt.Time
+		}
+		qq.allocate(job.ID, job.Resource, qq.endTime(startTime, expectedDuration(job.Config)))
+	}
+	qq.queue.Used = qq.used.Clone()
+	qq.syncTime = now
+	log.Debugf("sync quota of queue %s, used resources: %s", qq.queue.Name, qq.used)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+func newTestResource(t *testing.T, cpu, mem string) *resources.Resource {
+	res, err := resources.NewResourceFromMap(map[string]string{
+		resources.ResCPU:    cpu,
+		resources.ResMemory: mem,
+	})
+	assert.NoError(t, err)
+	return res
+}
+
+func TestQueueQuota(t *testing.T) {
+	queue := &QueueInfo{
+		UID:  "queue-1",
+		Name: "queue1",
+		Max:  newTestResource(t, "10", "20Gi"),
+	}
+	var dbJobs []models.Job
+	quota := NewQueueQuota(queue, time.Hour)
+	quota.listJobs = func(queueID string, status []schema.JobStatus) []models.Job {
+		return dbJobs
+	}
+
+	// running job in database holds the quota
+	dbJobs = []models.Job{{ID: "job-1", Resource: newTestResource(t, "4", "8Gi")}}
+	quota.Sync()
+	assert.Equal(t, newTestResource(t, "4", "8Gi"), queue.Used)
+
+	job2 := &PFJob{ID: "job-2", Resource: newTestResource(t, "4", "8Gi")}
+	job3 := &PFJob{ID: "job-3", Resource: newTestResource(t, "4", "1Gi")}
+	assert.True(t, quota.Allocate(job2))
+	assert.True(t, quota.Allocate(job2))
+	assert.False(t, quota.Allocate(job3))
+	assert.Equal(t, newTestResource(t, "8", "16Gi"), quota.Used())
+
+	// job submit failed
+	quota.Release(job2.ID)
+	assert.True(t, quota.Allocate(job3))
+	assert.Equal(t, newTestResource(t, "8", "9Gi"), queue.Used)
+
+	// job-1 finished, and its resources are released after sync
+	dbJobs = []models.Job{{ID: "job-3", Resource: newTestResource(t, "4", "1Gi")}}
+	quota.Sync()
+	assert.True(t, quota.Allocate(job2))
+	assert.Equal(t, newTestResource(t, "8", "9Gi"), queue.Used)
+
+	// job exceeds the max resources of queue
+	assert.True(t, quota.ExceedMax(&PFJob{ID: "job-5", Resource: newTestResource(t, "12", "1Gi")}))
+	assert.False(t, quota.ExceedMax(job3))
+	assert.Equal(t, newTestResource(t, "10", "20Gi"), quota.MaxResources())
+
+	// queue without max resources
+	quota.UpdateQueue(&QueueInfo{})
+	assert.False(t, quota.ExceedMax(&PFJob{ID: "job-5", Resource: newTestResource(t, "12", "1Gi")}))
+	assert.True(t, quota.Allocate(&PFJob{ID: "job-4", Resource: newTestResource(t, "100", "100Gi")}))
+}
+
+func TestQueueQuotaBackfill(t *testing.T) {
+	now := time.Now()
+	queue := &QueueInfo{
+		UID:      "queue-1",
+		Name:     "queue1",
+		Max:      newTestResource(t, "10", "20Gi"),
+		Backfill: &BackfillConf{},
+	}
+	quota := NewQueueQuota(queue, time.Hour)
+	quota.listJobs = func(queueID string, status []schema.JobStatus) []models.Job {
+		return []models.Job{
+			{
+				ID:          "job-1",
+				Resource:    newTestResource(t, "4", "8Gi"),
+				Config:      &schema.Conf{ExpectedDuration: "1h"},
+				ActivatedAt: sql.NullTime{Time: now.Add(-30 * time.Minute), Valid: true},
+			},
+			{
+				ID:       "job-2",
+				Resource: newTestResource(t, "4", "8Gi"),
+				Config:   &schema.Conf{},
+			},
+		}
+	}
+
+	// head job needs the resources of job-1, which is expected to finish in 30 minutes
+	headJob := &PFJob{ID: "head-job", Resource: newTestResource(t, "6", "8Gi")}
+	assert.False(t, quota.Allocate(headJob))
+	reservation := quota.Reserve(headJob, now)
+	assert.Equal(t, now.Add(30*time.Minute), reservation.StartTime)
+	assert.Equal(t, newTestResource(t, "0", "4Gi"), reservation.Extra)
+
+	// job which is too long and does not fit extra resources
+	longJob := &PFJob{ID: "long-job", Resource: newTestResource(t, "1", "1Gi"), ExpectedDuration: time.Hour}
+	assert.False(t, quota.Backfill(longJob, reservation, now))
+	// job without expected duration
+	unknownJob := &PFJob{ID: "unknown-job", Resource: newTestResource(t, "1", "1Gi")}
+	assert.False(t, quota.Backfill(unknownJob, reservation, now))
+	// job which finishes before reservation starts
+	shortJob := &PFJob{ID: "short-job", Resource: newTestResource(t, "2", "4Gi"), ExpectedDuration: 10 * time.Minute}
+	assert.True(t, quota.Backfill(shortJob, reservation, now))
+	assert.Equal(t, newTestResource(t, "10", "20Gi"), quota.Used())
+
+	// job with unknown duration is backfilled with extra resources
+	memJob := &PFJob{ID: "mem-job", Resource: newTestResource(t, "0", "4Gi")}
+	assert.False(t, quota.Backfill(memJob, reservation, now))
+	quota.Release(shortJob.ID)
+	assert.True(t, quota.Backfill(memJob, reservation, now))
+	assert.Equal(t, newTestResource(t, "0", "0"), reservation.Extra)
+
+	// start time of reservation is unknown when resources are held by jobs without expected duration
+	largeJob := &PFJob{ID: "large-job", Resource: newTestResource(t, "10", "20Gi")}
+	reservation = quota.Reserve(largeJob, now)
+	assert.True(t, reservation.StartTime.IsZero())
+	assert.False(t, quota.Backfill(shortJob, reservation, now))
+}
+
+func TestGangResource(t *testing.T) {
+	member := func(replicas int, cpu, mem string) models.Member {
+		return models.Member{
+			Replicas: replicas,
+			Conf: schema.Conf{
+				Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{CPU: cpu, Mem: mem}},
+			},
+		}
+	}
+	job := &PFJob{
+		ID:           "job-1",
+		Resource:     newTestResource(t, "10", "20Gi"),
+		MinAvailable: 3,
+		Tasks:        []models.Member{member(2, "1", "2Gi"), member(4, "2", "4Gi")},
+	}
+	assert.Equal(t, newTestResource(t, "4", "8Gi"), job.GangResource())
+	job = &PFJob{ID: "job-2", Resource: newTestResource(t, "10", "20Gi")}
+	assert.Equal(t, job.Resource, job.GangResource())
+}
+
+func TestMinAvailable(t *testing.T) {
+	job := &models.Job{
+		Members: []models.Member{{Replicas: 2}, {Replicas: 4}},
+	}
+	assert.Equal(t, int32(6), minAvailable(job))
+
+	job.ExtensionTemplate = "apiVersion: batch.paddlepaddle.org/v1\nkind: PaddleJob\nspec:\n  schedulingPolicy:\n    minAvailable: 3\n"
+	assert.Equal(t, int32(3), minAvailable(job))
+	job.ExtensionTemplate = `{"kind": "PyTorchJob", "spec": {"runPolicy": {"schedulingPolicy": {"minAvailable": 2}}}}`
+	assert.Equal(t, int32(2), minAvailable(job))
+	job.ExtensionTemplate = "kind: Job\nspec:\n  minAvailable: 10\n"
+	assert.Equal(t, int32(6), minAvailable(job))
+	job.ExtensionTemplate = "spec: ["
+	assert.Equal(t, int32(6), minAvailable(job))
+
+	assert.Equal(t, int32(1), minAvailable(&models.Job{}))
+}
+
+func TestJobQueueRemove(t *testing.T) {
+	jobQueue := NewJobQueue(&QueueInfo{Name: "queue1"}, time.Hour)
+	now := time.Now()
+	for i, id := range []string{"job-1", "job-2", "job-3"} {
+		jobQueue.Insert(&PFJob{ID: id, CreateTime: now.Add(time.Duration(i) * time.Second)})
+	}
+	jobQueue.Remove("job-2")
+	jobQueue.Remove("job-4")
+	// removed job can be inserted again
+	jobQueue.Insert(&PFJob{ID: "job-2", CreateTime: now.Add(time.Hour)})
+
+	var ids []string
+	for {
+		job, ok := jobQueue.GetJob()
+		if !ok {
+			break
+		}
+		ids = append(ids, job.ID)
+	}
+	assert.Equal(t, []string{"job-1", "job-3", "job-2"}, ids)
+}
+
+func TestJobQueueTopJobs(t *testing.T) {
+	jobQueue := NewJobQueue(&QueueInfo{Name: "queue1"}, time.Hour)
+	now := time.Now()
+	for i, id := range []string{"job-3", "job-1", "job-2"} {
+		jobQueue.Insert(&PFJob{ID: id, CreateTime: now.Add(time.Duration(3-i) * time.Second)})
+	}
+	var ids []string
+	for _, job := range jobQueue.TopJobs(2) {
+		ids = append(ids, job.ID)
+	}
+	assert.Equal(t, []string{"job-2", "job-1"}, ids)
+	// jobs are kept in queue
+	assert.Equal(t, 3, len(jobQueue.TopJobs(DefaultBackfillScanDepth)))
+	job, ok := jobQueue.GetJob()
+	assert.True(t, ok)
+	assert.Equal(t, "job-2", job.ID)
+}
+
+func TestNewBackfillConf(t *testing.T) {
+	conf, err := NewBackfillConf(Arguments{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultBackfillScanDepth, conf.ScanDepth)
+	conf, err = NewBackfillConf(Arguments{BackfillDefaultDurationArg: "1h", BackfillScanDepthArg: "10"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, conf.DefaultDuration)
+	assert.Equal(t, 10, conf.ScanDepth)
+	_, err = NewBackfillConf(Arguments{BackfillScanDepthArg: "0"})
+	assert.Error(t, err)
+}
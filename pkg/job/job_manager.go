@@ -25,9 +25,12 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/notification"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	// register queue sort policies
+	_ "github.com/PaddlePaddle/PaddleFlow/pkg/job/queue/sortpolicy"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/trace_logger"
 )
@@ -36,6 +39,9 @@ const (
 	defaultCacheSize  = 500
 	defaultExpireTime = 30
 	defaultJobLoop    = 1
+	// quotaSyncPeriod is the period to re-sync the quota of queue from database, the quota of a job is
+	// released on its final status event, and the re-sync only corrects the quota in case of missed events
+	quotaSyncPeriod = 5 * time.Minute
 )
 
 type ActiveClustersFunc func() []models.ClusterInfo
@@ -74,6 +80,7 @@ func (m *JobManagerImpl) Start(activeClusters ActiveClustersFunc, activeQueueJob
 	m.activeClusters = activeClusters
 	m.activeQueueJobs = activeQueueJobs
 	m.listQueueInitJobs = models.ListQueueInitJob
+	notification.AddJobListener(m.onJobFinished)
 	// init queue cache
 	cacheSize := config.GlobalServerConfig.Job.QueueCacheSize
 	if cacheSize < defaultCacheSize {
@@ -247,14 +254,14 @@ func (m *JobManagerImpl) submitQueueJob(jobSubmit func(*api.PFJob) error, queueI
 }
 
 // TODO: add trace logger support
-// submitJob submit a job to cluster
-func (m *JobManagerImpl) submitJob(jobSubmit func(*api.PFJob) error, jobInfo *api.PFJob) {
+// submitJob submit a job to cluster, and returns error if the job is not submitted
+func (m *JobManagerImpl) submitJob(jobSubmit func(*api.PFJob) error, jobInfo *api.PFJob) error {
 	log.Infof("begin to submit job %s to cluster", jobInfo.ID)
 	startTime := time.Now()
 	job, err := models.GetJobByID(jobInfo.ID)
 	if err != nil {
 		log.Errorf("get job %s from database failed, err: %v", job.ID, err)
-		return
+		return err
 	}
 	// check job status before create job on cluster
 	if job.Status == schema.StatusJobInit {
@@ -279,9 +286,58 @@ func (m *JobManagerImpl) submitJob(jobSubmit func(*api.PFJob) error, jobInfo *ap
 			trace_logger.KeyWithUpdate(jobInfo.ID).Errorf(errMsg)
 		}
 		log.Infof("submit job %s to cluster elasped time %s", jobInfo.ID, time.Since(startTime))
-	} else {
-		log.Errorf("job %s is already submit to cluster, skip it", job.ID)
+		return err
+	}
+	log.Errorf("job %s is already submit to cluster, skip it", job.ID)
+	return fmt.Errorf("job %s status is %s", job.ID, job.Status)
+}
+
+// waitForQuota keeps the job in init status when the quota of queue is not enough, and puts it back to job queue
+func (m *JobManagerImpl) waitForQuota(jobQueue *api.JobQueue, jobInfo *api.PFJob) {
+	jobQueue.DeleteMark(jobInfo.ID)
+	job, err := models.GetJobByID(jobInfo.ID)
+	if err != nil {
+		log.Errorf("get job %s from database failed, err: %v", jobInfo.ID, err)
+		return
+	}
+	if job.Status != schema.StatusJobInit {
+		log.Infof("job %s status is %s, remove it from queue %s", job.ID, job.Status, jobQueue.GetName())
+		return
+	}
+	msg := fmt.Sprintf("job is waiting for quota of queue %s, the request resources: %s",
+		jobQueue.GetName(), jobInfo.Resource)
+	if job.Message != msg {
+		trace_logger.KeyWithUpdate(jobInfo.ID).Infof(msg)
+		if err = models.UpdateJobStatus(jobInfo.ID, msg, schema.StatusJobInit); err != nil {
+			log.Errorf("update message of job %s failed, err: %v", jobInfo.ID, err)
+		}
+	}
+	jobQueue.Insert(jobInfo)
+}
+
+// rejectJob fails the job whose resources exceed the max resources of queue, otherwise it blocks the queue forever
+func (m *JobManagerImpl) rejectJob(jobQueue *api.JobQueue, jobInfo *api.PFJob) {
+	jobQueue.DeleteMark(jobInfo.ID)
+	msg := fmt.Sprintf("the request resources %s of job exceed the max resources %s of queue %s",
+		jobInfo.Resource, jobQueue.Quota.MaxResources(), jobQueue.GetName())
+	log.Warningf("reject job %s, %s", jobInfo.ID, msg)
+	trace_logger.KeyWithUpdate(jobInfo.ID).Errorf(msg)
+	if err := models.UpdateJobStatus(jobInfo.ID, msg, schema.StatusJobFailed); err != nil {
+		log.Errorf("update job %s status to %s failed, err: %v", jobInfo.ID, schema.StatusJobFailed, err)
+		return
+	}
+	notification.NotifyJob(jobInfo.ID, schema.StatusJobFailed)
+}
+
+// onJobFinished releases the quota allocated by job and removes it from job queue when job reaches a final status
+func (m *JobManagerImpl) onJobFinished(job *models.Job, status schema.JobStatus) {
+	jobQueue, find := m.jobQueues.Get(api.QueueID(job.QueueID))
+	if !find {
+		return
 	}
+	log.Debugf("job %s in queue %s is %s, release its quota", job.ID, jobQueue.GetName(), status)
+	jobQueue.Quota.Release(job.ID)
+	jobQueue.Remove(job.ID)
 }
 
 type clusterQueue struct {
@@ -351,9 +407,11 @@ func (m *JobManagerImpl) pJobProcessLoop() {
 
 			jobQueue, find := m.jobQueues.Get(queueID)
 			if !find {
-				jobQueue = api.NewJobQueue(qInfo)
+				jobQueue = api.NewJobQueue(qInfo, quotaSyncPeriod)
 				m.jobQueues.Insert(queueID, jobQueue)
 				go m.pSubmitQueueJob(jobQueue, cQueue.RuntimeSvc)
+			} else {
+				jobQueue.UpdateQueue(qInfo)
 			}
 
 			jobQueue.Insert(pfJob)
@@ -382,10 +440,22 @@ func (m *JobManagerImpl) pSubmitQueueJob(jobQueue *api.JobQueue, runtimeSvc runt
 			startTime := time.Now()
 			job, ok := jobQueue.GetJob()
 			if ok {
-				log.Infof("Entering submit %s job in queue %s", job.ID, name)
-				// get enqueue job
-				m.submitJob(runtimeSvc.SubmitJob, job)
-				jobQueue.DeleteMark(job.ID)
+				if jobQueue.Quota.ExceedMax(job) {
+					m.rejectJob(jobQueue, job)
+					continue
+				}
+				// jobs are submitted in order, so the following jobs wait until the quota of queue is enough,
+				// unless they can be backfilled
+				if !jobQueue.Quota.Allocate(job) {
+					log.Infof("quota of queue %s is not enough for job %s, used: %s", name, job.ID, jobQueue.Quota.Used())
+					if jobQueue.Quota.BackfillEnabled() {
+						m.backfillQueueJobs(jobQueue, runtimeSvc, job)
+					}
+					m.waitForQuota(jobQueue, job)
+					time.Sleep(m.jobLoopPeriod)
+					continue
+				}
+				m.submitAllocatedJob(jobQueue, runtimeSvc, job)
 				log.Infof("Leaving submit %s job in queue %s, total elapsed time: %s", job.ID, name, time.Since(startTime))
 			} else {
 				time.Sleep(m.jobLoopPeriod)
@@ -394,6 +464,49 @@ func (m *JobManagerImpl) pSubmitQueueJob(jobQueue *api.JobQueue, runtimeSvc runt
 	}
 }
 
+// submitAllocatedJob submits the job whose resources are allocated from queue quota
+func (m *JobManagerImpl) submitAllocatedJob(jobQueue *api.JobQueue, runtimeSvc runtime.RuntimeService, job *api.PFJob) {
+	log.Infof("Entering submit %s job in queue %s", job.ID, jobQueue.GetName())
+	// only release the allocation when the runtime failed to create the job, a job created on cluster
+	// keeps its allocation even if the status update afterwards failed
+	var submitErr error
+	m.submitJob(func(pfJob *api.PFJob) error {
+		submitErr = runtimeSvc.SubmitJob(pfJob)
+		return submitErr
+	}, job)
+	if submitErr != nil {
+		jobQueue.Quota.Release(job.ID)
+	}
+	jobQueue.DeleteMark(job.ID)
+}
+
+// backfillQueueJobs reserves resources for the head job of queue, and submits the following jobs in order
+// which do not delay the reservation. The jobs are kept in queue until they are backfilled, and the scan
+// stops at the first job which can not be backfilled or the scan depth of queue
+func (m *JobManagerImpl) backfillQueueJobs(jobQueue *api.JobQueue, runtimeSvc runtime.RuntimeService, headJob *api.PFJob) {
+	now := time.Now()
+	reservation := jobQueue.Quota.Reserve(headJob, now)
+	if reservation.StartTime.IsZero() {
+		log.Infof("start time of reservation for job %s is unknown, skip backfill in queue %s", headJob.ID, jobQueue.GetName())
+		return
+	}
+	for _, job := range jobQueue.TopJobs(jobQueue.Quota.BackfillScanDepth()) {
+		if jobQueue.Quota.ExceedMax(job) {
+			jobQueue.Remove(job.ID)
+			m.rejectJob(jobQueue, job)
+			continue
+		}
+		if !jobQueue.Quota.Backfill(job, reservation, now) {
+			log.Debugf("job %s can not be backfilled, stop backfill in queue %s", job.ID, jobQueue.GetName())
+			return
+		}
+		log.Infof("backfill job %s in queue %s, reservation for job %s starts at %s", job.ID,
+			jobQueue.GetName(), headJob.ID, reservation.StartTime)
+		jobQueue.Remove(job.ID)
+		m.submitAllocatedJob(jobQueue, runtimeSvc, job)
+	}
+}
+
 func (m *JobManagerImpl) stopClusterQueueSubmit(clusterID api.ClusterID) {
 	clusterQueues := models.ListQueuesByCluster(string(clusterID))
 	for _, q := range clusterQueues {
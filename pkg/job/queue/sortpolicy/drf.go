@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sortpolicy
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+)
+
+// DRFPolicyName indicates name of dominant resource fairness sort policy.
+const DRFPolicyName = "drf"
+
+// drfPolicy orders jobs by the dominant share of its user in queue, the dominant share of user is
+// the max share of resources allocated by pending and running jobs of user to the max resources of queue.
+type drfPolicy struct {
+	// Arguments given for the sort policy
+	policyArguments api.Arguments
+
+	cache    *userScoreCache
+	listJobs func(queueID string, status []schema.JobStatus) []models.Job
+	getQueue func(queueID string) (models.Queue, error)
+}
+
+// DRFPolicyNew return dominant resource fairness sort policy
+func DRFPolicyNew(arguments api.Arguments) (api.SortPolicy, error) {
+	dp := &drfPolicy{
+		policyArguments: arguments,
+		listJobs:        models.ListQueueJob,
+		getQueue:        models.GetQueueByID,
+	}
+	refreshPeriod := defaultRefreshPeriod
+	if err := arguments.GetDuration(&refreshPeriod, RefreshPeriodArg); err != nil {
+		return nil, err
+	}
+	dp.cache = newUserScoreCache(refreshPeriod, dp.userDominantShare)
+	return dp, nil
+}
+
+func (dp *drfPolicy) Name() string {
+	return DRFPolicyName
+}
+
+func (dp *drfPolicy) OrderFn(l, r interface{}) int {
+	return compareScore(dp.cache, l, r)
+}
+
+// userDominantShare calculates the dominant share of each user in queue
+func (dp *drfPolicy) userDominantShare(queueID api.QueueID) map[string]float64 {
+	shares := make(map[string]float64)
+	queue, err := dp.getQueue(string(queueID))
+	if err != nil {
+		log.Warningf("get queue %s failed, skip drf policy, err: %v", queueID, err)
+		return shares
+	}
+	capacity := queue.MaxResources
+	if capacity == nil {
+		return shares
+	}
+	allocated := make(map[string]*resources.Resource)
+	jobs := dp.listJobs(string(queueID), []schema.JobStatus{schema.StatusJobPending, schema.StatusJobRunning})
+	for _, job := range jobs {
+		if job.Resource == nil {
+			continue
+		}
+		if _, find := allocated[job.UserName]; !find {
+			allocated[job.UserName] = resources.EmptyResource()
+		}
+		allocated[job.UserName].Add(job.Resource)
+	}
+	for userName, res := range allocated {
+		var dominantShare float64
+		for name, quantity := range res.Resources {
+			total := capacity.Resources[name]
+			if total <= 0 {
+				continue
+			}
+			if share := float64(quantity) / float64(total); share > dominantShare {
+				dominantShare = share
+			}
+		}
+		shares[userName] = dominantShare
+	}
+	return shares
+}
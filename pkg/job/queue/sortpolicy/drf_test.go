@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sortpolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+)
+
+func TestDRFPolicyOrderFn(t *testing.T) {
+	policy, err := DRFPolicyNew(api.Arguments{})
+	assert.NoError(t, err)
+	dp := policy.(*drfPolicy)
+	loadCount := 0
+	dp.getQueue = func(queueID string) (models.Queue, error) {
+		loadCount++
+		return models.Queue{MaxResources: newTestResource(t, "100", "100Gi", "10")}, nil
+	}
+	dp.listJobs = func(queueID string, status []schema.JobStatus) []models.Job {
+		return []models.Job{
+			// dominant share of user1 is cpu, 40/100
+			{UserName: "user1", Resource: newTestResource(t, "20", "10Gi", "")},
+			{UserName: "user1", Resource: newTestResource(t, "20", "10Gi", "")},
+			// dominant share of user2 is gpu, 5/10
+			{UserName: "user2", Resource: newTestResource(t, "10", "10Gi", "5")},
+		}
+	}
+
+	user1Job := &api.PFJob{ID: "job-1", UserName: "user1", QueueID: "queue1"}
+	user2Job := &api.PFJob{ID: "job-2", UserName: "user2", QueueID: "queue1"}
+	user3Job := &api.PFJob{ID: "job-3", UserName: "user3", QueueID: "queue1"}
+	assert.Equal(t, -1, dp.OrderFn(user1Job, user2Job))
+	assert.Equal(t, -1, dp.OrderFn(user3Job, user1Job))
+	assert.Equal(t, 1, dp.OrderFn(user2Job, user3Job))
+	// scores are cached in refresh period
+	assert.Equal(t, 1, loadCount)
+	dp.cache.now = func() time.Time { return time.Now().Add(defaultRefreshPeriod) }
+	dp.OrderFn(user1Job, user2Job)
+	assert.Equal(t, 2, loadCount)
+}
+
+func TestQueueJobOrderFn(t *testing.T) {
+	queue := api.NewQueueInfo(models.Queue{
+		SchedulingPolicy: []string{PriorityPolicyName, DRFPolicyName},
+		SchedulingPolicyArgs: map[string]map[string]string{
+			DRFPolicyName: {RefreshPeriodArg: "1m"},
+		},
+	})
+	assert.Equal(t, 2, len(queue.SortPolicies))
+	highJob := &api.PFJob{ID: "job-1", Priority: api.JobPriority(schema.EnvJobHighPriority)}
+	normalJob := &api.PFJob{ID: "job-2", Priority: api.JobPriority("")}
+	assert.True(t, queue.JobOrderFn(highJob, normalJob))
+	assert.False(t, queue.JobOrderFn(normalJob, highJob))
+}
+
+func TestJobQueueUpdateSortPolicy(t *testing.T) {
+	queue := api.NewQueueInfo(models.Queue{})
+	jobQueue := api.NewJobQueue(queue, time.Minute)
+	now := time.Now()
+	normalJob := &api.PFJob{ID: "job-1", Priority: api.JobPriority(""), CreateTime: now}
+	highJob := &api.PFJob{ID: "job-2", Priority: api.JobPriority(schema.EnvJobHighPriority), CreateTime: now.Add(time.Second)}
+	jobQueue.Insert(normalJob)
+	jobQueue.Insert(highJob)
+
+	// the same policies do not change queue
+	jobQueue.UpdateQueue(api.NewQueueInfo(models.Queue{}))
+	assert.Equal(t, 0, len(jobQueue.Queue.SortPolicies))
+
+	// jobs are reordered by the new sort policies
+	jobQueue.UpdateQueue(api.NewQueueInfo(models.Queue{SchedulingPolicy: []string{PriorityPolicyName}}))
+	assert.Equal(t, []string{PriorityPolicyName}, jobQueue.Queue.SortPolicyNames)
+	assert.Equal(t, 1, len(jobQueue.Queue.SortPolicies))
+	job, ok := jobQueue.GetJob()
+	assert.True(t, ok)
+	assert.Equal(t, highJob.ID, job.ID)
+
+	// the arguments of policies are changed
+	jobQueue.UpdateQueue(api.NewQueueInfo(models.Queue{
+		SchedulingPolicy:     []string{PriorityPolicyName, DRFPolicyName},
+		SchedulingPolicyArgs: map[string]map[string]string{DRFPolicyName: {RefreshPeriodArg: "1m"}},
+	}))
+	assert.Equal(t, 2, len(jobQueue.Queue.SortPolicies))
+	jobQueue.UpdateQueue(api.NewQueueInfo(models.Queue{
+		SchedulingPolicy:     []string{PriorityPolicyName, DRFPolicyName},
+		SchedulingPolicyArgs: map[string]map[string]string{DRFPolicyName: {RefreshPeriodArg: "2m"}},
+	}))
+	assert.Equal(t, "2m", jobQueue.Queue.SortPolicyArgs[DRFPolicyName][RefreshPeriodArg])
+}
@@ -22,4 +22,6 @@ import (
 
 func init() {
 	api.QueueSortPolicies.Register(PriorityPolicyName, PriorityPolicyNew)
+	api.QueueSortPolicies.Register(FairSharePolicyName, FairSharePolicyNew)
+	api.QueueSortPolicies.Register(DRFPolicyName, DRFPolicyNew)
 }
@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sortpolicy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+)
+
+const (
+	// FairSharePolicyName indicates name of fair share sort policy.
+	FairSharePolicyName = "fairshare"
+
+	// FairShareWindowArg is the argument of time window to count consumption of users, such as 24h
+	FairShareWindowArg = "window"
+	// ResourceWeightArgPrefix is the prefix of argument for resource weight, such as weight.nvidia.com/gpu=10
+	ResourceWeightArgPrefix = "weight."
+	defaultFairShareWindow  = 24 * time.Hour
+	defaultResourceWeight   = 1.0
+)
+
+// fairSharePolicy orders jobs by the resource consumption of its user in queue recently,
+// and the consumption is the sum of weighted resources multiplied by running seconds of jobs in window.
+type fairSharePolicy struct {
+	// Arguments given for the sort policy
+	policyArguments api.Arguments
+
+	window  time.Duration
+	weights map[string]float64
+	cache   *userScoreCache
+	// listJobs lists activated jobs in queue after the specified time
+	listJobs func(queueID string, since time.Time) []models.Job
+}
+
+// FairSharePolicyNew return fair share sort policy
+func FairSharePolicyNew(arguments api.Arguments) (api.SortPolicy, error) {
+	fp := &fairSharePolicy{
+		policyArguments: arguments,
+		window:          defaultFairShareWindow,
+		weights:         make(map[string]float64),
+		listJobs:        models.ListQueueActivatedJob,
+	}
+	refreshPeriod := defaultRefreshPeriod
+	if err := arguments.GetDuration(&fp.window, FairShareWindowArg); err != nil {
+		return nil, err
+	}
+	if err := arguments.GetDuration(&refreshPeriod, RefreshPeriodArg); err != nil {
+		return nil, err
+	}
+	for key := range arguments {
+		if !strings.HasPrefix(key, ResourceWeightArgPrefix) {
+			continue
+		}
+		weight := defaultResourceWeight
+		if err := arguments.GetFloat64(&weight, key); err != nil {
+			return nil, err
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("argument %s of %s policy must not be negative", key, FairSharePolicyName)
+		}
+		fp.weights[strings.TrimPrefix(key, ResourceWeightArgPrefix)] = weight
+	}
+	fp.cache = newUserScoreCache(refreshPeriod, fp.userConsumption)
+	return fp, nil
+}
+
+func (fp *fairSharePolicy) Name() string {
+	return FairSharePolicyName
+}
+
+func (fp *fairSharePolicy) OrderFn(l, r interface{}) int {
+	return compareScore(fp.cache, l, r)
+}
+
+func (fp *fairSharePolicy) weight(name string) float64 {
+	if weight, find := fp.weights[name]; find {
+		return weight
+	}
+	return defaultResourceWeight
+}
+
+// userConsumption calculates the consumption of each user in queue within window
+func (fp *fairSharePolicy) userConsumption(queueID api.QueueID) map[string]float64 {
+	now := fp.cache.now()
+	windowStart := now.Add(-fp.window)
+	consumption := make(map[string]float64)
+	for _, job := range fp.listJobs(string(queueID), windowStart) {
+		if job.Resource == nil || !job.ActivatedAt.Valid {
+			continue
+		}
+		start := job.ActivatedAt.Time
+		if start.Before(windowStart) {
+			start = windowStart
+		}
+		end := now
+		if schema.IsImmutableJobStatus(job.Status) && job.UpdatedAt.Before(now) {
+			end = job.UpdatedAt
+		}
+		if !end.After(start) {
+			continue
+		}
+		var value float64
+		for name, quantity := range job.Resource.Resources {
+			value += fp.weight(name) * resourceValue(name, quantity)
+		}
+		consumption[job.UserName] += value * end.Sub(start).Seconds()
+	}
+	return consumption
+}
@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sortpolicy
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+)
+
+func newTestResource(t *testing.T, cpu, mem, gpu string) *resources.Resource {
+	resourceInfo := map[string]string{
+		resources.ResCPU:    cpu,
+		resources.ResMemory: mem,
+	}
+	if gpu != "" {
+		resourceInfo["nvidia.com/gpu"] = gpu
+	}
+	res, err := resources.NewResourceFromMap(resourceInfo)
+	assert.NoError(t, err)
+	return res
+}
+
+func TestFairSharePolicyNew(t *testing.T) {
+	testCases := []struct {
+		name      string
+		arguments api.Arguments
+		expectErr bool
+	}{
+		{
+			name:      "default arguments",
+			arguments: api.Arguments{},
+		},
+		{
+			name: "valid arguments",
+			arguments: api.Arguments{
+				FairShareWindowArg:                         "2h",
+				RefreshPeriodArg:                           "10s",
+				ResourceWeightArgPrefix + "nvidia.com/gpu": "10",
+			},
+		},
+		{
+			name:      "invalid window",
+			arguments: api.Arguments{FairShareWindowArg: "abc"},
+			expectErr: true,
+		},
+		{
+			name:      "negative weight",
+			arguments: api.Arguments{ResourceWeightArgPrefix + "cpu": "-1"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FairSharePolicyNew(tc.arguments)
+			assert.Equal(t, tc.expectErr, err != nil)
+		})
+	}
+}
+
+func TestFairSharePolicyOrderFn(t *testing.T) {
+	now := time.Now()
+	policy, err := FairSharePolicyNew(api.Arguments{
+		FairShareWindowArg:                         "1h",
+		ResourceWeightArgPrefix + "nvidia.com/gpu": "10",
+	})
+	assert.NoError(t, err)
+	fp := policy.(*fairSharePolicy)
+	fp.cache.now = func() time.Time { return now }
+	fp.listJobs = func(queueID string, since time.Time) []models.Job {
+		return []models.Job{
+			{
+				// user1 runs 4 cpu for 30 minutes
+				UserName:    "user1",
+				Status:      schema.StatusJobRunning,
+				Resource:    newTestResource(t, "4", "0", ""),
+				ActivatedAt: sql.NullTime{Time: now.Add(-30 * time.Minute), Valid: true},
+			},
+			{
+				// user2 runs 1 cpu and 1 gpu for 10 minutes
+				UserName:    "user2",
+				Status:      schema.StatusJobSucceeded,
+				Resource:    newTestResource(t, "1", "0", "1"),
+				ActivatedAt: sql.NullTime{Time: now.Add(-3 * time.Hour), Valid: true},
+				UpdatedAt:   now.Add(-50 * time.Minute),
+			},
+		}
+	}
+
+	user1Job := &api.PFJob{ID: "job-1", UserName: "user1", QueueID: "queue1"}
+	user2Job := &api.PFJob{ID: "job-2", UserName: "user2", QueueID: "queue1"}
+	user3Job := &api.PFJob{ID: "job-3", UserName: "user3", QueueID: "queue1"}
+	// user1: 4*1800=7200, user2: (1+10)*600=6600, user3: 0
+	assert.Equal(t, 1, fp.OrderFn(user1Job, user2Job))
+	assert.Equal(t, -1, fp.OrderFn(user3Job, user2Job))
+	assert.Equal(t, 0, fp.OrderFn(user1Job, user1Job))
+}
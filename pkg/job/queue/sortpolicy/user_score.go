@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sortpolicy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+)
+
+const (
+	// RefreshPeriodArg is the argument of period to refresh user scores, such as 30s
+	RefreshPeriodArg     = "refreshPeriod"
+	defaultRefreshPeriod = 30 * time.Second
+
+	milliUnit = 1000
+	gibiUnit  = 1 << 30
+)
+
+// scoreLoadFunc calculates the scores of users in queue
+type scoreLoadFunc func(queueID api.QueueID) map[string]float64
+
+// userScores is a snapshot of user scores in a queue
+type userScores struct {
+	scores     map[string]float64
+	updateTime time.Time
+}
+
+// userScoreCache caches the scores of users for each queue, and jobs of user with lower score are ordered first
+type userScoreCache struct {
+	sync.Mutex
+	refreshPeriod time.Duration
+	queues        map[api.QueueID]*userScores
+	load          scoreLoadFunc
+	// now is used to mock time in unit test
+	now func() time.Time
+}
+
+func newUserScoreCache(refreshPeriod time.Duration, load scoreLoadFunc) *userScoreCache {
+	return &userScoreCache{
+		refreshPeriod: refreshPeriod,
+		queues:        make(map[api.QueueID]*userScores),
+		load:          load,
+		now:           time.Now,
+	}
+}
+
+// Score returns the score of user in queue, and reloads scores of queue when expired
+func (c *userScoreCache) Score(queueID api.QueueID, userName string) float64 {
+	c.Lock()
+	defer c.Unlock()
+	now := c.now()
+	us, find := c.queues[queueID]
+	if !find || now.Sub(us.updateTime) >= c.refreshPeriod {
+		us = &userScores{
+			scores:     c.load(queueID),
+			updateTime: now,
+		}
+		c.queues[queueID] = us
+	}
+	return us.scores[userName]
+}
+
+// compareScore orders the job whose user has lower score first
+func compareScore(cache *userScoreCache, l, r interface{}) int {
+	lv := l.(*api.PFJob)
+	rv := r.(*api.PFJob)
+	if lv.UserName == rv.UserName {
+		return 0
+	}
+	ls := cache.Score(lv.QueueID, lv.UserName)
+	rs := cache.Score(rv.QueueID, rv.UserName)
+	if ls < rs {
+		return -1
+	}
+	if ls > rs {
+		return 1
+	}
+	return 0
+}
+
+// resourceValue returns the value of resource in common unit, cpu in cores, memory in GiB and others in units
+func resourceValue(name string, quantity resources.Quantity) float64 {
+	switch name {
+	case resources.ResCPU:
+		return float64(quantity) / milliUnit
+	case resources.ResMemory, resources.ResStorage:
+		return float64(quantity) / gibiUnit
+	default:
+		return float64(quantity)
+	}
+}
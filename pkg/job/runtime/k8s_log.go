@@ -95,49 +95,9 @@ func constructTaskLog(client kubernetes.Interface, namespace, name, logFilePosit
 		if err != nil {
 			return []schema.TaskLogInfo{}, err
 		}
-		startIndex := -1
-		endIndex := -1
-		hasNextPage := false
-		truncated := false
-		limitFlag := isReadLimitReached(int64(len(logContent)), int64(length), logFilePosition)
-		overFlag := false
-		// 判断开始位置是否已超过日志总行数，若超过overFlag为true；
-		// 如果是logFilePPosition为end，则看下startIndex是否已经超过0，若超过则置startIndex为-1（从最开始获取），并检查日志是否被截断
-		// 如果是logFilePPosition为begin，则判断末尾index是否超过总长度，若超过endIndex为-1（直到末尾），并检查日志是否被截断
-		if (pageNo-1)*pageSize+1 <= length {
-			switch logFilePosition {
-			case common.EndFilePosition:
-				startIndex = length - pageSize*pageNo
-				endIndex = length - (pageNo-1)*pageSize
-				if startIndex <= 0 {
-					startIndex = -1
-					truncated = limitFlag
-				} else {
-					hasNextPage = true
-				}
-				if endIndex == length {
-					endIndex = -1
-				}
-			case common.BeginFilePosition:
-				startIndex = (pageNo - 1) * pageSize
-				if pageNo*pageSize < length {
-					endIndex = pageNo * pageSize
-					hasNextPage = true
-				} else {
-					truncated = limitFlag
-				}
-			}
-		} else {
-			overFlag = true
-		}
-
 		taskLogInfo := schema.TaskLogInfo{
 			TaskID: fmt.Sprintf("%s_%s", pod.GetUID(), c.Name),
-			Info: schema.LogInfo{
-				LogContent:  splitLog(logContent, startIndex, endIndex, overFlag),
-				HasNextPage: hasNextPage,
-				Truncated:   truncated,
-			},
+			Info:   paginateLog(logContent, length, logFilePosition, pageSize, pageNo),
 		}
 		taskLogInfoList = append(taskLogInfoList, taskLogInfo)
 	}
@@ -145,6 +105,50 @@ func constructTaskLog(client kubernetes.Interface, namespace, name, logFilePosit
 
 }
 
+// paginateLog returns the page pageNo of logContent, which has length lines in total
+func paginateLog(logContent string, length int, logFilePosition string, pageSize, pageNo int) schema.LogInfo {
+	startIndex := -1
+	endIndex := -1
+	hasNextPage := false
+	truncated := false
+	limitFlag := isReadLimitReached(int64(len(logContent)), int64(length), logFilePosition)
+	overFlag := false
+	// 判断开始位置是否已超过日志总行数，若超过overFlag为true；
+	// 如果是logFilePPosition为end，则看下startIndex是否已经超过0，若超过则置startIndex为-1（从最开始获取），并检查日志是否被截断
+	// 如果是logFilePPosition为begin，则判断末尾index是否超过总长度，若超过endIndex为-1（直到末尾），并检查日志是否被截断
+	if (pageNo-1)*pageSize+1 <= length {
+		switch logFilePosition {
+		case common.EndFilePosition:
+			startIndex = length - pageSize*pageNo
+			endIndex = length - (pageNo-1)*pageSize
+			if startIndex <= 0 {
+				startIndex = -1
+				truncated = limitFlag
+			} else {
+				hasNextPage = true
+			}
+			if endIndex == length {
+				endIndex = -1
+			}
+		case common.BeginFilePosition:
+			startIndex = (pageNo - 1) * pageSize
+			if pageNo*pageSize < length {
+				endIndex = pageNo * pageSize
+				hasNextPage = true
+			} else {
+				truncated = limitFlag
+			}
+		}
+	} else {
+		overFlag = true
+	}
+	return schema.LogInfo{
+		LogContent:  splitLog(logContent, startIndex, endIndex, overFlag),
+		HasNextPage: hasNextPage,
+		Truncated:   truncated,
+	}
+}
+
 func getContainerLog(client kubernetes.Interface, namespace, name string, logOptions *apiv1.PodLogOptions) (string, int, error) {
 	readCloser, err := client.CoreV1().RESTClient().Get().
 		Namespace(namespace).
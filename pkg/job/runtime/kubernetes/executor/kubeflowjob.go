@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	kubeflowv1 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/training-operator/kubeflow.org/v1"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+)
+
+// validateKubeflowReplicaSpecs validate resources of replicas in custom yaml of kubeflow job
+func validateKubeflowReplicaSpecs(replicaSpecs map[kubeflowv1.ReplicaType]*kubeflowv1.ReplicaSpec) error {
+	for replicaType, replicaSpec := range replicaSpecs {
+		if replicaSpec == nil {
+			continue
+		}
+		if err := validateTemplateResources(&replicaSpec.Template.Spec); err != nil {
+			err = fmt.Errorf("validate resource in extensionTemplate.%s failed, err %v", replicaType, err)
+			log.Errorf("%v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// patchKubeflowReplicaSpecs fill replica specs of kubeflow job with tasks, the replica type of task is decided by
+// replicaTypeFn, and the replica types without tasks are removed
+func (j *KubeJob) patchKubeflowReplicaSpecs(replicaSpecs map[kubeflowv1.ReplicaType]*kubeflowv1.ReplicaSpec,
+	containerName string, replicaTypeFn func(task models.Member) (kubeflowv1.ReplicaType, error)) error {
+	taskReplicaTypes := make(map[kubeflowv1.ReplicaType]bool)
+	for _, task := range j.Tasks {
+		replicaType, err := replicaTypeFn(task)
+		if err != nil {
+			log.Errorf("get replica type of %s job %s failed, err=[%v]", j.Framework, j.ID, err)
+			return err
+		}
+		if taskReplicaTypes[replicaType] {
+			return fmt.Errorf("%s job %s has more than one task for replica type %s", j.Framework, j.ID, replicaType)
+		}
+		taskReplicaTypes[replicaType] = true
+
+		replicaSpec, find := replicaSpecs[replicaType]
+		if !find || replicaSpec == nil {
+			replicaSpec = &kubeflowv1.ReplicaSpec{}
+			replicaSpecs[replicaType] = replicaSpec
+		}
+		if err = j.patchKubeflowReplicaSpec(replicaSpec, task, containerName); err != nil {
+			log.Errorf("fill task %s of %s job %s failed, err=[%v]", replicaType, j.Framework, j.ID, err)
+			return err
+		}
+	}
+	for replicaType := range replicaSpecs {
+		if !taskReplicaTypes[replicaType] {
+			delete(replicaSpecs, replicaType)
+		}
+	}
+	return nil
+}
+
+// patchKubeflowReplicaSpec patches info of task into replica spec of kubeflow job
+func (j *KubeJob) patchKubeflowReplicaSpec(replicaSpec *kubeflowv1.ReplicaSpec, task models.Member, containerName string) error {
+	log.Infof("patchKubeflowReplicaSpec, replicaSpec=%#v, task=%#v", replicaSpec, task)
+	replicas := int32(defaultPSReplicas)
+	if task.Replicas > 0 {
+		replicas = int32(task.Replicas)
+	}
+	replicaSpec.Replicas = &replicas
+	if len(replicaSpec.RestartPolicy) == 0 {
+		replicaSpec.RestartPolicy = kubeflowv1.RestartPolicyNever
+	}
+
+	// set metadata
+	taskName := task.Name
+	if taskName == "" {
+		taskName = uuid.GenerateIDWithLength(j.ID, 3)
+	}
+	j.patchMetadata(&replicaSpec.Template.ObjectMeta, taskName)
+	// set pod template
+	j.fillPodSpec(&replicaSpec.Template.Spec, &task)
+
+	// patch Task.Template.Spec.Containers[0], and the name of container is required by training-operator
+	if len(replicaSpec.Template.Spec.Containers) != 1 {
+		replicaSpec.Template.Spec.Containers = []corev1.Container{{}}
+	}
+	container := &replicaSpec.Template.Spec.Containers[0]
+	if len(container.Name) == 0 {
+		container.Name = containerName
+	}
+	if err := j.fillContainerInTasks(container, task); err != nil {
+		log.Errorf("fill container in task failed, err=[%v]", err)
+		return err
+	}
+	// append into container.VolumeMounts
+	taskFs := task.Conf.GetAllFileSystem()
+	replicaSpec.Template.Spec.Volumes = appendVolumesIfAbsent(replicaSpec.Template.Spec.Volumes, generateVolumes(taskFs))
+	return nil
+}
+
+// patchKubeflowRunPolicy patches scheduling policy of kubeflow job, minAvailable and minResources are calculated
+// from all replicas, so that the replicas of job are scheduled together
+func (j *KubeJob) patchKubeflowRunPolicy(runPolicy *kubeflowv1.RunPolicy,
+	replicaSpecs map[kubeflowv1.ReplicaType]*kubeflowv1.ReplicaSpec) {
+	// policy will clean running pods only on job completed
+	if runPolicy.CleanPodPolicy == nil {
+		cleanPodPolicy := kubeflowv1.CleanPodPolicyRunning
+		runPolicy.CleanPodPolicy = &cleanPodPolicy
+	}
+	if runPolicy.SchedulingPolicy == nil {
+		runPolicy.SchedulingPolicy = &kubeflowv1.SchedulingPolicy{}
+	}
+	schedulingPolicy := runPolicy.SchedulingPolicy
+	if len(schedulingPolicy.Queue) == 0 {
+		schedulingPolicy.Queue = j.QueueName
+	}
+	if len(schedulingPolicy.PriorityClass) == 0 {
+		schedulingPolicy.PriorityClass = j.getPriorityClass()
+	}
+
+	var minAvailable int32
+	minResources := resources.EmptyResource()
+	for _, replicaSpec := range replicaSpecs {
+		if replicaSpec == nil {
+			continue
+		}
+		replicas := int32(defaultPodReplicas)
+		if replicaSpec.Replicas != nil {
+			replicas = *replicaSpec.Replicas
+		}
+		minAvailable += replicas
+		for _, container := range replicaSpec.Template.Spec.Containers {
+			containerResource := k8s.NewResource(container.Resources.Requests)
+			containerResource.Multi(int(replicas))
+			minResources.Add(containerResource)
+		}
+	}
+	if schedulingPolicy.MinAvailable == nil {
+		schedulingPolicy.MinAvailable = &minAvailable
+	}
+	if schedulingPolicy.MinResources == nil {
+		minResourceList := k8s.NewResourceList(minResources)
+		schedulingPolicy.MinResources = &minResourceList
+	}
+	log.Infof("%s job %s minAvailable=%d, minResources=%s", j.Framework, j.ID, *schedulingPolicy.MinAvailable, minResources)
+}
@@ -190,6 +190,16 @@ func newFrameWorkJob(kubeJob KubeJob, job *api.PFJob) (api.PFJobInterface, error
 			KubeJob:       kubeJob,
 			JobModeParams: newJobModeParams(job.Conf),
 		}, nil
+	case schema.FrameworkPytorch:
+		kubeJob.GroupVersionKind = k8s.PyTorchJobGVK
+		return &PyTorchJob{
+			KubeJob: kubeJob,
+		}, nil
+	case schema.FrameworkTF:
+		kubeJob.GroupVersionKind = k8s.TFJobGVK
+		return &TFJob{
+			KubeJob: kubeJob,
+		}, nil
 	default:
 		return nil, fmt.Errorf("kubernetes job framework[%s] is not supported", job.Framework)
 	}
@@ -600,7 +610,7 @@ func GetPodGroupName(jobID string) string {
 	}
 	pgName := ""
 	switch job.Framework {
-	case schema.FrameworkPaddle:
+	case schema.FrameworkPaddle, schema.FrameworkPytorch, schema.FrameworkTF:
 		pgName = jobID
 	case schema.FrameworkSpark:
 		pgName = fmt.Sprintf("spark-%s-pg", jobID)
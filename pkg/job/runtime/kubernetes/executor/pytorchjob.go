@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	kubeflowv1 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/training-operator/kubeflow.org/v1"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+type PyTorchJob struct {
+	KubeJob
+}
+
+func (pj *PyTorchJob) validateJob(ptj *kubeflowv1.PyTorchJob) error {
+	if err := pj.KubeJob.validateJob(); err != nil {
+		return err
+	}
+	if pj.IsCustomYaml {
+		if err := validateKubeflowReplicaSpecs(ptj.Spec.PyTorchReplicaSpecs); err != nil {
+			log.Errorf("validate custom yaml failed, err %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (pj *PyTorchJob) CreateJob() (string, error) {
+	ptj := &kubeflowv1.PyTorchJob{}
+	if len(pj.JobMode) == 0 {
+		// pytorch job only runs in collective mode, which decides the default template
+		pj.JobMode = schema.EnvJobModeCollective
+	}
+	if err := pj.createJobFromYaml(ptj); err != nil {
+		log.Errorf("create job[%s] failed, err %v", pj.ID, err)
+		return "", err
+	}
+	if err := pj.validateJob(ptj); err != nil {
+		log.Errorf("validate [%s]type job[%s] failed, err %v", pj.JobType, pj.ID, err)
+		return "", err
+	}
+
+	var err error
+	// patch .metadata field
+	pj.patchMetadata(&ptj.ObjectMeta, pj.ID)
+	// patch .spec field
+	if err = pj.patchPyTorchJobSpec(&ptj.Spec); err != nil {
+		log.Errorf("build job spec failed, err %v", err)
+		return "", err
+	}
+
+	// create job on cluster
+	log.Infof("create %s job %s/%s on cluster", pj.JobType, pj.Namespace, pj.Name)
+	if err = Create(ptj, pj.GroupVersionKind, pj.DynamicClientOption); err != nil {
+		log.Errorf("create %s job %s/%s on cluster failed, err %v", pj.JobType, pj.Namespace, pj.Name, err)
+		return "", err
+	}
+	return pj.ID, err
+}
+
+// patchPyTorchJobSpec fill pytorch job spec, role master and worker of tasks are converted to replica type
+// Master and Worker
+func (pj *PyTorchJob) patchPyTorchJobSpec(ptjSpec *kubeflowv1.PyTorchJobSpec) error {
+	log.Debugf("patch %s job %s/%s spec:%#v", pj.JobType, pj.Namespace, pj.Name, ptjSpec)
+	if ptjSpec.PyTorchReplicaSpecs == nil {
+		ptjSpec.PyTorchReplicaSpecs = make(map[kubeflowv1.ReplicaType]*kubeflowv1.ReplicaSpec)
+	}
+	if !pj.IsCustomYaml {
+		if pj.JobMode != schema.EnvJobModeCollective {
+			return errors.InvalidJobModeError(pj.JobMode)
+		}
+		err := pj.patchKubeflowReplicaSpecs(ptjSpec.PyTorchReplicaSpecs, kubeflowv1.PyTorchJobDefaultContainerName,
+			pytorchReplicaType)
+		if err != nil {
+			log.Errorf("patch pytorch job replica specs failed, err=[%v]", err)
+			return err
+		}
+	} else {
+		log.Infof("%s job %s/%s using custom yaml, pass the patch from tasks", pj.JobType, pj.Namespace, pj.Name)
+	}
+	if _, find := ptjSpec.PyTorchReplicaSpecs[kubeflowv1.PyTorchJobReplicaTypeMaster]; !find {
+		return fmt.Errorf("pytorch job %s must contain master", pj.ID)
+	}
+	pj.patchKubeflowRunPolicy(&ptjSpec.RunPolicy, ptjSpec.PyTorchReplicaSpecs)
+	return nil
+}
+
+func pytorchReplicaType(task models.Member) (kubeflowv1.ReplicaType, error) {
+	switch task.Role {
+	case schema.RoleMaster:
+		return kubeflowv1.PyTorchJobReplicaTypeMaster, nil
+	case schema.RoleWorker:
+		return kubeflowv1.PyTorchJobReplicaTypeWorker, nil
+	default:
+		return "", fmt.Errorf("role %s is not supported by pytorch job", task.Role)
+	}
+}
+
+func (pj *PyTorchJob) StopJobByID(jobID string) error {
+	job, err := models.GetJobByID(jobID)
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.GetNamespace()
+
+	if err = Delete(namespace, job.ID, k8s.PyTorchJobGVK, pj.DynamicClientOption); err != nil {
+		log.Errorf("stop pytorchJob %s in namespace %s failed, err %v", job.ID, namespace, err)
+		return err
+	}
+	return nil
+}
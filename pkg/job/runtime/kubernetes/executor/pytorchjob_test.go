@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	kubeflowv1 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/training-operator/kubeflow.org/v1"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+var (
+	extensionPyTorchYaml = `
+apiVersion: kubeflow.org/v1
+kind: PyTorchJob
+metadata:
+  name: default-name
+spec:
+  pytorchReplicaSpecs:
+    Master:
+      replicas: 1
+      template:
+        spec:
+          containers:
+            - name: pytorch
+              image: nginx
+    Worker:
+      replicas: 2
+      template:
+        spec:
+          containers:
+            - name: pytorch
+              image: nginx
+`
+)
+
+func newMockMember(role schema.MemberRole, replicas int) models.Member {
+	return models.Member{
+		Replicas: replicas,
+		Role:     role,
+		Conf: schema.Conf{
+			Name:    "normal",
+			Command: "sleep 200",
+			Image:   "mockImage",
+			Env:     map[string]string{},
+			Flavour: schema.Flavour{Name: "cpu", ResourceInfo: schema.ResourceInfo{CPU: "2", Mem: "2"}},
+		},
+	}
+}
+
+func TestPyTorchJob_CreateJob(t *testing.T) {
+	initGlobalServerConfig()
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	dynamicClient := newFakeDynamicClient(server)
+	// mock db
+	driver.InitMockDB()
+	tests := []struct {
+		caseName         string
+		jobObj           *api.PFJob
+		wantErr          bool
+		wantMinAvailable int32
+		wantReplicaTypes []kubeflowv1.ReplicaType
+	}{
+		{
+			caseName: "pytorch job with default template",
+			jobObj: &api.PFJob{
+				ID:        "job-pytorch-0001",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkPytorch,
+				JobMode:   schema.EnvJobModeCollective,
+				Conf:      schema.Conf{QueueName: "mockQueueName"},
+				Tasks: []models.Member{
+					newMockMember(schema.RoleMaster, 1),
+					newMockMember(schema.RoleWorker, 3),
+				},
+			},
+			wantMinAvailable: 4,
+			wantReplicaTypes: []kubeflowv1.ReplicaType{kubeflowv1.PyTorchJobReplicaTypeMaster, kubeflowv1.PyTorchJobReplicaTypeWorker},
+		},
+		{
+			caseName: "pytorch job with master only",
+			jobObj: &api.PFJob{
+				ID:        "job-pytorch-0002",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkPytorch,
+				Tasks: []models.Member{
+					newMockMember(schema.RoleMaster, 1),
+				},
+			},
+			wantMinAvailable: 1,
+			wantReplicaTypes: []kubeflowv1.ReplicaType{kubeflowv1.PyTorchJobReplicaTypeMaster},
+		},
+		{
+			caseName: "pytorch job with custom yaml",
+			jobObj: &api.PFJob{
+				ID:                "job-pytorch-0003",
+				Namespace:         "default",
+				JobType:           schema.TypeDistributed,
+				Framework:         schema.FrameworkPytorch,
+				ExtensionTemplate: extensionPyTorchYaml,
+			},
+			wantMinAvailable: 3,
+			wantReplicaTypes: []kubeflowv1.ReplicaType{kubeflowv1.PyTorchJobReplicaTypeMaster, kubeflowv1.PyTorchJobReplicaTypeWorker},
+		},
+		{
+			caseName: "pytorch job without master",
+			jobObj: &api.PFJob{
+				ID:        "job-pytorch-0004",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkPytorch,
+				Tasks: []models.Member{
+					newMockMember(schema.RoleWorker, 2),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			caseName: "pytorch job with invalid role",
+			jobObj: &api.PFJob{
+				ID:        "job-pytorch-0005",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkPytorch,
+				Tasks: []models.Member{
+					newMockMember(schema.RolePServer, 1),
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			p, err := NewKubeJob(test.jobObj, dynamicClient)
+			assert.NoError(t, err)
+			jobID, err := p.CreateJob()
+			if test.wantErr {
+				assert.Error(t, err)
+				t.Logf("create pytorch job failed, err: %v", err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, test.jobObj.ID, jobID)
+
+			jobObj, err := Get(test.jobObj.Namespace, test.jobObj.ID, k8s.PyTorchJobGVK, dynamicClient)
+			assert.NoError(t, err)
+			ptj := &kubeflowv1.PyTorchJob{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(jobObj.Object, ptj)
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.wantReplicaTypes), len(ptj.Spec.PyTorchReplicaSpecs))
+			for _, replicaType := range test.wantReplicaTypes {
+				replicaSpec, find := ptj.Spec.PyTorchReplicaSpecs[replicaType]
+				if assert.True(t, find) {
+					assert.Equal(t, kubeflowv1.PyTorchJobDefaultContainerName, replicaSpec.Template.Spec.Containers[0].Name)
+				}
+			}
+			assert.Equal(t, test.wantMinAvailable, *ptj.Spec.RunPolicy.SchedulingPolicy.MinAvailable)
+			assert.Equal(t, test.jobObj.Conf.GetQueueName(), ptj.Spec.RunPolicy.SchedulingPolicy.Queue)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	kubeflowv1 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/training-operator/kubeflow.org/v1"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+type TFJob struct {
+	KubeJob
+}
+
+func (tj *TFJob) validateJob(tfj *kubeflowv1.TFJob) error {
+	if err := tj.KubeJob.validateJob(); err != nil {
+		return err
+	}
+	if len(tj.JobMode) == 0 && !tj.IsCustomYaml {
+		// patch default value
+		tj.JobMode = schema.EnvJobModeCollective
+	}
+	if tj.IsCustomYaml {
+		if err := validateKubeflowReplicaSpecs(tfj.Spec.TFReplicaSpecs); err != nil {
+			log.Errorf("validate custom yaml failed, err %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (tj *TFJob) CreateJob() (string, error) {
+	tfj := &kubeflowv1.TFJob{}
+	if err := tj.createJobFromYaml(tfj); err != nil {
+		log.Errorf("create job[%s] failed, err %v", tj.ID, err)
+		return "", err
+	}
+	if err := tj.validateJob(tfj); err != nil {
+		log.Errorf("validate [%s]type job[%s] failed, err %v", tj.JobType, tj.ID, err)
+		return "", err
+	}
+
+	var err error
+	// patch .metadata field
+	tj.patchMetadata(&tfj.ObjectMeta, tj.ID)
+	// patch .spec field
+	if err = tj.patchTFJobSpec(&tfj.Spec); err != nil {
+		log.Errorf("build job spec failed, err %v", err)
+		return "", err
+	}
+
+	// create job on cluster
+	log.Infof("create %s job %s/%s on cluster", tj.JobType, tj.Namespace, tj.Name)
+	if err = Create(tfj, tj.GroupVersionKind, tj.DynamicClientOption); err != nil {
+		log.Errorf("create %s job %s/%s on cluster failed, err %v", tj.JobType, tj.Namespace, tj.Name, err)
+		return "", err
+	}
+	return tj.ID, err
+}
+
+// patchTFJobSpec fill tensorflow job spec
+//  - ps mode: role pserver and pworker of tasks are converted to replica type PS and Worker
+//  - collective mode: role worker of tasks is converted to replica type Worker
+func (tj *TFJob) patchTFJobSpec(tfjSpec *kubeflowv1.TFJobSpec) error {
+	log.Debugf("patch %s job %s/%s spec:%#v", tj.JobType, tj.Namespace, tj.Name, tfjSpec)
+	if tfjSpec.TFReplicaSpecs == nil {
+		tfjSpec.TFReplicaSpecs = make(map[kubeflowv1.ReplicaType]*kubeflowv1.ReplicaSpec)
+	}
+	if !tj.IsCustomYaml {
+		var replicaTypeFn func(task models.Member) (kubeflowv1.ReplicaType, error)
+		switch tj.JobMode {
+		case schema.EnvJobModePS:
+			replicaTypeFn = tfPSReplicaType
+		case schema.EnvJobModeCollective:
+			replicaTypeFn = tfCollectiveReplicaType
+		default:
+			return errors.InvalidJobModeError(tj.JobMode)
+		}
+		err := tj.patchKubeflowReplicaSpecs(tfjSpec.TFReplicaSpecs, kubeflowv1.TFJobDefaultContainerName, replicaTypeFn)
+		if err != nil {
+			log.Errorf("patch tensorflow job replica specs failed, err=[%v]", err)
+			return err
+		}
+	} else {
+		log.Infof("%s job %s/%s using custom yaml, pass the patch from tasks", tj.JobType, tj.Namespace, tj.Name)
+	}
+	if len(tfjSpec.TFReplicaSpecs) == 0 {
+		return fmt.Errorf("tensorflow job %s must contain at least one replica", tj.ID)
+	}
+	tj.patchKubeflowRunPolicy(&tfjSpec.RunPolicy, tfjSpec.TFReplicaSpecs)
+	return nil
+}
+
+func tfPSReplicaType(task models.Member) (kubeflowv1.ReplicaType, error) {
+	switch task.Role {
+	case schema.RolePServer:
+		return kubeflowv1.TFJobReplicaTypePS, nil
+	case schema.RolePWorker:
+		return kubeflowv1.TFJobReplicaTypeWorker, nil
+	default:
+		return "", fmt.Errorf("role %s is not supported by tensorflow job in ps mode", task.Role)
+	}
+}
+
+func tfCollectiveReplicaType(task models.Member) (kubeflowv1.ReplicaType, error) {
+	if task.Role != schema.RoleWorker {
+		return "", fmt.Errorf("role %s is not supported by tensorflow job in collective mode", task.Role)
+	}
+	return kubeflowv1.TFJobReplicaTypeWorker, nil
+}
+
+func (tj *TFJob) StopJobByID(jobID string) error {
+	job, err := models.GetJobByID(jobID)
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.GetNamespace()
+
+	if err = Delete(namespace, job.ID, k8s.TFJobGVK, tj.DynamicClientOption); err != nil {
+		log.Errorf("stop tfJob %s in namespace %s failed, err %v", job.ID, namespace, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	kubeflowv1 "github.com/PaddlePaddle/PaddleFlow/pkg/apis/training-operator/kubeflow.org/v1"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestTFJob_CreateJob(t *testing.T) {
+	initGlobalServerConfig()
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	dynamicClient := newFakeDynamicClient(server)
+	// mock db
+	driver.InitMockDB()
+	tests := []struct {
+		caseName         string
+		jobObj           *api.PFJob
+		wantErr          bool
+		wantMinAvailable int32
+		wantReplicas     map[kubeflowv1.ReplicaType]int32
+	}{
+		{
+			caseName: "tensorflow job in ps mode",
+			jobObj: &api.PFJob{
+				ID:        "job-tf-0001",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkTF,
+				JobMode:   schema.EnvJobModePS,
+				Tasks: []models.Member{
+					newMockMember(schema.RolePServer, 2),
+					newMockMember(schema.RolePWorker, 3),
+				},
+			},
+			wantMinAvailable: 5,
+			wantReplicas: map[kubeflowv1.ReplicaType]int32{
+				kubeflowv1.TFJobReplicaTypePS:     2,
+				kubeflowv1.TFJobReplicaTypeWorker: 3,
+			},
+		},
+		{
+			caseName: "tensorflow job in collective mode",
+			jobObj: &api.PFJob{
+				ID:        "job-tf-0002",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkTF,
+				JobMode:   schema.EnvJobModeCollective,
+				Tasks: []models.Member{
+					newMockMember(schema.RoleWorker, 4),
+				},
+			},
+			wantMinAvailable: 4,
+			wantReplicas: map[kubeflowv1.ReplicaType]int32{
+				kubeflowv1.TFJobReplicaTypeWorker: 4,
+			},
+		},
+		{
+			caseName: "tensorflow job in collective mode with pserver",
+			jobObj: &api.PFJob{
+				ID:        "job-tf-0003",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkTF,
+				JobMode:   schema.EnvJobModeCollective,
+				Tasks: []models.Member{
+					newMockMember(schema.RolePServer, 1),
+					newMockMember(schema.RoleWorker, 2),
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			p, err := NewKubeJob(test.jobObj, dynamicClient)
+			assert.NoError(t, err)
+			jobID, err := p.CreateJob()
+			if test.wantErr {
+				assert.Error(t, err)
+				t.Logf("create tensorflow job failed, err: %v", err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, test.jobObj.ID, jobID)
+
+			jobObj, err := Get(test.jobObj.Namespace, test.jobObj.ID, k8s.TFJobGVK, dynamicClient)
+			assert.NoError(t, err)
+			tfj := &kubeflowv1.TFJob{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(jobObj.Object, tfj)
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.wantReplicas), len(tfj.Spec.TFReplicaSpecs))
+			for replicaType, replicas := range test.wantReplicas {
+				replicaSpec, find := tfj.Spec.TFReplicaSpecs[replicaType]
+				if assert.True(t, find) {
+					assert.Equal(t, replicas, *replicaSpec.Replicas)
+					assert.Equal(t, kubeflowv1.TFJobDefaultContainerName, replicaSpec.Template.Spec.Containers[0].Name)
+				}
+			}
+			assert.Equal(t, test.wantMinAvailable, *tfj.Spec.RunPolicy.SchedulingPolicy.MinAvailable)
+		})
+	}
+}
@@ -17,24 +17,131 @@ limitations under the License.
 package runtime
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/notification"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/utils"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/trace_logger"
+)
+
+const (
+	localJobStdout = "stdout"
+	localJobStderr = "stderr"
+
+	defaultLocalJobDir = "paddleflow-jobs"
+	// localJobStopGracePeriod is the time between SIGTERM and SIGKILL when stopping a local job
+	localJobStopGracePeriod = 10 * time.Second
+	localJobSyncPeriod      = 5 * time.Second
+	localJobGCPeriod        = 60 * time.Second
+	localJobEventSize       = 1000
 )
 
+// localJob is a job running as a child process group of paddleflow server
+type localJob struct {
+	sync.RWMutex
+	ID       string
+	QueueID  api.QueueID
+	Resource *resources.Resource
+	// JobDir stores the stdout and stderr files of job
+	JobDir  string
+	WorkDir string
+
+	cmd      *exec.Cmd
+	status   schema.JobStatus
+	message  string
+	exitCode int
+	// stopped is true when job is stopped by user
+	stopped bool
+	// synced is true when the final status of job has been stored in database
+	synced     bool
+	finishTime time.Time
+	done       chan struct{}
+}
+
+func (lj *localJob) signal(sig syscall.Signal) error {
+	if lj.cmd == nil || lj.cmd.Process == nil {
+		return nil
+	}
+	// signal the whole process group, which is created by Setpgid
+	err := syscall.Kill(-lj.cmd.Process.Pid, sig)
+	if err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+func (lj *localJob) isFinished() bool {
+	select {
+	case <-lj.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (lj *localJob) runtimeInfo() map[string]interface{} {
+	info := map[string]interface{}{
+		"jobDir":  lj.JobDir,
+		"workDir": lj.WorkDir,
+	}
+	if lj.cmd != nil && lj.cmd.Process != nil {
+		info["pid"] = lj.cmd.Process.Pid
+	}
+	return info
+}
+
 type LocRuntime struct {
 	schema.Cluster
+	// jobDir is the root directory of local jobs
+	jobDir string
+	// jobs contains local jobs which are managed by runtime, key is job id
+	jobs sync.Map
+	// jobEvents notifies sync loop that the status of local job is changed
+	jobEvents chan string
 }
 
 func NewLocalRuntime(cluster schema.Cluster) RuntimeService {
 	return &LocRuntime{
-		Cluster: cluster,
+		Cluster:   cluster,
+		jobEvents: make(chan string, localJobEventSize),
 	}
 }
 
 func (l *LocRuntime) Init() error {
+	jobDir := ""
+	if config.GlobalServerConfig != nil {
+		jobDir = config.GlobalServerConfig.Job.LocalJobDir
+	}
+	if jobDir == "" {
+		jobDir = filepath.Join(os.TempDir(), defaultLocalJobDir)
+	}
+	jobDir = filepath.Join(jobDir, l.Cluster.ID)
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		log.Errorf("create job dir %s for local cluster[%s] failed, err: %v", jobDir, l.Cluster.Name, err)
+		return err
+	}
+	l.jobDir = jobDir
 	return nil
 }
 
@@ -43,63 +150,522 @@ func (l *LocRuntime) Name() string {
 }
 
 func (l *LocRuntime) SubmitJob(job *api.PFJob) error {
-	// TODO: submit local job
+	traceLogger := trace_logger.KeyWithUpdate(job.ID)
+	msg := fmt.Sprintf("submit job[%s] to local cluster[%s] queue[%s]", job.ID, l.Cluster.ID, job.QueueID)
+	log.Infof(msg)
+	traceLogger.Infof(msg)
+	if job.JobType != schema.TypeSingle {
+		return fmt.Errorf("job type %s is not supported by local runtime", job.JobType)
+	}
+	if _, find := l.jobs.Load(job.ID); find {
+		return fmt.Errorf("job %s is already submitted to local cluster", job.ID)
+	}
+	command := job.Conf.GetCommand()
+	if command == "" {
+		return fmt.Errorf("command of job %s is empty", job.ID)
+	}
+
+	jobDir := filepath.Join(l.jobDir, job.ID)
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		log.Errorf("create dir for job[%s] failed, err: %v", job.ID, err)
+		return err
+	}
+	mountPath, workDir := l.getWorkDir(job, jobDir)
+	stdout, err := os.OpenFile(filepath.Join(jobDir, localJobStdout), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	stderr, err := os.OpenFile(filepath.Join(jobDir, localJobStderr), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		stdout.Close()
+		return err
+	}
+
+	// the same as container command on kubernetes, args are passed to `sh -c`
+	args := append([]string{"-c", command}, job.Conf.GetArgs()...)
+	cmd := exec.Command("sh", args...)
+	cmd.Dir = workDir
+	cmd.Env = l.generateEnv(job, mountPath, workDir)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// run job in a new process group, so that its children can be signaled together
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	traceLogger.Infof("start local process for job")
+	if err = cmd.Start(); err != nil {
+		stdout.Close()
+		stderr.Close()
+		log.Errorf("start local process for job[%s] failed, err: %v", job.ID, err)
+		return err
+	}
+
+	lj := &localJob{
+		ID:       job.ID,
+		QueueID:  job.QueueID,
+		Resource: job.Resource,
+		JobDir:   jobDir,
+		WorkDir:  workDir,
+		cmd:      cmd,
+		status:   schema.StatusJobRunning,
+		message:  "job is running",
+		done:     make(chan struct{}),
+	}
+	l.jobs.Store(job.ID, lj)
+	go l.waitJob(lj, stdout, stderr)
+	l.notify(job.ID)
+	log.Debugf("submit job[%s] successful, pid: %d", job.ID, cmd.Process.Pid)
 	return nil
 }
 
+// getWorkDir returns mount path of the first file system and working directory of job
+func (l *LocRuntime) getWorkDir(job *api.PFJob, jobDir string) (string, string) {
+	fileSystems := job.Conf.GetAllFileSystem()
+	if len(fileSystems) == 0 || strings.ToUpper(job.Conf.GetEnv()[schema.EnvMountPath]) == "NONE" {
+		return "", jobDir
+	}
+	mountPath := utils.MountPathClean(fileSystems[0].MountPath)
+	if mountPath == "/" {
+		mountPath = filepath.Join(schema.DefaultFSMountPath, fileSystems[0].ID)
+	}
+	// file system must be mounted on local host by user, otherwise use job dir instead
+	if fi, err := os.Stat(mountPath); err != nil || !fi.IsDir() {
+		log.Warnf("mount path %s of job[%s] is not a directory, use %s as working directory", mountPath, job.ID, jobDir)
+		return mountPath, jobDir
+	}
+	return mountPath, mountPath
+}
+
+func (l *LocRuntime) generateEnv(job *api.PFJob, mountPath, workDir string) []string {
+	envs := map[string]string{
+		schema.EnvJobID:        job.ID,
+		schema.EnvJobType:      string(job.JobType),
+		schema.EnvJobQueueID:   string(job.QueueID),
+		schema.EnvJobClusterID: l.Cluster.ID,
+		schema.EnvJobNamespace: job.Namespace,
+		schema.EnvJobUserName:  job.UserName,
+		schema.EnvJobFsID:      job.FSID,
+		schema.EnvMountPath:    mountPath,
+		schema.EnvJobWorkDir:   workDir,
+	}
+	for k, v := range job.Conf.GetEnv() {
+		if k == schema.EnvMountPath && v == "" {
+			continue
+		}
+		envs[k] = v
+	}
+	result := os.Environ()
+	for k, v := range envs {
+		result = append(result, fmt.Sprintf("%s=%s", k, v))
+	}
+	return result
+}
+
+// waitJob waits for the process of local job to exit, and records the final status
+func (l *LocRuntime) waitJob(lj *localJob, stdout, stderr *os.File) {
+	err := lj.cmd.Wait()
+	stdout.Close()
+	stderr.Close()
+
+	lj.Lock()
+	lj.finishTime = time.Now()
+	lj.exitCode = lj.cmd.ProcessState.ExitCode()
+	switch {
+	case lj.stopped:
+		lj.status = schema.StatusJobTerminated
+		lj.message = "job is terminated"
+	case err == nil:
+		lj.status = schema.StatusJobSucceeded
+		lj.message = "job is succeeded"
+	default:
+		lj.status = schema.StatusJobFailed
+		lj.message = fmt.Sprintf("job is failed, err: %v", err)
+	}
+	log.Infof("local process of job[%s] exited, status: %s, exit code: %d", lj.ID, lj.status, lj.exitCode)
+	lj.Unlock()
+	close(lj.done)
+	l.notify(lj.ID)
+}
+
+func (l *LocRuntime) notify(jobID string) {
+	select {
+	case l.jobEvents <- jobID:
+	default:
+		// job status will be synced by the next resync period
+		log.Warnf("job event queue of local cluster[%s] is full, skip event for job[%s]", l.Cluster.Name, jobID)
+	}
+}
+
+func (l *LocRuntime) getJob(jobID string) (*localJob, bool) {
+	value, find := l.jobs.Load(jobID)
+	if !find {
+		return nil, false
+	}
+	return value.(*localJob), true
+}
+
 func (l *LocRuntime) StopJob(job *api.PFJob) error {
-	// TODO: stop local job
+	log.Infof("stop job[%s] on local cluster[%s] queue[%s]", job.ID, l.Cluster.ID, job.QueueID)
+	lj, find := l.getJob(job.ID)
+	if !find {
+		// job which is not found will be handled by sync loop
+		log.Warnf("local job[%s] is not found, skip stop it", job.ID)
+		return nil
+	}
+	lj.Lock()
+	if lj.isFinished() {
+		lj.Unlock()
+		return nil
+	}
+	lj.stopped = true
+	lj.Unlock()
+	if err := lj.signal(syscall.SIGTERM); err != nil {
+		log.Errorf("stop local job[%s] failed, err: %v", job.ID, err)
+		return err
+	}
+	go func() {
+		select {
+		case <-lj.done:
+		case <-time.After(localJobStopGracePeriod):
+			log.Warnf("local job[%s] is still running after %s, kill it", lj.ID, localJobStopGracePeriod)
+			if err := lj.signal(syscall.SIGKILL); err != nil {
+				log.Errorf("kill local job[%s] failed, err: %v", lj.ID, err)
+			}
+		}
+	}()
+	log.Debugf("stop job[%s] successful", job.ID)
 	return nil
 }
 
 func (l *LocRuntime) UpdateJob(job *api.PFJob) error {
-	// TODO: update local job
+	// priority, labels and annotations take no effect on local process
+	log.Infof("update job[%s] on local cluster[%s] is ignored", job.ID, l.Cluster.ID)
 	return nil
 }
 
 func (l *LocRuntime) DeleteJob(job *api.PFJob) error {
-	// TODO: kill local job
+	log.Infof("delete job %s from local cluster %s, and queue %s", job.ID, l.Cluster.ID, job.QueueID)
+	lj, find := l.getJob(job.ID)
+	if find {
+		lj.Lock()
+		if !lj.isFinished() {
+			lj.stopped = true
+		}
+		lj.Unlock()
+		if err := lj.signal(syscall.SIGKILL); err != nil {
+			log.Errorf("kill local job %s failed, err: %v", job.ID, err)
+			return err
+		}
+		select {
+		case <-lj.done:
+		case <-time.After(localJobStopGracePeriod):
+			return fmt.Errorf("wait for local job %s to exit timeout", job.ID)
+		}
+		l.jobs.Delete(job.ID)
+	}
+	if err := os.RemoveAll(filepath.Join(l.jobDir, job.ID)); err != nil {
+		log.Errorf("remove dir of local job %s failed, err: %v", job.ID, err)
+		return err
+	}
+	log.Debugf("delete job %s successful", job.ID)
 	return nil
 }
 
 func (l *LocRuntime) SyncJob(stopCh <-chan struct{}) {
-	// TODO: add local job sync
+	log.Infof("start job sync loop for local cluster[%s]", l.Cluster.ID)
+	go l.runJobSync(stopCh)
+}
+
+func (l *LocRuntime) runJobSync(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(localJobSyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			log.Infof("exit job sync loop for local cluster[%s]", l.Cluster.ID)
+			return
+		case jobID := <-l.jobEvents:
+			if lj, find := l.getJob(jobID); find {
+				l.syncJobStatus(lj)
+			}
+		case <-ticker.C:
+			l.jobs.Range(func(key, value interface{}) bool {
+				l.syncJobStatus(value.(*localJob))
+				return true
+			})
+			l.syncLostJobs()
+		}
+	}
+}
+
+// syncJobStatus stores the status of local job into database when it is changed
+func (l *LocRuntime) syncJobStatus(lj *localJob) {
+	lj.RLock()
+	status, message, synced := lj.status, lj.message, lj.synced
+	runtimeInfo := lj.runtimeInfo()
+	runtimeStatus := map[string]interface{}{
+		"exitCode": lj.exitCode,
+	}
+	lj.RUnlock()
+	if synced {
+		return
+	}
+
+	job, err := models.GetJobByID(lj.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Warnf("local job[%s] is not found in database, skip sync it", lj.ID)
+		l.markSynced(lj)
+		return
+	} else if err != nil {
+		log.Errorf("get job[%s] from database failed, err: %v", lj.ID, err)
+		return
+	}
+	if schema.IsImmutableJobStatus(job.Status) {
+		l.markSynced(lj)
+		return
+	}
+	// skip when job status is not changed, or job is terminating
+	if job.Status == status || (job.Status == schema.StatusJobTerminating && status == schema.StatusJobRunning) {
+		return
+	}
+	newStatus, err := models.UpdateJob(lj.ID, status, runtimeInfo, runtimeStatus, message)
+	if err != nil {
+		log.Errorf("update local job[%s] failed, err: %v", lj.ID, err)
+		return
+	}
+	if schema.IsImmutableJobStatus(newStatus) {
+		l.markSynced(lj)
+		notification.NotifyJob(lj.ID, newStatus)
+	}
+}
+
+func (l *LocRuntime) markSynced(lj *localJob) {
+	lj.Lock()
+	defer lj.Unlock()
+	if lj.isFinished() {
+		lj.synced = true
+	}
+}
+
+// syncLostJobs finishes jobs whose local process is lost, for example, paddleflow server is restarted
+func (l *LocRuntime) syncLostJobs() {
+	for _, status := range []schema.JobStatus{schema.StatusJobPending, schema.StatusJobRunning, schema.StatusJobTerminating} {
+		jobs := models.ListClusterJob(l.Cluster.ID, status)
+		for _, job := range jobs {
+			if _, find := l.jobs.Load(job.ID); find {
+				continue
+			}
+			newStatus, message := schema.StatusJobFailed, "local process of job is lost"
+			if status == schema.StatusJobTerminating {
+				newStatus, message = schema.StatusJobTerminated, "job is terminated"
+			}
+			log.Warnf("local process of job[%s] is not found, update job status to %s", job.ID, newStatus)
+			if _, err := models.UpdateJob(job.ID, newStatus, nil, nil, message); err != nil {
+				log.Errorf("update local job[%s] failed, err: %v", job.ID, err)
+				continue
+			}
+			notification.NotifyJob(job.ID, newStatus)
+		}
+	}
 }
 
 func (l *LocRuntime) GCJob(stopCh <-chan struct{}) {
-	// TODO: add local job gc
+	log.Infof("start job gc loop for local cluster[%s]", l.Cluster.ID)
+	go l.runJobGC(stopCh)
+}
+
+func (l *LocRuntime) runJobGC(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(localJobGCPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			log.Infof("exit job gc loop for local cluster[%s]", l.Cluster.ID)
+			return
+		case <-ticker.C:
+			l.jobs.Range(func(key, value interface{}) bool {
+				lj := value.(*localJob)
+				if l.isJobExpired(lj) {
+					log.Infof("local job[%s] is expired, clean it", lj.ID)
+					l.jobs.Delete(lj.ID)
+					if err := os.RemoveAll(lj.JobDir); err != nil {
+						log.Errorf("remove dir of local job[%s] failed, err: %v", lj.ID, err)
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// isJobExpired checks whether a finished job can be cleaned, according to reclaim config
+func (l *LocRuntime) isJobExpired(lj *localJob) bool {
+	if config.GlobalServerConfig == nil || !config.GlobalServerConfig.Job.Reclaim.CleanJob {
+		return false
+	}
+	reclaim := config.GlobalServerConfig.Job.Reclaim
+	lj.RLock()
+	defer lj.RUnlock()
+	if !lj.synced {
+		return false
+	}
+	var ttl int
+	switch lj.status {
+	case schema.StatusJobSucceeded:
+		ttl = reclaim.SucceededJobTTLSeconds
+	case schema.StatusJobFailed:
+		if reclaim.SkipCleanFailedJob {
+			return false
+		}
+		ttl = reclaim.FailedJobTTLSeconds
+	case schema.StatusJobTerminated:
+		ttl = reclaim.FailedJobTTLSeconds
+	default:
+		return false
+	}
+	return time.Since(lj.finishTime) > time.Duration(ttl)*time.Second
 }
 
 func (l *LocRuntime) SyncQueue(stopCh <-chan struct{}) {
-	// TODO: add local queue sync
+	// local cluster has no queue, queues are only stored in database
+	log.Infof("skip queue sync for local cluster[%s]", l.Cluster.ID)
 }
 
 func (l *LocRuntime) CreateQueue(q *models.Queue) error {
-	// TODO: add create queue
 	return nil
 }
 
 func (l *LocRuntime) DeleteQueue(q *models.Queue) error {
-	// TODO: add delete queue
 	return nil
 }
 
 func (l *LocRuntime) CloseQueue(q *models.Queue) error {
-	// TODO: add close queue
 	return nil
 }
 
 func (l *LocRuntime) UpdateQueue(q *models.Queue) error {
-	// TODO: add update queue
 	return nil
 }
 
+// ListNodeQuota returns the quota of local host, and idle quota excludes resource of running jobs
 func (l *LocRuntime) ListNodeQuota() (schema.QuotaSummary, []schema.NodeQuotaInfo, error) {
-	// TODO: add ListNodeQuota
-	return schema.QuotaSummary{}, []schema.NodeQuotaInfo{}, nil
+	totalQuota := resources.EmptyResource()
+	totalQuota.SetResources(resources.ResCPU, int64(goruntime.NumCPU())*1000)
+	if memory, err := hostMemory(); err == nil {
+		totalQuota.SetResources(resources.ResMemory, memory)
+	} else {
+		log.Warnf("get memory of local host failed, err: %v", err)
+	}
+	idleQuota := totalQuota.Clone()
+	l.jobs.Range(func(key, value interface{}) bool {
+		lj := value.(*localJob)
+		if !lj.isFinished() {
+			idleQuota.Sub(lj.Resource)
+		}
+		return true
+	})
+	nodeName, _ := os.Hostname()
+	nodeQuota := schema.NodeQuotaInfo{
+		NodeName:    nodeName,
+		Schedulable: true,
+		Total:       *totalQuota,
+		Idle:        *idleQuota,
+	}
+	summary := schema.QuotaSummary{
+		TotalQuota: *totalQuota.Clone(),
+		IdleQuota:  *idleQuota.Clone(),
+	}
+	return summary, []schema.NodeQuotaInfo{nodeQuota}, nil
+}
+
+// hostMemory returns total memory of local host in bytes
+func hostMemory() (int64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// MemTotal:       16318424 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, fmt.Errorf("MemTotal is not found in /proc/meminfo")
 }
 
 func (l *LocRuntime) GetJobLog(jobLogRequest schema.JobLogRequest) (schema.JobLogInfo, error) {
-	// TODO
-	return schema.JobLogInfo{}, nil
+	jobLogInfo := schema.JobLogInfo{
+		JobID:    jobLogRequest.JobID,
+		TaskList: make([]schema.TaskLogInfo, 0),
+	}
+	jobDir := filepath.Join(l.jobDir, jobLogRequest.JobID)
+	for _, name := range []string{localJobStdout, localJobStderr} {
+		logContent, length, err := readLocalLog(filepath.Join(jobDir, name), jobLogRequest.LogFilePosition)
+		if err != nil {
+			log.Errorf("job[%s] read %s log failed, err: %v", jobLogRequest.JobID, name, err)
+			return schema.JobLogInfo{}, err
+		}
+		taskLogInfo := schema.TaskLogInfo{
+			TaskID: fmt.Sprintf("%s_%s", jobLogRequest.JobID, name),
+			Info: paginateLog(logContent, length, jobLogRequest.LogFilePosition,
+				jobLogRequest.LogPageSize, jobLogRequest.LogPageNo),
+		}
+		jobLogInfo.TaskList = append(jobLogInfo.TaskList, taskLogInfo)
+	}
+	return jobLogInfo, nil
+}
+
+// readLocalLog reads log file with the same limits as kubernetes container log,
+// and returns log content and its line number
+func readLocalLog(path, logFilePosition string) (string, int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", 0, nil
+	} else if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	var content string
+	if logFilePosition == common.BeginFilePosition {
+		data, err := io.ReadAll(io.LimitReader(f, byteReadLimit))
+		if err != nil {
+			return "", 0, err
+		}
+		content = string(data)
+	} else {
+		fi, err := f.Stat()
+		if err != nil {
+			return "", 0, err
+		}
+		offset := fi.Size() - byteReadLimit
+		if offset < 0 {
+			offset = 0
+		}
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			return "", 0, err
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return "", 0, err
+		}
+		content = string(data)
+		// drop the incomplete first line
+		if idx := strings.Index(content, "\n"); offset > 0 && idx >= 0 {
+			content = content[idx+1:]
+		}
+		lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+		if int64(len(lines)) > lineReadLimit {
+			content = strings.Join(lines[int64(len(lines))-lineReadLimit:], "\n") + "\n"
+		}
+	}
+	if content == "" {
+		return "", 0, nil
+	}
+	return content, len(strings.Split(strings.TrimRight(content, "\n"), "\n")), nil
 }
@@ -0,0 +1,199 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func newTestLocalRuntime(t *testing.T) *LocRuntime {
+	config.GlobalServerConfig = &config.ServerConfig{
+		Job: config.JobConfig{
+			LocalJobDir: t.TempDir(),
+		},
+	}
+	localRuntime := NewLocalRuntime(schema.Cluster{ID: "local-cluster", Name: "local"}).(*LocRuntime)
+	err := localRuntime.Init()
+	assert.NoError(t, err)
+	return localRuntime
+}
+
+func newTestLocalJob(t *testing.T, jobID, command string) *api.PFJob {
+	err := models.CreateJob(&models.Job{
+		ID:     jobID,
+		Type:   string(schema.TypeSingle),
+		Status: schema.StatusJobPending,
+		Config: &schema.Conf{},
+	})
+	assert.NoError(t, err)
+	// jobs are queried with `deleted_at is null`
+	err = storage.DB.Model(&models.Job{}).Where("id = ?", jobID).UpdateColumn("deleted_at", gorm.Expr("NULL")).Error
+	assert.NoError(t, err)
+	return &api.PFJob{
+		ID:       jobID,
+		JobType:  schema.TypeSingle,
+		UserName: "root",
+		Conf: schema.Conf{
+			Command: command,
+			Env: map[string]string{
+				"TEST_ENV": "test-value",
+			},
+		},
+	}
+}
+
+func waitLocalJob(t *testing.T, l *LocRuntime, jobID string) *localJob {
+	lj, find := l.getJob(jobID)
+	assert.True(t, find)
+	select {
+	case <-lj.done:
+	case <-time.After(2 * localJobStopGracePeriod):
+		t.Fatalf("wait for local job %s timeout", jobID)
+	}
+	l.syncJobStatus(lj)
+	return lj
+}
+
+func TestLocalRuntimeJob(t *testing.T) {
+	driver.InitMockDB()
+	l := newTestLocalRuntime(t)
+
+	// succeeded job
+	pfJob := newTestLocalJob(t, "job-local-1", "echo $PF_JOB_ID $TEST_ENV; echo to-stderr >&2")
+	err := l.SubmitJob(pfJob)
+	assert.NoError(t, err)
+	err = l.SubmitJob(pfJob)
+	assert.Error(t, err)
+	waitLocalJob(t, l, pfJob.ID)
+	job, err := models.GetJobByID(pfJob.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusJobSucceeded, job.Status)
+
+	logInfo, err := l.GetJobLog(schema.JobLogRequest{
+		JobID:           pfJob.ID,
+		LogFilePosition: common.EndFilePosition,
+		LogPageSize:     10,
+		LogPageNo:       1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(logInfo.TaskList))
+	assert.Equal(t, "job-local-1 test-value\n", logInfo.TaskList[0].Info.LogContent)
+	assert.Equal(t, "to-stderr\n", logInfo.TaskList[1].Info.LogContent)
+
+	// failed job
+	pfJob = newTestLocalJob(t, "job-local-2", "exit 3")
+	err = l.SubmitJob(pfJob)
+	assert.NoError(t, err)
+	lj := waitLocalJob(t, l, pfJob.ID)
+	assert.Equal(t, 3, lj.exitCode)
+	job, err = models.GetJobByID(pfJob.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusJobFailed, job.Status)
+
+	// stopped job
+	pfJob = newTestLocalJob(t, "job-local-3", "sleep 60")
+	err = l.SubmitJob(pfJob)
+	assert.NoError(t, err)
+	lj, _ = l.getJob(pfJob.ID)
+	l.syncJobStatus(lj)
+	job, err = models.GetJobByID(pfJob.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusJobRunning, job.Status)
+	err = models.UpdateJobStatus(pfJob.ID, "job is terminating.", schema.StatusJobTerminating)
+	assert.NoError(t, err)
+	err = l.StopJob(pfJob)
+	assert.NoError(t, err)
+	waitLocalJob(t, l, pfJob.ID)
+	job, err = models.GetJobByID(pfJob.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusJobTerminated, job.Status)
+
+	// delete job
+	err = l.DeleteJob(pfJob)
+	assert.NoError(t, err)
+	_, find := l.getJob(pfJob.ID)
+	assert.False(t, find)
+	_, err = os.Stat(filepath.Join(l.jobDir, pfJob.ID))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalRuntimeNotSupportedJob(t *testing.T) {
+	l := newTestLocalRuntime(t)
+	err := l.SubmitJob(&api.PFJob{
+		ID:      "job-local-distributed",
+		JobType: schema.TypeDistributed,
+	})
+	assert.Error(t, err)
+	err = l.SubmitJob(&api.PFJob{
+		ID:      "job-local-empty",
+		JobType: schema.TypeSingle,
+	})
+	assert.Error(t, err)
+}
+
+func TestReadLocalLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout")
+	var lines []string
+	for i := 0; i < int(lineReadLimit)+10; i++ {
+		lines = append(lines, "line")
+	}
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	assert.NoError(t, err)
+
+	_, length, err := readLocalLog(path, common.EndFilePosition)
+	assert.NoError(t, err)
+	assert.Equal(t, int(lineReadLimit), length)
+	_, length, err = readLocalLog(path, common.BeginFilePosition)
+	assert.NoError(t, err)
+	assert.Equal(t, len(lines), length)
+
+	content, _, err := readLocalLog(filepath.Join(t.TempDir(), "not-exist"), common.EndFilePosition)
+	assert.NoError(t, err)
+	assert.Equal(t, "", content)
+
+	empty := filepath.Join(t.TempDir(), "stderr")
+	assert.NoError(t, os.WriteFile(empty, nil, 0644))
+	for _, position := range []string{common.BeginFilePosition, common.EndFilePosition} {
+		content, length, err = readLocalLog(empty, position)
+		assert.NoError(t, err)
+		assert.Equal(t, "", content)
+		assert.Equal(t, 0, length)
+	}
+}
+
+func TestLocalRuntimeNodeQuota(t *testing.T) {
+	l := newTestLocalRuntime(t)
+	summary, nodes, err := l.ListNodeQuota()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nodes))
+	assert.True(t, summary.TotalQuota.CPU() > 0)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AccessToken is a personal access token of user, only the hash of token is stored.
+type AccessToken struct {
+	Pk        int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	ID        string `json:"tokenID" gorm:"uniqueIndex"`
+	Name      string `json:"name"`
+	UserName  string `json:"userName" gorm:"index"`
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+	// ScopesStr is the comma separated scopes
+	ScopesStr string         `json:"-" gorm:"column:scopes"`
+	Scopes    []string       `json:"scopes" gorm:"-"`
+	ExpiresAt *time.Time     `json:"expireTime,omitempty"`
+	CreatedAt time.Time      `json:"createTime"`
+	UpdatedAt time.Time      `json:"-"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (AccessToken) TableName() string {
+	return "access_token"
+}
+
+func (t *AccessToken) BeforeSave(*gorm.DB) error {
+	t.ScopesStr = strings.Join(t.Scopes, ",")
+	return nil
+}
+
+func (t *AccessToken) AfterFind(*gorm.DB) error {
+	t.Scopes = nil
+	if t.ScopesStr != "" {
+		t.Scopes = strings.Split(t.ScopesStr, ",")
+	}
+	return nil
+}
+
+// IsExpired returns whether the token is expired, the token without expire time never expires
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const FsCacheWarmupTableName = "fs_cache_warmup"
+
+const (
+	WarmupStatusPending   = "pending"
+	WarmupStatusRunning   = "running"
+	WarmupStatusSucceeded = "succeeded"
+	WarmupStatusFailed    = "failed"
+)
+
+// FSCacheWarmup is a warm-up task of a fs on one node, which is dispatched to the cache-worker of the node
+// through the response of cache report, and the progress is reported back through cache report as well
+type FSCacheWarmup struct {
+	PK          int64          `json:"-"           gorm:"primaryKey;autoIncrement"`
+	WarmupID    string         `json:"warmupID"    gorm:"type:varchar(36);column:warmup_id;uniqueIndex"`
+	FsID        string         `json:"fsID"        gorm:"type:varchar(200);column:fs_id;index:idx_warmup_node"`
+	ClusterID   string         `json:"clusterID"   gorm:"column:cluster_id;default:'';index:idx_warmup_node"`
+	NodeName    string         `json:"nodename"    gorm:"type:varchar(255);column:nodename;index:idx_warmup_node"`
+	PathsJson   string         `json:"-"           gorm:"column:paths;type:text"`
+	Paths       []string       `json:"paths"       gorm:"-"`
+	Concurrency int            `json:"concurrency"`
+	Status      string         `json:"status"      gorm:"type:varchar(32)"`
+	Files       int64          `json:"files"`
+	Bytes       int64          `json:"bytes"`
+	Message     string         `json:"message"     gorm:"type:text"`
+	CreateTime  string         `json:"createTime"  gorm:"-"`
+	UpdateTime  string         `json:"updateTime"  gorm:"-"`
+	CreatedAt   time.Time      `json:"-"`
+	UpdatedAt   time.Time      `json:"-"`
+	DeletedAt   gorm.DeletedAt `json:"-"`
+}
+
+func (w *FSCacheWarmup) TableName() string {
+	return FsCacheWarmupTableName
+}
+
+func (w *FSCacheWarmup) BeforeSave(*gorm.DB) error {
+	if w.Paths == nil {
+		return nil
+	}
+	paths, err := json.Marshal(w.Paths)
+	if err != nil {
+		log.Errorf("json Marshal warmup paths[%v] failed: %v", w.Paths, err)
+		return err
+	}
+	w.PathsJson = string(paths)
+	return nil
+}
+
+func (w *FSCacheWarmup) AfterFind(*gorm.DB) error {
+	if w.PathsJson != "" {
+		if err := json.Unmarshal([]byte(w.PathsJson), &w.Paths); err != nil {
+			log.Errorf("json Unmarshal warmup paths[%s] failed: %v", w.PathsJson, err)
+			return err
+		}
+	}
+	w.CreateTime = w.CreatedAt.Format(TimeFormat)
+	w.UpdateTime = w.UpdatedAt.Format(TimeFormat)
+	return nil
+}
+
+func IsFinishedWarmupStatus(status string) bool {
+	return status == WarmupStatusSucceeded || status == WarmupStatusFailed
+}
@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// TokenRevocation revokes a single token when TokenID is set, otherwise it revokes all tokens of UserName
+// issued before or at IssuedBefore, as token issue time is in seconds.
+type TokenRevocation struct {
+	Pk           int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	TokenID      string `json:"tokenID" gorm:"index"`
+	UserName     string `json:"userName" gorm:"index"`
+	IssuedBefore int64  `json:"issuedBefore"`
+	// ExpiresAt is the time after which the revoked tokens are expired anyway, 0 means never
+	ExpiresAt int64     `json:"expiresAt"`
+	CreatedAt time.Time `json:"createTime"`
+}
+
+func (TokenRevocation) TableName() string {
+	return "token_revocation"
+}
@@ -285,9 +285,181 @@ func (cc *conservativeCacheCalculator) CalculateSecondFingerprint() (fingerprint
 	return secondFingerprint, err
 }
 
+type PathToDigest struct {
+	Digest map[string]string `json:",omitempty"`
+}
+
+// 用于计算内容策略的第二层 fingerprint 的结构
+type contentSecondCacheKey struct {
+	// 输入 artifact 的名字到其内容摘要的映射
+	InputArtifactsDigest map[string]string `json:",omitempty"`
+
+	// Fs 上的文件名与其内容摘要之间的映射关系
+	FsScopeDigest map[string]PathToDigest `json:",omitempty"`
+}
+
+// contentCacheCalculator: 第一层 fingerprint 的计算方式与保守策略一致，第二层 fingerprint 根据文件内容计算，
+// 因此文件的 mtime 发生变化，但内容没有变化时，依然可以命中 cache
+type contentCacheCalculator struct {
+	*conservativeCacheCalculator
+	contentSecondCacheKey *contentSecondCacheKey
+}
+
+// 调用方应该保证在启用了 cache 功能的情况下才会调用NewContentCacheCalculator
+func NewContentCacheCalculator(job PaddleFlowJob, cacheConfig schema.Cache, logger *logrus.Entry,
+	mainFs *schema.FsMount, extraFs []schema.FsMount) (CacheCalculator, error) {
+	calculator := contentCacheCalculator{
+		conservativeCacheCalculator: &conservativeCacheCalculator{
+			job:         job,
+			cacheConfig: cacheConfig,
+			logger:      logger,
+			mainFS:      mainFs,
+			extraFS:     extraFs,
+		},
+	}
+	return &calculator, nil
+}
+
+func (cc *contentCacheCalculator) getFsScopeDigest() (map[string]PathToDigest, error) {
+	// 注意， FsScope 的合法性需要由调用方保证
+	sd := map[string]PathToDigest{}
+	for _, scope := range cc.cacheConfig.FsScope {
+		cc.logger.Infof("begin to get the content digest of scope: %v", scope)
+		fsHandler, err := handler.NewFsHandlerWithServer(scope.ID, cc.logger)
+		if err != nil {
+			errMsg := fmt.Errorf("init fsHandler failed: %s", err.Error())
+			cc.logger.Errorln(errMsg)
+			return nil, err
+		}
+
+		var pathToDigest PathToDigest
+		if _, ok := sd[scope.ID]; ok {
+			pathToDigest = sd[scope.ID]
+		} else {
+			pathToDigest = PathToDigest{Digest: map[string]string{}}
+		}
+
+		// 与保守策略不同，path 为空时不会对整个 fs 计算摘要，避免读取整个 fs 的内容
+		FsScope := strings.TrimSpace(scope.Path)
+		if strings.Trim(FsScope, ",") == "" {
+			err := fmt.Errorf("path of fs_scope[%s] should be set explicitly when strategy of cache is [%s]",
+				scope.Name, common.CacheStrategyContent)
+			cc.logger.Errorln(err.Error())
+			return nil, err
+		}
+
+		for _, path := range strings.Split(FsScope, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+
+			digest, err := fsHandler.ContentDigest(path)
+			if err != nil {
+				err = fmt.Errorf("get the content digest of fsScope file[%s] failed: %s", path, err.Error())
+				cc.logger.Errorln(err.Error())
+				return nil, err
+			}
+			pathToDigest.Digest[path] = digest
+		}
+
+		sd[scope.ID] = pathToDigest
+	}
+	return sd, nil
+}
+
+func (cc *contentCacheCalculator) getInputArtifactDigest() (map[string]string, error) {
+	if cc.mainFS.ID == "" {
+		cc.logger.Info("there must be no input artifact because global fsId is empty")
+		return map[string]string{}, nil
+	}
+
+	fsHandler, err := handler.NewFsHandlerWithServer(cc.mainFS.ID, cc.logger)
+	if err != nil {
+		errMsg := fmt.Errorf("init fsHandler failed: %s", err.Error())
+		cc.logger.Errorln(errMsg)
+		return nil, err
+	}
+
+	inArtDigestMap := map[string]string{}
+	for name, paths := range cc.job.Artifacts.Input {
+		name = strings.TrimSpace(name)
+		digests := []string{}
+
+		for _, path := range strings.Split(paths, ",") {
+			path = strings.TrimSpace(path)
+
+			if name == "" || path == "" {
+				err := fmt.Errorf("the input artifact[%s] is illegal, name or path of it is empty", name)
+				cc.logger.Errorln(err.Error())
+				return map[string]string{}, err
+			}
+
+			digest, err := fsHandler.ContentDigest(path)
+			if err != nil {
+				err = fmt.Errorf("get the content digest of inputArtfact[%s] failed: %s", name, err.Error())
+				return map[string]string{}, err
+			}
+
+			digests = append(digests, digest)
+		}
+		inArtDigestMap[name] = strings.Join(digests, ",")
+	}
+
+	return inArtDigestMap, nil
+}
+
+func (cc *contentCacheCalculator) generateSecondCacheKey() error {
+	fsScopeDigest, err := cc.getFsScopeDigest()
+	if err != nil {
+		err := fmt.Errorf("generate SecondCacheKey failed: [%s]", err.Error())
+		cc.logger.Errorln(err.Error())
+		return err
+	}
+
+	inArt, err := cc.getInputArtifactDigest()
+	if err != nil {
+		err := fmt.Errorf("generate SecondCacheKey failed: [%s]", err.Error())
+		cc.logger.Errorln(err.Error())
+		return err
+	}
+
+	cc.contentSecondCacheKey = &contentSecondCacheKey{
+		InputArtifactsDigest: inArt,
+		FsScopeDigest:        fsScopeDigest,
+	}
+
+	logMsg := fmt.Sprintf("SecondCacheKey:\nInputArtDigest: %s, FsScopeDigest: %v", inArt, fsScopeDigest)
+	cc.logger.Debugf(logMsg)
+
+	return nil
+}
+
+func (cc *contentCacheCalculator) CalculateSecondFingerprint() (fingerprint string, err error) {
+	err = cc.generateSecondCacheKey()
+	if err != nil {
+		err = fmt.Errorf("Calculate SecondFingerprint failed due to generating SecondCacheKey failed: %s", err.Error())
+		cc.logger.Errorln(err.Error())
+		return "", err
+	}
+
+	secondFingerprint, err := calculateFingerprint(cc.contentSecondCacheKey)
+	if err != nil {
+		err = fmt.Errorf("Calculate SecondFingerprint failed: %s", err.Error())
+		cc.logger.Errorln(err.Error())
+		return "", err
+	}
+
+	return secondFingerprint, err
+}
+
 // 调用方应该保证在启用了 cache 功能的情况下才会调用NewCacheCalculator
 func NewCacheCalculator(job PaddleFlowJob, cacheConfig schema.Cache, logger *logrus.Entry,
 	mainFs *schema.FsMount, extraFs []schema.FsMount) (CacheCalculator, error) {
-	// TODO: 当支持多中 cache 策略时，做好分发的功能
-	return NewConservativeCacheCalculator(job, cacheConfig, logger, mainFs, extraFs)
+	switch cacheConfig.Strategy {
+	case common.CacheStrategyContent:
+		return NewContentCacheCalculator(job, cacheConfig, logger, mainFs, extraFs)
+	default:
+		return NewConservativeCacheCalculator(job, cacheConfig, logger, mainFs, extraFs)
+	}
 }
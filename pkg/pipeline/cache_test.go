@@ -19,8 +19,10 @@ package pipeline
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
@@ -28,6 +30,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/fs"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 	"github.com/stretchr/testify/assert"
 	// . "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 )
@@ -466,3 +469,79 @@ func TestNewCacheCalculator(t *testing.T) {
 	_, ok = calculator.(*conservativeCacheCalculator)
 	assert.Equal(t, ok, true)
 }
+
+func TestContentCacheCalculator(t *testing.T) {
+	ServerConf := &config.ServerConfig{}
+	err := config.InitConfigFromYaml(ServerConf, "../../config/server/default/paddleserver.yaml")
+	config.GlobalServerConfig = ServerConf
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+
+	step := mockStep()
+	cacheConfig := mockCacheConfig()
+	cacheConfig.Strategy = pplcommon.CacheStrategyContent
+	// 内容策略不允许 path 为空的 fs_scope
+	cacheConfig.FsScope = cacheConfig.FsScope[:2]
+	job := step.job.(*PaddleFlowJob)
+
+	calculator, err := NewCacheCalculator(*job, cacheConfig, step.logger, step.mainFS, step.getWorkFlowStep().ExtraFS)
+	assert.Nil(t, err)
+	_, ok := calculator.(*contentCacheCalculator)
+	assert.True(t, ok)
+
+	conservative, err := NewCacheCalculator(*job, mockCacheConfig(), step.logger, step.mainFS,
+		step.getWorkFlowStep().ExtraFS)
+	assert.Nil(t, err)
+
+	for _, path := range mockArtifact().Input {
+		assert.Nil(t, CreatefileByFsClient(path, true))
+	}
+	assert.Nil(t, CreatefileByFsClient("/class/mode/model.pdparams", false))
+	for _, scope := range cacheConfig.FsScope {
+		for _, path := range strings.Split(scope.Path, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				assert.Nil(t, CreatefileByFsClient(path, false))
+			}
+		}
+	}
+
+	// 第一层 fingerprint 与保守策略一致
+	fp1, err := calculator.CalculateFirstFingerprint()
+	assert.Nil(t, err)
+	conservativeFp1, err := conservative.CalculateFirstFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, conservativeFp1, fp1)
+
+	fp2, err := calculator.CalculateSecondFingerprint()
+	assert.Nil(t, err)
+	conservativeFp2, err := conservative.CalculateSecondFingerprint()
+	assert.Nil(t, err)
+	digest := calculator.(*contentCacheCalculator).contentSecondCacheKey
+	assert.Len(t, digest.InputArtifactsDigest, 2)
+	assert.Contains(t, digest.FsScopeDigest["123"].Digest, "a.txt")
+
+	// 只修改 mtime，内容策略的 fingerprint 不变，保守策略的 fingerprint 改变
+	later := time.Now().Add(time.Hour)
+	assert.Nil(t, os.Chtimes("./mock_fs_handler/class/mode/model.pdparams", later, later))
+	assert.Nil(t, os.Chtimes("./mock_fs_handler/a.txt", later, later))
+
+	newFp2, err := calculator.CalculateSecondFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, fp2, newFp2)
+	newConservativeFp2, err := conservative.CalculateSecondFingerprint()
+	assert.Nil(t, err)
+	assert.NotEqual(t, conservativeFp2, newConservativeFp2)
+
+	// 修改内容后，内容策略的 fingerprint 改变
+	assert.Nil(t, os.WriteFile("./mock_fs_handler/class/mode/model.pdparams", []byte("new model"), 0644))
+	newFp2, err = calculator.CalculateSecondFingerprint()
+	assert.Nil(t, err)
+	assert.NotEqual(t, fp2, newFp2)
+
+	// fs_scope 的 path 为空时，不会对整个 fs 计算摘要
+	cacheConfig.FsScope = mockCacheConfig().FsScope
+	calculator, err = NewCacheCalculator(*job, cacheConfig, step.logger, step.mainFS, step.getWorkFlowStep().ExtraFS)
+	assert.Nil(t, err)
+	_, err = calculator.CalculateSecondFingerprint()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "path of fs_scope[abc] should be set explicitly")
+}
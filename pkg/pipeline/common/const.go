@@ -53,6 +53,7 @@ const (
 
 	CacheStrategyConservative = "conservative"
 	CacheStrategyAggressive   = "aggressive"
+	CacheStrategyContent      = "content"
 	CacheExpiredTimeNever     = "-1"
 
 	NodeTypeEntrypoint  NodeType = "entrypoints"
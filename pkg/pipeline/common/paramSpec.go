@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+// ParamSpec 为 pipeline 中可以在发起运行时传入的参数的定义
+// 对于 dict 形式的参数，类型、可选值、取值范围等均来自于其声明，对于普通参数，类型由默认值推断
+type ParamSpec struct {
+	Name        string        `json:"name"` // 参数全名，如 step1.lr, dag1.step1.lr
+	Type        string        `json:"type"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Min         *float64      `json:"min,omitempty"`
+	Max         *float64      `json:"max,omitempty"`
+	Required    bool          `json:"required"`
+	Description string        `json:"description,omitempty"`
+
+	dictParam *DictParam
+}
+
+// ParamFieldError 为单个参数的校验错误
+type ParamFieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ParamValidationError 包含了发起运行时传入参数的所有校验错误
+type ParamValidationError struct {
+	Errors []ParamFieldError `json:"errors"`
+}
+
+func (e *ParamValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("parameter[%s]: %s", fieldErr.Field, fieldErr.Reason))
+	}
+	return fmt.Sprintf("invalid parameters: %s", strings.Join(msgs, "; "))
+}
+
+// GetParamSpecs 返回 entry_points 及 post_process 中所有节点的参数定义，按参数全名排序
+// 引用了其他节点参数或者系统参数的参数，其值在运行时确定，不会被返回
+func GetParamSpecs(wfs *schema.WorkflowSource) []ParamSpec {
+	specs := []ParamSpec{}
+	specs = appendParamSpecs(specs, "", wfs.EntryPoints.EntryPoints, wfs.Components)
+	postComps := map[string]schema.Component{}
+	for name, step := range wfs.PostProcess {
+		postComps[name] = step
+	}
+	specs = appendParamSpecs(specs, "", postComps, wfs.Components)
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+func appendParamSpecs(specs []ParamSpec, prefix string, comps, templates map[string]schema.Component) []ParamSpec {
+	tplReg := regexp.MustCompile(RegExpIncludingTpl)
+	for compName, comp := range comps {
+		fullName := compName
+		if prefix != "" {
+			fullName = prefix + "." + compName
+		}
+
+		var refParams map[string]interface{}
+		if step, ok := comp.(*schema.WorkflowSourceStep); ok && step.Reference.Component != "" {
+			if template, ok := templates[step.Reference.Component]; ok {
+				refParams = template.GetParameters()
+			}
+		}
+
+		for paramName, param := range comp.GetParameters() {
+			if str, ok := param.(string); ok && tplReg.MatchString(str) {
+				continue
+			}
+			spec := ParamSpec{Name: fullName + "." + paramName}
+			// reference 节点中的参数，使用被引用节点中的声明，节点中的值作为默认值
+			if _, ok := param.(map[string]interface{}); !ok {
+				if refParam, ok := refParams[paramName].(map[string]interface{}); ok {
+					spec.fromDict(refParam)
+					spec.Default = param
+					specs = append(specs, spec)
+					continue
+				}
+			}
+			spec.from(param)
+			specs = append(specs, spec)
+		}
+
+		if dag, ok := comp.(*schema.WorkflowSourceDag); ok {
+			specs = appendParamSpecs(specs, fullName, dag.EntryPoints, templates)
+		}
+	}
+	return specs
+}
+
+func (p *ParamSpec) from(param interface{}) {
+	switch param := param.(type) {
+	case map[string]interface{}:
+		p.fromDict(param)
+	case int, int32, int64:
+		p.Type, p.Default = ParamTypeInt, param
+	case float32, float64:
+		p.Type, p.Default = ParamTypeFloat, param
+	case []interface{}:
+		p.Type, p.Default = ParamTypeList, param
+	default:
+		p.Type, p.Default = ParamTypeString, param
+	}
+}
+
+func (p *ParamSpec) fromDict(param map[string]interface{}) {
+	dictParam := DictParam{}
+	if err := dictParam.From(param); err != nil {
+		p.Type, p.Default = ParamTypeString, param
+		return
+	}
+	p.Type = dictParam.Type
+	p.Default = dictParam.Default
+	p.Enum = dictParam.Enum
+	p.Min = dictParam.Min
+	p.Max = dictParam.Max
+	p.Required = dictParam.Required
+	p.Description = dictParam.Description
+	p.dictParam = &dictParam
+}
+
+// ValidateRunParams 在创建 run 之前，根据参数定义校验发起运行时传入的参数，并返回所有参数的校验错误
+// 参数名可以为参数全名，如 step1.lr，也可以只有参数名，如 lr，此时会校验所有同名的参数
+// 普通参数沿用运行时的校验逻辑，这里只校验 dict 形式的参数
+func ValidateRunParams(wfs *schema.WorkflowSource, params map[string]interface{}) error {
+	specs := GetParamSpecs(wfs)
+	fieldErrors := []ParamFieldError{}
+	for field, value := range params {
+		matched := false
+		for _, spec := range specs {
+			// 与 replaceRunParam 一致，带有节点名的参数只匹配对应节点的参数，否则匹配所有同名参数
+			if strings.Contains(field, ".") && spec.Name != field ||
+				!strings.Contains(field, ".") && !strings.HasSuffix(spec.Name, "."+field) {
+				continue
+			}
+			matched = true
+			if spec.dictParam == nil {
+				continue
+			}
+			if value == nil || value == "" {
+				if spec.Required {
+					fieldErrors = append(fieldErrors, ParamFieldError{Field: field, Reason: "value should not be empty"})
+					break
+				}
+				continue
+			}
+			if _, err := CheckDictParam(*spec.dictParam, field, value); err != nil {
+				fieldErrors = append(fieldErrors, ParamFieldError{Field: field, Reason: err.Error()})
+				break
+			}
+		}
+		if !matched {
+			fieldErrors = append(fieldErrors, ParamFieldError{Field: field, Reason: "parameter not exist"})
+		}
+	}
+
+	for _, spec := range specs {
+		if !spec.Required {
+			continue
+		}
+		paramName := spec.Name[strings.LastIndex(spec.Name, ".")+1:]
+		_, ok1 := params[spec.Name]
+		_, ok2 := params[paramName]
+		if !ok1 && !ok2 {
+			fieldErrors = append(fieldErrors, ParamFieldError{Field: spec.Name, Reason: RequiredParamError(spec.Name).Error()})
+		}
+	}
+
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	sort.Slice(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Field < fieldErrors[j].Field
+	})
+	return &ParamValidationError{Errors: fieldErrors}
+}
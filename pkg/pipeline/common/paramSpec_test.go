@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+const paramSpecYaml = `
+name: typed_params
+docker_env: images/training.tgz
+entry_points:
+  train:
+    command: "python train.py {{lr}} {{epoch}} {{optimizer}}"
+    parameters:
+      lr:
+        type: float
+        default: 0.1
+        min: 0
+        max: 1
+        description: learning rate
+      epoch:
+        type: int
+        required: true
+      optimizer:
+        type: string
+        default: sgd
+        enum: [sgd, adam]
+      batch: 32
+  eval:
+    deps: train
+    command: "python eval.py {{model}}"
+    parameters:
+      model: "{{train.optimizer}}"
+      threshold: 0.5
+`
+
+func TestCheckDictParamConstraints(t *testing.T) {
+	min, max := float64(0), float64(1)
+	dict := DictParam{Type: ParamTypeFloat, Default: 0.1, Min: &min, Max: &max}
+	val, err := CheckDictParam(dict, "lr", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.1, val)
+
+	_, err = CheckDictParam(dict, "lr", 1.5)
+	assert.NotNil(t, err)
+	assert.Equal(t, "value[1.5] of param[lr] should be less than or equal to 1", err.Error())
+
+	_, err = CheckDictParam(dict, "lr", -1)
+	assert.NotNil(t, err)
+	assert.Equal(t, "value[-1] of param[lr] should be greater than or equal to 0", err.Error())
+
+	dict = DictParam{Type: ParamTypeFloat, Default: 0.1, Min: &max, Max: &min}
+	_, err = CheckDictParam(dict, "lr", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "min[1] is greater than max[0] in dict param[lr]", err.Error())
+
+	dict = DictParam{Type: ParamTypeString, Default: "sgd", Enum: []interface{}{"sgd", "adam"}}
+	_, err = CheckDictParam(dict, "optimizer", "adam")
+	assert.Nil(t, err)
+	_, err = CheckDictParam(dict, "optimizer", "rmsprop")
+	assert.NotNil(t, err)
+	assert.Equal(t, "value[rmsprop] of param[optimizer] should be one of [sgd adam]", err.Error())
+
+	dict = DictParam{Type: ParamTypeString, Default: "sgd", Min: &min}
+	_, err = CheckDictParam(dict, "optimizer", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "min and max can only be setted for param[optimizer] with type [int] or [float]", err.Error())
+
+	// json 中的整数为 float64，int 类型的参数会转换为 int64
+	dict = DictParam{Type: ParamTypeInt, Required: true}
+	val, err = CheckDictParam(dict, "epoch", float64(10))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), val)
+	_, err = CheckDictParam(dict, "epoch", 10.5)
+	assert.NotNil(t, err)
+
+	// 必填参数可以没有默认值，但未传入值时返回参数缺失的错误
+	val, err = CheckDictParam(dict, "epoch", nil)
+	assert.True(t, errors.Is(err, ErrRequiredParamMissing))
+	assert.Nil(t, val)
+}
+
+func TestGetParamSpecs(t *testing.T) {
+	wfs, err := schema.GetWorkflowSource([]byte(paramSpecYaml))
+	assert.Nil(t, err)
+
+	min, max := float64(0), float64(1)
+	specs := GetParamSpecs(&wfs)
+	for i := range specs {
+		specs[i].dictParam = nil
+	}
+	assert.Equal(t, []ParamSpec{
+		{Name: "eval.threshold", Type: ParamTypeFloat, Default: 0.5},
+		{Name: "train.batch", Type: ParamTypeInt, Default: int64(32)},
+		{Name: "train.epoch", Type: ParamTypeInt, Required: true},
+		{Name: "train.lr", Type: ParamTypeFloat, Default: 0.1, Min: &min, Max: &max, Description: "learning rate"},
+		{Name: "train.optimizer", Type: ParamTypeString, Default: "sgd", Enum: []interface{}{"sgd", "adam"}},
+	}, specs)
+}
+
+func TestValidateRunParams(t *testing.T) {
+	wfs, err := schema.GetWorkflowSource([]byte(paramSpecYaml))
+	assert.Nil(t, err)
+
+	err = ValidateRunParams(&wfs, map[string]interface{}{"epoch": float64(10), "train.lr": 0.5})
+	assert.Nil(t, err)
+
+	err = ValidateRunParams(&wfs, map[string]interface{}{
+		"lr":        2,
+		"optimizer": "rmsprop",
+		"unknown":   1,
+		"batch":     "not checked",
+	})
+	assert.NotNil(t, err)
+	paramErr, ok := err.(*ParamValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []ParamFieldError{
+		{Field: "lr", Reason: "value[2] of param[lr] should be less than or equal to 1"},
+		{Field: "optimizer", Reason: "value[rmsprop] of param[optimizer] should be one of [sgd adam]"},
+		{Field: "train.epoch", Reason: "param[train.epoch] is required"},
+		{Field: "unknown", Reason: "parameter not exist"},
+	}, paramErr.Errors)
+	assert.Equal(t, "invalid parameters: parameter[lr]: value[2] of param[lr] should be less than or equal to 1; "+
+		"parameter[optimizer]: value[rmsprop] of param[optimizer] should be one of [sgd adam]; "+
+		"parameter[train.epoch]: param[train.epoch] is required; parameter[unknown]: parameter not exist", err.Error())
+
+	// 带有节点名的参数只匹配对应节点中的参数
+	err = ValidateRunParams(&wfs, map[string]interface{}{"epoch": 1, "eval.lr": 0.5})
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid parameters: parameter[eval.lr]: parameter not exist", err.Error())
+}
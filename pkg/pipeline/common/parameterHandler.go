@@ -17,7 +17,9 @@ limitations under the License.
 package common
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	"strings"
@@ -27,9 +29,20 @@ import (
 	"github.com/mitchellh/mapstructure"
 )
 
+// DictParam 为dict形式的参数定义，除类型和默认值外，还可以声明可选值、取值范围、是否必填以及描述
 type DictParam struct {
-	Type    string
-	Default interface{}
+	Type        string
+	Default     interface{}
+	Enum        []interface{}
+	Min         *float64
+	Max         *float64
+	Required    bool
+	Description string
+}
+
+// String 只输出类型和默认值，用于错误信息中展示参数
+func (p DictParam) String() string {
+	return fmt.Sprintf("{Type:%s Default:%v}", p.Type, p.Default)
 }
 
 func (p *DictParam) From(origin interface{}) error {
@@ -55,6 +68,17 @@ func UnsupportedPathParamError(param interface{}, paramName string) error {
 	return fmt.Errorf("invalid path value[%s] in parameter[%s]", param, paramName)
 }
 
+func RequiredParamError(paramName string) error {
+	return fmt.Errorf("param[%s] is required", paramName)
+}
+
+// ErrRequiredParamMissing 表示必填参数既没有默认值也没有传入值
+var ErrRequiredParamMissing = errors.New("required parameter missing")
+
+func RequiredParamMissingError(paramName string) error {
+	return fmt.Errorf("param[%s] is required but missing: %w", paramName, ErrRequiredParamMissing)
+}
+
 func MismatchRegexError(param, regex string) error {
 	return fmt.Errorf("param[%s] mismatches regex pattern[%s]", param, regex)
 }
@@ -280,7 +304,7 @@ func (s *ComponentParamChecker) checkReference(comp schema.Component) error {
 					if err := refDictParam.From(referedParam); err != nil {
 						return fmt.Errorf("invalid dict parameter[%v]", referedParam)
 					}
-					if _, err := CheckDictParam(refDictParam, paramName, param); err != nil {
+					if _, err := CheckDictParam(refDictParam, paramName, param); err != nil && !errors.Is(err, ErrRequiredParamMissing) {
 						return fmt.Errorf("parameters in step with reference check dict param in refered param failed, error: %s", err.Error())
 					}
 				default:
@@ -337,7 +361,12 @@ func (s *ComponentParamChecker) solveParamValue(compName string, paramName strin
 		if err := dictParam.From(param); err != nil {
 			return nil, fmt.Errorf("invalid dict parameter[%s]", param)
 		}
-		return CheckDictParam(dictParam, paramName, nil)
+		realVal, err := CheckDictParam(dictParam, paramName, nil)
+		// 必填参数的值在发起运行时传入，并在创建run之前校验
+		if errors.Is(err, ErrRequiredParamMissing) {
+			return nil, nil
+		}
+		return realVal, err
 	default:
 		return nil, UnsupportedParamTypeError(param, paramName)
 	}
@@ -515,13 +544,56 @@ func CheckDictParam(dict DictParam, paramName string, realVal interface{}) (inte
 	if dict.Type == "" {
 		return nil, UnsupportedDictParamTypeError(dict.Type, paramName, dict)
 	}
+	if dict.Min != nil && dict.Max != nil && *dict.Min > *dict.Max {
+		return nil, fmt.Errorf("min[%v] is greater than max[%v] in dict param[%s]", *dict.Min, *dict.Max, paramName)
+	}
 	if realVal == nil || realVal == "" {
 		if dict.Default == nil || dict.Default == "" {
+			// 必填参数可以不设置默认值，其值在发起运行时由请求传入，由调用方决定是否允许缺失
+			if dict.Required {
+				return nil, RequiredParamMissingError(paramName)
+			}
 			return nil, fmt.Errorf("invalid value[%v] in dict param[name: %s, value: %+v]", dict.Default, paramName, dict)
 		}
 		realVal = dict.Default
 	}
 
+	realVal, err := checkDictParamType(dict, paramName, realVal)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dict.Enum) > 0 {
+		inEnum := false
+		for _, item := range dict.Enum {
+			if fmt.Sprintf("%v", item) == fmt.Sprintf("%v", realVal) {
+				inEnum = true
+				break
+			}
+		}
+		if !inEnum {
+			return nil, fmt.Errorf("value[%v] of param[%s] should be one of %v", realVal, paramName, dict.Enum)
+		}
+	}
+
+	if dict.Min != nil || dict.Max != nil {
+		if dict.Type != ParamTypeInt && dict.Type != ParamTypeFloat {
+			return nil, fmt.Errorf("min and max can only be setted for param[%s] with type [%s] or [%s]",
+				paramName, ParamTypeInt, ParamTypeFloat)
+		}
+		value := reflect.ValueOf(realVal).Convert(reflect.TypeOf(float64(0))).Float()
+		if dict.Min != nil && value < *dict.Min {
+			return nil, fmt.Errorf("value[%v] of param[%s] should be greater than or equal to %v", realVal, paramName, *dict.Min)
+		}
+		if dict.Max != nil && value > *dict.Max {
+			return nil, fmt.Errorf("value[%v] of param[%s] should be less than or equal to %v", realVal, paramName, *dict.Max)
+		}
+	}
+	return realVal, nil
+}
+
+// checkDictParamType 检查参数值的类型，json 中的整数会被解析为 float64，对于 int 类型的参数，会被转换为 int64
+func checkDictParamType(dict DictParam, paramName string, realVal interface{}) (interface{}, error) {
 	switch dict.Type {
 	case ParamTypeString:
 		_, ok := realVal.(string)
@@ -547,6 +619,9 @@ func CheckDictParam(dict DictParam, paramName string, realVal interface{}) (inte
 		if ok1 || ok2 || ok3 {
 			return realVal, nil
 		}
+		if value, ok := realVal.(float64); ok && value == math.Trunc(value) {
+			return int64(value), nil
+		}
 		return nil, InvalidParamTypeError(realVal, ParamTypeInt)
 	case ParamTypeList:
 		_, ok1 := realVal.([]float32)
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -53,6 +54,21 @@ func isRuntimeFinallyStatus(status RuntimeStatus) bool {
 	return false
 }
 
+// remainingTimeout: 根据开始时间计算距离超时的剩余时间，开始时间为空或者无法解析时，则返回完整的超时时间
+func remainingTimeout(timeout int, startTime string) time.Duration {
+	full := time.Duration(timeout) * time.Second
+	start, err := time.ParseInLocation("2006-01-02 15:04:05", startTime, time.Local)
+	if startTime == "" || err != nil {
+		return full
+	}
+
+	remaining := full - time.Since(start)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
 // 管理并发信息
 type parallelismManager struct {
 	ch chan struct{}
@@ -72,6 +88,16 @@ func (pm *parallelismManager) decrease() {
 	<-pm.ch
 }
 
+// tryIncrease: 在没有达到并发上限时占用一个并发数并返回 true，否则直接返回 false，不会 block
+func (pm *parallelismManager) tryIncrease() bool {
+	select {
+	case pm.ch <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
 func (pm *parallelismManager) CurrentParallelism() int {
 	return len(pm.ch)
 }
@@ -167,6 +193,9 @@ type baseComponentRuntime struct {
 	// 是否处于终态
 	done bool
 
+	// 进入终态时关闭，用于通知超时监听等协程及时退出
+	doneCh chan struct{}
+
 	// run 级别的相关配置
 	*runConfig
 
@@ -178,6 +207,9 @@ type baseComponentRuntime struct {
 
 	// 父节点ID
 	parentDagID string
+
+	// 循环结构设置了 parallelism 时，用于控制同一个节点的多次运行的并发数，运行结束时释放
+	loopParallelism *parallelismManager
 }
 
 func NewBaseComponentRuntime(name, fullname string, component schema.Component, seq int, ctx context.Context, failureOpitonsCtx context.Context,
@@ -194,6 +226,7 @@ func NewBaseComponentRuntime(name, fullname string, component schema.Component,
 		runConfig:            config,
 		parentDagID:          parentDagID,
 		failureOpitonsCtx:    failureOpitonsCtx,
+		doneCh:               make(chan struct{}),
 	}
 
 	isv := NewInnerSolver(component, fullname, config)
@@ -260,10 +293,30 @@ func (crt *baseComponentRuntime) updateStatus(status RuntimeStatus) error {
 
 	if isRuntimeFinallyStatus(crt.status) {
 		crt.done = true
+		crt.closeDoneCh()
+		if crt.loopParallelism != nil {
+			crt.loopParallelism.decrease()
+		}
 	}
 	return nil
 }
 
+func (crt *baseComponentRuntime) closeDoneCh() {
+	if crt.doneCh == nil {
+		return
+	}
+	select {
+	case <-crt.doneCh:
+	default:
+		close(crt.doneCh)
+	}
+}
+
+// finished: 返回在节点进入终态时关闭的 channel
+func (crt *baseComponentRuntime) finished() <-chan struct{} {
+	return crt.doneCh
+}
+
 // 获取当次运行时循环参数的值
 func (crt *baseComponentRuntime) getPFLoopArgument() (value interface{}, err error) {
 	// LoopArgument 在创建 Runtime 之前便已经由其父节点resolve 了
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -63,6 +64,20 @@ type DagRuntime struct {
 
 	failureOptionsCtxAndCancels map[string]CtxAndCancel
 	hasFailureOptionsTriggered  bool
+
+	// 因超时而被终止时的信息，非空说明 dag 已经超时
+	timeoutMsg string
+
+	// 由于 parallelism 的限制，循环结构中还没有被调度的运行
+	pendingLoops map[string]*pendingLoop
+}
+
+// pendingLoop 记录循环结构中等待调度的运行
+type pendingLoop struct {
+	// 已经完成模板替换的节点
+	component schema.Component
+	seqs      []int
+	*parallelismManager
 }
 
 func generateDagID(runID string) string {
@@ -83,6 +98,7 @@ func NewDagRuntime(name, fullName string, dag *schema.WorkflowSourceDag, seq int
 		ID:                          ID,
 		subComponentRumtimes:        make(map[string][]componentRuntime),
 		failureOptionsCtxAndCancels: make(map[string]CtxAndCancel),
+		pendingLoops:                make(map[string]*pendingLoop),
 	}
 
 	drt.generateViewName()
@@ -209,11 +225,11 @@ func (drt *DagRuntime) resolveReference(subComponentName string, subComponent sc
 
 // createAndStartSubComponentRuntime: 创建并运行子节点 runtime
 // 无需返回 error 原因是将通过 event 来进行同步
+// pm 为 resume 或者 restart 时已经被仍在运行的 runtime 占用了并发数的并发控制，为 nil 时按需新建
 func (drt *DagRuntime) createAndStartSubComponentRuntime(subComponentName string, subComponent schema.Component,
-	exceptSeq map[int]int) {
+	exceptSeq map[int]int, pm *parallelismManager) {
 
 	subName := drt.generateSubRuntimeName(subComponentName, 0)
-	subFullName := drt.generateSubComponentFullName(subComponentName)
 	drt.logger.Infof("begin to create runtime for %s[%s]", subComponent.GetType(), subName)
 
 	// 如果已经有子节点对应的 runtime, 则说明该节点已经被调度过了. 此时终止任务
@@ -252,31 +268,133 @@ func (drt *DagRuntime) createAndStartSubComponentRuntime(subComponentName string
 		ll = 1
 	}
 
-	// 同一个节点的多次运行，共享同一个 failureOptionsCtx
-	ctxAndCc := drt.getfailureOptionsCtxAndCF(subComponentName)
-	step, isStep := newSubComponent.(*schema.WorkflowSourceStep)
-	dag, _ := newSubComponent.(*schema.WorkflowSourceDag)
+	// 设置了 parallelism 的循环结构，超出并发上限的运行需要等到有运行结束后再调度
+	if pm == nil {
+		pm = newLoopParallelismManager(newSubComponent)
+	}
 
+	pendingSeqs := []int{}
 	for index := 0; index < ll; index++ {
 		if _, ok := exceptSeq[index]; ok {
 			continue
 		}
 
-		subName = drt.generateSubRuntimeName(subComponentName, index)
-		var subRuntime componentRuntime
-		if isStep {
-			// 这里需要对 step 进行复制， 避免多个subRuntime 使用了同一个 component， 导致并发问题
-			subRuntime = NewStepRuntime(subName, subFullName, step.DeepCopy().(*schema.WorkflowSourceStep), index,
-				drt.ctx, ctxAndCc.ctx, drt.receiveEventChildren, drt.runConfig, drt.ID)
-		} else {
-			subRuntime = NewDagRuntime(subName, subFullName, dag.DeepCopy().(*schema.WorkflowSourceDag), index,
-				drt.ctx, ctxAndCc.ctx, drt.receiveEventChildren, drt.runConfig, drt.ID)
+		if pm != nil && !pm.tryIncrease() {
+			pendingSeqs = append(pendingSeqs, index)
+			continue
+		}
+		drt.startSubComponentRuntime(subComponentName, newSubComponent, index, pm)
+	}
+
+	if len(pendingSeqs) != 0 {
+		drt.logger.Infof("%d runs of %s[%s] are pending because of parallelism", len(pendingSeqs),
+			newSubComponent.GetType(), subName)
+		drt.pendingLoops[subComponentName] = &pendingLoop{
+			component:          newSubComponent,
+			seqs:               pendingSeqs,
+			parallelismManager: pm,
 		}
-		drt.subComponentRumtimes[subComponentName] = append(drt.subComponentRumtimes[subComponentName], subRuntime)
+	}
+}
+
+// startSubComponentRuntime: 创建并运行子节点的第 seq 次运行
+func (drt *DagRuntime) startSubComponentRuntime(subComponentName string, subComponent schema.Component, seq int,
+	pm *parallelismManager) {
+	subName := drt.generateSubRuntimeName(subComponentName, seq)
+	subFullName := drt.generateSubComponentFullName(subComponentName)
+
+	// 同一个节点的多次运行，共享同一个 failureOptionsCtx
+	ctxAndCc := drt.getfailureOptionsCtxAndCF(subComponentName)
+
+	var subRuntime componentRuntime
+	if step, isStep := subComponent.(*schema.WorkflowSourceStep); isStep {
+		// 这里需要对 step 进行复制， 避免多个subRuntime 使用了同一个 component， 导致并发问题
+		srt := NewStepRuntime(subName, subFullName, step.DeepCopy().(*schema.WorkflowSourceStep), seq,
+			drt.ctx, ctxAndCc.ctx, drt.receiveEventChildren, drt.runConfig, drt.ID)
+		srt.loopParallelism = pm
+		subRuntime = srt
+	} else {
+		dag := subComponent.(*schema.WorkflowSourceDag)
+		sDrt := NewDagRuntime(subName, subFullName, dag.DeepCopy().(*schema.WorkflowSourceDag), seq,
+			drt.ctx, ctxAndCc.ctx, drt.receiveEventChildren, drt.runConfig, drt.ID)
+		sDrt.loopParallelism = pm
+		subRuntime = sDrt
+	}
+	drt.subComponentRumtimes[subComponentName] = append(drt.subComponentRumtimes[subComponentName], subRuntime)
+
+	drt.logger.Infof("begion to run %s[%s]", subComponent.GetType(), subRuntime.getName())
+	go subRuntime.Start()
+}
+
+// scheduleLoopIterations: 在循环结构中有运行结束后，调度等待中的运行
+func (drt *DagRuntime) scheduleLoopIterations() {
+	for name, pl := range drt.pendingLoops {
+		// 如果此时收到了终止信号，则等待中的运行无需再调度
+		if drt.ctx.Err() != nil || drt.failureOpitonsCtx.Err() != nil ||
+			drt.getfailureOptionsCtxAndCF(name).ctx.Err() != nil {
+			drt.cancelPendingLoop(name, "receive termination signal before scheduled")
+			continue
+		}
+
+		for len(pl.seqs) != 0 && pl.tryIncrease() {
+			drt.startSubComponentRuntime(name, pl.component, pl.seqs[0], pl.parallelismManager)
+			pl.seqs = pl.seqs[1:]
+		}
+
+		if len(pl.seqs) == 0 {
+			delete(drt.pendingLoops, name)
+		}
+	}
+}
+
+// cancelPendingLoop: 将循环结构中等待调度的运行置为 cancelled 状态
+func (drt *DagRuntime) cancelPendingLoop(name string, reason string) {
+	pl, ok := drt.pendingLoops[name]
+	if !ok {
+		return
+	}
+
+	drt.logger.Infof("begin to cancel %d pending runs of %s[%s]: %s", len(pl.seqs), pl.component.GetType(),
+		drt.generateSubComponentFullName(name), reason)
+	for _, seq := range pl.seqs {
+		drt.processSubRuntimeErrorWithSeq(reason, pl.component, StatusRuntimeCancelled, seq)
+	}
+	delete(drt.pendingLoops, name)
+}
+
+// newLoopParallelismManager: 为设置了 parallelism 的循环结构创建并发控制，没有设置或者不是循环结构时返回 nil
+func newLoopParallelismManager(component schema.Component) *parallelismManager {
+	parallelism := getLoopParallelism(component)
+	if parallelism <= 0 || component.GetLoopArgumentLength() <= 1 {
+		return nil
+	}
+	return NewParallelismManager(parallelism)
+}
+
+// occupyLoopParallelism: resume 或者 restart 时根据 view 重新创建且仍需运行的 runtime 占用一个并发数，
+// 并在运行结束时释放，需要在 runtime 开始运行之前调用
+func occupyLoopParallelism(runtime componentRuntime, pm *parallelismManager) {
+	if pm == nil || !pm.tryIncrease() {
+		return
+	}
+	switch runtime := runtime.(type) {
+	case *StepRuntime:
+		runtime.loopParallelism = pm
+	case *DagRuntime:
+		runtime.loopParallelism = pm
+	default:
+		pm.decrease()
+	}
+}
 
-		drt.logger.Infof("begion to run %s[%s]", newSubComponent.GetType(), subRuntime.getName())
-		go subRuntime.Start()
+func getLoopParallelism(component schema.Component) int {
+	switch component := component.(type) {
+	case *schema.WorkflowSourceStep:
+		return component.Parallelism
+	case *schema.WorkflowSourceDag:
+		return component.Parallelism
 	}
+	return 0
 }
 
 func (drt *DagRuntime) getworkflowSouceDag() *schema.WorkflowSourceDag {
@@ -345,6 +463,9 @@ func (drt *DagRuntime) Start() {
 	// 监听子节点已经父节点传递过来的事件或者信号
 	go drt.Listen()
 	go drt.Stop()
+	if timeout := drt.getworkflowSouceDag().Timeout; timeout > 0 {
+		go drt.watchTimeout(time.Duration(timeout) * time.Second)
+	}
 
 	// 开始调度子节点
 	drt.scheduleSubComponent()
@@ -386,7 +507,7 @@ func (drt *DagRuntime) scheduleSubComponent() {
 		}
 
 		// 4. 创建 runtime 并运行 runtime
-		drt.createAndStartSubComponentRuntime(subComponentName, newSubCp, map[int]int{})
+		drt.createAndStartSubComponentRuntime(subComponentName, newSubCp, map[int]int{}, nil)
 	}
 }
 
@@ -476,6 +597,8 @@ func (drt *DagRuntime) Resume(dagView *schema.DagView) {
 
 	// 用于记录在循环结构中，还没来得及调度的 runtime， 需要在第一时间进行调度
 	exceptCpSeq := map[string]map[int]int{}
+	// 循环结构的并发控制，已经处于 running 状态的 runtime 会占用并发数
+	loopPms := map[string]*parallelismManager{}
 
 	// 用于记录已经运行失败的节点，据此触发 failureOptions
 	failedCp := map[string][]int{}
@@ -509,6 +632,7 @@ func (drt *DagRuntime) Resume(dagView *schema.DagView) {
 		}
 
 		exceptSeq := map[int]int{}
+		pm := newLoopParallelismManager(component)
 		_, isStep := component.(*schema.WorkflowSourceStep)
 		for _, view := range views {
 			exceptSeq[view.GetSeq()] = 1
@@ -539,6 +663,7 @@ func (drt *DagRuntime) Resume(dagView *schema.DagView) {
 					failedCp[name] = append(failedCp[name], view.GetSeq())
 				}
 			} else {
+				occupyLoopParallelism(runtime, pm)
 				if isStep {
 					go runtime.(*StepRuntime).Resume(view.(*schema.JobView))
 				} else {
@@ -564,6 +689,7 @@ func (drt *DagRuntime) Resume(dagView *schema.DagView) {
 			} else {
 				// 需要被正常的创建的与调度
 				exceptCpSeq[name] = exceptSeq
+				loopPms[name] = pm
 			}
 		}
 	}
@@ -571,7 +697,7 @@ func (drt *DagRuntime) Resume(dagView *schema.DagView) {
 	// 需要保证已经处于 running 状态 Runtime 占到坑，然后在处理还没有来得及调度的runtime
 	for name, exceptSeq := range exceptCpSeq {
 		cp := drt.getworkflowSouceDag().EntryPoints[name]
-		drt.createAndStartSubComponentRuntime(name, cp, exceptSeq)
+		drt.createAndStartSubComponentRuntime(name, cp, exceptSeq, loopPms[name])
 	}
 
 	// 根据 failed 节点， 来依次触发 failureOptions
@@ -602,6 +728,9 @@ func (drt *DagRuntime) Resume(dagView *schema.DagView) {
 	// 在最后才进入listen状态，为了避免在resume A 子节点的过程中，监听到了B 发过来的事件，有一次调度了子节点A
 	go drt.Listen()
 	go drt.Stop()
+	if timeout := drt.getworkflowSouceDag().Timeout; timeout > 0 {
+		go drt.watchTimeout(remainingTimeout(timeout, drt.startTime))
+	}
 
 }
 
@@ -645,6 +774,9 @@ func (drt *DagRuntime) Restart(dagView *schema.DagView) {
 	// 3、处理完所有的view 后 才开始 监听信号, 主要是为了在还没有处理完 view 中新，便接受到了事件， 导致在 view 中存在的节点再次被调度
 	go drt.Listen()
 	go drt.Stop()
+	if timeout := drt.getworkflowSouceDag().Timeout; timeout > 0 {
+		go drt.watchTimeout(time.Duration(timeout) * time.Second)
+	}
 
 	// 4、这里做一次调度的原因是，避免 3 中没有发起任何任务，导致永远监听不到信息，导致任务 hang 住的情况出现
 	drt.scheduleSubComponent()
@@ -785,6 +917,7 @@ func (drt *DagRuntime) scheduleSubComponentAccordingView(dagView *schema.DagView
 		}
 
 		// 对于restart 的场景，节点的 loop_argument 的长度一定与view的数量 相等
+		pm := newLoopParallelismManager(component)
 		_, isStep := component.(*schema.WorkflowSourceStep)
 		for _, view := range views {
 			status := view.GetStatus()
@@ -813,12 +946,22 @@ func (drt *DagRuntime) scheduleSubComponentAccordingView(dagView *schema.DagView
 				continue
 			}
 
+			occupyLoopParallelism(runtime, pm)
 			if isStep {
 				go runtime.(*StepRuntime).Restart(view.(*schema.JobView))
 			} else {
 				go runtime.(*DagRuntime).Restart(view.(*schema.DagView))
 			}
 		}
+
+		// 受 parallelism 的限制，循环结构中可能有还没有来得及调度的运行，需要补齐
+		if len(views) < component.GetLoopArgumentLength() {
+			exceptSeq := map[int]int{}
+			for _, view := range views {
+				exceptSeq[view.GetSeq()] = 1
+			}
+			drt.createAndStartSubComponentRuntime(name, drt.getworkflowSouceDag().EntryPoints[name], exceptSeq, pm)
+		}
 	}
 
 	return
@@ -843,6 +986,31 @@ func (drt *DagRuntime) GetSubComponentParameterValue(componentName string, param
 	return value, nil
 }
 
+// GetSubComponentParameterValues: 按照运行的次序获取节点每次运行的 parameter 的值，用于汇总循环结构的输出
+func (drt *DagRuntime) GetSubComponentParameterValues(componentName string, paramName string) ([]interface{}, error) {
+	subComponents, ok := drt.subComponentRumtimes[componentName]
+	if !ok {
+		err := fmt.Errorf("cannot get the value of parameter[%s] from subDag or subStep[%s], "+
+			"because there is no runtime for that subDag or subStep in dag[%s]", paramName, drt.name+"."+componentName,
+			drt.name)
+		return nil, err
+	}
+
+	subComponents = append([]componentRuntime{}, subComponents...)
+	sort.SliceStable(subComponents, func(i, j int) bool {
+		return subComponents[i].getSeq() < subComponents[j].getSeq()
+	})
+	values := make([]interface{}, 0, len(subComponents))
+	for index := range subComponents {
+		value, err := subComponents[index].getComponent().GetParameterValue(paramName)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
 func (drt *DagRuntime) GetSubComponentArtifactPaths(componentName string, artName string) (string, error) {
 	var value []string
 	subComponents, ok := drt.subComponentRumtimes[componentName]
@@ -852,6 +1020,11 @@ func (drt *DagRuntime) GetSubComponentArtifactPaths(componentName string, artNam
 			artName, drt.name+"."+componentName, drt.name)
 		return "", err
 	} else {
+		// 按照运行的次序拼接，以便下游节点能够与循环结构的每次运行一一对应
+		subComponents = append([]componentRuntime{}, subComponents...)
+		sort.SliceStable(subComponents, func(i, j int) bool {
+			return subComponents[i].getSeq() < subComponents[j].getSeq()
+		})
 		for index := range subComponents {
 			p, err := subComponents[index].getComponent().GetArtifactPath(artName)
 			if err != nil {
@@ -899,6 +1072,9 @@ func (drt *DagRuntime) processEventFromSubComponent(event WorkflowEvent) error {
 		}
 	}
 
+	// 调度循环结构中因为 parallelism 限制而等待中的运行
+	drt.scheduleLoopIterations()
+
 	StatusMsg := drt.updateStatusAccordingSubComponentRuntimeStatus()
 	view := drt.newView(StatusMsg)
 	drt.syncToApiServerAndParent(WfEventDagUpdate, &view, StatusMsg)
@@ -1005,6 +1181,7 @@ func (drt *DagRuntime) ProcessFailureOptionsWithFailFast() {
 		_, ok := drt.subComponentRumtimes[name]
 		if ok {
 			drt.getfailureOptionsCtxAndCF(name).cancel()
+			drt.cancelPendingLoop(name, "receive failure options signal")
 		}
 	}
 
@@ -1193,7 +1370,11 @@ func (drt *DagRuntime) updateStatusAccordingSubComponentRuntimeStatus() string {
 
 	var msg string
 	var err error
-	if len(faieldComponentNames) != 0 {
+	if drt.timeoutMsg != "" {
+		// 因超时而终止的 dag，统一视为失败，以便其父节点按照 failure_options 进行处理
+		err = drt.updateStatus(StatusRuntimeFailed)
+		msg = drt.timeoutMsg
+	} else if len(faieldComponentNames) != 0 {
 		err = drt.updateStatus(StatusRuntimeFailed)
 		msg = fmt.Sprintf("update dag[%s]'s status to [%s] due to subSteps or subDags[%s] faield",
 			drt.name, StatusRuntimeFailed, strings.Join(faieldComponentNames, ","))
@@ -1280,6 +1461,9 @@ func (drt *DagRuntime) newView(msg string) schema.DagView {
 // stopByCtx: 在监测到底 ctx 的信号后，开始终止逻辑
 func (drt *DagRuntime) stopByCtx() {
 	// 对于已经调度了节点，其本身也会监听 ctx 信号, 执行终止相关的逻辑，因此，此处只需要处理还未被调度的节点
+	for name := range drt.pendingLoops {
+		drt.cancelPendingLoop(name, "receive stop signall")
+	}
 	drt.cancellAllNotReadySubComponent("receive stop signall")
 }
 
@@ -1316,3 +1500,41 @@ func (drt *DagRuntime) Stop() {
 		drt.ProcessFailureOptionsWithFailFast()
 	}
 }
+
+// watchTimeout: 在超时后终止 dag
+func (drt *DagRuntime) watchTimeout(remaining time.Duration) {
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-drt.finished():
+		return
+	case <-drt.ctx.Done():
+		return
+	case <-drt.failureOpitonsCtx.Done():
+		return
+	}
+
+	drt.stopByTimeout(fmt.Sprintf("dag[%s] timed out after %ds", drt.name, drt.getworkflowSouceDag().Timeout))
+}
+
+// stopByTimeout: 终止所有已经调度的子节点，并取消还未调度的子节点，待所有子节点结束后，dag 的状态将会被置为 failed
+func (drt *DagRuntime) stopByTimeout(msg string) {
+	defer drt.processSubComponentLock.Unlock()
+	drt.processSubComponentLock.Lock()
+
+	if drt.done {
+		return
+	}
+
+	drt.logger.Warningf(msg)
+	drt.timeoutMsg = msg
+
+	err := drt.updateStatus(StatusRuntimeTerminating)
+	if err != nil {
+		drt.logger.Errorf(err.Error())
+	}
+
+	drt.ProcessFailureOptionsWithFailFast()
+}
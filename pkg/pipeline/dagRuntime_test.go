@@ -206,7 +206,7 @@ func TestCreateAndStartSubComponentRuntime(t *testing.T) {
 	})
 	defer patch1.Reset()
 
-	drt.createAndStartSubComponentRuntime("randint", st, map[int]int{})
+	drt.createAndStartSubComponentRuntime("randint", st, map[int]int{}, nil)
 	assert.Len(t, drt.subComponentRumtimes, 1)
 	assert.Len(t, drt.subComponentRumtimes["randint"], 1)
 	assert.False(t, stepStarted)
@@ -218,7 +218,7 @@ func TestCreateAndStartSubComponentRuntime(t *testing.T) {
 	defer patch2.Reset()
 
 	drt.getworkflowSouceDag().EntryPoints["square-loop"].UpdateLoopArguemt([]int{1, 2, 3})
-	drt.createAndStartSubComponentRuntime("square-loop", drt.getworkflowSouceDag().EntryPoints["square-loop"], map[int]int{})
+	drt.createAndStartSubComponentRuntime("square-loop", drt.getworkflowSouceDag().EntryPoints["square-loop"], map[int]int{}, nil)
 
 	time.Sleep(time.Millisecond * 100)
 	assert.True(t, dagStarted)
@@ -228,6 +228,86 @@ func TestCreateAndStartSubComponentRuntime(t *testing.T) {
 	assert.Equal(t, drt.subComponentRumtimes["square-loop"][1].getName(), "a.entrypoint.square-loop-1")
 }
 
+func TestCreateAndStartSubComponentRuntimeWithParallelism(t *testing.T) {
+	eventChan := make(chan WorkflowEvent)
+	drt, err := mockerDagRuntime(eventChan)
+	assert.Nil(t, err)
+
+	// square-loop 依赖于 randint 的输出 artifact
+	st := drt.getworkflowSouceDag().EntryPoints["randint"].(*schema.WorkflowSourceStep)
+	srt := NewStepRuntime("a.entrypoint.randint", "a.entrypoint.randint", st, 0, drt.ctx, drt.failureOpitonsCtx,
+		drt.receiveEventChildren, drt.runConfig, drt.ID)
+	drt.subComponentRumtimes["randint"] = append(drt.subComponentRumtimes["randint"], srt)
+
+	var sDrt *DagRuntime
+	patch := gomonkey.ApplyMethod(reflect.TypeOf(sDrt), "Start", func(_ *DagRuntime) {})
+	defer patch.Reset()
+
+	loop := drt.getworkflowSouceDag().EntryPoints["square-loop"].(*schema.WorkflowSourceDag)
+	loop.UpdateLoopArguemt([]int{1, 2, 3})
+	loop.Parallelism = 1
+	drt.createAndStartSubComponentRuntime("square-loop", loop, map[int]int{}, nil)
+
+	// 超出 parallelism 的运行处于等待状态
+	assert.Len(t, drt.subComponentRumtimes["square-loop"], 1)
+	assert.Equal(t, []int{1, 2}, drt.pendingLoops["square-loop"].seqs)
+
+	// 运行中的 runtime 没有结束时，不会调度新的运行
+	drt.scheduleLoopIterations()
+	assert.Len(t, drt.subComponentRumtimes["square-loop"], 1)
+
+	drt.subComponentRumtimes["square-loop"][0].updateStatus(StatusRuntimeSucceeded)
+	drt.scheduleLoopIterations()
+	assert.Len(t, drt.subComponentRumtimes["square-loop"], 2)
+	assert.Equal(t, 1, drt.subComponentRumtimes["square-loop"][1].getSeq())
+	assert.Equal(t, []int{2}, drt.pendingLoops["square-loop"].seqs)
+
+	// 收到终止信号后，等待中的运行会被取消
+	drt.getfailureOptionsCtxAndCF("square-loop").cancel()
+	drt.scheduleLoopIterations()
+	assert.Len(t, drt.subComponentRumtimes["square-loop"], 3)
+	assert.True(t, drt.subComponentRumtimes["square-loop"][2].isCancelled())
+	assert.Len(t, drt.pendingLoops, 0)
+}
+
+func TestCreateAndStartSubComponentRuntimeWithOccupiedParallelism(t *testing.T) {
+	eventChan := make(chan WorkflowEvent)
+	drt, err := mockerDagRuntime(eventChan)
+	assert.Nil(t, err)
+
+	st := drt.getworkflowSouceDag().EntryPoints["randint"].(*schema.WorkflowSourceStep)
+	srt := NewStepRuntime("a.entrypoint.randint", "a.entrypoint.randint", st, 0, drt.ctx, drt.failureOpitonsCtx,
+		drt.receiveEventChildren, drt.runConfig, drt.ID)
+	drt.subComponentRumtimes["randint"] = append(drt.subComponentRumtimes["randint"], srt)
+
+	var sDrt *DagRuntime
+	patch := gomonkey.ApplyMethod(reflect.TypeOf(sDrt), "Start", func(_ *DagRuntime) {})
+	defer patch.Reset()
+
+	loop := drt.getworkflowSouceDag().EntryPoints["square-loop"].(*schema.WorkflowSourceDag)
+	loop.UpdateLoopArguemt([]int{1, 2, 3})
+	loop.Parallelism = 2
+	pm := newLoopParallelismManager(loop)
+	assert.NotNil(t, pm)
+
+	// resume 时仍在运行的 runtime 占用并发数
+	resumed := NewDagRuntime("a.entrypoint.square-loop", "a.entrypoint.square-loop", loop, 0, drt.ctx,
+		drt.failureOpitonsCtx, drt.receiveEventChildren, drt.runConfig, drt.ID)
+	occupyLoopParallelism(resumed, pm)
+	assert.Equal(t, 1, pm.CurrentParallelism())
+	drt.subComponentRumtimes["square-loop"] = []componentRuntime{resumed}
+
+	drt.createAndStartSubComponentRuntime("square-loop", loop, map[int]int{0: 1}, pm)
+	assert.Len(t, drt.subComponentRumtimes["square-loop"], 2)
+	assert.Equal(t, []int{2}, drt.pendingLoops["square-loop"].seqs)
+
+	// resume 的 runtime 结束后释放并发数
+	resumed.updateStatus(StatusRuntimeSucceeded)
+	drt.scheduleLoopIterations()
+	assert.Len(t, drt.subComponentRumtimes["square-loop"], 3)
+	assert.Len(t, drt.pendingLoops, 0)
+}
+
 func TestDagRuntimeStart(t *testing.T) {
 	eventChan := make(chan WorkflowEvent)
 	drt, err := mockerDagRuntime(eventChan)
@@ -686,3 +766,32 @@ func TestProcessFailureOptions(t *testing.T) {
 
 	assert.Len(t, drt.subComponentRumtimes, 7)
 }
+
+func TestDagRuntimeStopByTimeout(t *testing.T) {
+	eventChan := make(chan WorkflowEvent)
+	drt, err := mockerDagRuntime(eventChan)
+	assert.Nil(t, err)
+
+	drt3 := NewDagRuntime("a.entrypoint.square-loop", "a.entrypoint.square-loop",
+		drt.getworkflowSouceDag().EntryPoints["square-loop"].(*schema.WorkflowSourceDag),
+		0, drt.ctx, drt.getfailureOptionsCtxAndCF("square-loop").ctx, drt.receiveEventChildren, drt.runConfig, drt.ID)
+	drt.subComponentRumtimes["square-loop"] = append(drt.subComponentRumtimes["square-loop"], drt3)
+
+	msg := "dag[a.entrypoint] timed out after 1s"
+	drt.stopByTimeout(msg)
+	time.Sleep(time.Millisecond * 100)
+
+	// 已经调度的子节点将会收到终止信号，还没有调度的子节点将会被取消
+	assert.Equal(t, StatusRuntimeTerminating, drt.status)
+	assert.NotNil(t, drt.getfailureOptionsCtxAndCF("square-loop").ctx.Err())
+	assert.Len(t, drt.subComponentRumtimes, 7)
+
+	drt3.updateStatus(StatusRuntimeTerminated)
+	assert.Equal(t, msg, drt.updateStatusAccordingSubComponentRuntimeStatus())
+	assert.Equal(t, StatusRuntimeFailed, drt.status)
+
+	// 已经处于终态的 dag 不会再因为超时而被终止
+	drt.timeoutMsg = ""
+	drt.stopByTimeout(msg)
+	assert.Equal(t, "", drt.timeoutMsg)
+}
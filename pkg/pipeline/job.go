@@ -69,6 +69,11 @@ type BaseJob struct {
 type PaddleFlowJob struct {
 	BaseJob
 	Image        string
+	Flavour      string
+	QueueName    string
+	Priority     string
+	Framework    string
+	Members      []schema.StepMember
 	mainFS       *schema.FsMount
 	extraFS      []schema.FsMount
 	eventChannel chan<- WorkflowEvent
@@ -110,6 +115,15 @@ func NewPaddleFlowJobWithJobView(view *schema.JobView, image string, eventChanne
 	return &pfj
 }
 
+// 根据step的配置设置job的资源规格、队列、优先级以及分布式角色
+func (pfj *PaddleFlowJob) updateResource(step *schema.WorkflowSourceStep) {
+	pfj.Flavour = step.Flavour
+	pfj.QueueName = step.Queue
+	pfj.Priority = step.Priority
+	pfj.Framework = step.Framework
+	pfj.Members = step.Members
+}
+
 // 发起作业接口
 func (pfj *PaddleFlowJob) Update(cmd string, params map[string]string, envs map[string]string,
 	artifacts *schema.Artifacts) {
@@ -131,7 +145,7 @@ func (pfj *PaddleFlowJob) Update(cmd string, params map[string]string, envs map[
 }
 
 // 生成job 的conf 信息
-func (pfj *PaddleFlowJob) generateJobConf() schema.Conf {
+func (pfj *PaddleFlowJob) generateJobConf() schema.PPLJobConf {
 	fs := schema.FileSystem{}
 
 	if pfj.mainFS != nil {
@@ -156,25 +170,46 @@ func (pfj *PaddleFlowJob) generateJobConf() schema.Conf {
 		efs = append(efs, fs)
 	}
 
-	priority := ""
-	if _, ok := pfj.Env["PF_JOB_PRIORITY"]; ok {
+	// step 中未配置时，兼容通过环境变量指定的方式
+	priority := pfj.Priority
+	if priority == "" {
 		priority = pfj.Env["PF_JOB_PRIORITY"]
 	}
 
-	queueName := ""
-	if _, ok := pfj.Env["PF_JOB_QUEUE_NAME"]; ok {
+	queueName := pfj.QueueName
+	if queueName == "" {
 		queueName = pfj.Env["PF_JOB_QUEUE_NAME"]
 	}
 
-	conf := schema.Conf{
-		Name:            pfj.Name,
-		Env:             pfj.Env,
-		Command:         pfj.Command,
-		Image:           pfj.Image,
-		ExtraFileSystem: efs,
-		QueueName:       queueName,
-		Priority:        priority,
-		FileSystem:      fs,
+	flavour := pfj.Flavour
+	if flavour == "" {
+		flavour = pfj.Env[schema.EnvJobFlavour]
+	}
+
+	members := []schema.PPLJobMember{}
+	for _, member := range pfj.Members {
+		members = append(members, schema.PPLJobMember{
+			Role:     schema.MemberRole(member.Role),
+			Replicas: member.Replicas,
+			Flavour:  member.Flavour,
+			Command:  member.Command,
+		})
+	}
+
+	conf := schema.PPLJobConf{
+		Conf: schema.Conf{
+			Name:            pfj.Name,
+			Env:             pfj.Env,
+			Command:         pfj.Command,
+			Image:           pfj.Image,
+			ExtraFileSystem: efs,
+			QueueName:       queueName,
+			Priority:        priority,
+			Flavour:         schema.Flavour{Name: flavour},
+			FileSystem:      fs,
+		},
+		Framework: schema.Framework(pfj.Framework),
+		Members:   members,
 	}
 
 	return conf
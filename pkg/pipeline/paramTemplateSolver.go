@@ -23,6 +23,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	. "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 )
@@ -138,14 +140,11 @@ func (isv *innerSolver) resolveArtifactTemplate(tpl []string, fieldType string,
 		path = GetArtifactMountPath(isv.runConfig.mainFS, path)
 		return path, err
 	} else {
-		var maxSize int
 		if fieldType == FieldCondition {
-			maxSize = ConditionArtifactMaxSize
+			result, err = GetArtifactContent(path, ConditionArtifactMaxSize, isv.runConfig.mainFS.ID, isv.logger)
 		} else {
-			maxSize = LoopArgumentArtifactMaxSize
+			result, err = getLoopArgumentFromArtifact(path, isv.runConfig.mainFS.ID, isv.logger)
 		}
-
-		result, err = GetArtifactContent(path, maxSize, isv.runConfig.mainFS.ID, isv.logger)
 		if err != nil {
 			err = fmt.Errorf("failed to resolve template[%s] for %s[%s], because cannot read the content from artifact[%s]",
 				isv.Component.GetType(), tpl[0], isv.runtimeName, refParamName)
@@ -299,6 +298,62 @@ func (isv *innerSolver) resolveLoopArugment() error {
 	return nil
 }
 
+// getLoopArgumentFromArtifact: 读取 artifact 的内容作为 loop_argument 的值
+// 循环结构的输出 artifact 的路径由每次运行的路径以逗号拼接而成，此时会将每次运行的 artifact 的内容汇总成一个 json list
+func getLoopArgumentFromArtifact(paths string, fsID string, logger *logrus.Entry) (string, error) {
+	pathList := strings.Split(paths, ",")
+	if len(pathList) == 1 {
+		return GetArtifactContent(paths, LoopArgumentArtifactMaxSize, fsID, logger)
+	}
+
+	items := make([]interface{}, 0, len(pathList))
+	for _, path := range pathList {
+		content, err := GetArtifactContent(path, LoopArgumentArtifactMaxSize, fsID, logger)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, loopArgumentItem(content))
+	}
+
+	result, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// getLoopArgumentFromParameters: 上游节点为循环结构时，将其每次运行的 parameter 的值按运行次序汇总成一个 json list
+func getLoopArgumentFromParameters(values []interface{}) (interface{}, error) {
+	if len(values) == 1 {
+		return values[0], nil
+	}
+
+	items := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if content, ok := value.(string); ok {
+			items = append(items, loopArgumentItem(content))
+		} else {
+			items = append(items, value)
+		}
+	}
+
+	result, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return string(result), nil
+}
+
+// loopArgumentItem: 内容为 json 的会被反序列化，否则去掉首尾空白后作为字符串
+func loopArgumentItem(content string) interface{} {
+	var item interface{}
+	if err := json.Unmarshal([]byte(content), &item); err != nil {
+		item = strings.TrimSpace(content)
+	}
+	return item
+}
+
 // 用于解析可能存在依赖关系的模版
 // 如 parameter，artifact 字段，因为其有可能会引用上游节点，以及父节点（子节点） 的相关信息
 type DependencySolver struct {
@@ -451,6 +506,51 @@ func (ds *DependencySolver) ResolveBeforeRun(subComponent schema.Component) erro
 	}
 	// 输出artifact 无需解析： step 的输出artifact的中不会有模版，dag 的输出artifact 虽然有模版，但是需要在dag 所有的子节点都运行完成后才能解析。
 
+	// 3. 解析 loop_argument 中对上游节点的引用
+	return ds.resolveLoopArgument(subComponent)
+}
+
+// resolveLoopArgument: 将 loop_argument 中对上游节点输出 artifact 或者 parameter 的引用替换成具体值
+// 引用节点自身 parameter 或者输入 artifact 的模板，由 innerSolver 负责解析
+func (ds *DependencySolver) resolveLoopArgument(subComponent schema.Component) error {
+	loopArgument, ok := subComponent.GetLoopArgument().(string)
+	if !ok || !regexp.MustCompile(RegExpUpstreamTpl).MatchString(loopArgument) {
+		return nil
+	}
+
+	tpls, err := fetchTemplate(loopArgument)
+	if err != nil {
+		return err
+	}
+	refComponentName, refValue := parseTemplate(tpls[0][2])
+
+	var value interface{}
+	refComponent, ok := ds.getworkflowSouceDag().EntryPoints[refComponentName]
+	if !ok {
+		err = fmt.Errorf("component[%s] not exist", refComponentName)
+	} else if _, ok := refComponent.GetArtifacts().Output[refValue]; ok {
+		var paths string
+		paths, err = ds.GetSubComponentArtifactPaths(refComponentName, refValue)
+		if err == nil {
+			value, err = getLoopArgumentFromArtifact(paths, ds.runConfig.mainFS.ID, ds.logger)
+		}
+	} else {
+		var values []interface{}
+		values, err = ds.GetSubComponentParameterValues(refComponentName, refValue)
+		if err == nil {
+			value, err = getLoopArgumentFromParameters(values)
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("cannot resolve loop_argument[%s] for %s[%s]: %v", loopArgument,
+			subComponent.GetType(), subComponent.GetName(), err.Error())
+		return err
+	}
+
+	subComponent.UpdateLoopArguemt(value)
+	ds.logger.Infof("after dependency solver, the value of loop_argument for %s[%s] is %v",
+		subComponent.GetType(), subComponent.GetName(), value)
 	return nil
 }
 
@@ -357,6 +357,71 @@ func TestResolveBeforeRun(t *testing.T) {
 	assert.Equal(t, "./s1o1.txt", inputs["s2i2"])
 }
 
+func TestResolveLoopArgumentFromUpstream(t *testing.T) {
+	dr := mockDagRuntime()
+	dr.sysParams = map[string]string{"PF_LOOP_ARGUMENT": "10", "PF_RUN_ID": "run-001"}
+	ds := NewDependencySolver(dr)
+
+	step1 := dr.getworkflowSouceDag().EntryPoints["step1"].(*schema.WorkflowSourceStep)
+	step1.Parameters["s1p5"] = "[1, 2, 3]"
+	step2 := dr.getworkflowSouceDag().EntryPoints["step2"]
+
+	// 上游节点还未被调度
+	step2.UpdateLoopArguemt("{{step1.s1p5}}")
+	err := ds.ResolveBeforeRun(step2)
+	assert.NotNil(t, err)
+
+	// 引用上游节点的输出 parameter
+	dr.subComponentRumtimes["step1"] = []componentRuntime{mockStepRuntime(step1)}
+	err = ds.ResolveBeforeRun(step2)
+	assert.Nil(t, err)
+	assert.Equal(t, "[1, 2, 3]", step2.GetLoopArgument())
+
+	is := NewInnerSolver(step2, "step2", dr.runConfig)
+	err = is.resolveLoopArugment()
+	assert.Nil(t, err)
+	assert.Len(t, step2.GetLoopArgument(), 3)
+
+	// 引用上游节点的输出 artifact, 上游节点为循环结构时，会将每次运行的输出汇总成 list
+	mockArtInJsonFormat("./s1o1.txt")
+	mockArtInJsonFormat("./s1o1-1.txt")
+
+	step1Loop1 := step1.DeepCopy().(*schema.WorkflowSourceStep)
+	step1Loop1.Artifacts.Output["s1o1"] = "./s1o1-1.txt"
+	srt1 := mockStepRuntime(step1Loop1)
+	srt1.loopSeq = 1
+	dr.subComponentRumtimes["step1"] = []componentRuntime{srt1, mockStepRuntime(step1)}
+
+	step2 = mockWorkflowDagForDs().EntryPoints["step2"]
+	step2.UpdateLoopArguemt("{{step1.s1o1}}")
+	err = ds.ResolveBeforeRun(step2)
+	assert.Nil(t, err)
+	assert.Equal(t, "[[10,12,13,14],[10,12,13,14]]", step2.GetLoopArgument())
+
+	is = NewInnerSolver(step2, "step2", dr.runConfig)
+	err = is.resolveLoopArugment()
+	assert.Nil(t, err)
+	assert.Len(t, step2.GetLoopArgument(), 2)
+
+	// 上游节点只运行了一次时，直接使用 artifact 的内容
+	dr.subComponentRumtimes["step1"] = []componentRuntime{mockStepRuntime(step1)}
+	step2 = mockWorkflowDagForDs().EntryPoints["step2"]
+	step2.UpdateLoopArguemt("{{step1.s1o1}}")
+	err = ds.ResolveBeforeRun(step2)
+	assert.Nil(t, err)
+	assert.Equal(t, "[10,12,13,14]", step2.GetLoopArgument())
+
+	// 引用循环结构的输出 parameter 时，同样会将每次运行的值按运行次序汇总成 list
+	step1Loop1.Parameters["s1p5"] = "b"
+	step1.Parameters["s1p5"] = "[1, 2]"
+	dr.subComponentRumtimes["step1"] = []componentRuntime{srt1, mockStepRuntime(step1)}
+	step2 = mockWorkflowDagForDs().EntryPoints["step2"]
+	step2.UpdateLoopArguemt("{{step1.s1p5}}")
+	err = ds.ResolveBeforeRun(step2)
+	assert.Nil(t, err)
+	assert.Equal(t, `[[1,2],"b"]`, step2.GetLoopArgument())
+}
+
 func TestResolveAfterDone(t *testing.T) {
 	dr := mockDagRuntime()
 	dr.sysParams = map[string]string{"PF_LOOP_ARGUMENT": "10", "PF_RUN_ID": "run-001"}
@@ -38,6 +38,15 @@ type StepRuntime struct {
 	CacheRunID        string
 	CacheJobID        string
 
+	// 已经结束并被重试的 job 的运行记录
+	attempts []schema.JobAttempt
+
+	// 因超时而被终止时的信息，非空说明 step 已经超时
+	timeoutMsg string
+
+	// 为 true 说明 step 正在等待 backoff 时间后发起重试，此时新的 job 还没有创建
+	retrying bool
+
 	// 需要避免在终止的同时在 创建 job 的情况，导致数据不一致
 	processJobLock sync.Mutex
 }
@@ -59,6 +68,7 @@ func NewStepRuntime(name, fullName string, step *schema.WorkflowSourceStep, seq
 	jobName := generateJobName(config.runID, step.GetName(), seq)
 	job := NewPaddleFlowJob(jobName, srt.getWorkFlowStep().DockerEnv, srt.receiveEventChildren,
 		srt.runConfig.mainFS, srt.getWorkFlowStep().ExtraFS)
+	job.updateResource(srt.getWorkFlowStep())
 	srt.job = job
 
 	srt.logger.Infof("step[%s] of runid[%s] before starting job: param[%s], env[%s], command[%s], artifacts[%s], deps[%s], "+
@@ -190,6 +200,9 @@ func (srt *StepRuntime) Start() {
 	// 监听channel, 及时除了时间
 	go srt.Listen()
 	go srt.Stop()
+	if timeout := srt.getWorkFlowStep().Timeout; timeout > 0 {
+		go srt.watchTimeout(time.Duration(timeout) * time.Second)
+	}
 	srt.Execute()
 }
 
@@ -226,6 +239,11 @@ func (srt *StepRuntime) Resume(view *schema.JobView) {
 		srt.receiveEventChildren, srt.runConfig.mainFS, srt.getWorkFlowStep().ExtraFS)
 
 	srt.pk = view.PK
+	// 如果 view 中的 job 已经被记录为重试过的 job，则说明在等待重试时发生了重启，此时该 job 会在 watch 到其终态后再次被记录并重试
+	srt.attempts = append([]schema.JobAttempt{}, view.Attempts...)
+	if len(srt.attempts) > 0 && srt.attempts[len(srt.attempts)-1].JobID == view.JobID {
+		srt.attempts = srt.attempts[:len(srt.attempts)-1]
+	}
 	err := srt.updateStatus(view.Status)
 	if err != nil {
 		errMsg := fmt.Sprintf("set the sysparams for dag[%s] failed: %s", srt.name, err.Error())
@@ -260,6 +278,14 @@ func (srt *StepRuntime) Resume(view *schema.JobView) {
 
 	go srt.Listen()
 	go srt.Stop()
+	if timeout := srt.getWorkFlowStep().Timeout; timeout > 0 {
+		// 超时时间从第一次发起 job 时开始计算
+		startTime := view.StartTime
+		if len(srt.attempts) > 0 {
+			startTime = srt.attempts[0].StartTime
+		}
+		go srt.watchTimeout(remainingTimeout(timeout, startTime))
+	}
 	go srt.job.Watch()
 	return
 }
@@ -295,6 +321,39 @@ func (srt *StepRuntime) Stop() {
 	}
 }
 
+// watchTimeout: 在超时后终止 step，如果 job 还没有发起，则直接将 step 置为 failed
+func (srt *StepRuntime) watchTimeout(remaining time.Duration) {
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-srt.finished():
+		return
+	case <-srt.ctx.Done():
+		return
+	case <-srt.failureOpitonsCtx.Done():
+		return
+	}
+
+	defer srt.processJobLock.Unlock()
+	srt.processJobLock.Lock()
+
+	if srt.done {
+		return
+	}
+
+	srt.timeoutMsg = fmt.Sprintf("step[%s] timed out after %ds", srt.name, srt.getWorkFlowStep().Timeout)
+	srt.logger.Warningf(srt.timeoutMsg)
+
+	if srt.job.JobID() == "" {
+		srt.retrying = false
+		srt.processStartAbnormalStatus(srt.timeoutMsg, StatusRuntimeFailed)
+		return
+	}
+	srt.stopWithMsg(srt.timeoutMsg)
+}
+
 func (srt *StepRuntime) updateJob(forCacheFingerprint bool) error {
 	// 替换command, envs, parameter 与 artifact 已经在创建Step前便已经替换完成
 	// 这个为啥要在这里替换，而不是在runtime初始化的时候呢？ 计算cache 需要进行的替换和运行时进行的替换有些许不同
@@ -452,7 +511,16 @@ func (srt *StepRuntime) checkCached() (cacheFound bool, err error) {
 	cacheFound = false
 	var cacheRunID string
 	var cacheJobID string
+	strategy := srt.cacheStrategy()
 	for _, runCache := range runCacheList {
+		// 不同策略计算出的第二层 fingerprint 含义不同，只能使用相同策略记录的 cache，没有记录策略的 cache 视为保守策略
+		cacheStrategy := runCache.Strategy
+		if cacheStrategy == "" {
+			cacheStrategy = CacheStrategyConservative
+		}
+		if cacheStrategy != strategy {
+			continue
+		}
 		if srt.secondFingerprint == runCache.SecondFp {
 			if runCache.ExpiredTime == CacheExpiredTimeNever {
 				cacheFound = true
@@ -519,6 +587,14 @@ func (srt *StepRuntime) checkCached() (cacheFound bool, err error) {
 	return cacheFound, nil
 }
 
+// cacheStrategy: 获取 step 所使用的 cache 策略，没有配置时，使用保守策略
+func (srt *StepRuntime) cacheStrategy() string {
+	if strategy := srt.getWorkFlowStep().Cache.Strategy; strategy != "" {
+		return strategy
+	}
+	return CacheStrategyConservative
+}
+
 func (srt *StepRuntime) logCache() error {
 	// 写cache记录到数据库
 	req := schema.LogRunCacheRequest{
@@ -532,7 +608,7 @@ func (srt *StepRuntime) logCache() error {
 		FsName:      srt.runConfig.mainFS.Name,
 		UserName:    srt.userName,
 		ExpiredTime: srt.getWorkFlowStep().Cache.MaxExpiredTime,
-		Strategy:    CacheStrategyConservative,
+		Strategy:    srt.cacheStrategy(),
 	}
 
 	// logcache失败，不影响job正常结束，但是把cache失败添加日志
@@ -715,6 +791,12 @@ func (srt *StepRuntime) Execute() {
 }
 
 func (srt *StepRuntime) stopWithMsg(msg string) {
+	if srt.retrying {
+		// 等待重试期间收到终止信号，此时不再发起新的 job，直接将状态置为 terminated
+		srt.stopRetrying(msg)
+		return
+	}
+
 	if srt.job.JobID() == "" {
 		// 此时说明还没有创建job，因此直接将状态置为 failed，并通过事件进行同步即可
 		var msg string
@@ -787,15 +869,131 @@ func (srt *StepRuntime) processEventFromJob(event WorkflowEvent) {
 			srt.logger.Infof(logMsg)
 		}
 
-		err := srt.updateStatus(extra["status"].(RuntimeStatus))
+		status := extra["status"].(RuntimeStatus)
+		msg := event.Message
+		// 因超时而结束的 job，统一视为失败，以便 failure_options 按照失败进行处理
+		if srt.timeoutMsg != "" && isRuntimeFinallyStatus(status) && status != StatusRuntimeSucceeded {
+			status = StatusRuntimeFailed
+			msg = srt.timeoutMsg
+		}
+
+		if srt.isRetryable(status) {
+			srt.retry(status, msg)
+			return
+		}
+
+		err := srt.updateStatus(status)
 		if err != nil {
 			srt.logger.Errorf(err.Error())
 		}
-		view := srt.newJobView(event.Message)
-		srt.syncToApiServerAndParent(WfEventJobUpdate, &view, event.Message)
+		view := srt.newJobView(msg)
+		srt.syncToApiServerAndParent(WfEventJobUpdate, &view, msg)
 	}
 }
 
+// isRetryable: 判断 job 处于终态 status 后，是否需要根据重试策略发起新的 job
+func (srt *StepRuntime) isRetryable(status RuntimeStatus) bool {
+	retry := srt.getWorkFlowStep().Retry
+	if retry == nil || srt.done || srt.timeoutMsg != "" || !isRuntimeFinallyStatus(status) || !retry.IsRetryable(status) {
+		return false
+	}
+
+	if srt.ctx.Err() != nil || srt.failureOpitonsCtx.Err() != nil {
+		return false
+	}
+
+	return len(srt.attempts)+1 < retry.MaxAttempts
+}
+
+// retry: 记录当前 job 的运行情况，并在等待 backoff 时间后，发起新的 job
+// 在重试次数耗尽之前，不会将 job 的终态同步至父节点
+func (srt *StepRuntime) retry(status RuntimeStatus, msg string) {
+	defer srt.processJobLock.Unlock()
+	srt.processJobLock.Lock()
+
+	job := srt.job.Job()
+	srt.attempts = append(srt.attempts, schema.JobAttempt{
+		JobID:     job.ID,
+		Status:    status,
+		Message:   msg,
+		StartTime: job.StartTime,
+		EndTime:   time.Now().Format("2006-01-02 15:04:05"),
+	})
+
+	retried := len(srt.attempts)
+	backoff := srt.getWorkFlowStep().Retry.GetBackoff(retried)
+	retryMsg := fmt.Sprintf("job[%s] of step[%s] is %s, retry it after %v, retried %d times",
+		job.ID, srt.name, status, backoff, retried)
+	srt.logger.Infof(retryMsg)
+
+	err := srt.updateStatus(StatusRuntimePending)
+	if err != nil {
+		srt.logger.Errorf(err.Error())
+	}
+	view := srt.newJobView(retryMsg)
+	srt.callback(srt.newEvent(WfEventJobUpdate, &view, retryMsg))
+
+	// 在发起新的 job 之前，替换掉已经结束的 job，避免此时收到终止信号后，反复终止已经结束的 job
+	jobName := fmt.Sprintf("%s-retry-%d", generateJobName(srt.runID, srt.getWorkFlowStep().GetName(), srt.loopSeq), retried)
+	newJob := NewPaddleFlowJob(jobName, srt.getWorkFlowStep().DockerEnv, srt.receiveEventChildren,
+		srt.runConfig.mainFS, srt.getWorkFlowStep().ExtraFS)
+	newJob.updateResource(srt.getWorkFlowStep())
+	newJob.Update(job.Command, job.Parameters, job.Env, &job.Artifacts)
+	srt.job = newJob
+	srt.retrying = true
+
+	go srt.restartJob(backoff)
+}
+
+// restartJob: 等待 backoff 时间后，发起新的 job
+// 如果在等待期间收到了终止信号，则交由 Stop 将 step 置为 terminated，不再发起新的 job
+func (srt *StepRuntime) restartJob(backoff time.Duration) {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-srt.ctx.Done():
+		return
+	case <-srt.failureOpitonsCtx.Done():
+		return
+	}
+
+	defer srt.processJobLock.Unlock()
+	srt.processJobLock.Lock()
+
+	if srt.done || !srt.retrying || srt.ctx.Err() != nil || srt.failureOpitonsCtx.Err() != nil {
+		srt.logger.Infof("step[%s] has been stopped, wouldn't retry", srt.name)
+		return
+	}
+	srt.retrying = false
+
+	_, err := srt.job.Start()
+	if err != nil {
+		errMsg := fmt.Sprintf("start job for step[%s] with runid[%s] failed when retry: [%s]",
+			srt.name, srt.runID, err.Error())
+		srt.logger.Errorf(errMsg)
+		srt.processStartAbnormalStatus(errMsg, StatusRuntimeFailed)
+		return
+	}
+
+	err = srt.logCache()
+	if err != nil {
+		srt.logger.Errorf(err.Error())
+	}
+
+	srt.logger.Infof("step[%s] of runid[%s] retried with jobID[%s]", srt.name, srt.runID, srt.job.JobID())
+	srt.logInputArtifact()
+}
+
+// stopRetrying: 在等待重试期间终止 step，由于新的 job 还没有发起，直接将状态置为 terminated 即可
+func (srt *StepRuntime) stopRetrying(msg string) {
+	srt.retrying = false
+	stopMsg := fmt.Sprintf("step[%s] is stopped while waiting for retry, wouldn't retry: %s", srt.name, msg)
+	srt.logger.Infof(stopMsg)
+	srt.processStartAbnormalStatus(stopMsg, StatusRuntimeTerminated)
+}
+
 func (srt *StepRuntime) newJobView(msg string) schema.JobView {
 	step := srt.getWorkFlowStep()
 	params := map[string]string{}
@@ -823,6 +1021,8 @@ func (srt *StepRuntime) newJobView(msg string) schema.JobView {
 		ParentDagID: srt.parentDagID,
 		CacheRunID:  srt.CacheRunID,
 		CacheJobID:  srt.CacheJobID,
+		Attempt:     len(srt.attempts) + 1,
+		Attempts:    append([]schema.JobAttempt{}, srt.attempts...),
 		StepName:    srt.getComponent().GetName(),
 		Cache:       srt.getWorkFlowStep().Cache,
 		PK:          srt.pk,
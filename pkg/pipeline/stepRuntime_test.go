@@ -427,6 +427,33 @@ func TestCheckCached(t *testing.T) {
 	cacheFound, err = srt.checkCached()
 	assert.Nil(t, err)
 	assert.Equal(t, true, cacheFound)
+
+	// 使用内容策略时，不会命中保守策略记录的 cache
+	st.Cache.Strategy = pplcommon.CacheStrategyContent
+	patch3 := gomonkey.ApplyMethod(reflect.TypeOf(&contentCacheCalculator{}), "CalculateSecondFingerprint",
+		func(_ *contentCacheCalculator) (string, error) {
+			return "2222", nil
+		})
+	defer patch3.Reset()
+
+	srt = NewStepRuntime("a.entrypoint."+st.Name, "a.entrypoint."+st.Name, st, 0, context.Background(), failctx,
+		make(chan<- WorkflowEvent), rf, "dag-11")
+	cacheFound, err = srt.checkCached()
+	assert.Nil(t, err)
+	assert.Equal(t, false, cacheFound)
+
+	rf.callbacks.ListCacheCb = func(firstFp, fsID, yamlPath string) ([]models.RunCache, error) {
+		return []models.RunCache{
+			models.RunCache{FirstFp: "1111", SecondFp: "2222", RunID: "run-000027", JobID: "job-001",
+				UpdatedAt: updateTime, ExpiredTime: "-1", Strategy: pplcommon.CacheStrategyContent},
+		}, nil
+	}
+	srt = NewStepRuntime("a.entrypoint."+st.Name, "a.entrypoint."+st.Name, st, 0, context.Background(), failctx,
+		make(chan<- WorkflowEvent), rf, "dag-11")
+	cacheFound, err = srt.checkCached()
+	assert.Nil(t, err)
+	assert.Equal(t, true, cacheFound)
+	assert.Equal(t, pplcommon.CacheStrategyContent, srt.cacheStrategy())
 }
 
 func mockToListenEvent(ec chan WorkflowEvent, ep *WorkflowEvent) {
@@ -652,6 +679,211 @@ func TestProcessEventFromJob(t *testing.T) {
 
 }
 
+func TestProcessEventFromJobWithRetry(t *testing.T) {
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	testCase := loadcase(runYamlPath)
+	wfs, err := schema.GetWorkflowSource([]byte(testCase))
+	assert.Nil(t, err)
+
+	rf := mockRunConfigForComponentRuntime()
+	rf.WorkflowSource = &wfs
+	rf.callbacks = mockCbs
+
+	var views []schema.JobView
+	rf.callbacks.UpdateRuntimeCb = func(id string, event interface{}) (int64, bool) {
+		views = append(views, *event.(*WorkflowEvent).Extra[apicommon.WfEventKeyView].(*schema.JobView))
+		return 123, true
+	}
+
+	eventChan := make(chan WorkflowEvent)
+	ep := &WorkflowEvent{}
+	go func(eventChan chan WorkflowEvent, ep *WorkflowEvent) {
+		for {
+			*ep = <-eventChan
+		}
+	}(eventChan, ep)
+
+	failctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := wfs.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep)
+	st.Retry = &schema.RetryPolicy{MaxAttempts: 2}
+	srt := NewStepRuntime("a.entrypoint.data-preprocess", "a.entrypoint.data-preprocess", st, 0, context.Background(),
+		failctx, eventChan, rf, "dag-11")
+	srt.setSysParams()
+	srt.job.(*PaddleFlowJob).ID = "job-0001"
+
+	startedJob := ""
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(srt.job), "Start", func(pfj *PaddleFlowJob) (string, error) {
+		pfj.ID = "job-0002"
+		startedJob = pfj.Name
+		return pfj.ID, nil
+	})
+	defer patches.Reset()
+
+	event := NewWorkflowEvent(WfEventJobUpdate, "failed", map[string]interface{}{
+		apicommon.WfEventKeyStatus: StatusRuntimeFailed,
+	})
+	srt.processEventFromJob(*event)
+	time.Sleep(time.Millisecond * 100)
+
+	// 第一次失败后进行重试，不会将失败状态同步至父节点
+	assert.False(t, srt.done)
+	assert.Equal(t, StatusRuntimePending, srt.status)
+	assert.Equal(t, "", ep.Message)
+	assert.Equal(t, "job-0002", srt.job.JobID())
+	assert.Equal(t, generateJobName(srt.runID, st.GetName(), 0)+"-retry-1", startedJob)
+	assert.Equal(t, 2, views[len(views)-1].Attempt)
+	assert.Equal(t, []schema.JobAttempt{{JobID: "job-0001", Status: StatusRuntimeFailed, Message: "failed",
+		EndTime: views[len(views)-1].Attempts[0].EndTime}}, views[len(views)-1].Attempts)
+
+	// 重试次数耗尽后，将失败状态同步至父节点
+	srt.increase()
+	srt.processEventFromJob(*event)
+	time.Sleep(time.Millisecond * 100)
+
+	assert.True(t, srt.done)
+	assert.True(t, srt.isFailed())
+	assert.Equal(t, "failed", ep.Message)
+	assert.Equal(t, 2, views[len(views)-1].Attempt)
+	assert.Equal(t, "job-0002", views[len(views)-1].JobID)
+}
+
+func TestStopStepRuntimeWhileRetrying(t *testing.T) {
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	testCase := loadcase(runYamlPath)
+	wfs, err := schema.GetWorkflowSource([]byte(testCase))
+	assert.Nil(t, err)
+
+	rf := mockRunConfigForComponentRuntime()
+	rf.WorkflowSource = &wfs
+	rf.callbacks = mockCbs
+
+	eventChan := make(chan WorkflowEvent)
+	ep := &WorkflowEvent{}
+	go func(eventChan chan WorkflowEvent, ep *WorkflowEvent) {
+		for {
+			*ep = <-eventChan
+		}
+	}(eventChan, ep)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := wfs.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep)
+	st.Retry = &schema.RetryPolicy{MaxAttempts: 2, Backoff: schema.Backoff{Duration: 60}}
+	srt := NewStepRuntime("a.entrypoint.data-preprocess", "a.entrypoint.data-preprocess", st, 0, ctx,
+		context.Background(), eventChan, rf, "dag-11")
+	srt.setSysParams()
+	srt.increase()
+	srt.job.(*PaddleFlowJob).ID = "job-0001"
+
+	started := false
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(srt.job), "Start", func(pfj *PaddleFlowJob) (string, error) {
+		started = true
+		return "job-0002", nil
+	})
+	defer patches.Reset()
+
+	go srt.Stop()
+	event := NewWorkflowEvent(WfEventJobUpdate, "failed", map[string]interface{}{
+		apicommon.WfEventKeyStatus: StatusRuntimeFailed,
+	})
+	srt.processEventFromJob(*event)
+	assert.True(t, srt.retrying)
+
+	// 等待重试期间被终止，step 的状态为 terminated，且不会再发起新的 job
+	cancel()
+	time.Sleep(time.Millisecond * 100)
+
+	assert.False(t, started)
+	assert.False(t, srt.retrying)
+	assert.True(t, srt.done)
+	assert.Equal(t, StatusRuntimeTerminated, srt.status)
+	assert.Contains(t, ep.Message, "stopped while waiting for retry")
+}
+
+func TestStepRuntimeTimeout(t *testing.T) {
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	testCase := loadcase(runYamlPath)
+	wfs, err := schema.GetWorkflowSource([]byte(testCase))
+	assert.Nil(t, err)
+
+	rf := mockRunConfigForComponentRuntime()
+	rf.WorkflowSource = &wfs
+	rf.callbacks = mockCbs
+
+	eventChan := make(chan WorkflowEvent)
+	ep := &WorkflowEvent{}
+	go func(eventChan chan WorkflowEvent, ep *WorkflowEvent) {
+		for {
+			*ep = <-eventChan
+		}
+	}(eventChan, ep)
+
+	failctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := wfs.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep)
+	st.Timeout = 1
+	st.Retry = &schema.RetryPolicy{MaxAttempts: 3}
+	srt := NewStepRuntime("a.entrypoint.data-preprocess", "a.entrypoint.data-preprocess", st, 0, context.Background(),
+		failctx, eventChan, rf, "dag-11")
+	srt.setSysParams()
+	srt.increase()
+	srt.job.(*PaddleFlowJob).ID = "job-0001"
+
+	stopped := false
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(srt.job), "Stop", func(_ *PaddleFlowJob) error {
+		stopped = true
+		return nil
+	})
+	defer patches.Reset()
+
+	// 超时后通过 Stop 终止 job
+	srt.watchTimeout(time.Millisecond * 10)
+	assert.True(t, stopped)
+	assert.Equal(t, "step[a.entrypoint.data-preprocess] timed out after 1s", srt.timeoutMsg)
+	assert.False(t, srt.done)
+
+	// 因超时而终止的 job，视为失败，且不会进行重试
+	event := NewWorkflowEvent(WfEventJobUpdate, "terminated", map[string]interface{}{
+		apicommon.WfEventKeyStatus: StatusRuntimeTerminated,
+	})
+	srt.processEventFromJob(*event)
+	time.Sleep(time.Millisecond * 100)
+
+	assert.True(t, srt.done)
+	assert.True(t, srt.isFailed())
+	assert.Equal(t, srt.timeoutMsg, ep.Message)
+	assert.Equal(t, StatusRuntimeFailed, ep.Extra[apicommon.WfEventKeyStatus])
+
+	// job 还没有发起时超时，直接置为 failed
+	srt = NewStepRuntime("a.entrypoint.data-preprocess", "a.entrypoint.data-preprocess", st, 0, context.Background(),
+		failctx, eventChan, rf, "dag-11")
+	srt.setSysParams()
+	srt.increase()
+	srt.watchTimeout(0)
+	time.Sleep(time.Millisecond * 100)
+
+	assert.True(t, srt.isFailed())
+	assert.Equal(t, srt.timeoutMsg, ep.Message)
+
+	// step 进入终态后，停止监听超时
+	srt = NewStepRuntime("a.entrypoint.data-preprocess", "a.entrypoint.data-preprocess", st, 0, context.Background(),
+		failctx, eventChan, rf, "dag-11")
+	srt.increase()
+	returned := make(chan struct{})
+	go func() {
+		srt.watchTimeout(time.Hour)
+		close(returned)
+	}()
+	assert.Nil(t, srt.updateStatus(StatusRuntimeSucceeded))
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Errorf("watchTimeout should return when step is finished")
+	}
+	assert.Equal(t, "", srt.timeoutMsg)
+}
+
 func TestStart(t *testing.T) {
 	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
 	testCase := loadcase(runYamlPath)
@@ -850,3 +1082,40 @@ func TestStop(t *testing.T) {
 	assert.Equal(t, 0, srt.CurrentParallelism())
 	assert.True(t, stoped)
 }
+
+func TestGenerateJobConf(t *testing.T) {
+	pfj := NewPaddleFlowJob("job-1", "images/training.tgz", make(chan WorkflowEvent), nil, nil)
+	pfj.Update("python train.py", nil, map[string]string{
+		"PF_JOB_QUEUE_NAME":  "env-queue",
+		"PF_JOB_PRIORITY":    "LOW",
+		schema.EnvJobFlavour: "env-flavour",
+	}, nil)
+
+	// step 中未配置时，使用环境变量中的配置
+	conf := pfj.generateJobConf()
+	assert.Equal(t, "env-queue", conf.GetQueueName())
+	assert.Equal(t, "LOW", conf.GetPriority())
+	assert.Equal(t, "env-flavour", conf.Flavour.Name)
+	assert.False(t, conf.IsDistributed())
+	assert.Empty(t, conf.Members)
+
+	pfj.updateResource(&schema.WorkflowSourceStep{
+		Flavour:   "flavour1",
+		Queue:     "train-queue",
+		Priority:  "HIGH",
+		Framework: "paddle",
+		Members: []schema.StepMember{
+			{Role: "pserver", Replicas: 2, Flavour: "flavour-cpu"},
+			{Role: "pworker", Replicas: 4, Command: "python worker.py"},
+		},
+	})
+	conf = pfj.generateJobConf()
+	assert.Equal(t, "train-queue", conf.GetQueueName())
+	assert.Equal(t, "HIGH", conf.GetPriority())
+	assert.Equal(t, "flavour1", conf.Flavour.Name)
+	assert.True(t, conf.IsDistributed())
+	assert.Equal(t, []schema.PPLJobMember{
+		{Role: schema.RolePServer, Replicas: 2, Flavour: "flavour-cpu"},
+		{Role: schema.RolePWorker, Replicas: 4, Command: "python worker.py"},
+	}, conf.Members)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/job"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -111,11 +112,17 @@ func (bwf *BaseWorkflow) checkAttrRecursively(components map[string]schema.Compo
 		}
 
 		// loopArgument
-		if err := bwf.checkLoopArgument(component); err != nil {
+		if err := bwf.checkLoopArgument(component, components); err != nil {
 			logger.LoggerForRun(bwf.RunID).Errorf("check loopArgument failed, error: %s", err.Error())
 			return err
 		}
 
+		// parallelism
+		if err := bwf.checkLoopParallelism(name, component); err != nil {
+			logger.LoggerForRun(bwf.RunID).Errorf("check parallelism failed, error: %s", err.Error())
+			return err
+		}
+
 		if dag, ok := component.(*schema.WorkflowSourceDag); ok {
 			if err := bwf.checkAttrRecursively(dag.EntryPoints); err != nil {
 				return err
@@ -170,7 +177,7 @@ func (bwf *BaseWorkflow) checkCondition(component schema.Component) error {
 	return nil
 }
 
-func (bwf *BaseWorkflow) checkLoopArgument(component schema.Component) error {
+func (bwf *BaseWorkflow) checkLoopArgument(component schema.Component, components map[string]schema.Component) error {
 	loop := component.GetLoopArgument()
 	if loop == nil {
 		return nil
@@ -195,6 +202,11 @@ func (bwf *BaseWorkflow) checkLoopArgument(component schema.Component) error {
 			}
 		}
 	case string:
+		// 引用了上游节点的输出 artifact 或者 parameter
+		if regexp.MustCompile(RegExpUpstreamTpl).MatchString(loop) {
+			return bwf.checkUpstreamLoopArgument(component, components, loop)
+		}
+
 		pattern := RegExpIncludingCurTpl
 		reg := regexp.MustCompile(pattern)
 		matches := reg.FindAllStringSubmatch(loop, -1)
@@ -245,6 +257,49 @@ func (bwf *BaseWorkflow) checkLoopArgument(component schema.Component) error {
 	return nil
 }
 
+// checkUpstreamLoopArgument: loop_argument 引用了上游节点的输出 artifact 或者 parameter 时，被引用的节点必须在 deps 中
+func (bwf *BaseWorkflow) checkUpstreamLoopArgument(component schema.Component, components map[string]schema.Component,
+	loop string) error {
+	matches := regexp.MustCompile(RegExpIncludingUpstreamTpl).FindStringSubmatch(loop)
+	refComponentName, refName := parseTemplate(matches[2])
+
+	isDep := false
+	for _, dep := range component.GetDeps() {
+		if dep == refComponentName {
+			isDep = true
+			break
+		}
+	}
+	refComponent, ok := components[refComponentName]
+	if !isDep || !ok {
+		return fmt.Errorf("loopArgument[%s] is invalid, component[%s] should be in deps", loop, refComponentName)
+	}
+
+	// reference 节点的输出 artifact 与 parameter 需要在运行时确定
+	if step, ok := refComponent.(*schema.WorkflowSourceStep); ok && step.Reference.Component != "" {
+		return nil
+	}
+	_, ok1 := refComponent.GetArtifacts().Output[refName]
+	_, ok2 := refComponent.GetParameters()[refName]
+	if !ok1 && !ok2 {
+		return fmt.Errorf("loopArgument[%s] is invalid, [%s] is not output artifact or parameter of component[%s]",
+			loop, refName, refComponentName)
+	}
+	return nil
+}
+
+// checkLoopParallelism: parallelism 只能在有 loop_argument 的节点中设置
+func (bwf *BaseWorkflow) checkLoopParallelism(name string, component schema.Component) error {
+	parallelism := getLoopParallelism(component)
+	if parallelism < 0 {
+		return fmt.Errorf("parallelism of component[%s] should be non-negative", name)
+	}
+	if parallelism > 0 && component.GetLoopArgument() == nil {
+		return fmt.Errorf("parallelism can only be set for component[%s] with loop_argument", name)
+	}
+	return nil
+}
+
 func (bwf *BaseWorkflow) recursiveGetComponents(components map[string]schema.Component, prefix string, dags map[string]*schema.WorkflowSourceDag, steps map[string]*schema.WorkflowSourceStep) {
 	for name, component := range components {
 		var absoluteName string
@@ -321,6 +376,18 @@ func (bwf *BaseWorkflow) validate() error {
 		return err
 	}
 
+	// 10. 检查重试策略
+	if err := bwf.checkRetry(); err != nil {
+		bwf.log().Errorf("check retry failed. err: %s", err.Error())
+		return err
+	}
+
+	// 11. 检查step的资源规格、优先级及分布式作业配置
+	if err := bwf.checkJobConf(); err != nil {
+		bwf.log().Errorf("check job conf failed. err: %s", err.Error())
+		return err
+	}
+
 	return nil
 }
 
@@ -423,6 +490,119 @@ func (bwf *BaseWorkflow) checkFailureOption() error {
 	}
 }
 
+func (bwf *BaseWorkflow) checkRetry() error {
+	if bwf.Source.Retry != nil {
+		if err := bwf.Source.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	postComps := map[string]schema.Component{}
+	for name, step := range bwf.Source.PostProcess {
+		postComps[name] = step
+	}
+	for _, comps := range []map[string]schema.Component{bwf.Source.EntryPoints.EntryPoints, bwf.Source.Components,
+		postComps} {
+		if err := bwf.checkCompRetry(comps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bwf *BaseWorkflow) checkCompRetry(components map[string]schema.Component) error {
+	for name, component := range components {
+		var retry *schema.RetryPolicy
+		if dag, ok := component.(*schema.WorkflowSourceDag); ok {
+			if err := bwf.checkCompRetry(dag.EntryPoints); err != nil {
+				return err
+			}
+			retry = dag.Retry
+		} else if step, ok := component.(*schema.WorkflowSourceStep); ok {
+			retry = step.Retry
+		} else {
+			return fmt.Errorf("component not step or dag")
+		}
+
+		if retry != nil {
+			if err := retry.Validate(); err != nil {
+				return fmt.Errorf("check retry of %s[%s] failed: %s", component.GetType(), name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func (bwf *BaseWorkflow) checkJobConf() error {
+	postComps := map[string]schema.Component{}
+	for name, step := range bwf.Source.PostProcess {
+		postComps[name] = step
+	}
+	for _, comps := range []map[string]schema.Component{bwf.Source.EntryPoints.EntryPoints, bwf.Source.Components,
+		postComps} {
+		if err := bwf.checkCompJobConf(comps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bwf *BaseWorkflow) checkCompJobConf(components map[string]schema.Component) error {
+	for name, component := range components {
+		if dag, ok := component.(*schema.WorkflowSourceDag); ok {
+			if err := bwf.checkCompJobConf(dag.EntryPoints); err != nil {
+				return err
+			}
+		} else if step, ok := component.(*schema.WorkflowSourceStep); ok {
+			// reference 节点使用被引用 component 的配置，在检查被引用的 component 时校验
+			if step.Reference.Component != "" {
+				continue
+			}
+			if err := checkStepJobConf(step); err != nil {
+				return fmt.Errorf("check job conf of step[%s] failed: %s", name, err.Error())
+			}
+		} else {
+			return fmt.Errorf("component not step or dag")
+		}
+	}
+	return nil
+}
+
+// checkStepJobConf 校验step中的优先级及分布式作业配置，flavour与queue是否存在由job子系统在发起作业前校验
+func checkStepJobConf(step *schema.WorkflowSourceStep) error {
+	switch strings.ToUpper(step.Priority) {
+	case "", schema.EnvJobLowPriority, schema.EnvJobNormalPriority, schema.EnvJobHighPriority:
+	default:
+		return fmt.Errorf("priority should be one of [%s, %s, %s], setted by [%s]", schema.EnvJobLowPriority,
+			schema.EnvJobNormalPriority, schema.EnvJobHighPriority, step.Priority)
+	}
+
+	framework := schema.Framework(step.Framework)
+	if framework == "" || framework == schema.FrameworkStandalone {
+		if len(step.Members) != 0 {
+			return fmt.Errorf("members can only be setted when framework is distributed")
+		}
+		return nil
+	}
+
+	roles := job.GetFrameworkRoles(framework)
+	if len(roles) == 0 {
+		return fmt.Errorf("framework[%s] is not supported", framework)
+	}
+	if len(step.Members) == 0 {
+		return fmt.Errorf("members should be setted when framework is [%s]", framework)
+	}
+	for _, member := range step.Members {
+		if _, ok := roles[schema.MemberRole(member.Role)]; !ok {
+			return fmt.Errorf("role[%s] is not supported by framework[%s]", member.Role, framework)
+		}
+		if member.Replicas < 1 {
+			return fmt.Errorf("replicas of role[%s] should be greater than 0", member.Role)
+		}
+	}
+	return nil
+}
+
 func (bwf *BaseWorkflow) checkComponents() error {
 	/*
 		components不能有deps(最外层，不包括子节点)
@@ -621,6 +801,11 @@ func (bwf *BaseWorkflow) checkCache() error {
 		return fmt.Errorf("MaxExpiredTime[%s] of cache not correct", bwf.Source.Cache.MaxExpiredTime)
 	}
 
+	// 校验Strategy，如果没传，默认为conservative
+	if err := checkCacheStrategy(&bwf.Source.Cache); err != nil {
+		return err
+	}
+
 	// FsScope 由于涉及到 Fs权限校验，FsID填充等操作，不便在此进行，在此前已经完成校验
 
 	if err := bwf.checkStepCache(bwf.Source.EntryPoints.EntryPoints); err != nil {
@@ -644,6 +829,9 @@ func (bwf *BaseWorkflow) checkStepCache(components map[string]schema.Component)
 				if err != nil {
 					return fmt.Errorf("MaxExpiredTime[%s] of cache in step[%s] not correct", step.Cache.MaxExpiredTime, name)
 				}
+				if err := checkCacheStrategy(&step.Cache); err != nil {
+					return fmt.Errorf("%s in step[%s]", err.Error(), name)
+				}
 			}
 		} else {
 			return fmt.Errorf("component not step or dag")
@@ -652,6 +840,26 @@ func (bwf *BaseWorkflow) checkStepCache(components map[string]schema.Component)
 	return nil
 }
 
+func checkCacheStrategy(cache *schema.Cache) error {
+	switch cache.Strategy {
+	case "":
+		cache.Strategy = CacheStrategyConservative
+	case CacheStrategyConservative:
+	case CacheStrategyContent:
+		// content 策略需要读取 fs_scope 中所有文件的内容，不允许通过不设置 path 的方式对整个 fs 计算摘要
+		for _, scope := range cache.FsScope {
+			if strings.Trim(strings.TrimSpace(scope.Path), ",") == "" {
+				return fmt.Errorf("path of fs_scope[%s] should be set explicitly when strategy of cache is [%s]",
+					scope.Name, CacheStrategyContent)
+			}
+		}
+	default:
+		return fmt.Errorf("strategy of cache should be [%s] or [%s], setted by [%s]",
+			CacheStrategyConservative, CacheStrategyContent, cache.Strategy)
+	}
+	return nil
+}
+
 func (bwf *BaseWorkflow) checkParams() error {
 	for paramName, paramVal := range bwf.Params {
 		if err := bwf.replaceRunParam(paramName, paramVal); err != nil {
@@ -748,9 +956,12 @@ func replaceNodeParam(nodes map[string]schema.Component, compsAndParam []string,
 
 			dictParam := DictParam{}
 			if err := dictParam.From(orgVal); err == nil {
-				if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+				realVal, err := CheckDictParam(dictParam, paramName, value)
+				if err != nil {
 					return false, err
 				}
+				// 使用校验后的值，如 json 中整数形式的 float64 会被转换为 int 类型参数所需的 int64
+				value = realVal
 			}
 			dag.Parameters[paramName] = value
 		} else if step, ok := comp.(*schema.WorkflowSourceStep); ok {
@@ -761,9 +972,12 @@ func replaceNodeParam(nodes map[string]schema.Component, compsAndParam []string,
 
 			dictParam := DictParam{}
 			if err := dictParam.From(orgVal); err == nil {
-				if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+				realVal, err := CheckDictParam(dictParam, paramName, value)
+				if err != nil {
 					return false, err
 				}
+				// 使用校验后的值，如 json 中整数形式的 float64 会被转换为 int 类型参数所需的 int64
+				value = realVal
 			}
 			step.Parameters[paramName] = value
 		} else {
@@ -782,13 +996,16 @@ func replaceAllNodeParam(entryPoints map[string]schema.Component, paramName stri
 	for _, node := range entryPoints {
 		if dag, ok := node.(*schema.WorkflowSourceDag); ok {
 			if orgVal, ok := dag.Parameters[paramName]; ok {
+				realVal := value
 				dictParam := DictParam{}
 				if err := dictParam.From(orgVal); err == nil {
-					if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+					checkedVal, err := CheckDictParam(dictParam, paramName, value)
+					if err != nil {
 						return false, err
 					}
+					realVal = checkedVal
 				}
-				dag.Parameters[paramName] = value
+				dag.Parameters[paramName] = realVal
 				isReplace = true
 			}
 			isReplaceSub, err := replaceAllNodeParam(dag.EntryPoints, paramName, value)
@@ -798,13 +1015,16 @@ func replaceAllNodeParam(entryPoints map[string]schema.Component, paramName stri
 			isReplace = isReplace || isReplaceSub
 		} else if step, ok := node.(*schema.WorkflowSourceStep); ok {
 			if orgVal, ok := step.Parameters[paramName]; ok {
+				realVal := value
 				dictParam := DictParam{}
 				if err := dictParam.From(orgVal); err == nil {
-					if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+					checkedVal, err := CheckDictParam(dictParam, paramName, value)
+					if err != nil {
 						return false, err
 					}
+					realVal = checkedVal
 				}
-				step.Parameters[paramName] = value
+				step.Parameters[paramName] = realVal
 				isReplace = true
 			}
 		}
@@ -116,6 +116,9 @@ func (wfr *WorkflowRuntime) Start() {
 		wfr.callback("begin to running, update status to running")
 
 		go wfr.Listen()
+		if timeout := wfr.WorkflowSource.Timeout; timeout > 0 {
+			go wfr.watchTimeout(time.Duration(timeout) * time.Second)
+		}
 		wfr.entryPoints.Start()
 	}
 }
@@ -135,6 +138,9 @@ func (wfr *WorkflowRuntime) Resume(entryPointView *schema.DagView, postProcessVi
 	if !isRuntimeFinallyStatus(entryPointView.Status) {
 		go wfr.entryPoints.Resume(entryPointView)
 		go wfr.Listen()
+		if timeout := wfr.WorkflowSource.Timeout; timeout > 0 {
+			go wfr.watchTimeout(remainingTimeout(timeout, wfr.startTime))
+		}
 
 		if runStatus == string(StatusRuntimeTerminating) {
 			wfr.entryPointsCtx.Done()
@@ -210,6 +216,9 @@ func (wfr *WorkflowRuntime) Restart(entryPointView *schema.DagView,
 	if entryPointView.Status != StatusRuntimeSucceeded {
 		wfr.entryPoints.Restart(entryPointView)
 		go wfr.Listen()
+		if timeout := wfr.WorkflowSource.Timeout; timeout > 0 {
+			go wfr.watchTimeout(time.Duration(timeout) * time.Second)
+		}
 		return
 	} else {
 		// 此时 postPost节点的状态一定为 异常状态，直接重新调度 postProcess 即可
@@ -271,6 +280,27 @@ func (wfr *WorkflowRuntime) Stop(force bool) error {
 	return nil
 }
 
+// watchTimeout: 在超时后终止 entryPoints 中的所有节点，run 的状态将会被置为 failed，postProcess 节点依然会被调度
+// 此处不持有 scheduleLock，避免与 entryPoints 向 EventChan 同步事件时发生死锁
+func (wfr *WorkflowRuntime) watchTimeout(remaining time.Duration) {
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-wfr.entryPoints.finished():
+		return
+	case <-wfr.entryPointsCtx.Done():
+		return
+	}
+
+	if wfr.IsCompleted() || wfr.entryPoints.isDone() {
+		return
+	}
+
+	wfr.entryPoints.stopByTimeout(fmt.Sprintf("run[%s] timed out after %ds", wfr.runID, wfr.WorkflowSource.Timeout))
+}
+
 func (wfr *WorkflowRuntime) Status() string {
 	return wfr.status
 }
@@ -459,6 +459,33 @@ func TestValidateWorkflowCache(t *testing.T) {
 	err = mockValidate(&bwf)
 	assert.NotNil(t, err)
 	assert.Equal(t, "MaxExpiredTime[notInt] of cache in step[data-preprocess] not correct", err.Error())
+
+	// cache Strategy 默认为 conservative，且只能为 conservative 或 content
+	bwf.Source.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep).Cache.MaxExpiredTime = ""
+	err = mockValidate(&bwf)
+	assert.Nil(t, err)
+	assert.Equal(t, pplcommon.CacheStrategyConservative, bwf.Source.Cache.Strategy)
+
+	bwf.Source.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep).Cache.Strategy = pplcommon.CacheStrategyContent
+	err = mockValidate(&bwf)
+	assert.Nil(t, err)
+
+	bwf.Source.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep).Cache.Strategy = "aggressive"
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "strategy of cache should be [conservative] or [content], setted by [aggressive] in step[data-preprocess]",
+		err.Error())
+
+	// content 策略需要显式设置 fs_scope 的 path
+	step := bwf.Source.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep)
+	step.Cache.Strategy = pplcommon.CacheStrategyContent
+	step.Cache.FsScope = []schema.FsScope{{Name: "xd", Path: ""}}
+	err = checkCacheStrategy(&step.Cache)
+	assert.NotNil(t, err)
+	assert.Equal(t, "path of fs_scope[xd] should be set explicitly when strategy of cache is [content]", err.Error())
+
+	step.Cache.FsScope = []schema.FsScope{{Name: "xd", Path: "/data"}}
+	assert.Nil(t, checkCacheStrategy(&step.Cache))
 }
 
 // 测试不使用Fs时，workflow校验逻辑
@@ -529,3 +556,131 @@ func TestFsOptions(t *testing.T) {
 	assert.Equal(t, wfs.EntryPoints.EntryPoints["main"].(*schema.WorkflowSourceStep).ExtraFS[0].Name, "abc")
 	assert.Equal(t, wfs.EntryPoints.EntryPoints["main"].(*schema.WorkflowSourceStep).Cache.FsScope[0].Name, "xd")
 }
+
+func TestCheckStepJobConf(t *testing.T) {
+	step := &schema.WorkflowSourceStep{
+		Priority:  "high",
+		Framework: "paddle",
+		Members: []schema.StepMember{
+			{Role: "pserver", Replicas: 2},
+			{Role: "pworker", Replicas: 4},
+		},
+	}
+	assert.Nil(t, checkStepJobConf(step))
+
+	step.Priority = "urgent"
+	err := checkStepJobConf(step)
+	assert.NotNil(t, err)
+	assert.Equal(t, "priority should be one of [LOW, NORMAL, HIGH], setted by [urgent]", err.Error())
+
+	step.Priority = ""
+	step.Members[1].Role = "driver"
+	err = checkStepJobConf(step)
+	assert.NotNil(t, err)
+	assert.Equal(t, "role[driver] is not supported by framework[paddle]", err.Error())
+
+	step.Members[1] = schema.StepMember{Role: "pworker", Replicas: 0}
+	err = checkStepJobConf(step)
+	assert.NotNil(t, err)
+	assert.Equal(t, "replicas of role[pworker] should be greater than 0", err.Error())
+
+	step.Framework = "caffe"
+	err = checkStepJobConf(step)
+	assert.NotNil(t, err)
+	assert.Equal(t, "framework[caffe] is not supported", err.Error())
+
+	step.Framework = "standalone"
+	err = checkStepJobConf(step)
+	assert.NotNil(t, err)
+	assert.Equal(t, "members can only be setted when framework is distributed", err.Error())
+
+	step.Framework = "spark"
+	step.Members = nil
+	err = checkStepJobConf(step)
+	assert.NotNil(t, err)
+	assert.Equal(t, "members should be setted when framework is [spark]", err.Error())
+
+	// 校验会递归到 dag 中的 step
+	wfs, err := schema.GetWorkflowSource(loadcase(runYamlPath))
+	assert.Nil(t, err)
+	wfs.EntryPoints.EntryPoints["data-preprocess"].(*schema.WorkflowSourceStep).Framework = "mpi"
+	bwf := NewBaseWorkflow(wfs, "", nil, GetExtra())
+	err = bwf.checkJobConf()
+	assert.NotNil(t, err)
+	assert.Equal(t, "check job conf of step[data-preprocess] failed: members should be setted when framework is [mpi]", err.Error())
+}
+
+func TestValidateWorkflowTypedParam(t *testing.T) {
+	wfs, err := schema.GetWorkflowSource(loadcase(runYamlPath))
+	assert.Nil(t, err)
+
+	// 必填参数可以不设置默认值，校验 pipeline 时不需要传入
+	wfs.EntryPoints.EntryPoints["main"].GetParameters()["epoch"] = map[string]interface{}{"type": "int", "required": true, "min": 1}
+	bwf := NewBaseWorkflow(wfs, "", nil, GetExtra())
+	assert.Nil(t, mockValidate(&bwf))
+
+	// 请求中 json 形式的整数会被转换为 int64
+	wfs, err = schema.GetWorkflowSource(loadcase(runYamlPath))
+	assert.Nil(t, err)
+	wfs.EntryPoints.EntryPoints["main"].GetParameters()["epoch"] = map[string]interface{}{"type": "int", "required": true, "min": 1}
+	bwf = NewBaseWorkflow(wfs, "", map[string]interface{}{"main.epoch": float64(10)}, GetExtra())
+	assert.Nil(t, mockValidate(&bwf))
+	assert.Equal(t, int64(10), bwf.Source.EntryPoints.EntryPoints["main"].GetParameters()["epoch"])
+
+	wfs, err = schema.GetWorkflowSource(loadcase(runYamlPath))
+	assert.Nil(t, err)
+	wfs.EntryPoints.EntryPoints["main"].GetParameters()["epoch"] = map[string]interface{}{"type": "int", "required": true, "min": 1}
+	bwf = NewBaseWorkflow(wfs, "", map[string]interface{}{"main.epoch": float64(0)}, GetExtra())
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "value[0] of param[epoch] should be greater than or equal to 1", err.Error())
+}
+
+func TestValidateWorkflowLoopArgument(t *testing.T) {
+	newWorkflow := func() BaseWorkflow {
+		wfs, err := schema.GetWorkflowSource(loadcase(dagRunYaml))
+		assert.Nil(t, err)
+		return NewBaseWorkflow(wfs, "", nil, GetExtra())
+	}
+
+	// loop_argument 可以引用上游节点的输出 artifact
+	bwf := newWorkflow()
+	loop := bwf.Source.EntryPoints.EntryPoints["square-loop"].(*schema.WorkflowSourceDag)
+	loop.LoopArgument = "{{randint.random_int}}"
+	loop.Parallelism = 2
+	assert.Nil(t, mockValidate(&bwf))
+
+	// 以及上游节点的 parameter
+	bwf = newWorkflow()
+	loop = bwf.Source.EntryPoints.EntryPoints["square-loop"].(*schema.WorkflowSourceDag)
+	loop.LoopArgument = "{{randint.num}}"
+	assert.Nil(t, mockValidate(&bwf))
+
+	// 被引用的节点必须在 deps 中
+	bwf = newWorkflow()
+	bwf.Source.EntryPoints.EntryPoints["sum"].UpdateLoopArguemt("{{randint.random_int}}")
+	err := mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "loopArgument[{{randint.random_int}}] is invalid, component[randint] should be in deps", err.Error())
+
+	bwf = newWorkflow()
+	loop = bwf.Source.EntryPoints.EntryPoints["square-loop"].(*schema.WorkflowSourceDag)
+	loop.LoopArgument = "{{randint.noexist}}"
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "loopArgument[{{randint.noexist}}] is invalid, [noexist] is not output artifact or parameter of component[randint]", err.Error())
+
+	// parallelism 只能在循环结构中设置，且不能为负数
+	bwf = newWorkflow()
+	loop = bwf.Source.EntryPoints.EntryPoints["square-loop"].(*schema.WorkflowSourceDag)
+	loop.Parallelism = -1
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "parallelism of component[square-loop] should be non-negative", err.Error())
+
+	bwf = newWorkflow()
+	bwf.Source.EntryPoints.EntryPoints["randint"].(*schema.WorkflowSourceStep).Parallelism = 2
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "parallelism can only be set for component[randint] with loop_argument", err.Error())
+}
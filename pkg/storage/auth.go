@@ -17,6 +17,8 @@ limitations under the License.
 package storage
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
@@ -211,3 +213,93 @@ func (as *AuthStore) GetLastGrant(ctx *logger.RequestContext) (model.Grant, erro
 	}
 	return grant, nil
 }
+
+// ============================================================= table token_revocation ============================================================= //
+
+func (as *AuthStore) RevokeToken(ctx *logger.RequestContext, revocation *model.TokenRevocation) error {
+	ctx.Logging().Debugf("model begin revoke token. tokenID:%s, userName:%s", revocation.TokenID, revocation.UserName)
+	// the revocations are useless once the revoked tokens are expired
+	err := as.db.Model(&model.TokenRevocation{}).Where("expires_at > 0 and expires_at < ?", time.Now().Unix()).
+		Delete(&model.TokenRevocation{}).Error
+	if err != nil {
+		ctx.Logging().Warnf("model clean expired token revocations failed. error:%s", err.Error())
+	}
+	tx := as.db.Model(&model.TokenRevocation{}).Create(revocation)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model revoke token failed. revocation:%v, error:%s", revocation, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AuthStore) IsTokenRevoked(ctx *logger.RequestContext, tokenID, userName string, issuedAt int64) (bool, error) {
+	var num int64
+	tx := as.db.Model(&model.TokenRevocation{}).
+		Where("(token_id != '' and token_id = ?) or (token_id = '' and user_name = ? and issued_before >= ?)",
+			tokenID, userName, issuedAt).Count(&num)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model check token revocation failed. tokenID:%s, error:%s", tokenID, tx.Error.Error())
+		return false, tx.Error
+	}
+	return num > 0, nil
+}
+
+// ============================================================= table access_token ============================================================= //
+
+func (as *AuthStore) CreateAccessToken(ctx *logger.RequestContext, token *model.AccessToken) error {
+	ctx.Logging().Debugf("model begin create access token. userName:%s, name:%s", token.UserName, token.Name)
+	token.ID = uuid.GenerateID(common.PrefixAccessToken)
+	tx := as.db.Model(&model.AccessToken{}).Create(token)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model create access token failed. userName:%s, name:%s, error:%s",
+			token.UserName, token.Name, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AuthStore) GetAccessTokenByHash(ctx *logger.RequestContext, tokenHash string) (*model.AccessToken, error) {
+	var token model.AccessToken
+	tx := as.db.Model(&model.AccessToken{}).Where("token_hash = ?", tokenHash).First(&token)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model get access token failed. error:%s", tx.Error.Error())
+		return nil, tx.Error
+	}
+	return &token, nil
+}
+
+func (as *AuthStore) ListAccessToken(ctx *logger.RequestContext, userName string) ([]model.AccessToken, error) {
+	ctx.Logging().Debugf("model begin list access tokens. userName:%s", userName)
+	var tokens []model.AccessToken
+	tx := as.db.Model(&model.AccessToken{}).Where("user_name = ?", userName).Order("pk").Find(&tokens)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model list access tokens failed. userName:%s, error:%s", userName, tx.Error.Error())
+		return nil, tx.Error
+	}
+	return tokens, nil
+}
+
+func (as *AuthStore) DeleteAccessToken(ctx *logger.RequestContext, userName, tokenID string) error {
+	ctx.Logging().Debugf("model begin delete access token. userName:%s, tokenID:%s", userName, tokenID)
+	tx := as.db.Model(&model.AccessToken{}).Unscoped().Where("user_name = ? and id = ?", userName, tokenID).
+		Delete(&model.AccessToken{})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model delete access token failed. tokenID:%s, error:%s", tokenID, tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (as *AuthStore) DeleteAccessTokenByUserName(ctx *logger.RequestContext, userName string) error {
+	ctx.Logging().Debugf("model begin delete access tokens by userName. userName:%s", userName)
+	err := as.db.Model(&model.AccessToken{}).Unscoped().Where("user_name = ?", userName).
+		Delete(&model.AccessToken{}).Error
+	if err != nil {
+		ctx.Logging().Errorf("model delete access tokens failed. userName:%s, error:%s", userName, err.Error())
+		return err
+	}
+	return nil
+}
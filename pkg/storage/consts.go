@@ -19,6 +19,7 @@ package storage
 const (
 	QueryEqualWithParam = " (%s = ?) "
 	QueryLess           = " (%s <= %s) "
+	QueryLessWithParam  = " (%s < ?) "
 	QueryGreater        = " (%s >= %d) "
 	QueryLikeWithParam  = " (%s LIKE ?) "
 	QueryInWithParam    = " (%s IN (?)) "
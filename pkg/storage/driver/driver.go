@@ -204,6 +204,8 @@ func createDatabaseTables(db *gorm.DB) error {
 		&models.Pipeline{},
 		&models.PipelineVersion{},
 		&models.Schedule{},
+		&models.Notifier{},
+		&models.NotificationDelivery{},
 		&models.RunCache{},
 		&models.ArtifactEvent{},
 		&model.User{},
@@ -213,6 +215,8 @@ func createDatabaseTables(db *gorm.DB) error {
 		&models.Queue{},
 		&models.Flavour{},
 		&model.Grant{},
+		&model.TokenRevocation{},
+		&model.AccessToken{},
 		&models.Job{},
 		&models.JobTask{},
 		&models.JobLabel{},
@@ -222,5 +226,6 @@ func createDatabaseTables(db *gorm.DB) error {
 		&model.Link{},
 		&model.FSCacheConfig{},
 		&model.FSCache{},
+		&model.FSCacheWarmup{},
 	)
 }
@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+const (
+	WarmupID = "warmup_id"
+	Status   = "status"
+)
+
+type FsCacheWarmupStoreInterface interface {
+	Create(warmups []model.FSCacheWarmup) error
+	// List lists warm-up tasks of fs, the tasks are filtered by node and status if given
+	List(fsID, clusterID, nodeName string, status ...string) ([]model.FSCacheWarmup, error)
+	// UpdateProgress updates the progress of a warm-up task on the node which is not finished
+	UpdateProgress(fsID, clusterID, nodeName, warmupID, status string, files, bytes int64, message string) error
+	// Dispatch marks pending or re-dispatched running warm-up tasks as running
+	Dispatch(warmupIDs []string) error
+	// FailStale fails running warm-up tasks of fs whose progress is not reported since before
+	FailStale(fsID string, before time.Time, message string) error
+	DeleteByFsID(fsID string) error
+}
+
+func newDBFSCacheWarmup(db *gorm.DB) FsCacheWarmupStoreInterface {
+	return &DBFSCacheWarmup{db: db}
+}
+
+type DBFSCacheWarmup struct {
+	db *gorm.DB
+}
+
+func (f *DBFSCacheWarmup) Create(warmups []model.FSCacheWarmup) error {
+	if len(warmups) == 0 {
+		return nil
+	}
+	return f.db.Create(&warmups).Error
+}
+
+func (f *DBFSCacheWarmup) List(fsID, clusterID, nodeName string, status ...string) ([]model.FSCacheWarmup, error) {
+	tx := f.db.Model(&model.FSCacheWarmup{}).Where(fmt.Sprintf(QueryEqualWithParam, FsID), fsID)
+	if nodeName != "" {
+		tx = tx.Where(fmt.Sprintf(QueryEqualWithParam, ClusterID), clusterID).
+			Where(fmt.Sprintf(QueryEqualWithParam, NodeName), nodeName)
+	}
+	if len(status) > 0 {
+		tx = tx.Where(fmt.Sprintf(QueryInWithParam, Status), status)
+	}
+	var warmups []model.FSCacheWarmup
+	if err := tx.Order(CreatedAt).Find(&warmups).Error; err != nil {
+		return nil, err
+	}
+	return warmups, nil
+}
+
+func (f *DBFSCacheWarmup) UpdateProgress(fsID, clusterID, nodeName, warmupID, status string,
+	files, bytes int64, message string) error {
+	return f.db.Model(&model.FSCacheWarmup{}).
+		Where(fmt.Sprintf(QueryEqualWithParam, WarmupID), warmupID).
+		Where(fmt.Sprintf(QueryEqualWithParam, FsID), fsID).
+		Where(fmt.Sprintf(QueryEqualWithParam, ClusterID), clusterID).
+		Where(fmt.Sprintf(QueryEqualWithParam, NodeName), nodeName).
+		Where(fmt.Sprintf(QueryNotInWithParam, Status), []string{model.WarmupStatusSucceeded, model.WarmupStatusFailed}).
+		Updates(map[string]interface{}{
+			Status:    status,
+			"files":   files,
+			"bytes":   bytes,
+			"message": message,
+			UpdatedAt: time.Now(),
+		}).Error
+}
+
+// Dispatch marks warm-up tasks as running after they are sent to the cache-worker, updated_at is refreshed
+// so that re-dispatched running tasks are not sent again until they are stale once more
+func (f *DBFSCacheWarmup) Dispatch(warmupIDs []string) error {
+	if len(warmupIDs) == 0 {
+		return nil
+	}
+	return f.db.Model(&model.FSCacheWarmup{}).
+		Where(fmt.Sprintf(QueryInWithParam, WarmupID), warmupIDs).
+		Where(fmt.Sprintf(QueryInWithParam, Status), []string{model.WarmupStatusPending, model.WarmupStatusRunning}).
+		Updates(map[string]interface{}{
+			Status:    model.WarmupStatusRunning,
+			UpdatedAt: time.Now(),
+		}).Error
+}
+
+func (f *DBFSCacheWarmup) FailStale(fsID string, before time.Time, message string) error {
+	return f.db.Model(&model.FSCacheWarmup{}).
+		Where(fmt.Sprintf(QueryEqualWithParam, FsID), fsID).
+		Where(fmt.Sprintf(QueryEqualWithParam, Status), model.WarmupStatusRunning).
+		Where(fmt.Sprintf(QueryLessWithParam, UpdatedAt), before).
+		Updates(map[string]interface{}{
+			Status:    model.WarmupStatusFailed,
+			"message": message,
+		}).Error
+}
+
+func (f *DBFSCacheWarmup) DeleteByFsID(fsID string) error {
+	return f.db.Where(fmt.Sprintf(QueryEqualWithParam, FsID), fsID).Unscoped().Delete(&model.FSCacheWarmup{}).Error
+}
@@ -28,6 +28,7 @@ var (
 
 	Filesystem FileSystemStoreInterface
 	FsCache    FsCacheStoreInterface
+	FsWarmup   FsCacheWarmupStoreInterface
 	Auth       AuthStoreInterface
 )
 
@@ -35,6 +36,7 @@ func InitStores(db *gorm.DB) {
 	// do not use once.Do() because unit test need to init db twice
 	Filesystem = newFilesystemStore(db)
 	FsCache = newDBFSCache(db)
+	FsWarmup = newDBFSCacheWarmup(db)
 	Auth = newAuthStore(db)
 }
 
@@ -88,4 +90,13 @@ type AuthStoreInterface interface {
 	DeleteGrantByResourceID(ctx *logger.RequestContext, resourceID string) error
 	ListGrant(ctx *logger.RequestContext, pk int64, maxKeys int, userName string) ([]model.Grant, error)
 	GetLastGrant(ctx *logger.RequestContext) (model.Grant, error)
+	// token revocation
+	RevokeToken(ctx *logger.RequestContext, revocation *model.TokenRevocation) error
+	IsTokenRevoked(ctx *logger.RequestContext, tokenID, userName string, issuedAt int64) (bool, error)
+	// access token
+	CreateAccessToken(ctx *logger.RequestContext, token *model.AccessToken) error
+	GetAccessTokenByHash(ctx *logger.RequestContext, tokenHash string) (*model.AccessToken, error)
+	ListAccessToken(ctx *logger.RequestContext, userName string) ([]model.AccessToken, error)
+	DeleteAccessToken(ctx *logger.RequestContext, userName, tokenID string) error
+	DeleteAccessTokenByUserName(ctx *logger.RequestContext, userName string) error
 }